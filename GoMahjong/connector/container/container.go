@@ -12,6 +12,7 @@ import (
 	"connector/runtime"
 	"fmt"
 	"sync"
+	"time"
 )
 
 type ConnectorContainer struct {
@@ -50,8 +51,11 @@ func (c *ConnectorContainer) GetWorker() *conn.Worker {
 
 		opts = append(opts, withNatsWorker())
 		opts = append(opts, withRateLimiter(100, 1))
+		opts = append(opts, withUserRateLimiter(config.ConnectorConfig.RateLimitConf))
+		opts = append(opts, withProtocol(config.ConnectorConfig.ProtocolConf))
 		opts = append(opts, withGameRouteCache())
 		opts = append(opts, withUserRoute(userRepository))
+		opts = append(opts, withPrivateRoom(realtime.NewRedisPrivateRoomRepository(c.redis)))
 
 		c.worker = conn.NewWorkerWithDeps(opts...)
 		if c.worker == nil {
@@ -75,6 +79,30 @@ func withRateLimiter(rate, burst int) conn.WorkerOption {
 	}
 }
 
+func withUserRateLimiter(conf config.RateLimitConf) conn.WorkerOption {
+	return func(w *conn.Worker) error {
+		rate, burst := conf.UserMessageRate, conf.UserMessageBurst
+		if rate <= 0 {
+			rate = 20
+		}
+		if burst <= 0 {
+			burst = 2
+		}
+		w.UserRateLimiter = ratelimiter.NewUserRateLimiter(rate, burst)
+		return nil
+	}
+}
+
+func withProtocol(conf config.ProtocolConf) conn.WorkerOption {
+	return func(w *conn.Worker) error {
+		w.MinProtoVersion = conf.MinProtoVersion
+		if conf.ResumeTokenTTL > 0 {
+			w.ResumeTokenTTL = time.Duration(conf.ResumeTokenTTL) * time.Second
+		}
+		return nil
+	}
+}
+
 func withGameRouteCache() conn.WorkerOption {
 	return func(w *conn.Worker) error {
 		gameCache, err := cache.NewGameRouteCache()
@@ -93,6 +121,13 @@ func withUserRoute(repo repository.UserRouterRepository) conn.WorkerOption {
 	}
 }
 
+func withPrivateRoom(repo repository.PrivateRoomRepository) conn.WorkerOption {
+	return func(w *conn.Worker) error {
+		w.PrivateRoom = repo
+		return nil
+	}
+}
+
 func (c *ConnectorContainer) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -0,0 +1,9 @@
+package repository
+
+import "context"
+
+// PrivateRoomRepository 房间号 -> game 节点的索引，由创建房间的 game 节点写入
+type PrivateRoomRepository interface {
+	// GetGameNode 房间号不存在或已过期时返回空字符串
+	GetGameNode(ctx context.Context, code string) (string, error)
+}
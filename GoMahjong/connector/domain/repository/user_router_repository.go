@@ -13,4 +13,10 @@ type UserRouterRepository interface {
 	GetGameRouter(ctx context.Context, userID string) (string, error)
 	DeleteGameRouter(ctx context.Context, userID string) error
 	HasGameRouter(ctx context.Context, userID string) (bool, error)
+	// 续连令牌：token -> userID，过期后不可用
+	SaveResumeToken(ctx context.Context, token, userID string, ttl time.Duration) error
+	// RefreshResumeToken 重置令牌有效期，令牌已被使用或已过期时不会重新创建
+	RefreshResumeToken(ctx context.Context, token string, ttl time.Duration) error
+	// ConsumeResumeToken 原子地取出并删除令牌，返回令牌所属的 userID，不存在时返回空串
+	ConsumeResumeToken(ctx context.Context, token string) (string, error)
 }
@@ -17,13 +17,27 @@ type BaseConfig struct {
 }
 
 type ConnectorConfiguration struct {
-	BaseConfig   `mapstructure:",squash"`
-	DatabaseConf `mapstructure:"database"`
-	JwtConf      `mapstructure:"jwt"`
-	EtcdConf     `mapstructure:"etcd"`
-	LogConf      `mapstructure:"log"`
-	NatsConfig   `mapstructure:"nats"`
-	Domains      map[string]Domain `mapstructure:"domain"`
+	BaseConfig    `mapstructure:",squash"`
+	DatabaseConf  `mapstructure:"database"`
+	JwtConf       `mapstructure:"jwt"`
+	EtcdConf      `mapstructure:"etcd"`
+	LogConf       `mapstructure:"log"`
+	NatsConfig    `mapstructure:"nats"`
+	RateLimitConf `mapstructure:"rateLimit"`
+	ProtocolConf  `mapstructure:"protocol"`
+	Domains       map[string]Domain `mapstructure:"domain"`
+}
+
+// RateLimitConf 单个用户的消息限流（令牌桶），未配置时使用默认值
+type RateLimitConf struct {
+	UserMessageRate  int `mapstructure:"userMessageRate"`  // 每秒补充的令牌数
+	UserMessageBurst int `mapstructure:"userMessageBurst"` // 桶容量 = rate * burst
+}
+
+// ProtocolConf 客户端协议要求
+type ProtocolConf struct {
+	MinProtoVersion uint8 `mapstructure:"minProtoVersion"` // 握手允许的最低协议版本，低于该版本的客户端被拒绝；0 表示不限制
+	ResumeTokenTTL  int   `mapstructure:"resumeTokenTTL"`  // 续连令牌在断线后的有效期（秒），0 使用默认值
 }
 
 type LogConf struct {
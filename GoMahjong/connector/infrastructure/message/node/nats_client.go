@@ -2,9 +2,21 @@ package node
 
 import (
 	"connector/infrastructure/log"
+	"expvar"
 	"github.com/nats-io/nats.go"
+	"math/rand/v2"
+	"time"
 )
 
+const (
+	reconnectWaitMin = 500 * time.Millisecond // 断线后重连等待的起始值
+	reconnectWaitMax = 30 * time.Second       // 重连等待的上限
+)
+
+// natsStats 连接健康指标，在 metrics 端口的 /debug/vars 中查看
+// connected 当前是否已连接，disconnects 断线次数，reconnects 重连成功次数
+var natsStats = expvar.NewMap("nats")
+
 type Client interface {
 	Run(string) error
 	SendMessage(string, []byte) error
@@ -30,24 +42,69 @@ func (nc *NatsClient) IsConnected() bool {
 
 func (nc *NatsClient) Run(url string) error {
 	var err error
-	nc.conn, err = nats.Connect(url)
+	nc.conn, err = nats.Connect(url,
+		nats.MaxReconnects(-1), // 放弃重连后连接关闭、订阅全部丢失，所以一直重连
+		nats.CustomReconnectDelay(reconnectDelay),
+		nats.DisconnectErrHandler(nc.onDisconnect),
+		nats.ReconnectHandler(nc.onReconnect),
+		nats.ClosedHandler(nc.onClosed),
+	)
 	if err != nil {
 		log.Error("nats 连接错误,err:%v", err)
 		return err
 	}
+	setConnected(true)
 	go nc.Subscribe()
 
 	log.Info("nats 服务启动成功, url:%s", url)
 	return nil
 }
 
+// Subscribe 订阅本节点的消息，断线期间的订阅由 nats 客户端在重连后自动恢复
 func (nc *NatsClient) Subscribe() {
 	_, err := nc.conn.Subscribe(nc.topic, func(message *nats.Msg) {
 		nc.readChan <- message.Data
 	})
 	if err != nil {
-		log.Error("nats sub err:%v", err)
+		log.Error("nats 订阅失败: subject=%s, err=%v", nc.topic, err)
+	}
+}
+
+func (nc *NatsClient) onDisconnect(conn *nats.Conn, err error) {
+	if conn.IsClosed() {
+		return // 主动关闭，由 onClosed 处理
+	}
+	natsStats.Add("disconnects", 1)
+	setConnected(false)
+	log.Warn("nats 连接断开, err:%v", err)
+}
+
+func (nc *NatsClient) onReconnect(conn *nats.Conn) {
+	natsStats.Add("reconnects", 1)
+	setConnected(true)
+	log.Info("nats 重连成功, url:%s", conn.ConnectedUrl())
+}
+
+func (nc *NatsClient) onClosed(_ *nats.Conn) {
+	setConnected(false)
+}
+
+// reconnectDelay 重连等待按次数指数增长并加上至多 1/5 的随机抖动，避免所有节点同时重连
+// 在 nats 的重连协程中调用，rand/v2 的全局函数可并发使用
+func reconnectDelay(attempts int) time.Duration {
+	delay := reconnectWaitMax
+	if attempts >= 0 && attempts < 16 { // 限制位移次数，避免溢出
+		delay = min(reconnectWaitMin<<attempts, reconnectWaitMax)
+	}
+	return delay + rand.N(delay/5+1)
+}
+
+func setConnected(connected bool) {
+	v := new(expvar.Int)
+	if connected {
+		v.Set(1)
 	}
+	natsStats.Set("connected", v)
 }
 
 func (nc *NatsClient) Close() error {
@@ -59,8 +116,9 @@ func (nc *NatsClient) Close() error {
 	return nil
 }
 
+// SendMessage 发布消息，重连期间的消息由 nats 客户端缓存，重连成功后发出
 func (nc *NatsClient) SendMessage(subject string, data []byte) error {
-	if !nc.IsConnected() {
+	if nc.conn == nil || nc.conn.IsClosed() {
 		return ErrNotConnected
 	}
 	return nc.conn.Publish(subject, data)
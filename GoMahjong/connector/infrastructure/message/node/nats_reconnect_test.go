@@ -0,0 +1,238 @@
+package node
+
+import (
+	"bufio"
+	"connector/infrastructure/log"
+	"expvar"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	log.InitLog("node_test", "error")
+	os.Exit(m.Run())
+}
+
+// mockNats 只实现 CONNECT/PING/SUB/UNSUB/PUB 的进程内 nats 服务，可以停止后在同一地址重新启动
+type mockNats struct {
+	t    *testing.T
+	addr string
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]*sync.Mutex       // 连接 -> 写锁
+	subs     map[net.Conn]map[string]string // 连接 -> sid -> subject
+}
+
+func startMockNats(t *testing.T) *mockNats {
+	t.Helper()
+	s := &mockNats{t: t, addr: "127.0.0.1:0"}
+	s.start()
+	t.Cleanup(s.stop)
+	return s
+}
+
+func (s *mockNats) url() string {
+	return "nats://" + s.addr
+}
+
+// start 在上次的地址上监听，第一次启动时随机分配端口
+func (s *mockNats) start() {
+	s.t.Helper()
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		s.t.Fatalf("mock nats 监听失败: %v", err)
+	}
+	s.mu.Lock()
+	s.addr = listener.Addr().String()
+	s.listener = listener
+	s.conns = make(map[net.Conn]*sync.Mutex)
+	s.subs = make(map[net.Conn]map[string]string)
+	s.mu.Unlock()
+	go s.accept(listener)
+}
+
+// stop 关闭监听和所有连接，相当于 nats 服务宕机
+func (s *mockNats) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener != nil {
+		_ = s.listener.Close()
+		s.listener = nil
+	}
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.conns = make(map[net.Conn]*sync.Mutex)
+	s.subs = make(map[net.Conn]map[string]string)
+}
+
+// subscribed 当前订阅了 subject 的连接数
+func (s *mockNats) subscribed(subject string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, sids := range s.subs {
+		for _, sub := range sids {
+			if sub == subject {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func (s *mockNats) accept(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.listener != listener {
+			s.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		s.conns[conn] = &sync.Mutex{}
+		s.subs[conn] = make(map[string]string)
+		s.mu.Unlock()
+		go s.serve(conn)
+	}
+}
+
+func (s *mockNats) serve(conn net.Conn) {
+	defer conn.Close()
+	s.write(conn, `INFO {"server_id":"mock","version":"2.10.0","proto":1,"max_payload":1048576}`+"\r\n")
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			continue
+		}
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			s.write(conn, "PONG\r\n")
+		case "SUB": // SUB <subject> [queue] <sid>
+			s.mu.Lock()
+			if sids, ok := s.subs[conn]; ok {
+				sids[args[len(args)-1]] = args[1]
+			}
+			s.mu.Unlock()
+		case "UNSUB":
+			s.mu.Lock()
+			delete(s.subs[conn], args[1])
+			s.mu.Unlock()
+		case "PUB": // PUB <subject> [reply] <#bytes>
+			size, _ := strconv.Atoi(args[len(args)-1])
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+			s.publish(args[1], payload[:size])
+		}
+	}
+}
+
+// publish 按 subject 精确匹配投递给所有订阅
+func (s *mockNats) publish(subject string, payload []byte) {
+	s.mu.Lock()
+	var targets []func()
+	for conn, sids := range s.subs {
+		for sid, sub := range sids {
+			if sub == subject {
+				conn, msg := conn, fmt.Sprintf("MSG %s %s %d\r\n%s\r\n", subject, sid, len(payload), payload)
+				targets = append(targets, func() { s.write(conn, msg) })
+			}
+		}
+	}
+	s.mu.Unlock()
+	for _, send := range targets {
+		send()
+	}
+}
+
+func (s *mockNats) write(conn net.Conn, msg string) {
+	s.mu.Lock()
+	lock := s.conns[conn]
+	s.mu.Unlock()
+	if lock == nil {
+		return
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	_, _ = conn.Write([]byte(msg))
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待超时: %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func expectMessage(t *testing.T, readChan chan []byte, want string) {
+	t.Helper()
+	select {
+	case got := <-readChan:
+		if string(got) != want {
+			t.Fatalf("收到 %q, want %q", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("没有收到消息 %q", want)
+	}
+}
+
+func natsStat(name string) int64 {
+	if v, ok := natsStats.Get(name).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+// nats 服务宕机后恢复：客户端自动重连并恢复订阅，之后的消息照常收到，健康指标记录断线和重连
+func TestNatsClientReconnect(t *testing.T) {
+	srv := startMockNats(t)
+	readChan := make(chan []byte, 8)
+	nc := NewNatsClient("connector-1", readChan)
+	if err := nc.Run(srv.url()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	defer nc.Close()
+
+	waitFor(t, "订阅本节点的消息", func() bool { return srv.subscribed("connector-1") == 1 })
+	if err := nc.SendMessage("connector-1", []byte("before")); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	expectMessage(t, readChan, "before")
+
+	disconnects, reconnects := natsStat("disconnects"), natsStat("reconnects")
+	srv.stop()
+	// 断线、重连的回调由 nats 异步执行
+	waitFor(t, "记录断线", func() bool { return natsStat("connected") == 0 && natsStat("disconnects") == disconnects+1 })
+	srv.start()
+	waitFor(t, "重连并恢复订阅", func() bool { return natsStat("connected") == 1 && srv.subscribed("connector-1") == 1 })
+	if natsStat("reconnects") != reconnects+1 {
+		t.Fatalf("重连次数 = %d, want %d", natsStat("reconnects"), reconnects+1)
+	}
+
+	if err := nc.SendMessage("connector-1", []byte("after")); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	expectMessage(t, readChan, "after")
+}
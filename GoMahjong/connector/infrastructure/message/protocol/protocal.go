@@ -78,11 +78,61 @@ type Sys struct {
 	Heartbeat    uint8             `json:"heartbeat"`
 	Dict         map[string]uint16 `json:"dict"`
 	Serializer   string            `json:"serializer"`
+	Compression  string            `json:"compression"`
+	ResumeToken  string            `json:"resumeToken,omitempty"` // 请求中为上次连接的续连令牌，响应中为本次签发的新令牌
+}
+
+const (
+	SerializerJSON     = "json"
+	SerializerProtobuf = "protobuf"
+)
+
+// NegotiateSerializer 客户端请求的推送格式，不支持时回退到 json
+func NegotiateSerializer(requested string) string {
+	if requested == SerializerProtobuf {
+		return SerializerProtobuf
+	}
+	return SerializerJSON
+}
+
+const (
+	ProtoVersionLegacy  uint8 = 1                 // 一个 websocket 帧只含一个 pomelo 包
+	ProtoVersionBatch   uint8 = 2                 // 客户端能按包头长度拆分一帧中的多个 pomelo 包（合并推送）
+	CurrentProtoVersion       = ProtoVersionBatch // 服务端支持的最高协议版本
+
+	CompressionNone = "none" // pomelo 层不压缩，websocket 层的 permessage-deflate 由 Upgrader 单独协商
+
+	HandshakeCodeOK        uint16 = 200
+	HandshakeCodeOldClient uint16 = 501 // 客户端协议版本低于服务端要求（pomelo RES_OLD_CLIENT）
+)
+
+// Capabilities 握手时协商出的连接能力
+type Capabilities struct {
+	ProtoVersion uint8
+	Serializer   string
+	Compression  string
+}
+
+// Negotiate 按客户端在握手中声明的能力协商：未声明协议版本的客户端按 ProtoVersionLegacy 处理，
+// 高于服务端的版本降到 CurrentProtoVersion；版本低于 minVersion 时返回 false，应拒绝该客户端
+func Negotiate(sys Sys, minVersion uint8) (Capabilities, bool) {
+	version := sys.ProtoVersion
+	if version == 0 {
+		version = ProtoVersionLegacy
+	}
+	version = min(version, CurrentProtoVersion)
+	caps := Capabilities{
+		ProtoVersion: version,
+		Serializer:   NegotiateSerializer(sys.Serializer),
+		Compression:  CompressionNone,
+	}
+	return caps, version >= minVersion
 }
 
 type HandshakeResponse struct {
-	Code uint16 `json:"code"`
-	Sys  Sys    `json:"sys"`
+	Code    uint16 `json:"code"`
+	Sys     Sys    `json:"sys"`
+	Resumed bool   `json:"resumed,omitempty"` // 续连令牌有效且找到了玩家的对局路由，随后会收到重连快照
 }
 
 type Message struct {
@@ -0,0 +1,9 @@
+package transfer
+
+// PrivateRoomReq 转发给 game 节点的创建/加入私人房间请求（与 game/infrastructure/message/transfer 保持一致）
+type PrivateRoomReq struct {
+	UserID      string `json:"userID"`
+	ConnectorID string `json:"connectorID"`
+	EngineType  int32  `json:"engineType"` // 仅创建时使用
+	Code        string `json:"code"`       // 仅加入时使用
+}
@@ -0,0 +1,8 @@
+package transfer
+
+// PushBatchItem 合并推送中的一条消息，Data 为已按玩家协商格式编码的推送数据
+type PushBatchItem struct {
+	Users []string `json:"users"`
+	Route string   `json:"route"` // 客户端路由
+	Data  []byte   `json:"data"`
+}
@@ -44,3 +44,38 @@ func (rl *RateLimiter) Allow() bool {
 
 	return false
 }
+
+// UserRateLimiter 按 userID 分别限流的令牌桶，和全局的建连限流互不影响
+type UserRateLimiter struct {
+	rate     int
+	burst    int
+	limiters map[string]*RateLimiter
+	mu       sync.Mutex
+}
+
+func NewUserRateLimiter(rate int, burst int) *UserRateLimiter {
+	return &UserRateLimiter{
+		rate:     rate,
+		burst:    burst,
+		limiters: make(map[string]*RateLimiter),
+	}
+}
+
+// Allow 该用户是否还能发送一条消息
+func (ul *UserRateLimiter) Allow(userID string) bool {
+	ul.mu.Lock()
+	rl, ok := ul.limiters[userID]
+	if !ok {
+		rl = NewRateLimiter(ul.rate, ul.burst)
+		ul.limiters[userID] = rl
+	}
+	ul.mu.Unlock()
+	return rl.Allow()
+}
+
+// Remove 用户断开后释放其令牌桶
+func (ul *UserRateLimiter) Remove(userID string) {
+	ul.mu.Lock()
+	delete(ul.limiters, userID)
+	ul.mu.Unlock()
+}
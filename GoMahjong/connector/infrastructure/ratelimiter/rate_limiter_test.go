@@ -0,0 +1,34 @@
+package ratelimiter
+
+import (
+	"testing"
+	"time"
+)
+
+// 单个用户超出速率后被限流，其他用户不受影响，令牌随时间恢复
+func TestUserRateLimiter(t *testing.T) {
+	ul := NewUserRateLimiter(10, 1)
+	for i := 0; i < 10; i++ {
+		if !ul.Allow("abuser") {
+			t.Fatalf("第 %d 条消息不应被限流", i+1)
+		}
+	}
+	if ul.Allow("abuser") {
+		t.Fatalf("超出突发容量后应被限流")
+	}
+	if !ul.Allow("other") {
+		t.Fatalf("其他用户不应受影响")
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if !ul.Allow("abuser") {
+		t.Fatalf("令牌恢复后应允许发送")
+	}
+
+	ul.Remove("abuser")
+	for i := 0; i < 10; i++ {
+		if !ul.Allow("abuser") {
+			t.Fatalf("移除后重新计数，第 %d 条消息不应被限流", i+1)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package realtime
+
+import (
+	"connector/domain/repository"
+	"connector/infrastructure/database"
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// privateRoomKeyPrefix 与 game/infrastructure/persistence 保持一致
+const privateRoomKeyPrefix = "privateroom:code:"
+
+type RedisPrivateRoomRepository struct {
+	rdb *redis.Client
+}
+
+func NewRedisPrivateRoomRepository(redisManager *database.RedisManager) repository.PrivateRoomRepository {
+	return &RedisPrivateRoomRepository{
+		rdb: redisManager.Cli,
+	}
+}
+
+func (r *RedisPrivateRoomRepository) GetGameNode(ctx context.Context, code string) (string, error) {
+	result, err := r.rdb.Get(ctx, privateRoomKeyPrefix+code).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return "", nil
+		}
+		return "", err
+	}
+	return result, nil
+}
@@ -14,6 +14,7 @@ import (
 const (
 	connectorRouterPrefix = "user:router:connector:"
 	gameRouterPrefix      = "user:router:game:"
+	resumeTokenPrefix     = "user:resume:"
 )
 
 type RedisUserRouterRepository struct {
@@ -94,3 +95,35 @@ func (r *RedisUserRouterRepository) HasGameRouter(ctx context.Context, userID st
 	}
 	return exists > 0, nil
 }
+
+func (r *RedisUserRouterRepository) SaveResumeToken(ctx context.Context, token, userID string, ttl time.Duration) error {
+	key := fmt.Sprintf("%s%s", resumeTokenPrefix, token)
+	if err := r.rdb.Set(ctx, key, userID, ttl).Err(); err != nil {
+		log.Error("SaveResumeToken 保存失败: userID=%s, err=%v", userID, err)
+		return err
+	}
+	return nil
+}
+
+func (r *RedisUserRouterRepository) RefreshResumeToken(ctx context.Context, token string, ttl time.Duration) error {
+	key := fmt.Sprintf("%s%s", resumeTokenPrefix, token)
+	// EXPIRE 只作用于已存在的 key，已被续连使用的令牌不会被断线刷新重新写回
+	if err := r.rdb.Expire(ctx, key, ttl).Err(); err != nil {
+		log.Error("RefreshResumeToken 刷新失败: err=%v", err)
+		return err
+	}
+	return nil
+}
+
+func (r *RedisUserRouterRepository) ConsumeResumeToken(ctx context.Context, token string) (string, error) {
+	key := fmt.Sprintf("%s%s", resumeTokenPrefix, token)
+	result, err := r.rdb.GetDel(ctx, key).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return "", nil
+		}
+		log.Error("ConsumeResumeToken 读取失败: err=%v", err)
+		return "", err
+	}
+	return result, nil
+}
@@ -2,6 +2,7 @@ package conn
 
 import (
 	"connector/infrastructure/log"
+	"errors"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -16,6 +17,8 @@ import (
 type Connection interface {
 	TakeSession() *Session
 	SendMessage(buf []byte) error
+	Kick(buf []byte)
+	ResetHeartbeat()
 	Close()
 }
 
@@ -31,26 +34,63 @@ var (
 	writeWait            = 10 * time.Second
 	pingInterval         = (pongWait * 9) / 10
 	maxMessageSize int64 = 1024
+	writeBacklog         = 1024 // 写通道容量，积压满时视为慢客户端并断开
 )
 
+var (
+	errConnectionClosed = errors.New("连接已关闭")
+	errSlowClient       = errors.New("客户端写积压已满")
+)
+
+const (
+	heartbeatInterval  = 3 * time.Second // 握手时下发给客户端的心跳间隔
+	heartbeatTolerance = 3               // 允许连续丢失的心跳数
+)
+
+// heartbeatTimeout 超过该时间没收到心跳就断开
+var heartbeatTimeout = heartbeatInterval * heartbeatTolerance
+
 type LongConnection struct {
-	ConnID        string
-	Conn          *websocket.Conn
-	worker        *Worker
-	WriteChan     chan []byte
-	Session       *Session
-	pingTicker    *time.Ticker
-	closeChan     chan struct{}
-	closeOnce     sync.Once
-	writeChanOnce sync.Once
+	ConnID         string
+	Conn           *websocket.Conn
+	worker         *Worker
+	WriteChan      chan []byte
+	Session        *Session
+	pingTicker     *time.Ticker
+	heartbeatTimer atomic.Pointer[time.Timer] // 应用层心跳超时，收到心跳时重置
+	closeChan      chan struct{}
+	closeOnce      sync.Once
+	writeChanOnce  sync.Once
 }
 
 func (con *LongConnection) Run() {
+	con.startHeartbeat()
 	go con.readMessage()
 	go con.writeMessage()
 	con.Conn.SetPongHandler(con.PongHandler)
 }
 
+// startHeartbeat 开始心跳超时计时，连接对象会被连接池复用，所以回调只认本次连接的 closeChan
+func (con *LongConnection) startHeartbeat() {
+	worker, closeChan := con.worker, con.closeChan
+	con.heartbeatTimer.Store(time.AfterFunc(heartbeatTimeout, func() {
+		select {
+		case <-closeChan:
+			return
+		default:
+		}
+		log.Info("客户端[%s] %v 内没有收到心跳，断开连接", con.ConnID, heartbeatTimeout)
+		worker.removeClient(con)
+	}))
+}
+
+// ResetHeartbeat 收到心跳后重置超时
+func (con *LongConnection) ResetHeartbeat() {
+	if timer := con.heartbeatTimer.Load(); timer != nil {
+		timer.Reset(heartbeatTimeout)
+	}
+}
+
 // websocket.Conn.WriteMessage
 func (con *LongConnection) writeMessage() {
 	con.pingTicker = time.NewTicker(pingInterval)
@@ -67,21 +107,20 @@ func (con *LongConnection) writeMessage() {
 		select {
 		case message, ok := <-con.WriteChan:
 			if !ok {
-				if err := con.Conn.WriteMessage(websocket.CloseMessage, nil); err != nil {
+				if err := con.write(websocket.CloseMessage, nil); err != nil {
 					log.Error("客户端[%s] 连接关闭, %+v", con.ConnID, err)
 				}
 				con.Close()
 				return
 			}
 			log.Debug("写入消息: %#v", message)
-			if err := con.Conn.WriteMessage(websocket.BinaryMessage, message); err != nil {
+			if err := con.write(websocket.BinaryMessage, message); err != nil {
+				// 写超时或出错后连接不可再写，退出时由 defer 移除连接
 				log.Error("客户端[%s] write transfer err :%+v", con.ConnID, err)
+				return
 			}
 		case <-con.pingTicker.C:
-			if err := con.Conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
-				log.Error("客户端[%s] ping SetWriteDeadline err :%+v", con.ConnID, err)
-			}
-			if err := con.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+			if err := con.write(websocket.PingMessage, nil); err != nil {
 				log.Error("客户端[%s] ping  err :%+v", con.ConnID, err)
 				con.Close()
 			}
@@ -92,6 +131,14 @@ func (con *LongConnection) writeMessage() {
 	}
 }
 
+// write 每次写入前刷新写超时，客户端长时间不读取时写入失败，而不是一直阻塞写协程
+func (con *LongConnection) write(messageType int, data []byte) error {
+	if err := con.Conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+		return err
+	}
+	return con.Conn.WriteMessage(messageType, data)
+}
+
 // 读取客户端消息，打包成 ConnectionPack
 func (con *LongConnection) readMessage() {
 	defer func() {
@@ -119,12 +166,18 @@ func (con *LongConnection) readMessage() {
 			}
 			log.Debug("[%s] 收到二进制消息, 大小 %d 字节, 详细: %+v", con.ConnID, len(message), message)
 			if messageType == websocket.BinaryMessage {
+				if con.worker.closing.Load() {
+					log.Debug("客户端[%s] 服务关闭中，丢弃消息", con.ConnID)
+					continue
+				}
 				pack := &ConnectionPack{ConnID: con.ConnID, Body: message}
 				hash := fnv32(con.ConnID)
 				workerID := hash % uint32(con.worker.clientWorkerCount)
 
+				con.worker.pendingPacks.Add(1)
 				select {
 				case <-con.closeChan:
+					con.worker.pendingPacks.Add(-1)
 					log.Info("客户端[%s] 异常 while sending to channel", con.ConnID)
 					return
 				case con.worker.clientWorkers[workerID] <- pack:
@@ -132,6 +185,7 @@ func (con *LongConnection) readMessage() {
 					atomic.AddInt64(&con.worker.stats.messageErrors, 1)
 					log.Warn("工作池满了，直接处理:\n workerID:%#v\n messagePack:%#v", workerID, pack)
 					con.worker.DecodeAndHandlePack(pack)
+					con.worker.pendingPacks.Add(-1)
 				}
 			} else {
 				log.Error("不支持的流类型 : %d", messageType)
@@ -151,9 +205,38 @@ func (con *LongConnection) TakeSession() *Session {
 	return con.Session
 }
 
+// SendMessage 投递到写通道，不阻塞调用方（推送协程）：通道积压已满说明客户端消费过慢，直接断开该连接
 func (con *LongConnection) SendMessage(buf []byte) error {
-	con.WriteChan <- buf
-	return nil
+	select {
+	case <-con.closeChan:
+		return errConnectionClosed
+	default:
+	}
+	select {
+	case con.WriteChan <- buf:
+		return nil
+	default:
+		log.Warn("客户端[%s] 写积压达到 %d 条，断开慢客户端", con.ConnID, cap(con.WriteChan))
+		con.worker.removeClient(con)
+		return errSlowClient
+	}
+}
+
+// Kick 发送踢下线包后关闭写通道，写协程写完剩余消息后发送 websocket close 帧并关闭连接
+func (con *LongConnection) Kick(buf []byte) {
+	select {
+	case <-con.closeChan:
+		return
+	default:
+	}
+	con.writeChanOnce.Do(func() {
+		select {
+		case con.WriteChan <- buf:
+		default:
+			log.Warn("客户端[%s] 写通道已满，跳过 Kick 包", con.ConnID)
+		}
+		close(con.WriteChan)
+	})
 }
 
 func (con *LongConnection) Close() {
@@ -166,6 +249,9 @@ func (con *LongConnection) Close() {
 		if con.pingTicker != nil {
 			con.pingTicker.Stop()
 		}
+		if timer := con.heartbeatTimer.Load(); timer != nil {
+			timer.Stop()
+		}
 		if con.Session != nil {
 			con.Session.Close()
 		}
@@ -184,5 +270,6 @@ func (con *LongConnection) reset() {
 	con.WriteChan = nil
 	con.Session = nil
 	con.pingTicker = nil
+	con.heartbeatTimer.Store(nil)
 	con.closeChan = nil
 }
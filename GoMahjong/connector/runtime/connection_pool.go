@@ -53,7 +53,7 @@ func (po *LongConnectionPool) Get(conn *websocket.Conn, worker *Worker) *LongCon
 	longConn.Conn = conn
 	longConn.worker = worker
 	longConn.ConnID = connID
-	longConn.WriteChan = make(chan []byte, 1024)
+	longConn.WriteChan = make(chan []byte, writeBacklog)
 	longConn.Session = NewSession(connID, worker)
 	longConn.closeChan = make(chan struct{})
 
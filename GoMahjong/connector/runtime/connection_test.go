@@ -0,0 +1,86 @@
+package conn
+
+import (
+	"connector/infrastructure/message/protocol"
+	"connector/infrastructure/message/transfer"
+	"testing"
+	"time"
+)
+
+// newHeartbeatConnection 不带 websocket 连接的长连接，只用于心跳计时
+func newHeartbeatConnection(w *Worker, connID string) *LongConnection {
+	con := &LongConnection{ConnID: connID, worker: w, closeChan: make(chan struct{})}
+	con.Session = NewSession(connID, w)
+	bucket := w.getBucket(connID)
+	bucket.Lock()
+	bucket.clients[connID] = con
+	bucket.Unlock()
+	con.startHeartbeat()
+	return con
+}
+
+func (w *Worker) hasClient(connID string) bool {
+	bucket := w.getBucket(connID)
+	bucket.RLock()
+	defer bucket.RUnlock()
+	_, ok := bucket.clients[connID]
+	return ok
+}
+
+// 停止发送心跳的连接超时后被移除，持续发送心跳的连接保留
+func TestHeartbeatTimeoutEvictsClient(t *testing.T) {
+	old := heartbeatTimeout
+	heartbeatTimeout = 100 * time.Millisecond
+	defer func() { heartbeatTimeout = old }()
+
+	w, _ := newResumeTestWorker(t)
+	w.clientBuckets = []*ClientBucket{NewClientBucket()}
+	silentID, aliveID := "conn-silent", "conn-alive"
+	newHeartbeatConnection(w, silentID)
+	alive := newHeartbeatConnection(w, aliveID)
+	defer alive.Close()
+
+	for i := 0; i < 6; i++ {
+		time.Sleep(40 * time.Millisecond)
+		alive.ResetHeartbeat()
+	}
+	if w.hasClient(silentID) {
+		t.Fatalf("没有心跳的连接应被移除")
+	}
+	if !w.hasClient(aliveID) {
+		t.Fatalf("持续发送心跳的连接不应被移除")
+	}
+}
+
+// 客户端不再读取时写通道积压，推送不阻塞；积压满后连接被移除并解绑用户
+func TestSlowClientEvicted(t *testing.T) {
+	w, _ := newResumeTestWorker(t)
+	w.clientBuckets = []*ClientBucket{NewClientBucket()}
+	// 没有写协程消费写通道，相当于卡住的连接
+	con := &LongConnection{ConnID: "conn-slow", worker: w, closeChan: make(chan struct{}), WriteChan: make(chan []byte, 2)}
+	con.Session = NewSession(con.ConnID, w)
+	con.Session.SetUserID("u1")
+	w.clientBuckets[0].clients[con.ConnID] = con
+	w.BindUser("u1", con)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 4; i++ {
+			w.handlePush([]string{"u1"}, &protocol.Message{Type: protocol.Push, Route: transfer.GamePush, Data: []byte(`{}`)}, transfer.GamePush)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("慢客户端阻塞了推送协程")
+	}
+
+	// 移除后连接对象回到连接池复用，这里不再访问 con
+	if w.hasClient("conn-slow") {
+		t.Fatalf("写积压满的连接应被移除")
+	}
+	if _, ok := w.connMap.Load("u1"); ok {
+		t.Fatalf("被移除的连接应解绑用户")
+	}
+}
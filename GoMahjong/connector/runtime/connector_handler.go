@@ -2,12 +2,16 @@ package conn
 
 import (
 	"connector/infrastructure/log"
+	"connector/infrastructure/message/transfer"
 	"connector/infrastructure/rpc"
 	matchpb "connector/pb"
 	"context"
 	"encoding/json"
 	"fmt"
 	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func failMessage(tip string) map[string]any {
@@ -22,7 +26,8 @@ type joinQueueRequest struct {
 	PoolID string `json:"poolID"` // 匹配池ID（如 "classic:rank4", "classic:casual4", "classic:casual3"）
 }
 
-func joinQueueHandler(session *Session, body []byte) (any, error) {
+// joinQueueHandler 加入匹配队列，入队前先查路由：有进行中的对局时不入队，引导客户端回到原对局
+func (w *Worker) joinQueueHandler(session *Session, body []byte) (any, error) {
 	userID := session.GetUserID()
 	if userID == "" {
 		return failMessage("用户ID未检测"), nil
@@ -42,6 +47,15 @@ func joinQueueHandler(session *Session, body []byte) (any, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	gameNodeID, err := w.activeGameRoute(ctx, userID)
+	if err != nil {
+		log.Error("查询对局路由失败: userID=%s, err=%v", userID, err)
+		return failMessage("加入匹配队列失败"), nil
+	}
+	if gameNodeID != "" {
+		return w.rejoinGame(userID, gameNodeID), nil
+	}
+
 	req := &matchpb.JoinQueueRequest{
 		UserID: userID,
 		PoolID: clientReq.PoolID,
@@ -63,6 +77,175 @@ func joinQueueHandler(session *Session, body []byte) (any, error) {
 	return result, nil
 }
 
+// rejoinGame 玩家已在对局中，通知 game 节点下发重连快照，并告知客户端回到原对局
+func (w *Worker) rejoinGame(userID, gameNodeID string) map[string]any {
+	w.notifyGameReconnect(userID, gameNodeID)
+	log.Info("用户已在对局中，拒绝入队并重新进入对局: userID=%s, gameNode=%s", userID, gameNodeID)
+	return map[string]any{
+		"success":    false,
+		"rejoin":     true,
+		"gameNodeID": gameNodeID,
+		"message":    "正在游戏中，已重新进入对局",
+	}
+}
+
+// leaveQueueHandler 取消匹配，march 按加入时记录的匹配池移除玩家（段位场会落到具体段位的池）
+// 已经被匹配池取出（正在成桌）时取消会被拒绝，客户端随后会收到匹配成功推送
+func leaveQueueHandler(session *Session, body []byte) (any, error) {
+	userID := session.GetUserID()
+	if userID == "" {
+		return failMessage("用户ID未检测"), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	resp, err := rpc.MatchClient.LeaveQueue(ctx, &matchpb.LeaveQueueRequest{UserID: userID})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.FailedPrecondition {
+			log.Info("用户取消匹配被拒绝，已匹配或不在队列中: userID=%s", userID)
+			return failMessage("已匹配成功或不在匹配队列中"), nil
+		}
+		log.Error("LeaveQueue RPC 调用失败: userID=%s, err=%v", userID, err)
+		return failMessage(fmt.Sprintf("取消匹配失败: %v", err)), nil
+	}
+
+	log.Info("用户取消匹配: userID=%s", userID)
+	return map[string]any{
+		"message": resp.GetMessage(),
+	}, nil
+}
+
+// createPrivateRoomRequest 客户端请求结构
+type createPrivateRoomRequest struct {
+	EngineType int32 `json:"engineType"` // 0 四麻半庄、1 三麻、2 四麻东风
+}
+
+// createPrivateRoomHandler 创建私人房间，请求发往 game 节点的队列组，房间号随后通过 privateroom.update 推送
+func (w *Worker) createPrivateRoomHandler(session *Session, body []byte) (any, error) {
+	userID := session.GetUserID()
+	if userID == "" {
+		return failMessage("用户ID未检测"), nil
+	}
+	if _, inGame := w.GameRouteCache.Get(userID); inGame {
+		return failMessage("正在游戏中"), nil
+	}
+
+	var clientReq createPrivateRoomRequest
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &clientReq); err != nil {
+			log.Warn("解析 createPrivateRoom 请求失败: %v, body=%s", err, string(body))
+			return failMessage("请求参数格式错误"), nil
+		}
+	}
+
+	w.notifyGame(transfer.PrivateRoomTopic, transfer.GameCreatePrivateRoom, &transfer.PrivateRoomReq{
+		UserID:      userID,
+		ConnectorID: w.nodeID,
+		EngineType:  clientReq.EngineType,
+	})
+	log.Info("用户创建私人房间: userID=%s, engineType=%d", userID, clientReq.EngineType)
+	return map[string]any{"message": "正在创建房间"}, nil
+}
+
+// joinPrivateRoomRequest 客户端请求结构
+type joinPrivateRoomRequest struct {
+	Code string `json:"code"`
+}
+
+// joinPrivateRoomHandler 按房间号加入私人房间，请求直接转发到创建房间的 game 节点
+func (w *Worker) joinPrivateRoomHandler(session *Session, body []byte) (any, error) {
+	userID := session.GetUserID()
+	if userID == "" {
+		return failMessage("用户ID未检测"), nil
+	}
+	if _, inGame := w.GameRouteCache.Get(userID); inGame {
+		return failMessage("正在游戏中"), nil
+	}
+
+	var clientReq joinPrivateRoomRequest
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &clientReq); err != nil {
+			log.Warn("解析 joinPrivateRoom 请求失败: %v, body=%s", err, string(body))
+			return failMessage("请求参数格式错误"), nil
+		}
+	}
+	if clientReq.Code == "" {
+		return failMessage("房间号不能为空"), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	gameNodeID, err := w.PrivateRoom.GetGameNode(ctx, clientReq.Code)
+	if err != nil {
+		log.Error("查询房间号失败: code=%s, err=%v", clientReq.Code, err)
+		return failMessage("加入房间失败"), nil
+	}
+	if gameNodeID == "" {
+		return failMessage("房间号不存在或已过期"), nil
+	}
+
+	w.notifyGame(gameNodeID, transfer.GameJoinPrivateRoom, &transfer.PrivateRoomReq{
+		UserID:      userID,
+		ConnectorID: w.nodeID,
+		Code:        clientReq.Code,
+	})
+	log.Info("用户加入私人房间: userID=%s, code=%s, gameNode=%s", userID, clientReq.Code, gameNodeID)
+	return map[string]any{"message": "正在加入房间"}, nil
+}
+
 func redirectGame(session *Session, body []byte) (any, error) {
 	return nil, nil
 }
+
+type spectateRequest struct {
+	TargetUserID string `json:"targetUserID"` // 被观战的玩家
+	Leave        bool   `json:"leave"`        // 离开观战
+}
+
+// spectateHandler 观战被观战玩家所在的对局，只会收到公开推送，请求转发到该玩家所在的 game 节点
+func (w *Worker) spectateHandler(session *Session, body []byte) (any, error) {
+	userID := session.GetUserID()
+	if userID == "" {
+		return failMessage("用户ID未检测"), nil
+	}
+	if _, inGame := w.GameRouteCache.Get(userID); inGame {
+		return failMessage("正在游戏中"), nil
+	}
+
+	var clientReq spectateRequest
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &clientReq); err != nil {
+			log.Warn("解析 spectate 请求失败: %v, body=%s", err, string(body))
+			return failMessage("请求参数格式错误"), nil
+		}
+	}
+	if clientReq.TargetUserID == "" || clientReq.TargetUserID == userID {
+		return failMessage("观战对象不合法"), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	gameNodeID, err := w.UserRouter.GetGameRouter(ctx, clientReq.TargetUserID)
+	if err != nil {
+		log.Error("查询观战对象路由失败: target=%s, err=%v", clientReq.TargetUserID, err)
+		return failMessage("观战失败"), nil
+	}
+	if gameNodeID == "" {
+		return failMessage("对方不在对局中"), nil
+	}
+
+	w.notifyGame(gameNodeID, transfer.GameSpectate, &transfer.SpectateReq{
+		UserID:       userID,
+		ConnectorID:  w.nodeID,
+		TargetUserID: clientReq.TargetUserID,
+		Leave:        clientReq.Leave,
+	})
+	log.Info("用户观战: userID=%s, target=%s, leave=%v, gameNode=%s", userID, clientReq.TargetUserID, clientReq.Leave, gameNodeID)
+	if clientReq.Leave {
+		return map[string]any{"message": "已离开观战"}, nil
+	}
+	return map[string]any{"message": "正在进入观战"}, nil
+}
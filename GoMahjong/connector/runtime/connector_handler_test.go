@@ -0,0 +1,83 @@
+package conn
+
+import (
+	"connector/infrastructure/message/node"
+	"connector/infrastructure/rpc"
+	matchpb "connector/pb"
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// recordingMatchClient 记录入队请求
+type recordingMatchClient struct {
+	matchpb.MatchServiceClient
+	joined []string
+}
+
+func (c *recordingMatchClient) JoinQueue(_ context.Context, in *matchpb.JoinQueueRequest, _ ...grpc.CallOption) (*matchpb.JoinQueueResponse, error) {
+	c.joined = append(c.joined, in.UserID)
+	return &matchpb.JoinQueueResponse{Message: "ok"}, nil
+}
+
+func newQueueTestWorker(t *testing.T) (*Worker, *memoryUserRouter, *recordingMatchClient) {
+	t.Helper()
+	w, router := newResumeTestWorker(t)
+	w.MiddleWorker = node.NewNatsWorker()
+	match := &recordingMatchClient{}
+	old := rpc.MatchClient
+	rpc.MatchClient = match
+	t.Cleanup(func() { rpc.MatchClient = old })
+	return w, router, match
+}
+
+func joinQueue(w *Worker, userID string) map[string]any {
+	session := NewSession("conn-"+userID, w)
+	session.SetUserID(userID)
+	res, _ := w.joinQueueHandler(session, []byte(`{"poolID":"classic:casual4"}`))
+	return res.(map[string]any)
+}
+
+// 对局进行中的玩家不入队，返回原对局所在的 game 节点
+func TestJoinQueueRejoinsActiveGame(t *testing.T) {
+	w, router, match := newQueueTestWorker(t)
+	router.games["u1"] = "game-1"
+
+	res := joinQueue(w, "u1")
+	if res["rejoin"] != true || res["gameNodeID"] != "game-1" || res["success"] != false {
+		t.Fatalf("应回到对局 game-1, got %v", res)
+	}
+	if len(match.joined) != 0 {
+		t.Fatalf("对局中的玩家不应入队, got %v", match.joined)
+	}
+}
+
+// 没有对局路由，或者对局结束清理路由后，玩家正常入队
+func TestJoinQueueEnqueuesWithoutActiveGame(t *testing.T) {
+	w, router, match := newQueueTestWorker(t)
+	router.games["u2"] = "game-1"
+	w.clearGameRoute([]string{"u2"})
+	// Redis 中的路由异步删除
+	deadline := time.Now().Add(time.Second)
+	for {
+		if gameNodeID, _ := router.GetGameRouter(context.Background(), "u2"); gameNodeID == "" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("对局结束后应清理对局路由")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for _, userID := range []string{"u1", "u2"} {
+		if res := joinQueue(w, userID); res["rejoin"] != nil || res["message"] != "ok" {
+			t.Fatalf("%s 应正常入队, got %v", userID, res)
+		}
+	}
+	if !slices.Equal(match.joined, []string{"u1", "u2"}) {
+		t.Fatalf("入队玩家 = %v, want [u1 u2]", match.joined)
+	}
+}
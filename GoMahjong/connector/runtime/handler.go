@@ -18,7 +18,11 @@ func (w *Worker) injectDefaultHandlers() {
 	w.clientHandlers[protocol.Data] = w.messageHandler
 	w.clientHandlers[protocol.Kick] = w.kickHandler
 
-	w.MessageTypeHandlers[transfer.JoinQueue] = joinQueueHandler
+	w.MessageTypeHandlers[transfer.JoinQueue] = w.joinQueueHandler
+	w.MessageTypeHandlers[transfer.LeaveQueue] = leaveQueueHandler
+	w.MessageTypeHandlers[transfer.CreatePrivateRoom] = w.createPrivateRoomHandler
+	w.MessageTypeHandlers[transfer.JoinPrivateRoom] = w.joinPrivateRoomHandler
+	w.MessageTypeHandlers[transfer.Spectate] = w.spectateHandler
 }
 
 // nats 消息路由
@@ -4,8 +4,9 @@ import (
 	"connector/infrastructure/log"
 	"connector/infrastructure/message/protocol"
 	"connector/infrastructure/message/transfer"
+	"context"
 	"encoding/json"
-	"fmt"
+	"time"
 )
 
 // handlePush 处理所有 Push 类型消息
@@ -15,8 +16,12 @@ func (w *Worker) handlePush(users []string, body *protocol.Message, route string
 		w.handleMatchSuccessPush(users, body)
 	case transfer.GamePush:
 		w.handleGamePush(users, body)
+	case transfer.GamePushBatch:
+		w.handleGamePushBatch(body)
+	case transfer.PrivateRoomUpdate:
+		w.handlePrivateRoomPush(users, body)
 	default:
-		log.Warn(fmt.Sprintf("connector handlePush 未知消息类型: %s", route))
+		log.Warn("connector handlePush 未知消息类型: %s", route)
 	}
 }
 
@@ -30,7 +35,7 @@ func (w *Worker) handleMatchSuccessPush(users []string, body *protocol.Message)
 	}
 
 	if len(failedUsers) > 0 {
-		log.Warn(fmt.Sprintf("connector handleMatchSuccessPush 发送失败的用户: %v", failedUsers))
+		log.Warn("connector handleMatchSuccessPush 发送失败的用户: %v", failedUsers)
 	}
 }
 
@@ -44,7 +49,53 @@ func (w *Worker) handleGamePush(users []string, body *protocol.Message) {
 	}
 
 	if len(failedUsers) > 0 {
-		log.Warn(fmt.Sprintf("connector handleGamePush 发送失败的用户: %v", failedUsers))
+		log.Warn("connector handleGamePush 发送失败的用户: %v", failedUsers)
+	}
+	if body.Route == transfer.GameplayGameEnd {
+		w.clearGameRoute(users)
+	}
+}
+
+// handleGamePushBatch 处理 game 节点合并的推送：按用户拆分，同一用户的多条消息按顺序编码成连续的 pomelo 包，
+// 在一个 websocket 帧中发出，客户端按包头长度依次解包
+func (w *Worker) handleGamePushBatch(body *protocol.Message) {
+	var items []transfer.PushBatchItem
+	if err := json.Unmarshal(body.Data, &items); err != nil {
+		log.Warn("connector handleGamePushBatch 解析失败: %v", err)
+		return
+	}
+
+	var order []string
+	userMessages := make(map[string][][]byte)
+	for _, item := range items {
+		for _, userID := range item.Users {
+			if _, exists := userMessages[userID]; !exists {
+				order = append(order, userID)
+			}
+			userMessages[userID] = append(userMessages[userID], item.Data)
+		}
+		if item.Route == transfer.GameplayGameEnd {
+			w.clearGameRoute(item.Users)
+		}
+	}
+
+	var failedUsers []error
+	for _, userID := range order {
+		if err := w.sendBatch(protocol.Push, userID, transfer.GamePush, userMessages[userID]); err != nil {
+			failedUsers = append(failedUsers, err)
+		}
+	}
+	if len(failedUsers) > 0 {
+		log.Warn("connector handleGamePushBatch 发送失败的用户: %v", failedUsers)
+	}
+}
+
+// handlePrivateRoomPush 处理私人房间状态推送
+func (w *Worker) handlePrivateRoomPush(users []string, body *protocol.Message) {
+	for _, userID := range users {
+		if err := w.send(protocol.Push, userID, transfer.PrivateRoomUpdate, body.Data); err != nil {
+			log.Warn("connector handlePrivateRoomPush 发送失败: %v", err)
+		}
 	}
 }
 
@@ -52,13 +103,22 @@ func (w *Worker) handleGamePush(users []string, body *protocol.Message) {
 func (w *Worker) handlerMatchSuccess(message []byte) any {
 	var msg transfer.MatchSuccessDTO
 	if err := json.Unmarshal(message, &msg); err != nil {
-		log.Error(fmt.Sprintf("connector 解析匹配成功消息失败: %v", err))
+		log.Error("connector 解析匹配成功消息失败: %v", err)
 		return nil
 	}
 
 	for userID := range msg.Players {
 		w.GameRouteCache.Set(userID, msg.GameNodeID)
-		log.Info(fmt.Sprintf("connector 保存用户路由: %s -> %s", userID, msg.GameNodeID))
+		log.Info("connector 保存用户路由: %s -> %s", userID, msg.GameNodeID)
+		go func(userID string) {
+			// 观战时按被观战玩家查找 game 节点，保存失败只影响观战
+			_ = w.UserRouter.SaveGameRouter(context.Background(), userID, msg.GameNodeID, 2*time.Hour)
+		}(userID)
+		if conn, ok := w.connMap.Load(userID); ok {
+			if c, ok := conn.(Connection); ok && c.TakeSession() != nil {
+				w.notifyGameSerializer(userID, c.TakeSession().GetSerializer())
+			}
+		}
 	}
 
 	return nil
@@ -0,0 +1,90 @@
+package conn
+
+import (
+	"connector/infrastructure/message/protocol"
+	"connector/infrastructure/message/transfer"
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+// frameConnection 记录每次发送的 websocket 帧
+type frameConnection struct {
+	session *Session
+	frames  [][]byte
+}
+
+func (c *frameConnection) TakeSession() *Session { return c.session }
+func (c *frameConnection) SendMessage(buf []byte) error {
+	c.frames = append(c.frames, buf)
+	return nil
+}
+func (c *frameConnection) Kick([]byte)     {}
+func (c *frameConnection) ResetHeartbeat() {}
+func (c *frameConnection) Close()          {}
+
+// splitFrame 按包头长度把一个帧拆成连续的 pomelo 数据包
+func splitFrame(t *testing.T, frame []byte) []string {
+	t.Helper()
+	var data []string
+	for len(frame) > 0 {
+		if len(frame) < protocol.HeaderLen {
+			t.Fatalf("帧末尾有残缺的包头: %v", frame)
+		}
+		end := protocol.HeaderLen + protocol.BytesToInt(frame[1:protocol.HeaderLen])
+		p, err := protocol.Decode(frame[:end])
+		if err != nil || p.Type != protocol.Data {
+			t.Fatalf("Decode: %v", err)
+		}
+		m := p.Body.(protocol.Message)
+		if m.Type != protocol.Push || m.Route != transfer.GamePush {
+			t.Fatalf("拆出的消息应为 game.push 推送, got %+v", m)
+		}
+		data = append(data, string(m.Data))
+		frame = frame[end:]
+	}
+	return data
+}
+
+// 合并推送按用户拆分：支持合并帧的用户收到一个帧，帧内按顺序是发给该用户的全部消息；旧客户端每条消息一帧
+func TestHandleGamePushBatch(t *testing.T) {
+	w := &Worker{}
+	u1 := &frameConnection{session: NewSession("c1", w)}
+	u1.session.SetCapabilities(protocol.Capabilities{ProtoVersion: protocol.ProtoVersionBatch})
+	u2 := &frameConnection{session: NewSession("c2", w)}
+	w.connMap.Store("u1", u1)
+	w.connMap.Store("u2", u2)
+
+	items := []transfer.PushBatchItem{
+		{Users: []string{"u1", "u2"}, Route: "gameplay.round.start", Data: []byte(`{"n":1}`)},
+		{Users: []string{"u1"}, Route: "gameplay.draw", Data: []byte(`{"n":2}`)},
+		{Users: []string{"u1", "u2", "u3"}, Route: "gameplay.discard", Data: []byte(`{"n":3}`)},
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	w.handlePush([]string{"u1", "u2", "u3"}, &protocol.Message{Type: protocol.Push, Route: transfer.GamePushBatch, Data: data}, transfer.GamePushBatch)
+
+	if len(u1.frames) != 1 {
+		t.Fatalf("u1 应只收到一个帧, got %d", len(u1.frames))
+	}
+	assertMessages(t, splitFrame(t, u1.frames[0]), `{"n":1}`, `{"n":2}`, `{"n":3}`)
+
+	var legacy []string
+	for _, frame := range u2.frames {
+		messages := splitFrame(t, frame)
+		if len(messages) != 1 {
+			t.Fatalf("旧客户端每帧只应有一条消息, got %v", messages)
+		}
+		legacy = append(legacy, messages...)
+	}
+	assertMessages(t, legacy, `{"n":1}`, `{"n":3}`)
+}
+
+func assertMessages(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if !slices.Equal(got, want) {
+		t.Fatalf("拆包得到 %v, want %v", got, want)
+	}
+}
@@ -8,16 +8,16 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 )
 
 func (w *Worker) handshakeHandler(packet *protocol.Packet, conn Connection) error {
 	log.Debug("握手事件发生: %#v", packet.ParseBody())
-	res := protocol.HandshakeResponse{
-		Code: 200,
-		Sys: protocol.Sys{
-			Heartbeat: 3,
-		},
+	var sys protocol.Sys
+	if body, ok := packet.Body.(protocol.HandshakeBody); ok {
+		sys = body.Sys
 	}
+	res := w.negotiate(sys, conn.TakeSession())
 	data, _ := json.Marshal(res)
 	buf, err := protocol.Wrap(packet.Type, data)
 	if err != nil {
@@ -27,6 +27,50 @@ func (w *Worker) handshakeHandler(packet *protocol.Packet, conn Connection) erro
 	return conn.SendMessage(buf)
 }
 
+// negotiate 协商客户端能力，成功时保存到 session；协议版本过低的客户端返回 HandshakeCodeOldClient，由客户端断开
+func (w *Worker) negotiate(sys protocol.Sys, session *Session) protocol.HandshakeResponse {
+	caps, ok := protocol.Negotiate(sys, w.MinProtoVersion)
+	if !ok {
+		log.Warn("握手拒绝: 客户端协议版本 %d 低于最低要求 %d", sys.ProtoVersion, w.MinProtoVersion)
+		return protocol.HandshakeResponse{
+			Code: protocol.HandshakeCodeOldClient,
+			Sys:  protocol.Sys{ProtoVersion: w.MinProtoVersion},
+		}
+	}
+	res := protocol.HandshakeResponse{
+		Code: protocol.HandshakeCodeOK,
+		Sys: protocol.Sys{
+			Heartbeat:    uint8(heartbeatInterval / time.Second),
+			ProtoVersion: caps.ProtoVersion,
+			Serializer:   caps.Serializer,
+			Compression:  caps.Compression,
+		},
+	}
+	if session == nil {
+		return res
+	}
+
+	userID := session.GetUserID()
+	gameNodeID := ""
+	if sys.ResumeToken != "" && userID != "" {
+		var err error
+		// 令牌无效时照常建立连接，客户端按普通重连处理
+		if gameNodeID, err = w.resumeSession(sys.ResumeToken, userID); err != nil {
+			log.Warn("续连令牌校验失败: userID=%s, err=%v", userID, err)
+		}
+	}
+	session.SetCapabilities(caps)
+	// 对局中重连的玩家可能换了推送格式，需要在下发快照之前通知
+	w.notifyGameSerializer(userID, caps.Serializer)
+	if gameNodeID != "" {
+		w.notifyGameReconnect(userID, gameNodeID)
+		res.Resumed = true
+		log.Info("玩家 %s 凭续连令牌恢复对局, gameNode=%s", userID, gameNodeID)
+	}
+	res.Sys.ResumeToken = w.issueResumeToken(session)
+	return res
+}
+
 func (w *Worker) handshakeAckHandler(packet *protocol.Packet, conn Connection) error {
 	log.Debug("握手确认事件发生: %#v", packet.ParseBody())
 	return nil
@@ -34,6 +78,7 @@ func (w *Worker) handshakeAckHandler(packet *protocol.Packet, conn Connection) e
 
 func (w *Worker) heartbeatHandler(packet *protocol.Packet, conn Connection) error {
 	log.Debug("心跳事件发生: %#v", packet.ParseBody())
+	conn.ResetHeartbeat()
 	var res []byte
 	data, _ := json.Marshal(res)
 	buf, err := protocol.Wrap(packet.Type, data)
@@ -50,6 +95,12 @@ func (w *Worker) messageHandler(packet *protocol.Packet, conn Connection) error
 	parse := packet.ParseBody()
 	routes := parse.Route
 
+	if w.UserRateLimiter != nil {
+		if userID := conn.TakeSession().GetUserID(); userID != "" && !w.UserRateLimiter.Allow(userID) {
+			return fmt.Errorf("用户 %s 消息过于频繁，丢弃 route: %s", userID, routes)
+		}
+	}
+
 	routeList := strings.Split(routes, ".")
 	if len(routeList) < 2 {
 		return errors.New(fmt.Sprintf("route 格式错误, %v", parse))
@@ -0,0 +1,67 @@
+package conn
+
+import (
+	"connector/infrastructure/message/protocol"
+	"encoding/json"
+	"testing"
+)
+
+// handshake 发送一次握手，返回服务端的握手响应
+func handshake(t *testing.T, w *Worker, conn *frameConnection, sys protocol.Sys) protocol.HandshakeResponse {
+	t.Helper()
+	body, err := json.Marshal(protocol.HandshakeBody{Sys: sys})
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	buf, err := protocol.Wrap(protocol.Handshake, body)
+	if err != nil {
+		t.Fatalf("Wrap: %v", err)
+	}
+	packet, err := protocol.Decode(buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if err := w.handshakeHandler(packet, conn); err != nil {
+		t.Fatalf("handshakeHandler: %v", err)
+	}
+	if len(conn.frames) != 1 {
+		t.Fatalf("握手应回复一个帧, got %d", len(conn.frames))
+	}
+	var res protocol.HandshakeResponse
+	if err := json.Unmarshal(conn.frames[0][protocol.HeaderLen:], &res); err != nil {
+		t.Fatalf("解析握手响应: %v", err)
+	}
+	return res
+}
+
+// 客户端声明的版本高于服务端时降到服务端版本，协商结果返回给客户端并保存到 session
+func TestHandshakeNegotiation(t *testing.T) {
+	w := &Worker{MinProtoVersion: protocol.ProtoVersionBatch}
+	conn := &frameConnection{session: NewSession("c1", w)}
+	res := handshake(t, w, conn, protocol.Sys{ProtoVersion: 9, Serializer: protocol.SerializerProtobuf, Compression: "gzip"})
+
+	if res.Code != protocol.HandshakeCodeOK || res.Sys.Heartbeat == 0 {
+		t.Fatalf("握手应成功, got %+v", res)
+	}
+	if res.Sys.ProtoVersion != protocol.CurrentProtoVersion || res.Sys.Serializer != protocol.SerializerProtobuf || res.Sys.Compression != protocol.CompressionNone {
+		t.Fatalf("协商结果不对: %+v", res.Sys)
+	}
+	if conn.session.GetProtoVersion() != protocol.CurrentProtoVersion || conn.session.GetSerializer() != protocol.SerializerProtobuf {
+		t.Fatalf("协商结果应保存到 session")
+	}
+}
+
+// 协议版本低于最低要求（含未声明版本的旧客户端）时返回 HandshakeCodeOldClient，session 保持未协商
+func TestHandshakeRejectsOldClient(t *testing.T) {
+	w := &Worker{MinProtoVersion: protocol.ProtoVersionBatch}
+	for _, version := range []uint8{0, protocol.ProtoVersionLegacy} {
+		conn := &frameConnection{session: NewSession("c1", w)}
+		res := handshake(t, w, conn, protocol.Sys{ProtoVersion: version, Serializer: protocol.SerializerProtobuf})
+		if res.Code != protocol.HandshakeCodeOldClient || res.Sys.ProtoVersion != protocol.ProtoVersionBatch {
+			t.Fatalf("版本 %d 应被拒绝并返回最低版本, got %+v", version, res)
+		}
+		if conn.session.GetSerializer() != "" {
+			t.Fatalf("被拒绝的握手不应保存协商结果")
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package conn
+
+import (
+	"connector/infrastructure/log"
+	"connector/infrastructure/message/transfer"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// defaultResumeTokenTTL 续连令牌在断线后的默认有效期
+const defaultResumeTokenTTL = 2 * time.Minute
+
+var (
+	errResumeTokenExpired  = errors.New("续连令牌不存在或已过期")
+	errResumeTokenMismatch = errors.New("续连令牌不属于当前用户")
+)
+
+/*
+续连令牌：
+ 1. 握手成功后签发，保存在 session 和 Redis（token -> userID）中，随握手响应返回给客户端
+ 2. 连接断开时重置有效期（已使用的令牌不会被重置），客户端在有效期内重连并在握手中带上令牌，即可跳过匹配直接恢复对局
+ 3. 令牌只能使用一次，每次握手都会签发新令牌
+*/
+
+// newResumeToken 生成随机令牌
+func newResumeToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// issueResumeToken 为当前连接签发续连令牌，失败时返回空串，客户端断线后只能走普通重连
+func (w *Worker) issueResumeToken(session *Session) string {
+	userID := session.GetUserID()
+	if userID == "" || w.UserRouter == nil {
+		return ""
+	}
+	token, err := newResumeToken()
+	if err != nil {
+		log.Error("生成续连令牌失败: userID=%s, err=%v", userID, err)
+		return ""
+	}
+
+	// 连接期间令牌同样有效，断开时 refreshResumeToken 再从断开时刻开始计算有效期
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := w.UserRouter.SaveResumeToken(ctx, token, userID, w.ResumeTokenTTL); err != nil {
+		return ""
+	}
+	session.SetResumeToken(token)
+	return token
+}
+
+// refreshResumeToken 连接断开时重置令牌有效期
+func (w *Worker) refreshResumeToken(session *Session) {
+	token, userID := session.GetResumeToken(), session.GetUserID()
+	if token == "" || userID == "" || w.UserRouter == nil {
+		return
+	}
+	go func() {
+		// 只刷新仍然存在的令牌：服务端发现半开连接断开时，客户端可能已经用这个令牌在新连接上续连
+		// 刷新失败时令牌按签发时的有效期过期
+		_ = w.UserRouter.RefreshResumeToken(context.Background(), token, w.ResumeTokenTTL)
+	}()
+}
+
+// resumeSession 校验客户端出示的续连令牌并恢复对局路由，令牌校验通过后即作废
+// 返回玩家所在的 game 节点，为空表示玩家不在对局中
+func (w *Worker) resumeSession(token, userID string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	// 取出和作废是一次原子操作，同一令牌的并发握手只有一个能通过；读取失败按校验失败处理
+	owner, err := w.UserRouter.ConsumeResumeToken(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	if owner == "" {
+		return "", errResumeTokenExpired
+	}
+	if owner != userID {
+		return "", errResumeTokenMismatch
+	}
+
+	// 换了 connector 时本地没有对局路由，从 Redis 恢复
+	return w.activeGameRoute(ctx, userID)
+}
+
+// notifyGameReconnect 通知 game 节点玩家已重连到本 connector，由引擎下发重连快照
+func (w *Worker) notifyGameReconnect(userID, gameNodeID string) {
+	w.notifyGame(gameNodeID, transfer.GameReconnect, map[string]string{"userID": userID, "connectorNodeID": w.nodeID})
+}
@@ -0,0 +1,208 @@
+package conn
+
+import (
+	"connector/infrastructure/cache"
+	"connector/infrastructure/log"
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	log.InitLog("connector_test", "error")
+	os.Exit(m.Run())
+}
+
+// memoryUserRouter 内存中的 UserRouterRepository，令牌的读写语义与 Redis 实现一致（不模拟过期）
+type memoryUserRouter struct {
+	mu         sync.Mutex
+	games      map[string]string
+	tokens     map[string]string
+	consumeErr error
+	refreshed  chan string
+}
+
+func newMemoryUserRouter() *memoryUserRouter {
+	return &memoryUserRouter{
+		games:     make(map[string]string),
+		tokens:    make(map[string]string),
+		refreshed: make(chan string, 8),
+	}
+}
+
+func (r *memoryUserRouter) SaveConnectorRouter(context.Context, string, string, time.Duration) error {
+	return nil
+}
+
+func (r *memoryUserRouter) GetConnectorRouter(context.Context, string) (string, error) {
+	return "", nil
+}
+
+func (r *memoryUserRouter) DeleteConnectorRouter(context.Context, string) error {
+	return nil
+}
+
+func (r *memoryUserRouter) SaveGameRouter(_ context.Context, userID, gameNodeID string, _ time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.games[userID] = gameNodeID
+	return nil
+}
+
+func (r *memoryUserRouter) GetGameRouter(_ context.Context, userID string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.games[userID], nil
+}
+
+func (r *memoryUserRouter) DeleteGameRouter(_ context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.games, userID)
+	return nil
+}
+
+func (r *memoryUserRouter) HasGameRouter(_ context.Context, userID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.games[userID]
+	return ok, nil
+}
+
+func (r *memoryUserRouter) SaveResumeToken(_ context.Context, token, userID string, _ time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[token] = userID
+	return nil
+}
+
+// RefreshResumeToken 与 EXPIRE 相同，不存在的令牌不会被创建；内存实现没有过期，只记录调用
+func (r *memoryUserRouter) RefreshResumeToken(_ context.Context, token string, _ time.Duration) error {
+	r.refreshed <- token
+	return nil
+}
+
+func (r *memoryUserRouter) ConsumeResumeToken(_ context.Context, token string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.consumeErr != nil {
+		return "", r.consumeErr
+	}
+	owner := r.tokens[token]
+	delete(r.tokens, token)
+	return owner, nil
+}
+
+func (r *memoryUserRouter) hasToken(token string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.tokens[token]
+	return ok
+}
+
+func newResumeTestWorker(t *testing.T) (*Worker, *memoryUserRouter) {
+	t.Helper()
+	routeCache, err := cache.NewGameRouteCache()
+	if err != nil {
+		t.Fatalf("NewGameRouteCache: %v", err)
+	}
+	router := newMemoryUserRouter()
+	return &Worker{UserRouter: router, GameRouteCache: routeCache, ResumeTokenTTL: time.Minute}, router
+}
+
+// issueForUser 模拟一次握手：为 userID 的连接签发令牌
+func issueForUser(t *testing.T, w *Worker, userID string) (*Session, string) {
+	t.Helper()
+	session := NewSession("conn-"+userID, w)
+	session.SetUserID(userID)
+	token := w.issueResumeToken(session)
+	if token == "" {
+		t.Fatalf("签发续连令牌失败")
+	}
+	return session, token
+}
+
+func TestResumeSession(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		w, router := newResumeTestWorker(t)
+		router.games["u1"] = "game-1"
+		_, token := issueForUser(t, w, "u1")
+
+		gameNodeID, err := w.resumeSession(token, "u1")
+		if err != nil || gameNodeID != "game-1" {
+			t.Fatalf("续连应恢复对局路由, got %q, %v", gameNodeID, err)
+		}
+		if _, err := w.resumeSession(token, "u1"); !errors.Is(err, errResumeTokenExpired) {
+			t.Fatalf("令牌只能使用一次, got %v", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		w, _ := newResumeTestWorker(t)
+		if _, err := w.resumeSession("unknown", "u1"); !errors.Is(err, errResumeTokenExpired) {
+			t.Fatalf("不存在的令牌应被拒绝, got %v", err)
+		}
+	})
+
+	t.Run("mismatched", func(t *testing.T) {
+		w, _ := newResumeTestWorker(t)
+		_, token := issueForUser(t, w, "u1")
+		if _, err := w.resumeSession(token, "u2"); !errors.Is(err, errResumeTokenMismatch) {
+			t.Fatalf("其他用户的令牌应被拒绝, got %v", err)
+		}
+	})
+
+	t.Run("consume error", func(t *testing.T) {
+		w, router := newResumeTestWorker(t)
+		_, token := issueForUser(t, w, "u1")
+		router.consumeErr = errors.New("redis down")
+		if _, err := w.resumeSession(token, "u1"); err == nil {
+			t.Fatalf("令牌读取失败时应拒绝续连")
+		}
+	})
+}
+
+// 同一令牌的并发握手只有一个能通过
+func TestResumeSessionConcurrent(t *testing.T) {
+	w, _ := newResumeTestWorker(t)
+	_, token := issueForUser(t, w, "u1")
+
+	var ok atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := w.resumeSession(token, "u1"); err == nil {
+				ok.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := ok.Load(); got != 1 {
+		t.Fatalf("并发续连通过 %d 次, want 1", got)
+	}
+}
+
+// 旧连接晚于续连才被发现断开时，刷新不能让已使用的令牌复活
+func TestRefreshAfterResumeKeepsTokenConsumed(t *testing.T) {
+	w, router := newResumeTestWorker(t)
+	oldSession, token := issueForUser(t, w, "u1")
+
+	if _, err := w.resumeSession(token, "u1"); err != nil {
+		t.Fatalf("续连失败: %v", err)
+	}
+	w.refreshResumeToken(oldSession)
+	select {
+	case <-router.refreshed:
+	case <-time.After(time.Second):
+		t.Fatalf("断线后没有刷新令牌")
+	}
+	if router.hasToken(token) {
+		t.Fatalf("已使用的令牌被断线刷新重新创建")
+	}
+}
@@ -1,16 +1,21 @@
 package conn
 
 import (
+	"connector/infrastructure/message/protocol"
 	"sync"
 )
 
 type Session struct {
 	sync.RWMutex
-	ConnID string                 // 连接 ID
-	UserID string                 // 用户 ID
-	data   map[string]interface{} // 单连接数据（仅当前连接可见）
-	all    map[string]interface{} // 全局共享数据（所有连接可见）
-	worker *Worker
+	ConnID       string                 // 连接 ID
+	UserID       string                 // 用户 ID
+	Serializer   string                 // 握手时协商的推送格式
+	ProtoVersion uint8                  // 握手时协商的协议版本
+	Compression  string                 // 握手时协商的压缩方式
+	ResumeToken  string                 // 握手时签发的续连令牌
+	data         map[string]interface{} // 单连接数据（仅当前连接可见）
+	all          map[string]interface{} // 全局共享数据（所有连接可见）
+	worker       *Worker
 }
 
 func NewSession(connID string, worker *Worker) *Session {
@@ -52,6 +57,43 @@ func (s *Session) GetUserID() string {
 	return s.UserID
 }
 
+// SetCapabilities 保存握手协商的结果
+func (s *Session) SetCapabilities(caps protocol.Capabilities) {
+	s.Lock()
+	s.Serializer = caps.Serializer
+	s.ProtoVersion = caps.ProtoVersion
+	s.Compression = caps.Compression
+	s.Unlock()
+}
+
+func (s *Session) GetSerializer() string {
+	s.RLock()
+	defer s.RUnlock()
+	return s.Serializer
+}
+
+// GetProtoVersion 协商的协议版本，尚未握手时按 ProtoVersionLegacy 处理
+func (s *Session) GetProtoVersion() uint8 {
+	s.RLock()
+	defer s.RUnlock()
+	if s.ProtoVersion == 0 {
+		return protocol.ProtoVersionLegacy
+	}
+	return s.ProtoVersion
+}
+
+func (s *Session) SetResumeToken(token string) {
+	s.Lock()
+	s.ResumeToken = token
+	s.Unlock()
+}
+
+func (s *Session) GetResumeToken() string {
+	s.RLock()
+	defer s.RUnlock()
+	return s.ResumeToken
+}
+
 func (s *Session) Close() {
 	s.Lock()
 	defer s.Unlock()
@@ -39,6 +39,9 @@ import (
 	支持 websocket、TCP、KCP、UDP 等
 */
 
+// drainTimeout 关闭时每个阶段（停止监听、排空工作池、等待连接退出）的最长等待时间
+const drainTimeout = 5 * time.Second
+
 type CheckOriginHandler func(r *http.Request) bool
 
 type PacketTypeHandler func(packet *protocol.Packet, c Connection) error
@@ -64,6 +67,9 @@ type Worker struct {
 	bucketMask            uint32
 	clientWorkerCount     int
 	ConnectionRateLimiter *ratelimiter.RateLimiter
+	UserRateLimiter       *ratelimiter.UserRateLimiter // 单用户消息限流
+	MinProtoVersion       uint8                        // 握手允许的最低客户端协议版本，0 表示不限制
+	ResumeTokenTTL        time.Duration                // 续连令牌在断线后的有效期
 	MiddleWorker          *node.NatsWorker
 	MessageTypeHandlers   MessageTypeHandler // see: pomelo_handler.go
 
@@ -79,8 +85,16 @@ type Worker struct {
 	connMap   sync.Map
 	isRunning bool
 
+	// 优雅关闭
+	httpServer   *http.Server
+	closing      atomic.Bool   // 关闭中：拒绝新连接，丢弃客户端新消息
+	pendingPacks atomic.Int64  // 已投递到 clientWorkers 但尚未处理完的消息数
+	workerQuit   chan struct{} // 通知 clientWorkerRoutine 退出
+	workerWG     sync.WaitGroup
+
 	GameRouteCache *cache.GameRouteCache
 	UserRouter     repository.UserRouterRepository
+	PrivateRoom    repository.PrivateRoomRepository
 }
 
 // NewWorkerWithDeps 接收依赖的构造函数（推荐用于生产环境）
@@ -98,6 +112,8 @@ func NewWorkerWithDeps(opts ...WorkerOption) *Worker {
 		clientWorkerCount:   workerCount,
 		maxConnectionCount:  100000,
 		connSemaphore:       make(chan struct{}, 100000),
+		workerQuit:          make(chan struct{}),
+		ResumeTokenTTL:      defaultResumeTokenTTL,
 	}
 	for _, opt := range opts {
 		if err := opt(w); err != nil {
@@ -156,6 +172,7 @@ func (w *Worker) Run(topic string, addr string) error {
 	// 启动 WebSocket 服务
 	log.Info("websocket worker 正在启动服务")
 	for i := range w.clientWorkerCount {
+		w.workerWG.Add(1)
 		go w.clientWorkerRoutine(i)
 	}
 
@@ -166,7 +183,11 @@ func (w *Worker) Run(topic string, addr string) error {
 	http.HandleFunc("/ws/", w.upgradeFunc) // 注意匹配子路径
 	log.Info("websocket worker 启动了 %d 个 worker 协程和 %d 个连接分片桶", w.clientWorkerCount, len(w.clientBuckets))
 	log.Info("http 监听地址 %s", addr)
-	return http.ListenAndServe(addr, nil)
+	w.httpServer = &http.Server{Addr: addr}
+	if err := w.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
 func (w *Worker) upgradeFunc(writer http.ResponseWriter, r *http.Request) {
@@ -176,6 +197,10 @@ func (w *Worker) upgradeFunc(writer http.ResponseWriter, r *http.Request) {
 		log.Warn("连接鉴权失败 remote=%s err=%v", r.RemoteAddr, err)
 		return
 	}
+	if w.closing.Load() {
+		http.Error(writer, "Server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
 	if !w.ConnectionRateLimiter.Allow() {
 		http.Error(writer, "Too many connections", http.StatusTooManyRequests)
 		log.Warn("连接速率限流 exceeded from %s", r.RemoteAddr)
@@ -202,8 +227,8 @@ func (w *Worker) upgradeFunc(writer http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 写超时由写协程在每次写入前设置
 	conn.SetReadDeadline(time.Now().Add(120 * time.Second))
-	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 
 	client := takeLongConnection(conn, w)
 	client.TakeSession().SetUserID(userID)
@@ -250,6 +275,8 @@ func (w *Worker) removeClient(con *LongConnection) {
 
 	if session := con.TakeSession(); session != nil {
 		w.UnbindUser(session.GetUserID(), con)
+		w.notifyGameDisconnect(session.GetUserID())
+		w.refreshResumeToken(session)
 	}
 
 	con.Close()
@@ -265,16 +292,23 @@ func (w *Worker) removeClient(con *LongConnection) {
 }
 
 func (w *Worker) clientWorkerRoutine(workerID int) {
-	for messagePack := range w.clientWorkers[workerID] {
-		startTime := time.Now()
+	defer w.workerWG.Done()
+	for {
+		select {
+		case <-w.workerQuit:
+			return
+		case messagePack := <-w.clientWorkers[workerID]:
+			startTime := time.Now()
 
-		w.DecodeAndHandlePack(messagePack)
+			w.DecodeAndHandlePack(messagePack)
+			w.pendingPacks.Add(-1)
 
-		processingTime := time.Since(startTime).Milliseconds()
-		atomic.AddInt64(&w.stats.messageProcessed, 1)
-		oldAvg := atomic.LoadInt64(&w.stats.avgProcessingTime)
-		newAvg := (oldAvg*9 + processingTime) / 10
-		atomic.StoreInt64(&w.stats.avgProcessingTime, newAvg)
+			processingTime := time.Since(startTime).Milliseconds()
+			atomic.AddInt64(&w.stats.messageProcessed, 1)
+			oldAvg := atomic.LoadInt64(&w.stats.avgProcessingTime)
+			newAvg := (oldAvg*9 + processingTime) / 10
+			atomic.StoreInt64(&w.stats.avgProcessingTime, newAvg)
+		}
 	}
 }
 
@@ -426,6 +460,9 @@ func (w *Worker) UnbindUser(userID string, conn Connection) {
 	if stored, ok := w.connMap.Load(userID); ok {
 		if conn == nil || stored == conn {
 			w.connMap.Delete(userID)
+			if w.UserRateLimiter != nil {
+				w.UserRateLimiter.Remove(userID)
+			}
 		}
 	}
 	go func() {
@@ -434,6 +471,78 @@ func (w *Worker) UnbindUser(userID string, conn Connection) {
 	}()
 }
 
+// notifyGameDisconnect 玩家在对局中且没有其他连接时，通知 game 节点暂停其计时
+func (w *Worker) notifyGameDisconnect(userID string) {
+	if userID == "" || w.GameRouteCache == nil {
+		return
+	}
+	if _, ok := w.connMap.Load(userID); ok {
+		return
+	}
+	gameNodeID, ok := w.GameRouteCache.Get(userID)
+	if !ok {
+		return
+	}
+	w.notifyGame(gameNodeID, transfer.GameDisconnect, map[string]string{"userID": userID})
+}
+
+// activeGameRoute 查路由：玩家进行中对局所在的 game 节点，为空表示不在对局中
+// 本地缓存只记录本 connector 上匹配成功的玩家，没有时查 Redis（对局可能在其他 connector 上开始）
+func (w *Worker) activeGameRoute(ctx context.Context, userID string) (string, error) {
+	if gameNodeID, ok := w.GameRouteCache.Get(userID); ok {
+		return gameNodeID, nil
+	}
+	gameNodeID, err := w.UserRouter.GetGameRouter(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if gameNodeID != "" {
+		w.GameRouteCache.Set(userID, gameNodeID)
+	}
+	return gameNodeID, nil
+}
+
+// clearGameRoute 对局结束后清理玩家的对局路由，之后才能重新匹配
+func (w *Worker) clearGameRoute(userIDs []string) {
+	w.GameRouteCache.DeleteBatch(userIDs)
+	go func() {
+		for _, userID := range userIDs {
+			// 删除失败时路由按 TTL 过期
+			_ = w.UserRouter.DeleteGameRouter(context.Background(), userID)
+		}
+	}()
+}
+
+// notifyGameSerializer 玩家在对局中时，通知 game 节点按其协商的格式编码推送
+func (w *Worker) notifyGameSerializer(userID, serializer string) {
+	if userID == "" || serializer == "" || w.GameRouteCache == nil {
+		return
+	}
+	gameNodeID, ok := w.GameRouteCache.Get(userID)
+	if !ok {
+		return
+	}
+	w.notifyGame(gameNodeID, transfer.GameSerializer, map[string]string{"userID": userID, "serializer": serializer})
+}
+
+// notifyGame 向 game 节点发送 Notify 消息
+func (w *Worker) notifyGame(gameNodeID, route string, body any) {
+	data, _ := json.Marshal(body)
+	packet := &transfer.ServicePacket{
+		Body: &protocol.Message{
+			Type:  protocol.Notify,
+			Route: route,
+			Data:  data,
+		},
+		Source:      w.nodeID,
+		Destination: gameNodeID,
+		Route:       route,
+	}
+	if err := w.MiddleWorker.PushMessage(packet); err != nil {
+		log.Warn("通知 game 节点 %s 失败, route: %s, err: %v", gameNodeID, route, err)
+	}
+}
+
 func (w *Worker) send(messageType protocol.MessageType, userID string, route string, body any) error {
 	connAny, ok := w.connMap.Load(userID)
 	if !ok {
@@ -479,7 +588,53 @@ func (w *Worker) send(messageType protocol.MessageType, userID string, route str
 		return fmt.Errorf("发送消息给玩家 %s 失败: %w", userID, err)
 	}
 
-	log.Info(fmt.Sprintf("connector send 发送消息给玩家 %s, route: %s", userID, route))
+	log.Info("connector send 发送消息给玩家 %s, route: %s", userID, route)
+	return nil
+}
+
+// sendBatch 把多条消息编码成连续的 pomelo 包，作为一个 websocket 帧发送给玩家
+// 协商的协议版本不支持合并帧的客户端，每个包单独发送一帧
+func (w *Worker) sendBatch(messageType protocol.MessageType, userID string, route string, bodies [][]byte) error {
+	connAny, ok := w.connMap.Load(userID)
+	if !ok {
+		return fmt.Errorf("玩家 %s 连接不存在", userID)
+	}
+	conn, ok := connAny.(Connection)
+	if !ok {
+		return fmt.Errorf("玩家 %s 连接类型断言失败", userID)
+	}
+
+	var frame []byte
+	for _, data := range bodies {
+		msgEncoded, err := protocol.MessageEncode(&protocol.Message{
+			Type:  messageType,
+			Route: route,
+			Data:  data,
+		})
+		if err != nil {
+			return fmt.Errorf("%s 编码消息失败: %w", userID, err)
+		}
+		packet, err := protocol.Wrap(protocol.Data, msgEncoded)
+		if err != nil {
+			return fmt.Errorf("%s 打包消息失败: %w", userID, err)
+		}
+		if conn.TakeSession().GetProtoVersion() < protocol.ProtoVersionBatch {
+			if err := conn.SendMessage(packet); err != nil {
+				return fmt.Errorf("发送消息给玩家 %s 失败: %w", userID, err)
+			}
+			continue
+		}
+		frame = append(frame, packet...)
+	}
+	if len(frame) == 0 {
+		log.Info("connector sendBatch 逐帧发送 %d 条消息给玩家 %s, route: %s", len(bodies), userID, route)
+		return nil
+	}
+
+	if err := conn.SendMessage(frame); err != nil {
+		return fmt.Errorf("发送消息给玩家 %s 失败: %w", userID, err)
+	}
+	log.Info("connector sendBatch 发送 %d 条消息给玩家 %s, route: %s", len(bodies), userID, route)
 	return nil
 }
 
@@ -498,14 +653,83 @@ func (w *Worker) InitUpgrade() {
 	}
 }
 
+// Close 按顺序优雅关闭：停止接受新连接 -> 排空工作池 -> 关闭 nats -> 踢出客户端 -> 等待连接与工作协程退出
 func (w *Worker) Close() {
-	if w.isRunning {
-		if w.MiddleWorker != nil {
-			w.MiddleWorker.Close()
+	if !w.isRunning {
+		return
+	}
+	w.closing.Store(true)
+
+	if w.httpServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+		if err := w.httpServer.Shutdown(ctx); err != nil {
+			log.Warn("关闭 http 监听失败: %v", err)
 		}
-		if w.GameRouteCache != nil {
-			w.GameRouteCache.Close()
+		cancel()
+	}
+
+	// 已经进入工作池的消息要处理完（可能还要转发给 game/march）
+	if !waitUntil(drainTimeout, func() bool { return w.pendingPacks.Load() <= 0 }) {
+		log.Warn("排空工作池超时，剩余 %d 条消息", w.pendingPacks.Load())
+	}
+
+	if w.MiddleWorker != nil {
+		w.MiddleWorker.Close()
+	}
+
+	w.kickAllClients("server shutdown")
+	if !waitUntil(drainTimeout, func() bool { return atomic.LoadInt32(&w.stats.currentConnections) <= 0 }) {
+		log.Warn("等待客户端断开超时，剩余 %d 个连接", atomic.LoadInt32(&w.stats.currentConnections))
+	}
+
+	close(w.workerQuit)
+	workersDone := make(chan struct{})
+	go func() {
+		w.workerWG.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-time.After(drainTimeout):
+		log.Warn("等待工作协程退出超时")
+	}
+
+	if w.GameRouteCache != nil {
+		w.GameRouteCache.Close()
+	}
+	w.isRunning = false
+}
+
+// kickAllClients 给每个分片桶的客户端发送 Kick 包，写完后关闭连接
+func (w *Worker) kickAllClients(reason string) {
+	body, _ := json.Marshal(map[string]string{"reason": reason})
+	buf, err := protocol.Wrap(protocol.Kick, body)
+	if err != nil {
+		log.Error("kickAllClients 打包错误 err:%v", err)
+		return
+	}
+	for _, bucket := range w.clientBuckets {
+		bucket.RLock()
+		clients := make([]Connection, 0, len(bucket.clients))
+		for _, c := range bucket.clients {
+			clients = append(clients, c)
+		}
+		bucket.RUnlock()
+
+		for _, c := range clients {
+			c.Kick(buf)
+		}
+	}
+}
+
+// waitUntil 轮询直到 cond 成立或超时，返回 cond 是否成立
+func waitUntil(timeout time.Duration, cond func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			return false
 		}
-		w.isRunning = false
+		time.Sleep(20 * time.Millisecond)
 	}
+	return true
 }
@@ -0,0 +1,92 @@
+package conn
+
+import (
+	"connector/infrastructure/message/protocol"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeConnection 记录收到的 Kick 包，踢出时像真实连接一样从分片桶中移除
+type fakeConnection struct {
+	connID  string
+	worker  *Worker
+	session *Session
+	onKick  func()
+	kicked  atomic.Bool
+}
+
+func (c *fakeConnection) TakeSession() *Session    { return c.session }
+func (c *fakeConnection) SendMessage([]byte) error { return nil }
+func (c *fakeConnection) ResetHeartbeat()          {}
+func (c *fakeConnection) Close()                   {}
+func (c *fakeConnection) Kick(buf []byte) {
+	if p, err := protocol.Decode(buf); err != nil || p.Type != protocol.Kick {
+		return
+	}
+	c.onKick()
+	c.kicked.Store(true)
+	bucket := c.worker.getBucket(c.connID)
+	bucket.Lock()
+	delete(bucket.clients, c.connID)
+	bucket.Unlock()
+	atomic.AddInt32(&c.worker.stats.currentConnections, -1)
+}
+
+// newDrainTestWorker 只有一个工作协程和一个分片桶的 Worker
+func newDrainTestWorker() *Worker {
+	w := &Worker{
+		clientBuckets:     []*ClientBucket{NewClientBucket()},
+		clientWorkers:     []chan *ConnectionPack{make(chan *ConnectionPack, 256)},
+		clientHandlers:    make(map[protocol.PackageType]PacketTypeHandler),
+		clientWorkerCount: 1,
+		workerQuit:        make(chan struct{}),
+		isRunning:         true,
+	}
+	w.workerWG.Add(1)
+	go w.clientWorkerRoutine(0)
+	return w
+}
+
+// 关闭时先处理完工作池中的消息，再踢出客户端、停止工作协程
+func TestCloseDrainsQueuedPacks(t *testing.T) {
+	w := newDrainTestWorker()
+	var mu sync.Mutex
+	var processed, processedAtKick int
+	w.clientHandlers[protocol.Heartbeat] = func(*protocol.Packet, Connection) error {
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		processed++
+		mu.Unlock()
+		return nil
+	}
+
+	client := &fakeConnection{connID: "conn-1", worker: w}
+	client.session = NewSession(client.connID, w)
+	client.onKick = func() {
+		mu.Lock()
+		processedAtKick = processed
+		mu.Unlock()
+	}
+	w.clientBuckets[0].clients[client.connID] = client
+	atomic.AddInt32(&w.stats.currentConnections, 1)
+
+	body, err := protocol.Wrap(protocol.Heartbeat, nil)
+	if err != nil {
+		t.Fatalf("Wrap: %v", err)
+	}
+	const queued = 20
+	for i := 0; i < queued; i++ {
+		w.pendingPacks.Add(1)
+		w.clientWorkers[0] <- &ConnectionPack{ConnID: client.connID, Body: body}
+	}
+
+	w.Close()
+	if processed != queued || processedAtKick != queued {
+		t.Fatalf("关闭前处理 %d 条消息，踢出客户端时已处理 %d 条, want %d", processed, processedAtKick, queued)
+	}
+	if !client.kicked.Load() || w.pendingPacks.Load() != 0 || w.isRunning {
+		t.Fatalf("关闭后客户端应被踢出, kicked=%v pending=%d", client.kicked.Load(), w.pendingPacks.Load())
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"game/container"
 	"game/infrastructure/config"
 	"game/infrastructure/log"
+	"game/interfaces/admin"
 	provider "game/interfaces/grpc"
 	"game/pb"
 	"google.golang.org/grpc"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -55,6 +57,8 @@ func Run(ctx context.Context) error {
 		}
 	}()
 
+	adminServer := startAdminServer(gameContainer)
+
 	stop := func() {
 		log.Info("正在关闭 game 服务...")
 
@@ -62,6 +66,10 @@ func Run(ctx context.Context) error {
 		grpcServer.GracefulStop()
 		log.Info("gRPC 服务已关闭")
 
+		if adminServer != nil {
+			_ = adminServer.Close()
+		}
+
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
@@ -104,3 +112,23 @@ func Run(ctx context.Context) error {
 		}
 	}
 }
+
+// startAdminServer 启动管理接口，未配置地址或 token 时不启动
+func startAdminServer(gameContainer *container.GameContainer) *http.Server {
+	conf := config.GameNodeConfig.Admin
+	if conf.Addr == "" || conf.Token == "" {
+		return nil
+	}
+	server := &http.Server{
+		Addr:              conf.Addr,
+		Handler:           admin.NewHandler(gameContainer.GameWorker, conf.Token),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		log.Info("Game 管理接口启动，监听 %s", conf.Addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Error("管理接口启动失败: %v", err)
+		}
+	}()
+	return server
+}
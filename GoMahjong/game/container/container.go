@@ -1,21 +1,30 @@
 package container
 
 import (
+	"game/domain/repository"
 	"game/infrastructure/config"
 	"game/infrastructure/database"
 	"game/infrastructure/log"
 	"game/infrastructure/persistence"
 	gameRuntime "game/runtime"
+	"game/runtime/application/service"
 	"game/runtime/application/service/impl"
 	"game/runtime/engines"
 	"game/runtime/engines/mahjong"
+	"game/runtime/export"
 	"sync"
+	"time"
 )
 
 type GameContainer struct {
-	mongo      *database.MongoManager
-	redis      *database.RedisManager
-	GameWorker *gameRuntime.Worker
+	mongo          *database.MongoManager
+	redis          *database.RedisManager
+	GameWorker     *gameRuntime.Worker
+	ReplayService  service.ReplayService
+	TenhouExporter *export.TenhouExporter
+	StatsRepo      repository.StatsRepository
+	// DeterministicReplay 按洗牌种子复盘四麻对局，规则与对局节点一致
+	DeterministicReplay *mahjong.DeterministicReplay
 
 	closed bool
 	mu     sync.Mutex
@@ -31,9 +40,13 @@ func NewContainer() *GameContainer {
 	}
 
 	gameRecordRepo := persistence.NewGameRecordRepository(mongo)
+	conf := config.GameNodeConfig.EngineRules
 
 	worker := gameRuntime.NewWorker(config.GameNodeConfig.ID)
 	worker.SetGameRecordRepository(gameRecordRepo)
+	worker.SetLeaderboardRepository(persistence.NewLeaderboardRepository(redis))
+	worker.SetPrivateRoomRepository(persistence.NewPrivateRoomRepository(redis))
+	worker.SetCrashReportRepository(persistence.NewCrashReportRepository(mongo))
 
 	enginePrototypes := createEnginePrototypes(worker)
 	for engineType, engine := range enginePrototypes {
@@ -47,19 +60,73 @@ func NewContainer() *GameContainer {
 	worker.SetGameService(gameService)
 
 	return &GameContainer{
-		mongo:      mongo,
-		redis:      redis,
-		GameWorker: worker,
+		mongo:          mongo,
+		redis:          redis,
+		GameWorker:     worker,
+		ReplayService:  impl.NewReplayService(gameRecordRepo),
+		TenhouExporter: export.NewTenhouExporter(gameRecordRepo),
+		StatsRepo:      persistence.NewStatsRepository(mongo, redis),
+		DeterministicReplay: mahjong.NewDeterministicReplay(gameRecordRepo,
+			engineRules(conf, mahjong.DefaultEngineRules(), conf.InitialPoint, conf.TargetScore)),
 	}
 }
 
 func createEnginePrototypes(worker *gameRuntime.Worker) map[int32]engines.Engine {
+	conf := config.GameNodeConfig.EngineRules
+	rules := engineRules(conf, mahjong.DefaultEngineRules(), conf.InitialPoint, conf.TargetScore)
+	sanmaRules := engineRules(conf, mahjong.DefaultSanmaEngineRules(), conf.SanmaInitialPoint, conf.SanmaTargetScore)
+
 	prototypes := make(map[int32]engines.Engine)
-	prototypes[int32(engines.RIICHI_MAHJONG_4P_ENGINE)] = mahjong.NewRiichiMahjong4p(worker)
+	prototypes[int32(engines.RIICHI_MAHJONG_4P_ENGINE)] = mahjong.NewRiichiMahjong4p(worker, mahjong.GameLengthHanchan, rules)
+	prototypes[int32(engines.RIICHI_MAHJONG_4P_TONPUU_ENGINE)] = mahjong.NewRiichiMahjong4p(worker, mahjong.GameLengthEastOnly, rules)
+	prototypes[int32(engines.RIICHI_MAHJONG_3P_ENGINE)] = mahjong.NewRiichiMahjong3p(worker, mahjong.GameLengthHanchan, sanmaRules)
 	log.Info("GameContainer 创建 Engine 原型完成，共 %d 个引擎", len(prototypes))
 	return prototypes
 }
 
+// engineRules 用配置覆盖默认规则，未配置的字段保留默认值
+func engineRules(conf config.EngineRulesConf, rules mahjong.EngineRules, initialPoint, targetScore int) mahjong.EngineRules {
+	if conf.MaxRoundTime > 0 {
+		rules.MaxRoundTime = conf.MaxRoundTime
+	}
+	if conf.RoundCompensation > 0 {
+		rules.RoundCompensation = conf.RoundCompensation
+	}
+	if conf.ReactionTime > 0 {
+		rules.ReactionTime = conf.ReactionTime
+	}
+	if conf.WaitStartTime > 0 {
+		rules.WaitStartTime = time.Duration(conf.WaitStartTime) * time.Second
+	}
+	if initialPoint > 0 {
+		rules.InitialPoint = initialPoint
+	}
+	if targetScore > 0 {
+		rules.TargetScore = targetScore
+	}
+	if conf.UseRedFive != nil {
+		rules.UseRedFive = *conf.UseRedFive
+	}
+	if len(conf.AkaDora) == 3 {
+		rules.Aka = mahjong.AkaRules{Man: conf.AkaDora[0], Pin: conf.AkaDora[1], So: conf.AkaDora[2]}
+	}
+	if conf.KazoeYakuman != nil {
+		rules.KazoeYakuman = *conf.KazoeYakuman
+	}
+	rules.BeginnerMode = conf.BeginnerMode
+	rules.Atamahane = conf.Atamahane
+	rules.TripleRon = conf.TripleRon
+	rules.CoalescePush = conf.CoalescePush
+	rules.SearchWorkers = conf.SearchWorkers
+	if conf.StatePushInterval != 0 {
+		rules.StatePushInterval = time.Duration(max(conf.StatePushInterval, 0)) * time.Millisecond
+	}
+	if conf.ChomboBase != 0 {
+		rules.ChomboBase = max(conf.ChomboBase, 0)
+	}
+	return rules
+}
+
 func (c *GameContainer) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
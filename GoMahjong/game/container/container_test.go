@@ -0,0 +1,37 @@
+package container
+
+import (
+	"game/infrastructure/config"
+	"game/runtime/engines/mahjong"
+	"testing"
+	"time"
+)
+
+func TestEngineRules(t *testing.T) {
+	useRedFive := false
+	conf := config.EngineRulesConf{
+		MaxRoundTime:      10,
+		WaitStartTime:     3,
+		InitialPoint:      30000,
+		SanmaInitialPoint: 40000,
+		UseRedFive:        &useRedFive,
+		StatePushInterval: -1,
+	}
+
+	rules := engineRules(conf, mahjong.DefaultEngineRules(), conf.InitialPoint, conf.TargetScore)
+	if rules.MaxRoundTime != 10 || rules.WaitStartTime != 3*time.Second || rules.InitialPoint != 30000 || rules.UseRedFive {
+		t.Fatalf("配置的字段应覆盖默认值, got %+v", rules)
+	}
+	if rules.StatePushInterval != 0 {
+		t.Fatalf("statePushInterval 为 -1 时不推送, got %v", rules.StatePushInterval)
+	}
+	defaults := mahjong.DefaultEngineRules()
+	if rules.RoundCompensation != defaults.RoundCompensation || rules.TargetScore != defaults.TargetScore || rules.Aka != defaults.Aka {
+		t.Fatalf("未配置的字段应保留默认值, got %+v", rules)
+	}
+
+	sanma := engineRules(conf, mahjong.DefaultSanmaEngineRules(), conf.SanmaInitialPoint, conf.SanmaTargetScore)
+	if sanma.InitialPoint != 40000 || sanma.TargetScore != mahjong.SanmaTargetScore || sanma.MaxRoundTime != 10 {
+		t.Fatalf("三麻应使用三麻的点数配置, got %+v", sanma)
+	}
+}
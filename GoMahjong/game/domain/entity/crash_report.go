@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CrashReport 房间因状态异常自毁时的现场，用于事后排查
+type CrashReport struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	RoomID       string             `bson:"room_id"`
+	Reason       string             `bson:"reason"`
+	Situation    string             `bson:"situation"`     // 场况（庄家、场风、局数、本场、供托）
+	TurnState    string             `bson:"turn_state"`    // 回合状态
+	CurrentTurn  int                `bson:"current_turn"`  // 当前行动的座位
+	RecentEvents []string           `bson:"recent_events"` // 最近处理的事件，按时间先后
+	Stack        string             `bson:"stack"`
+	CreatedAt    time.Time          `bson:"created_at"`
+}
@@ -16,9 +16,19 @@ type GameRecord struct {
 	Duration    int                `bson:"duration"`
 	FinalResult *GameFinalResult   `bson:"final_result"`
 	Status      string             `bson:"status"`
+	Seed        int64              `bson:"seed"`        // 洗牌随机种子，整场对局共用一个牌山随机源
+	GameLength  int                `bson:"game_length"` // 对局长度（东风战/半庄战）
+	Aka         *AkaRules          `bson:"aka"`         // 对局使用的赤牌规则，没有保存规则的旧记录为空
 	CreatedAt   time.Time          `bson:"created_at"`
 }
 
+// AkaRules 各花色赤 5 的张数，不使用赤牌时全为 0
+type AkaRules struct {
+	Man int `bson:"man"`
+	Pin int `bson:"pin"`
+	So  int `bson:"so"`
+}
+
 type PlayerInfo struct {
 	UserID    string `bson:"user_id"`
 	SeatIndex int    `bson:"seat_index"`
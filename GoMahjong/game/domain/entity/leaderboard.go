@@ -0,0 +1,20 @@
+package entity
+
+// LeaderboardEntry 排行榜条目，Rank 从 1 开始，同分同名次
+type LeaderboardEntry struct {
+	UserID string `json:"userID"`
+	Rating int    `json:"rating"`
+	Rank   int    `json:"rank"`
+}
+
+// RatingDelta 终局顺位对应的积分变化，三麻的二位不加不减
+func RatingDelta(rank, playerCount int) int {
+	deltas := []int{30, 10, -10, -30}
+	if playerCount == 3 {
+		deltas = []int{30, 0, -30}
+	}
+	if rank < 1 || rank > len(deltas) {
+		return 0
+	}
+	return deltas[rank-1]
+}
@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+// PlayerStats 玩家在一段时间内的统计数据，只统计已完成的对局
+type PlayerStats struct {
+	UserID string    `json:"userID"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+
+	Games      int     `json:"games"`
+	RankCounts [4]int  `json:"rankCounts"` // 一位到四位的次数
+	AvgRank    float64 `json:"avgRank"`
+	AvgPoints  float64 `json:"avgPoints"` // 终局平均点数
+
+	Rounds     int     `json:"rounds"`
+	Wins       int     `json:"wins"`
+	DealIns    int     `json:"dealIns"`
+	Riichis    int     `json:"riichis"`
+	WinRate    float64 `json:"winRate"`    // 和了率 = 和了局数 / 总局数
+	DealInRate float64 `json:"dealInRate"` // 放铳率 = 放铳局数 / 总局数
+	RiichiRate float64 `json:"riichiRate"` // 立直率 = 立直局数 / 总局数
+}
+
+// Fill 根据次数计算平均值和各项比率
+func (s *PlayerStats) Fill(rankSum, pointSum int) {
+	if s.Games > 0 {
+		s.AvgRank = float64(rankSum) / float64(s.Games)
+		s.AvgPoints = float64(pointSum) / float64(s.Games)
+	}
+	if s.Rounds > 0 {
+		s.WinRate = float64(s.Wins) / float64(s.Rounds)
+		s.DealInRate = float64(s.DealIns) / float64(s.Rounds)
+		s.RiichiRate = float64(s.Riichis) / float64(s.Rounds)
+	}
+}
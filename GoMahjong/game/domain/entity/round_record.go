@@ -32,20 +32,29 @@ type RoundEvent struct {
 type RoundResult struct {
 	EndType    string    `bson:"end_type"`
 	Claims     []HuClaim `bson:"claims"`
-	Delta      [4]int    `bson:"delta"`
-	Points     [4]int    `bson:"points"`
+	Delta      [4]int    `bson:"delta"`  // 本局结算的点数变化，含本场和收走的供托
+	Points     [4]int    `bson:"points"` // 结算前的点数，本局宣言立直的立直棒已扣除
 	Reason     string    `bson:"reason"`
 	NextDealer int       `bson:"next_dealer"`
 }
 
 type HuClaim struct {
-	WinnerSeat int      `bson:"winner_seat"`
-	LoserSeat  int      `bson:"loser_seat"`
-	WinTile    Tile     `bson:"win_tile"`
-	Han        int      `bson:"han"`
-	Fu         int      `bson:"fu"`
-	Yaku       []string `bson:"yaku"`
-	Points     int      `bson:"points"`
+	WinnerSeat int       `bson:"winner_seat"`
+	LoserSeat  int       `bson:"loser_seat"`
+	WinTile    Tile      `bson:"win_tile"`
+	Han        int       `bson:"han"`
+	Fu         int       `bson:"fu"`
+	Yaku       []string  `bson:"yaku"`
+	Points     int       `bson:"points"`
+	Hand       []HandSet `bson:"hand"`
+	Wait       string    `bson:"wait"`
+}
+
+// HandSet 和牌拆解中的一组牌
+type HandSet struct {
+	Kind  string `bson:"kind"`
+	Tiles []Tile `bson:"tiles"`
+	Open  bool   `bson:"open"`
 }
 
 type Tile struct {
@@ -84,6 +93,33 @@ func (rr *RoundRecord) CompleteRound(result *RoundResult) {
 	rr.RoundResult = result
 }
 
+// StartPoints 局开始时的点数 = 结算前的点数 + 本局宣言立直时扣的立直棒。局未结束时返回 nil
+func (rr *RoundRecord) StartPoints(seatCount int) []int {
+	if rr.RoundResult == nil {
+		return nil
+	}
+	points := make([]int, seatCount)
+	copy(points, rr.RoundResult.Points[:seatCount])
+	for _, event := range rr.Events {
+		if event.EventType == EventTypeRiichi && event.SeatIndex >= 0 && event.SeatIndex < seatCount {
+			points[event.SeatIndex] += 1000
+		}
+	}
+	return points
+}
+
+// EndPoints 局结束时的点数 = 结算前的点数 + 本局点数变化。局未结束时返回 nil
+func (rr *RoundRecord) EndPoints(seatCount int) []int {
+	if rr.RoundResult == nil {
+		return nil
+	}
+	points := make([]int, seatCount)
+	for i := range points {
+		points[i] = rr.RoundResult.Points[i] + rr.RoundResult.Delta[i]
+	}
+	return points
+}
+
 const (
 	EventTypeRoundStart  = "round_start"
 	EventTypeDrawTile    = "draw_tile"
@@ -0,0 +1,10 @@
+package repository
+
+import (
+	"context"
+	"game/domain/entity"
+)
+
+type CrashReportRepository interface {
+	SaveCrashReport(ctx context.Context, report *entity.CrashReport) error
+}
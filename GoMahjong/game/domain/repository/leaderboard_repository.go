@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"game/domain/entity"
+)
+
+type LeaderboardRepository interface {
+	// ApplyGameResult 按终局顺位调整积分，playerCount 为对局人数（包含不参与排行的机器人）
+	ApplyGameResult(ctx context.Context, rankings []entity.PlayerRanking, playerCount int) error
+	TopN(ctx context.Context, n int) ([]entity.LeaderboardEntry, error)
+	// RankOf 不在排行榜中时返回 transfer.ErrLeaderboardNotFound
+	RankOf(ctx context.Context, userID string) (*entity.LeaderboardEntry, error)
+}
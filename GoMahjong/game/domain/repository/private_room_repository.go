@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// PrivateRoomRepository 房间号 -> game 节点的全局索引，connector 据此把加入请求转发到创建房间的节点
+type PrivateRoomRepository interface {
+	// Reserve 占用房间号，已被占用时返回 false
+	Reserve(ctx context.Context, code, gameNodeID string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, code string) error
+}
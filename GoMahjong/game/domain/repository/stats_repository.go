@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+	"game/domain/entity"
+	"time"
+)
+
+type StatsRepository interface {
+	// GetPlayerStats 统计 [from, to) 内开始的对局，from/to 为零值时不限制
+	GetPlayerStats(ctx context.Context, userID string, from, to time.Time) (*entity.PlayerStats, error)
+}
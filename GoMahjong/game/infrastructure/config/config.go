@@ -23,9 +23,39 @@ type GameConfiguration struct {
 	EtcdConf     `mapstructure:"etcd"`
 	LogConf      `mapstructure:"log"`
 	NatsConfig   `mapstructure:"nats"`
+	Admin        AdminConf         `mapstructure:"admin"`
+	EngineRules  EngineRulesConf   `mapstructure:"engineRules"`
 	Domains      map[string]Domain `mapstructure:"domain"`
 }
 
+// EngineRulesConf 对局规则，未配置（零值）的字段使用引擎默认值
+type EngineRulesConf struct {
+	MaxRoundTime      int   `mapstructure:"maxRoundTime"`      // 每回合的最多分配时间（秒）
+	RoundCompensation int   `mapstructure:"roundCompensation"` // 每回合补偿时间（秒）
+	ReactionTime      int   `mapstructure:"reactionTime"`      // 反应阶段（吃碰杠荣和）的计时（秒）
+	WaitStartTime     int   `mapstructure:"waitStartTime"`     // 等待游戏开始时间（秒）
+	InitialPoint      int   `mapstructure:"initialPoint"`      // 四麻初始点数
+	TargetScore       int   `mapstructure:"targetScore"`       // 四麻结束所需点数
+	SanmaInitialPoint int   `mapstructure:"sanmaInitialPoint"` // 三麻初始点数
+	SanmaTargetScore  int   `mapstructure:"sanmaTargetScore"`  // 三麻结束所需点数
+	UseRedFive        *bool `mapstructure:"useRedFive"`        // 是否使用赤牌
+	AkaDora           []int `mapstructure:"akaDora"`           // 万、筒、索各自赤 5 的张数，如 [1, 2, 1]
+	KazoeYakuman      *bool `mapstructure:"kazoeYakuman"`      // 13 番以上是否按累计役满计
+	BeginnerMode      bool  `mapstructure:"beginnerMode"`      // 新手模式，出牌阶段推送打牌建议
+	Atamahane         bool  `mapstructure:"atamahane"`         // 头跳：多家荣和时只有放铳者下家方向最近的一家和牌
+	TripleRon         bool  `mapstructure:"tripleRon"`         // 一炮三响时三家各自结算，关闭时三家点铳流局
+	CoalescePush      bool  `mapstructure:"coalescePush"`      // 合并同一事件内发往同一 connector 的推送
+	SearchWorkers     int   `mapstructure:"searchWorkers"`     // 计算打牌候选时并行的 goroutine 数，0 或 1 为串行
+	StatePushInterval int   `mapstructure:"statePushInterval"` // 状态更新推送的最小间隔（毫秒），-1 表示不推送
+	ChomboBase        int   `mapstructure:"chomboBase"`        // 错和罚点的基本点（2000 为满贯），-1 表示不处罚
+}
+
+// AdminConf 管理接口，Addr 或 Token 为空时不启动
+type AdminConf struct {
+	Addr  string `mapstructure:"addr"`  // HTTP 监听地址，如 0.0.0.0:8013
+	Token string `mapstructure:"token"` // 请求头 Authorization: Bearer <token>
+}
+
 type LogConf struct {
 	Level string `mapstructure:"level"`
 	Path  string `mapstructure:"path"`
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const sampleConfig = `serverType: game
+metricPort: 9095
+log:
+  level: info
+database:
+  redis:
+    addr: localhost:6379
+nats:
+  url: nats://127.0.0.1:4222
+engineRules:
+  maxRoundTime: 30
+  initialPoint: 30000
+  useRedFive: false
+  akaDora: [1, 2, 1]
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "application.yml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("写入配置文件: %v", err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeConfig(t, sampleConfig)
+
+	t.Setenv("NODE_ID", "")
+	if err := Load(path); err == nil {
+		t.Fatalf("缺少 NODE_ID 时应加载失败")
+	}
+
+	t.Setenv("NODE_ID", "game-1")
+	if err := Load(path); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg := GameNodeConfig
+	if cfg.ID != "game-1" || cfg.ServerType != "game" || cfg.MetricPort != 9095 {
+		t.Fatalf("基础配置错误: %+v", cfg.BaseConfig)
+	}
+	if cfg.RedisConf.Addr != "localhost:6379" || cfg.NatsConfig.URL != "nats://127.0.0.1:4222" || cfg.LogConf.Level != "info" {
+		t.Fatalf("依赖配置错误: %+v %+v", cfg.DatabaseConf, cfg.NatsConfig)
+	}
+	rules := cfg.EngineRules
+	if rules.MaxRoundTime != 30 || rules.InitialPoint != 30000 || rules.UseRedFive == nil || *rules.UseRedFive {
+		t.Fatalf("对局规则错误: %+v", rules)
+	}
+	if len(rules.AkaDora) != 3 || rules.AkaDora[1] != 2 || rules.KazoeYakuman != nil {
+		t.Fatalf("未配置的规则应保持零值: %+v", rules)
+	}
+
+	// 配置只在启动时读取，重新 Load 才会生效
+	path = writeConfig(t, sampleConfig+"  reactionTime: 5\n")
+	if err := Load(path); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if GameNodeConfig.EngineRules.ReactionTime != 5 {
+		t.Fatalf("重新加载后应读到新的配置, got %+v", GameNodeConfig.EngineRules)
+	}
+
+	if err := Load(filepath.Join(t.TempDir(), "missing.yml")); err == nil {
+		t.Fatalf("配置文件不存在时应加载失败")
+	}
+}
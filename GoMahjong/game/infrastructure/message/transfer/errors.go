@@ -6,7 +6,8 @@ var (
 	ErrSessionNotFound = errors.New("session not found")
 	ErrRouterNotFound  = errors.New("user router not found")
 
-	ErrGameRecordNotFound = errors.New("game record not found")
+	ErrGameRecordNotFound  = errors.New("game record not found")
+	ErrLeaderboardNotFound = errors.New("user not on leaderboard")
 
 	ErrMongodb = errors.New("mongodb error happen")
 	ErrRedis   = errors.New("redis error happen")
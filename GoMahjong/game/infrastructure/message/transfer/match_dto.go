@@ -1,5 +1,15 @@
 package transfer
 
+import "strings"
+
+// BotUserPrefix bot 玩家 ID 前缀，由 march 补位时生成（与 march/infrastructure/message/transfer 保持一致）
+const BotUserPrefix = "bot_"
+
+// IsBotUser 是否为 bot 玩家
+func IsBotUser(userID string) bool {
+	return strings.HasPrefix(userID, BotUserPrefix)
+}
+
 type MatchSuccessDTO struct {
 	GameNodeID string            `json:"gameNodeID"`
 	Players    map[string]string `json:"players"`
@@ -0,0 +1,19 @@
+package transfer
+
+// PrivateRoomReq connector 转发给 game 节点的创建/加入私人房间请求
+type PrivateRoomReq struct {
+	UserID      string `json:"userID"`
+	ConnectorID string `json:"connectorID"`
+	EngineType  int32  `json:"engineType"` // 仅创建时使用
+	Code        string `json:"code"`       // 仅加入时使用
+}
+
+// PrivateRoomUpdateDTO 私人房间状态推送，创建、有人加入、开局、解散以及请求失败时发送
+type PrivateRoomUpdateDTO struct {
+	Success  bool     `json:"success"`
+	Message  string   `json:"message"`
+	Code     string   `json:"code,omitempty"`
+	Players  []string `json:"players,omitempty"`
+	Capacity int      `json:"capacity,omitempty"`
+	Started  bool     `json:"started"`
+}
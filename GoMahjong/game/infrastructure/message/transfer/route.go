@@ -2,8 +2,28 @@ package transfer
 
 const MatchingSuccess = "matching.success"
 const JoinQueue = "connector.joinqueue"
+const LeaveQueue = "connector.leavequeue"
+const CreatePrivateRoom = "connector.createroom"
+const JoinPrivateRoom = "connector.joinroom"
+const Spectate = "connector.spectate"
+
+// PrivateRoomTopic 所有 game 节点以同一队列组订阅的 NATS 主题，创建私人房间的请求只会被其中一个节点处理
+const PrivateRoomTopic = "game.private"
+const PrivateRoomQueue = "game.private.queue"
+const GameCreatePrivateRoom = "game.privateroom.create"
+const GameJoinPrivateRoom = "game.privateroom.join"
+const PrivateRoomUpdate = "privateroom.update"
 
 const GamePush = "game.push"
+
+// GamePushBatch game 节点把同一事件内发往同一 connector 的多条推送合并为一个包，Data 为 []PushBatchItem 的 JSON
+const GamePushBatch = "game.push.batch"
+
+// GameReconnect 客户端断线重连或续连时由 connector 转发，引擎下发重连快照
+const GameReconnect = "game.reconnect"
+const GameDisconnect = "game.session.disconnect"
+const GameSerializer = "game.session.serializer"
+const GameSpectate = "game.session.spectate"
 const DispatchWaitMain = "gameplay.operations.main"
 const DispatchWaitReaction = "gameplay.operations.reaction"
 
@@ -16,8 +36,15 @@ const GameplayPeng = "gameplay.peng"
 const GameplayGang = "gameplay.gang"
 const GameplayAnkan = "gameplay.ankan"
 const GameplayKakan = "gameplay.kakan"
+const GameplayKita = "gameplay.kita"
+const GameplayNewDora = "gameplay.new.dora"
 const GameplayRon = "gameplay.ron"
 const GameplayTsumo = "gameplay.tsumo"
 const GameplayRoundEnd = "gameplay.round.end"
 const GameplayGameEnd = "gameplay.game.end"
 const GameplayStateUpdate = "gameplay.state.update"
+const GameplayReconnectSnapshot = "gameplay.reconnect.snapshot"
+const GameplaySpectateSnapshot = "gameplay.spectate.snapshot"
+const GameplayScorePreview = "gameplay.score.preview"
+const GameplayDiscardSuggestion = "gameplay.discard.suggestion"
+const GameplayWaits = "gameplay.waits"
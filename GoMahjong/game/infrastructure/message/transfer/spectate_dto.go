@@ -0,0 +1,9 @@
+package transfer
+
+// SpectateReq connector 转发给 game 节点的观战请求，按被观战玩家所在的房间加入
+type SpectateReq struct {
+	UserID       string `json:"userID"`
+	ConnectorID  string `json:"connectorID"`
+	TargetUserID string `json:"targetUserID"`
+	Leave        bool   `json:"leave"`
+}
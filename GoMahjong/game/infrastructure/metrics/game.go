@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"expvar"
+	"strconv"
+	"time"
+)
+
+/*
+对局领域指标，在 metrics 端口的 /debug/vars 中以 "game" 输出：
+ 1. games_started / games_ended：开局、正常结束的对局数，两者之差包含进行中和中途解散的对局
+ 2. rounds 与 rounds_ron / rounds_tsumo / rounds_draw / rounds_chombo：结束的局数及各结束方式的局数，round_end_rates 为各方式占比
+ 3. round_seconds：局时长分布，le_N 为时长不超过 N 秒的局数（累计），avg_round_seconds 为平均局时长
+复盘不计入指标
+*/
+
+// 局的结束方式
+const (
+	RoundRon    = "ron"
+	RoundTsumo  = "tsumo"
+	RoundDraw   = "draw"
+	RoundChombo = "chombo"
+)
+
+// roundSecondsBuckets 局时长分布的桶上限（秒）
+var roundSecondsBuckets = []int{60, 120, 180, 300, 600}
+
+var (
+	gameStats = expvar.NewMap("game")
+
+	gamesStarted = new(expvar.Int)
+	gamesEnded   = new(expvar.Int)
+	rounds       = new(expvar.Int)
+	roundsByKind = map[string]*expvar.Int{
+		RoundRon:    new(expvar.Int),
+		RoundTsumo:  new(expvar.Int),
+		RoundDraw:   new(expvar.Int),
+		RoundChombo: new(expvar.Int),
+	}
+
+	roundSeconds      = new(expvar.Map).Init()
+	roundSecondsCount = new(expvar.Int)
+	roundSecondsSum   = new(expvar.Float)
+)
+
+func init() {
+	gameStats.Set("games_started", gamesStarted)
+	gameStats.Set("games_ended", gamesEnded)
+	gameStats.Set("rounds", rounds)
+	for kind, counter := range roundsByKind {
+		gameStats.Set("rounds_"+kind, counter)
+	}
+	gameStats.Set("round_end_rates", expvar.Func(roundEndRates))
+
+	for _, le := range roundSecondsBuckets {
+		roundSeconds.Set("le_"+strconv.Itoa(le), new(expvar.Int))
+	}
+	roundSeconds.Set("le_inf", roundSecondsCount)
+	roundSeconds.Set("sum", roundSecondsSum)
+	gameStats.Set("round_seconds", roundSeconds)
+	gameStats.Set("avg_round_seconds", expvar.Func(avgRoundSeconds))
+}
+
+// GameStarted 对局开始
+func GameStarted() {
+	gamesStarted.Add(1)
+}
+
+// GameEnded 对局正常结束
+func GameEnded() {
+	gamesEnded.Add(1)
+}
+
+// RoundEnded 一局结束，kind 为 RoundRon 等结束方式，未知方式只计入总局数
+func RoundEnded(kind string) {
+	rounds.Add(1)
+	if counter, ok := roundsByKind[kind]; ok {
+		counter.Add(1)
+	}
+}
+
+// ObserveRoundDuration 记录一局的时长
+func ObserveRoundDuration(d time.Duration) {
+	seconds := d.Seconds()
+	for _, le := range roundSecondsBuckets {
+		if seconds <= float64(le) {
+			roundSeconds.Add("le_"+strconv.Itoa(le), 1)
+		}
+	}
+	roundSecondsCount.Add(1)
+	roundSecondsSum.Add(seconds)
+}
+
+func roundEndRates() any {
+	rates := make(map[string]float64, len(roundsByKind))
+	total := rounds.Value()
+	for kind, counter := range roundsByKind {
+		if total > 0 {
+			rates[kind] = float64(counter.Value()) / float64(total)
+		} else {
+			rates[kind] = 0
+		}
+	}
+	return rates
+}
+
+func avgRoundSeconds() any {
+	count := roundSecondsCount.Value()
+	if count == 0 {
+		return 0.0
+	}
+	return roundSecondsSum.Value() / float64(count)
+}
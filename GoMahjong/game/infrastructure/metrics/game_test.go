@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"expvar"
+	"testing"
+	"time"
+)
+
+// stat 读取 "game" 中的计数
+func stat(t *testing.T, m *expvar.Map, name string) int64 {
+	t.Helper()
+	v, ok := m.Get(name).(*expvar.Int)
+	if !ok {
+		t.Fatalf("缺少指标 %s", name)
+	}
+	return v.Value()
+}
+
+// 各结束方式计入总局数和对应分类，未知方式只计入总局数；各方式占比按总局数计算
+func TestRoundEnded(t *testing.T) {
+	before := map[string]int64{"rounds": stat(t, gameStats, "rounds")}
+	for _, kind := range []string{RoundRon, RoundTsumo, RoundDraw, RoundChombo} {
+		before[kind] = stat(t, gameStats, "rounds_"+kind)
+	}
+
+	RoundEnded(RoundRon)
+	RoundEnded(RoundRon)
+	RoundEnded(RoundDraw)
+	RoundEnded("unknown")
+
+	want := map[string]int64{"rounds": 4, RoundRon: 2, RoundDraw: 1}
+	if got := stat(t, gameStats, "rounds") - before["rounds"]; got != want["rounds"] {
+		t.Fatalf("rounds 增加 %d, want %d", got, want["rounds"])
+	}
+	for _, kind := range []string{RoundRon, RoundTsumo, RoundDraw, RoundChombo} {
+		if got := stat(t, gameStats, "rounds_"+kind) - before[kind]; got != want[kind] {
+			t.Fatalf("rounds_%s 增加 %d, want %d", kind, got, want[kind])
+		}
+	}
+
+	rates := roundEndRates().(map[string]float64)
+	total := float64(stat(t, gameStats, "rounds"))
+	if rates[RoundRon] != float64(stat(t, gameStats, "rounds_ron"))/total {
+		t.Fatalf("荣和占比 = %v", rates[RoundRon])
+	}
+}
+
+// 局时长计入所有不小于它的桶，平均时长按记录的局数计算
+func TestObserveRoundDuration(t *testing.T) {
+	le60, le300, count := stat(t, roundSeconds, "le_60"), stat(t, roundSeconds, "le_300"), stat(t, roundSeconds, "le_inf")
+	sum := roundSeconds.Get("sum").(*expvar.Float).Value()
+
+	ObserveRoundDuration(30 * time.Second)
+	ObserveRoundDuration(200 * time.Second)
+	ObserveRoundDuration(time.Hour)
+
+	if stat(t, roundSeconds, "le_60")-le60 != 1 || stat(t, roundSeconds, "le_300")-le300 != 2 || stat(t, roundSeconds, "le_inf")-count != 3 {
+		t.Fatalf("局时长分布不对: %s", roundSeconds.String())
+	}
+	if got := roundSeconds.Get("sum").(*expvar.Float).Value() - sum; got != 3830 {
+		t.Fatalf("sum 增加 %v, want 3830", got)
+	}
+	wantAvg := (sum + 3830) / float64(count+3)
+	if got := avgRoundSeconds().(float64); got != wantAvg {
+		t.Fatalf("平均局时长 = %v, want %v", got, wantAvg)
+	}
+}
+
+// 开局、正常结束分别计数
+func TestGameCounters(t *testing.T) {
+	started, ended := gamesStarted.Value(), gamesEnded.Value()
+	GameStarted()
+	GameStarted()
+	GameEnded()
+	if gamesStarted.Value()-started != 2 || gamesEnded.Value()-ended != 1 {
+		t.Fatalf("games_started +%d games_ended +%d, want +2 +1", gamesStarted.Value()-started, gamesEnded.Value()-ended)
+	}
+}
@@ -0,0 +1,27 @@
+package persistence
+
+import (
+	"context"
+	"game/domain/entity"
+	"game/domain/repository"
+	"game/infrastructure/database"
+	"game/infrastructure/log"
+	"game/infrastructure/message/transfer"
+)
+
+type CrashReportRepository struct {
+	mongo *database.MongoManager
+}
+
+func NewCrashReportRepository(mongo *database.MongoManager) repository.CrashReportRepository {
+	return &CrashReportRepository{mongo: mongo}
+}
+
+func (r *CrashReportRepository) SaveCrashReport(ctx context.Context, report *entity.CrashReport) error {
+	collection := r.mongo.Db.Collection("crash_reports")
+	if _, err := collection.InsertOne(ctx, report); err != nil {
+		log.Error("保存房间崩溃报告失败: roomID=%s, err=%v", report.RoomID, err)
+		return transfer.ErrMongodb
+	}
+	return nil
+}
@@ -27,7 +27,17 @@ func NewGameRecordRepository(mongo *database.MongoManager) repository.GameRecord
 func (r *GameRecordRepository) SaveGameRecord(ctx context.Context, record *entity.GameRecord) error {
 	collection := r.mongo.Db.Collection("game_records")
 
-	doc := bson.M{
+	_, err := collection.InsertOne(ctx, r.gameRecordToBson(record))
+	if err != nil {
+		log.Error("保存游戏记录失败: %v", err)
+		return transfer.ErrMongodb
+	}
+	return nil
+}
+
+// gameRecordToBson game_records 集合中的文档格式，与 docToGameRecord 对应
+func (r *GameRecordRepository) gameRecordToBson(record *entity.GameRecord) bson.M {
+	return bson.M{
 		"_id":          record.ID,
 		"room_id":      record.RoomID,
 		"game_type":    record.GameType,
@@ -37,15 +47,11 @@ func (r *GameRecordRepository) SaveGameRecord(ctx context.Context, record *entit
 		"duration":     record.Duration,
 		"final_result": r.finalResultToBson(record.FinalResult),
 		"status":       record.Status,
+		"seed":         record.Seed,
+		"game_length":  record.GameLength,
+		"aka":          r.akaRulesToBson(record.Aka),
 		"created_at":   record.CreatedAt,
 	}
-
-	_, err := collection.InsertOne(ctx, doc)
-	if err != nil {
-		log.Error("保存游戏记录失败: %v", err)
-		return transfer.ErrMongodb
-	}
-	return nil
 }
 
 func (r *GameRecordRepository) FindGameRecord(ctx context.Context, recordID primitive.ObjectID) (*entity.GameRecord, error) {
@@ -80,14 +86,20 @@ func (r *GameRecordRepository) FindGameRecordsByUser(ctx context.Context, userID
 	}
 	defer cursor.Close(ctx)
 
+	// 游标只遍历一次；遍历中断（网络、超时）时返回错误，而不是截断的分页
 	var result []*entity.GameRecord
 	for cursor.Next(ctx) {
 		var doc bson.M
 		if err := cursor.Decode(&doc); err != nil {
+			log.Warn("解码用户游戏记录失败: userID=%s, err=%v", userID, err)
 			continue
 		}
 		result = append(result, r.docToGameRecord(doc))
 	}
+	if err := cursor.Err(); err != nil {
+		log.Error("遍历用户游戏记录失败: %v", err)
+		return nil, err
+	}
 
 	return result, nil
 }
@@ -254,6 +266,29 @@ func (r *GameRecordRepository) finalResultToBson(result *entity.GameFinalResult)
 	}
 }
 
+func (r *GameRecordRepository) akaRulesToBson(aka *entity.AkaRules) bson.M {
+	if aka == nil {
+		return nil
+	}
+	return bson.M{
+		"man": aka.Man,
+		"pin": aka.Pin,
+		"so":  aka.So,
+	}
+}
+
+func (r *GameRecordRepository) akaRulesFromBson(value any) *entity.AkaRules {
+	m := utils.ToMap(value)
+	if m == nil {
+		return nil
+	}
+	return &entity.AkaRules{
+		Man: utils.ToInt(m["man"]),
+		Pin: utils.ToInt(m["pin"]),
+		So:  utils.ToInt(m["so"]),
+	}
+}
+
 func (r *GameRecordRepository) eventsToBson(events []entity.RoundEvent) []bson.M {
 	result := make([]bson.M, len(events))
 	for i, e := range events {
@@ -285,6 +320,8 @@ func (r *GameRecordRepository) roundResultToBson(result *entity.RoundResult) bso
 			"fu":     c.Fu,
 			"yaku":   c.Yaku,
 			"points": c.Points,
+			"hand":   r.handSetsToBson(c.Hand),
+			"wait":   c.Wait,
 		}
 	}
 	return bson.M{
@@ -297,6 +334,50 @@ func (r *GameRecordRepository) roundResultToBson(result *entity.RoundResult) bso
 	}
 }
 
+func (r *GameRecordRepository) handSetsToBson(sets []entity.HandSet) []bson.M {
+	result := make([]bson.M, len(sets))
+	for i, set := range sets {
+		tiles := make([]bson.M, len(set.Tiles))
+		for j, t := range set.Tiles {
+			tiles[j] = bson.M{"type": t.Type, "id": t.ID}
+		}
+		result[i] = bson.M{
+			"kind":  set.Kind,
+			"tiles": tiles,
+			"open":  set.Open,
+		}
+	}
+	return result
+}
+
+// handSetsFromBson 早期牌谱没有 hand 字段，返回 nil
+func (r *GameRecordRepository) handSetsFromBson(value interface{}) []entity.HandSet {
+	docs := utils.ToSlice(value)
+	if docs == nil {
+		return nil
+	}
+	sets := make([]entity.HandSet, len(docs))
+	for i, d := range docs {
+		setMap := utils.ToMap(d)
+		open, _ := setMap["open"].(bool)
+		tileDocs := utils.ToSlice(setMap["tiles"])
+		tiles := make([]entity.Tile, len(tileDocs))
+		for j, t := range tileDocs {
+			tileMap := utils.ToMap(t)
+			tiles[j] = entity.Tile{
+				Type: utils.ToInt(tileMap["type"]),
+				ID:   utils.ToInt(tileMap["id"]),
+			}
+		}
+		sets[i] = entity.HandSet{
+			Kind:  utils.ToString(setMap["kind"]),
+			Tiles: tiles,
+			Open:  open,
+		}
+	}
+	return sets
+}
+
 func (r *GameRecordRepository) docToGameRecord(doc bson.M) *entity.GameRecord {
 	playersDoc := doc["players"].(bson.A)
 	players := make([]entity.PlayerInfo, len(playersDoc))
@@ -338,6 +419,9 @@ func (r *GameRecordRepository) docToGameRecord(doc bson.M) *entity.GameRecord {
 		EndTime:     utils.ToTime(doc["end_time"]),
 		Duration:    utils.ToInt(doc["duration"]),
 		FinalResult: finalResult,
+		Seed:        int64(utils.ToInt(doc["seed"])),
+		GameLength:  utils.ToInt(doc["game_length"]),
+		Aka:         r.akaRulesFromBson(doc["aka"]),
 		Status:      doc["status"].(string),
 		CreatedAt:   utils.ToTime(doc["created_at"]),
 	}
@@ -353,7 +437,7 @@ func (r *GameRecordRepository) docToRoundRecord(doc bson.M) *entity.RoundRecord
 			EventType: eMap["event_type"].(string),
 			Timestamp: utils.ToTime(eMap["timestamp"]),
 			SeatIndex: utils.ToInt(eMap["seat_index"]),
-			Data:      eMap["data"].(map[string]interface{}),
+			Data:      utils.ToMap(eMap["data"]),
 		}
 	}
 
@@ -376,6 +460,8 @@ func (r *GameRecordRepository) docToRoundRecord(doc bson.M) *entity.RoundRecord
 				Fu:     utils.ToInt(cMap["fu"]),
 				Yaku:   utils.ToStringArray(cMap["yaku"]),
 				Points: utils.ToInt(cMap["points"]),
+				Hand:   r.handSetsFromBson(cMap["hand"]),
+				Wait:   utils.ToString(cMap["wait"]),
 			}
 		}
 		roundResult = &entity.RoundResult{
@@ -0,0 +1,98 @@
+package persistence
+
+import (
+	"game/domain/entity"
+	"game/infrastructure/database"
+	"game/infrastructure/log"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMain(m *testing.M) {
+	log.InitLog("persistence_test", "error")
+	os.Exit(m.Run())
+}
+
+// recordDocs 按 SaveGameRecord 的存储格式生成文档，供 mock 游标返回
+func recordDocs(t *testing.T, r *GameRecordRepository, userID string, rooms ...string) []bson.D {
+	t.Helper()
+	var docs []bson.D
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, roomID := range rooms {
+		record := &entity.GameRecord{
+			ID:        primitive.NewObjectID(),
+			RoomID:    roomID,
+			GameType:  "riichi4p",
+			Players:   []entity.PlayerInfo{{UserID: userID}, {UserID: "other", SeatIndex: 1}},
+			StartTime: start.Add(-time.Duration(i) * time.Hour),
+			Status:    "finished",
+		}
+		raw, err := bson.Marshal(r.gameRecordToBson(record))
+		if err != nil {
+			t.Fatalf("bson.Marshal: %v", err)
+		}
+		var doc bson.D
+		if err := bson.Unmarshal(raw, &doc); err != nil {
+			t.Fatalf("bson.Unmarshal: %v", err)
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+func TestFindGameRecordsByUser(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	const ns = "test.game_records"
+
+	mt.Run("pages across batches", func(mt *mtest.T) {
+		r := &GameRecordRepository{mongo: &database.MongoManager{Cli: mt.Client, Db: mt.DB}}
+		docs := recordDocs(mt.T, r, "u1", "room-3", "room-4", "room-5")
+		// 分页结果分两批返回，游标要跟进 getMore 取完
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(42, ns, mtest.FirstBatch, docs[:2]...),
+			mtest.CreateCursorResponse(0, ns, mtest.NextBatch, docs[2:]...),
+		)
+
+		records, err := r.FindGameRecordsByUser(mt.Context(), "u1", 3, 2)
+		if err != nil {
+			mt.Fatalf("FindGameRecordsByUser: %v", err)
+		}
+		var rooms []string
+		for _, record := range records {
+			rooms = append(rooms, record.RoomID)
+		}
+		if len(rooms) != 3 || rooms[0] != "room-3" || rooms[2] != "room-5" {
+			mt.Fatalf("应按顺序返回整页记录, got %v", rooms)
+		}
+
+		cmd := mt.GetStartedEvent().Command
+		if got := cmd.Lookup("filter", "players.user_id").StringValue(); got != "u1" {
+			mt.Fatalf("filter players.user_id = %q", got)
+		}
+		if skip, limit := cmd.Lookup("skip").AsInt64(), cmd.Lookup("limit").AsInt64(); skip != 2 || limit != 3 {
+			mt.Fatalf("skip/limit = %d/%d, want 2/3", skip, limit)
+		}
+		if got := cmd.Lookup("sort", "start_time").AsInt64(); got != -1 {
+			mt.Fatalf("应按 start_time 倒序, got %d", got)
+		}
+	})
+
+	mt.Run("cursor error", func(mt *mtest.T) {
+		r := &GameRecordRepository{mongo: &database.MongoManager{Cli: mt.Client, Db: mt.DB}}
+		docs := recordDocs(mt.T, r, "u1", "room-0")
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(42, ns, mtest.FirstBatch, docs...),
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 43, Message: "cursor killed"}),
+		)
+
+		records, err := r.FindGameRecordsByUser(mt.Context(), "u1", 10, 0)
+		if err == nil {
+			mt.Fatalf("遍历中断时应返回错误而不是截断的分页, got %d 条", len(records))
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"game/domain/entity"
+	"game/domain/repository"
+	"game/infrastructure/database"
+	"game/infrastructure/log"
+	"game/infrastructure/message/transfer"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const leaderboardKey = "leaderboard:rating"
+
+// applyRatingScript 原子地给多名玩家加减积分，积分最低为 0
+// ARGV: userID1, delta1, userID2, delta2, ...
+const applyRatingScript = `
+local key = KEYS[1]
+for i = 1, #ARGV, 2 do
+	local userID = ARGV[i]
+	local rating = tonumber(redis.call('ZSCORE', key, userID) or 0) + tonumber(ARGV[i + 1])
+	if rating < 0 then
+		rating = 0
+	end
+	redis.call('ZADD', key, rating, userID)
+end
+return 1
+`
+
+type LeaderboardRepository struct {
+	redis *database.RedisManager
+}
+
+func NewLeaderboardRepository(redis *database.RedisManager) repository.LeaderboardRepository {
+	return &LeaderboardRepository{redis: redis}
+}
+
+func (r *LeaderboardRepository) ApplyGameResult(ctx context.Context, rankings []entity.PlayerRanking, playerCount int) error {
+	if len(rankings) == 0 {
+		return nil
+	}
+	args := make([]any, 0, len(rankings)*2)
+	for _, rk := range rankings {
+		args = append(args, rk.UserID, entity.RatingDelta(rk.Rank, playerCount))
+	}
+	if _, err := r.redis.EvalScript(ctx, "leaderboard_apply", applyRatingScript, []string{leaderboardKey}, args...); err != nil {
+		log.Error("更新排行榜失败: %v", err)
+		return transfer.ErrRedis
+	}
+	return nil
+}
+
+func (r *LeaderboardRepository) TopN(ctx context.Context, n int) ([]entity.LeaderboardEntry, error) {
+	if n <= 0 {
+		return []entity.LeaderboardEntry{}, nil
+	}
+	cli, err := r.redis.GetClient()
+	if err != nil {
+		return nil, err
+	}
+	members, err := cli.ZRevRangeWithScores(ctx, leaderboardKey, 0, int64(n-1)).Result()
+	if err != nil {
+		log.Error("查询排行榜失败: %v", err)
+		return nil, transfer.ErrRedis
+	}
+
+	// 同分同名次，下一个不同分数的名次为其位置 + 1（1, 1, 3）
+	entries := make([]entity.LeaderboardEntry, len(members))
+	for i, m := range members {
+		entries[i] = entity.LeaderboardEntry{
+			UserID: m.Member.(string),
+			Rating: int(m.Score),
+			Rank:   i + 1,
+		}
+		if i > 0 && m.Score == members[i-1].Score {
+			entries[i].Rank = entries[i-1].Rank
+		}
+	}
+	return entries, nil
+}
+
+func (r *LeaderboardRepository) RankOf(ctx context.Context, userID string) (*entity.LeaderboardEntry, error) {
+	cli, err := r.redis.GetClient()
+	if err != nil {
+		return nil, err
+	}
+	score, err := cli.ZScore(ctx, leaderboardKey, userID).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, transfer.ErrLeaderboardNotFound
+		}
+		log.Error("查询排行榜失败: userID=%s, err=%v", userID, err)
+		return nil, transfer.ErrRedis
+	}
+	// 名次 = 分数严格更高的人数 + 1
+	higher, err := cli.ZCount(ctx, leaderboardKey, "("+strconv.FormatFloat(score, 'f', -1, 64), "+inf").Result()
+	if err != nil {
+		log.Error("查询排行榜失败: userID=%s, err=%v", userID, err)
+		return nil, transfer.ErrRedis
+	}
+	return &entity.LeaderboardEntry{UserID: userID, Rating: int(score), Rank: int(higher) + 1}, nil
+}
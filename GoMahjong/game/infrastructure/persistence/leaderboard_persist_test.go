@@ -0,0 +1,82 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"game/domain/entity"
+	"game/infrastructure/config"
+	"game/infrastructure/database"
+	"game/infrastructure/message/transfer"
+	"slices"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestLeaderboard(t *testing.T) *LeaderboardRepository {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	manager := database.NewRedis(config.RedisConf{Addr: mr.Addr()})
+	t.Cleanup(func() { manager.Close() })
+	return NewLeaderboardRepository(manager).(*LeaderboardRepository)
+}
+
+func TestLeaderboard(t *testing.T) {
+	ctx := context.Background()
+	r := newTestLeaderboard(t)
+	// 积分最低为 0：a 30 → 40，b 10 → 0，c 0 → 10 → 40，d 0 → 0 → 10
+	games := [][]entity.PlayerRanking{
+		{{UserID: "a", Rank: 1}, {UserID: "b", Rank: 2}, {UserID: "c", Rank: 3}, {UserID: "d", Rank: 4}},
+		// 一位是机器人，不上榜但占据顺位
+		{{UserID: "c", Rank: 2}, {UserID: "d", Rank: 3}, {UserID: "b", Rank: 4}},
+		{{UserID: "c", Rank: 1}, {UserID: "d", Rank: 2}},
+		{{UserID: "a", Rank: 2}},
+	}
+	for _, rankings := range games {
+		if err := r.ApplyGameResult(ctx, rankings, 4); err != nil {
+			t.Fatalf("ApplyGameResult: %v", err)
+		}
+	}
+
+	top, err := r.TopN(ctx, 3)
+	if err != nil {
+		t.Fatalf("TopN: %v", err)
+	}
+	var users []string
+	for _, e := range top {
+		users = append(users, e.UserID)
+	}
+	slices.Sort(users[:2])
+	if !slices.Equal(users, []string{"a", "c", "d"}) {
+		t.Fatalf("TopN 顺序错误: %+v", top)
+	}
+	for i, want := range []struct{ rating, rank int }{{40, 1}, {40, 1}, {10, 3}} {
+		if top[i].Rating != want.rating || top[i].Rank != want.rank {
+			t.Fatalf("第 %d 名 = %+v, want rating=%d rank=%d", i+1, top[i], want.rating, want.rank)
+		}
+	}
+
+	for _, tt := range []struct {
+		userID       string
+		rating, rank int
+	}{
+		{userID: "a", rating: 40, rank: 1},
+		{userID: "c", rating: 40, rank: 1},
+		{userID: "d", rating: 10, rank: 3},
+		{userID: "b", rating: 0, rank: 4},
+	} {
+		entry, err := r.RankOf(ctx, tt.userID)
+		if err != nil {
+			t.Fatalf("RankOf(%s): %v", tt.userID, err)
+		}
+		if entry.Rating != tt.rating || entry.Rank != tt.rank {
+			t.Fatalf("RankOf(%s) = %+v, want rating=%d rank=%d", tt.userID, entry, tt.rating, tt.rank)
+		}
+	}
+	if _, err := r.RankOf(ctx, "nobody"); !errors.Is(err, transfer.ErrLeaderboardNotFound) {
+		t.Fatalf("不在排行榜中应返回 ErrLeaderboardNotFound, got %v", err)
+	}
+	if top, err := r.TopN(ctx, 0); err != nil || len(top) != 0 {
+		t.Fatalf("TopN(0) = %v, %v", top, err)
+	}
+}
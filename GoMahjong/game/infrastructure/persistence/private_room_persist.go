@@ -0,0 +1,42 @@
+package persistence
+
+import (
+	"context"
+	"game/domain/repository"
+	"game/infrastructure/database"
+	"game/infrastructure/log"
+	"game/infrastructure/message/transfer"
+	"time"
+)
+
+// privateRoomKeyPrefix 与 connector/infrastructure/realtime 保持一致
+const privateRoomKeyPrefix = "privateroom:code:"
+
+type PrivateRoomRepository struct {
+	redis *database.RedisManager
+}
+
+func NewPrivateRoomRepository(redis *database.RedisManager) repository.PrivateRoomRepository {
+	return &PrivateRoomRepository{redis: redis}
+}
+
+func (r *PrivateRoomRepository) Reserve(ctx context.Context, code, gameNodeID string, ttl time.Duration) (bool, error) {
+	cli, err := r.redis.GetClient()
+	if err != nil {
+		return false, err
+	}
+	ok, err := cli.SetNX(ctx, privateRoomKeyPrefix+code, gameNodeID, ttl).Result()
+	if err != nil {
+		log.Error("占用房间号失败: code=%s, err=%v", code, err)
+		return false, transfer.ErrRedis
+	}
+	return ok, nil
+}
+
+func (r *PrivateRoomRepository) Release(ctx context.Context, code string) error {
+	if err := r.redis.Del(ctx, privateRoomKeyPrefix+code); err != nil {
+		log.Error("释放房间号失败: code=%s, err=%v", code, err)
+		return transfer.ErrRedis
+	}
+	return nil
+}
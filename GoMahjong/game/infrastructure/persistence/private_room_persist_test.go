@@ -0,0 +1,41 @@
+package persistence
+
+import (
+	"context"
+	"game/infrastructure/config"
+	"game/infrastructure/database"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestPrivateRoomReserve(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	manager := database.NewRedis(config.RedisConf{Addr: mr.Addr()})
+	defer manager.Close()
+	r := NewPrivateRoomRepository(manager)
+
+	if ok, err := r.Reserve(ctx, "123456", "game-1", time.Minute); err != nil || !ok {
+		t.Fatalf("Reserve = %v, %v", ok, err)
+	}
+	if ok, err := r.Reserve(ctx, "123456", "game-2", time.Minute); err != nil || ok {
+		t.Fatalf("已被占用的房间号不能再占用, got %v, %v", ok, err)
+	}
+	if got, _ := mr.Get(privateRoomKeyPrefix + "123456"); got != "game-1" {
+		t.Fatalf("房间号应指向创建房间的节点, got %q", got)
+	}
+
+	// 过期后可以重新占用
+	mr.FastForward(time.Minute)
+	if ok, err := r.Reserve(ctx, "123456", "game-2", time.Minute); err != nil || !ok {
+		t.Fatalf("过期后 Reserve = %v, %v", ok, err)
+	}
+	if err := r.Release(ctx, "123456"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if mr.Exists(privateRoomKeyPrefix + "123456") {
+		t.Fatalf("释放后房间号应删除")
+	}
+}
@@ -0,0 +1,235 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"game/domain/entity"
+	"game/domain/repository"
+	"game/infrastructure/database"
+	"game/infrastructure/log"
+	"game/infrastructure/message/transfer"
+	utils "game/infrastructure/utils"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// statsCacheTTL 统计结果缓存时间，对局结束后最多延迟这么久才能在统计中看到
+const statsCacheTTL = 60 * time.Second
+
+type StatsRepository struct {
+	mongo *database.MongoManager
+	redis *database.RedisManager
+}
+
+func NewStatsRepository(mongo *database.MongoManager, redis *database.RedisManager) repository.StatsRepository {
+	return &StatsRepository{mongo: mongo, redis: redis}
+}
+
+func (r *StatsRepository) GetPlayerStats(ctx context.Context, userID string, from, to time.Time) (*entity.PlayerStats, error) {
+	key := statsCacheKey(userID, from, to)
+	if stats := r.loadCache(ctx, key); stats != nil {
+		return stats, nil
+	}
+
+	stats := &entity.PlayerStats{UserID: userID, From: from, To: to}
+	rankSum, pointSum, err := r.aggregatePlacements(ctx, stats)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.aggregateRounds(ctx, stats); err != nil {
+		return nil, err
+	}
+	stats.Fill(rankSum, pointSum)
+
+	r.saveCache(ctx, key, stats)
+	return stats, nil
+}
+
+// matchGames 用户参与的、在时间范围内开始的已完成对局
+func matchGames(userID string, from, to time.Time) bson.M {
+	filter := bson.M{
+		"status":                        "completed",
+		"final_result.rankings.user_id": userID,
+	}
+	startTime := bson.M{}
+	if !from.IsZero() {
+		startTime["$gte"] = from
+	}
+	if !to.IsZero() {
+		startTime["$lt"] = to
+	}
+	if len(startTime) > 0 {
+		filter["start_time"] = startTime
+	}
+	return filter
+}
+
+// aggregatePlacements 在 game_records 上统计对局数、顺位分布、顺位和与点数和
+func (r *StatsRepository) aggregatePlacements(ctx context.Context, stats *entity.PlayerStats) (rankSum, pointSum int, err error) {
+	rankIs := func(rank int) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$final_result.rankings.rank", rank}}, 1, 0}}}
+	}
+	pipeline := bson.A{
+		bson.M{"$match": matchGames(stats.UserID, stats.From, stats.To)},
+		bson.M{"$unwind": "$final_result.rankings"},
+		bson.M{"$match": bson.M{"final_result.rankings.user_id": stats.UserID}},
+		bson.M{"$group": bson.M{
+			"_id":       nil,
+			"games":     bson.M{"$sum": 1},
+			"rank_sum":  bson.M{"$sum": "$final_result.rankings.rank"},
+			"point_sum": bson.M{"$sum": "$final_result.rankings.points"},
+			"rank1":     rankIs(1),
+			"rank2":     rankIs(2),
+			"rank3":     rankIs(3),
+			"rank4":     rankIs(4),
+		}},
+	}
+
+	doc, err := r.aggregateOne(ctx, "game_records", pipeline)
+	if err != nil || doc == nil {
+		return 0, 0, err
+	}
+	stats.Games = utils.ToInt(doc["games"])
+	for i := range stats.RankCounts {
+		stats.RankCounts[i] = utils.ToInt(doc[fmt.Sprintf("rank%d", i+1)])
+	}
+	return utils.ToInt(doc["rank_sum"]), utils.ToInt(doc["point_sum"]), nil
+}
+
+// aggregateRounds 关联 round_records，按用户在每场对局中的座位统计局数、和了、放铳、立直
+func (r *StatsRepository) aggregateRounds(ctx context.Context, stats *entity.PlayerStats) error {
+	flag := func(cond any) bson.M {
+		return bson.M{"$cond": bson.A{cond, 1, 0}}
+	}
+	claims := func(field string) bson.M {
+		return bson.M{"$ifNull": bson.A{"$round.round_result.claims." + field, bson.A{}}}
+	}
+	pipeline := bson.A{
+		bson.M{"$match": matchGames(stats.UserID, stats.From, stats.To)},
+		bson.M{"$project": bson.M{
+			"seat": bson.M{"$arrayElemAt": bson.A{
+				bson.M{"$filter": bson.M{
+					"input": "$players",
+					"cond":  bson.M{"$eq": bson.A{"$$this.user_id", stats.UserID}},
+				}}, 0,
+			}},
+		}},
+		bson.M{"$lookup": bson.M{
+			"from":         "round_records",
+			"localField":   "_id",
+			"foreignField": "game_record_id",
+			"as":           "round",
+		}},
+		bson.M{"$unwind": "$round"},
+		bson.M{"$project": bson.M{
+			"win": flag(bson.M{"$in": bson.A{"$seat.seat_index", claims("winner_seat")}}),
+			"deal_in": flag(bson.M{"$and": bson.A{
+				bson.M{"$eq": bson.A{"$round.round_result.end_type", "RON"}}, // 自摸时 loser_seat 没有意义
+				bson.M{"$in": bson.A{"$seat.seat_index", claims("loser_seat")}},
+			}}),
+			"riichi": flag(bson.M{"$gt": bson.A{
+				bson.M{"$size": bson.M{"$filter": bson.M{
+					"input": bson.M{"$ifNull": bson.A{"$round.events", bson.A{}}},
+					"cond": bson.M{"$and": bson.A{
+						bson.M{"$eq": bson.A{"$$this.event_type", entity.EventTypeRiichi}},
+						bson.M{"$eq": bson.A{"$$this.seat_index", "$seat.seat_index"}},
+					}},
+				}}}, 0,
+			}}),
+		}},
+		bson.M{"$group": bson.M{
+			"_id":      nil,
+			"rounds":   bson.M{"$sum": 1},
+			"wins":     bson.M{"$sum": "$win"},
+			"deal_ins": bson.M{"$sum": "$deal_in"},
+			"riichis":  bson.M{"$sum": "$riichi"},
+		}},
+	}
+
+	doc, err := r.aggregateOne(ctx, "game_records", pipeline)
+	if err != nil || doc == nil {
+		return err
+	}
+	stats.Rounds = utils.ToInt(doc["rounds"])
+	stats.Wins = utils.ToInt(doc["wins"])
+	stats.DealIns = utils.ToInt(doc["deal_ins"])
+	stats.Riichis = utils.ToInt(doc["riichis"])
+	return nil
+}
+
+// aggregateOne 执行以 $group _id:nil 结尾的聚合，没有匹配的文档时返回 nil
+func (r *StatsRepository) aggregateOne(ctx context.Context, collection string, pipeline bson.A) (bson.M, error) {
+	cursor, err := r.mongo.Db.Collection(collection).Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		log.Error("统计聚合失败: collection=%s, err=%v", collection, err)
+		return nil, transfer.ErrMongodb
+	}
+	defer cursor.Close(ctx)
+
+	if !cursor.Next(ctx) {
+		if err := cursor.Err(); err != nil {
+			log.Error("统计聚合失败: collection=%s, err=%v", collection, err)
+			return nil, transfer.ErrMongodb
+		}
+		return nil, nil
+	}
+	var doc bson.M
+	if err := cursor.Decode(&doc); err != nil {
+		log.Error("解码统计结果失败: collection=%s, err=%v", collection, err)
+		return nil, transfer.ErrMongodb
+	}
+	return doc, nil
+}
+
+func statsCacheKey(userID string, from, to time.Time) string {
+	return fmt.Sprintf("stats:player:%s:%d:%d", userID, unixOrZero(from), unixOrZero(to))
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// loadCache 缓存只是优化，读取失败时直接回源
+func (r *StatsRepository) loadCache(ctx context.Context, key string) *entity.PlayerStats {
+	if r.redis == nil {
+		return nil
+	}
+	cmd := r.redis.Get(ctx, key)
+	if cmd == nil {
+		return nil
+	}
+	data, err := cmd.Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Warn("读取统计缓存失败: key=%s, err=%v", key, err)
+		}
+		return nil
+	}
+	var stats entity.PlayerStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		log.Warn("解析统计缓存失败: key=%s, err=%v", key, err)
+		return nil
+	}
+	return &stats
+}
+
+func (r *StatsRepository) saveCache(ctx context.Context, key string, stats *entity.PlayerStats) {
+	if r.redis == nil {
+		return
+	}
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return
+	}
+	if err := r.redis.Set(ctx, key, string(data), statsCacheTTL); err != nil {
+		log.Warn("写入统计缓存失败: key=%s, err=%v", key, err)
+	}
+}
@@ -0,0 +1,96 @@
+package persistence
+
+import (
+	"game/infrastructure/config"
+	"game/infrastructure/database"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// 聚合管道本身需要真实的 MongoDB 执行，这里用 mock 返回两段聚合的结果，检查统计值的换算、时间范围过滤和 Redis 缓存
+func TestGetPlayerStats(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	const ns = "test.game_records"
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	mt.Run("placements and deal-ins", func(mt *mtest.T) {
+		mr := miniredis.RunT(mt.T)
+		redisManager := database.NewRedis(config.RedisConf{Addr: mr.Addr()})
+		defer redisManager.Close()
+		r := &StatsRepository{mongo: &database.MongoManager{Cli: mt.Client, Db: mt.DB}, redis: redisManager}
+
+		// 5 场：一位 2 次、二位 1 次、四位 2 次；40 局中和了 10 局、放铳 6 局、立直 8 局
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: nil}, {Key: "games", Value: int32(5)},
+				{Key: "rank_sum", Value: int32(12)}, {Key: "point_sum", Value: int32(130000)},
+				{Key: "rank1", Value: int32(2)}, {Key: "rank2", Value: int32(1)},
+				{Key: "rank3", Value: int32(0)}, {Key: "rank4", Value: int32(2)},
+			}),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: nil}, {Key: "rounds", Value: int32(40)},
+				{Key: "wins", Value: int32(10)}, {Key: "deal_ins", Value: int32(6)}, {Key: "riichis", Value: int32(8)},
+			}),
+		)
+
+		stats, err := r.GetPlayerStats(mt.Context(), "u1", from, to)
+		if err != nil {
+			mt.Fatalf("GetPlayerStats: %v", err)
+		}
+		if stats.Games != 5 || stats.RankCounts != [4]int{2, 1, 0, 2} || stats.AvgRank != 2.4 || stats.AvgPoints != 26000 {
+			mt.Fatalf("顺位统计错误: %+v", stats)
+		}
+		if stats.DealIns != 6 || stats.DealInRate != 0.15 || stats.WinRate != 0.25 || stats.RiichiRate != 0.2 {
+			mt.Fatalf("局统计错误: %+v", stats)
+		}
+
+		match := mt.GetStartedEvent().Command.Lookup("pipeline", "0", "$match")
+		if got := match.Document().Lookup("final_result.rankings.user_id").StringValue(); got != "u1" {
+			mt.Fatalf("应只统计用户参与的对局, got %q", got)
+		}
+		startTime := match.Document().Lookup("start_time").Document()
+		if gte := startTime.Lookup("$gte").Time(); !gte.Equal(from) {
+			mt.Fatalf("start_time $gte = %v, want %v", gte, from)
+		}
+		if lt := startTime.Lookup("$lt").Time(); !lt.Equal(to) {
+			mt.Fatalf("start_time $lt = %v, want %v", lt, to)
+		}
+
+		// 缓存有效期内不再查询 MongoDB（没有排队的 mock 响应，查询会失败）
+		mt.ClearEvents()
+		cached, err := r.GetPlayerStats(mt.Context(), "u1", from, to)
+		if err != nil || cached.DealIns != 6 || cached.AvgRank != 2.4 {
+			mt.Fatalf("应命中缓存, got %+v err=%v", cached, err)
+		}
+		if mt.GetStartedEvent() != nil {
+			mt.Fatalf("命中缓存时不应查询 MongoDB")
+		}
+		if ttl := mr.TTL(statsCacheKey("u1", from, to)); ttl <= 0 || ttl > statsCacheTTL {
+			mt.Fatalf("缓存有效期 = %v", ttl)
+		}
+	})
+
+	mt.Run("no games", func(mt *mtest.T) {
+		r := &StatsRepository{mongo: &database.MongoManager{Cli: mt.Client, Db: mt.DB}}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch),
+		)
+
+		stats, err := r.GetPlayerStats(mt.Context(), "u1", time.Time{}, time.Time{})
+		if err != nil {
+			mt.Fatalf("GetPlayerStats: %v", err)
+		}
+		if stats.Games != 0 || stats.AvgRank != 0 || stats.DealInRate != 0 {
+			mt.Fatalf("没有对局时统计应为零值, got %+v", stats)
+		}
+		if mt.GetStartedEvent().Command.Lookup("pipeline", "0", "$match", "start_time").Type != 0 {
+			mt.Fatalf("不限时间范围时不应过滤 start_time")
+		}
+	})
+}
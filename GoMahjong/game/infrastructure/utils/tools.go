@@ -54,25 +54,64 @@ func ToString(value interface{}) string {
 
 func ToIntArray(value interface{}) [4]int {
 	var result [4]int
-	switch v := value.(type) {
-	case []interface{}:
-		for i := 0; i < len(v) && i < 4; i++ {
-			result[i] = ToInt(v[i])
-		}
+	v := ToSlice(value)
+	for i := 0; i < len(v) && i < 4; i++ {
+		result[i] = ToInt(v[i])
 	}
 	return result
 }
 
 func ToStringArray(value interface{}) []string {
+	if v, ok := value.([]string); ok {
+		return v
+	}
+	v := ToSlice(value)
+	if v == nil {
+		return nil
+	}
+	result := make([]string, len(v))
+	for i, x := range v {
+		result[i] = ToString(x)
+	}
+	return result
+}
+
+// ToMap 嵌套文档：从 mongo 解码出来是 primitive.M，内存中构造的是 map[string]interface{}
+func ToMap(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case primitive.M:
+		return v
+	case map[string]interface{}:
+		return v
+	}
+	return nil
+}
+
+// ToSlice 数组：从 mongo 解码出来是 primitive.A，内存中构造的可能是具体类型的切片
+func ToSlice(value interface{}) []interface{} {
 	switch v := value.(type) {
+	case primitive.A:
+		return v
 	case []interface{}:
-		result := make([]string, len(v))
+		return v
+	case []map[string]interface{}:
+		result := make([]interface{}, len(v))
 		for i, x := range v {
-			result[i] = ToString(x)
+			result[i] = x
+		}
+		return result
+	case [][]map[string]interface{}:
+		result := make([]interface{}, len(v))
+		for i, x := range v {
+			result[i] = x
+		}
+		return result
+	case []int:
+		result := make([]interface{}, len(v))
+		for i, x := range v {
+			result[i] = x
 		}
 		return result
-	case []string:
-		return v
 	}
 	return nil
 }
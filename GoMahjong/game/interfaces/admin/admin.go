@@ -0,0 +1,100 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	gameRuntime "game/runtime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RoomListResp 房间列表
+type RoomListResp struct {
+	NodeID      string                    `json:"nodeId"`
+	GameCount   int                       `json:"gameCount"`
+	PlayerCount int                       `json:"playerCount"`
+	Rooms       []gameRuntime.RoomSummary `json:"rooms"`
+}
+
+// DestroyRoomResp 强制解散结果
+type DestroyRoomResp struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// Handler game 节点的管理接口，所有请求都要求携带 Authorization: Bearer <token>
+//
+//	GET  /admin/rooms                 列出当前节点的房间
+//	POST /admin/rooms/destroy?roomId= 强制解散房间
+type Handler struct {
+	worker *gameRuntime.Worker
+	token  string
+	mux    *http.ServeMux
+}
+
+// NewHandler 创建管理接口，token 为空时拒绝所有请求
+func NewHandler(worker *gameRuntime.Worker, token string) *Handler {
+	h := &Handler{worker: worker, token: token, mux: http.NewServeMux()}
+	h.mux.HandleFunc("/admin/rooms", h.listRooms)
+	h.mux.HandleFunc("/admin/rooms/destroy", h.destroyRoom)
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// authorized 校验管理 token，使用常量时间比较
+func (h *Handler) authorized(r *http.Request) bool {
+	if h.token == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
+
+func (h *Handler) listRooms(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	gameCount, playerCount := h.worker.RoomManager.GetStats()
+	writeJSON(w, http.StatusOK, &RoomListResp{
+		NodeID:      h.worker.NodeID,
+		GameCount:   gameCount,
+		PlayerCount: playerCount,
+		Rooms:       h.worker.RoomManager.RoomSummaries(time.Now()),
+	})
+}
+
+func (h *Handler) destroyRoom(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	roomID := r.URL.Query().Get("roomId")
+	if roomID == "" {
+		writeJSON(w, http.StatusBadRequest, &DestroyRoomResp{Message: "缺少 roomId"})
+		return
+	}
+	if _, exists := h.worker.RoomManager.GetRoom(roomID); !exists {
+		writeJSON(w, http.StatusNotFound, &DestroyRoomResp{Message: "房间不存在"})
+		return
+	}
+	if err := h.worker.ForceDestroyRoom(roomID); err != nil {
+		writeJSON(w, http.StatusNotFound, &DestroyRoomResp{Message: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, &DestroyRoomResp{Success: true, Message: "房间已解散"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,128 @@
+package admin
+
+import (
+	"encoding/json"
+	"game/infrastructure/log"
+	gameRuntime "game/runtime"
+	"game/runtime/engines"
+	"game/runtime/share"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	log.InitLog("game_admin_test", "error")
+	os.Exit(m.Run())
+}
+
+// statusEngine 报告固定运行状态的空引擎
+type statusEngine struct {
+	closed bool
+}
+
+func (e *statusEngine) InitializeEngine(string, map[string]*share.UserInfo) error { return nil }
+func (e *statusEngine) NotifyEvent(share.GameEvent)                               {}
+func (e *statusEngine) Clone() engines.Engine                                     { return &statusEngine{} }
+func (e *statusEngine) Close()                                                    { e.closed = true }
+func (e *statusEngine) Status() engines.EngineStatus {
+	return engines.EngineStatus{State: engines.GameInProgress, RoundWind: "东", RoundNumber: 2, Honba: 1, CurrentTurn: 3, TurnState: "WaitMain"}
+}
+
+func newTestHandler(t *testing.T) (*Handler, *gameRuntime.Worker, *gameRuntime.Room) {
+	t.Helper()
+	worker := &gameRuntime.Worker{RoomManager: gameRuntime.NewRoomManager(), NodeID: "game-1"}
+	engineType := int32(engines.RIICHI_MAHJONG_4P_ENGINE)
+	if err := worker.RoomManager.SetEnginePrototype(engineType, &statusEngine{}); err != nil {
+		t.Fatalf("SetEnginePrototype: %v", err)
+	}
+	room, err := worker.RoomManager.CreateRoom(map[string]string{"u1": "c1", "u2": "c1", "u3": "c1", "u4": "c1"}, engineType)
+	if err != nil {
+		t.Fatalf("CreateRoom: %v", err)
+	}
+	room.Users["u4"].SetOffline()
+	return NewHandler(worker, "secret"), worker, room
+}
+
+func serve(h *Handler, method, target, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminAuth(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+	for _, token := range []string{"", "wrong"} {
+		if rec := serve(h, http.MethodGet, "/admin/rooms", token); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("token=%q 应返回 401, got %d", token, rec.Code)
+		}
+	}
+	if rec := serve(NewHandler(&gameRuntime.Worker{}, ""), http.MethodGet, "/admin/rooms", "x"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("未配置 token 时应拒绝所有请求, got %d", rec.Code)
+	}
+}
+
+func TestAdminListRooms(t *testing.T) {
+	h, _, room := newTestHandler(t)
+	rec := serve(h, http.MethodGet, "/admin/rooms", "secret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body)
+	}
+	var resp RoomListResp
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析响应: %v", err)
+	}
+	if resp.NodeID != "game-1" || resp.GameCount != 1 || resp.PlayerCount != 4 || len(resp.Rooms) != 1 {
+		t.Fatalf("节点统计不对: %+v", resp)
+	}
+	got := resp.Rooms[0]
+	if got.RoomID != room.ID || got.EngineType != int32(engines.RIICHI_MAHJONG_4P_ENGINE) ||
+		got.PlayerCount != 4 || got.OnlineCount != 3 || len(got.Players) != 4 || got.Players[0] != "u1" {
+		t.Fatalf("房间概况不对: %+v", got)
+	}
+	if got.RoundWind != "东" || got.RoundNumber != 2 || got.Honba != 1 || got.CurrentTurn != 3 || got.TurnState != "WaitMain" {
+		t.Fatalf("应带上引擎快照: %+v", got)
+	}
+	if got.CreatedAt != room.CreatedAt.UnixMilli() || got.UptimeSeconds < 0 {
+		t.Fatalf("创建时间与存活时长不对: %+v", got)
+	}
+
+	if rec := serve(h, http.MethodPost, "/admin/rooms", "secret"); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("列表只接受 GET, got %d", rec.Code)
+	}
+}
+
+func TestAdminDestroyRoom(t *testing.T) {
+	h, worker, room := newTestHandler(t)
+	if rec := serve(h, http.MethodGet, "/admin/rooms/destroy?roomId="+room.ID, "secret"); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("解散只接受 POST, got %d", rec.Code)
+	}
+	if rec := serve(h, http.MethodPost, "/admin/rooms/destroy", "secret"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("缺少 roomId 应返回 400, got %d", rec.Code)
+	}
+	if rec := serve(h, http.MethodPost, "/admin/rooms/destroy?roomId=missing", "secret"); rec.Code != http.StatusNotFound {
+		t.Fatalf("不存在的房间应返回 404, got %d", rec.Code)
+	}
+
+	rec := serve(h, http.MethodPost, "/admin/rooms/destroy?roomId="+room.ID, "secret")
+	var resp DestroyRoomResp
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusOK || !resp.Success {
+		t.Fatalf("解散失败: %d %s", rec.Code, rec.Body)
+	}
+	if _, exists := worker.RoomManager.GetRoom(room.ID); exists {
+		t.Fatalf("房间应已删除")
+	}
+	for userID := range room.Users {
+		if _, exists := worker.RoomManager.GetPlayerRoom(userID); exists {
+			t.Fatalf("玩家 %s 的路由应已清理", userID)
+		}
+	}
+	if !room.Engine.(*statusEngine).closed {
+		t.Fatalf("解散时应关闭引擎")
+	}
+}
@@ -0,0 +1,79 @@
+package game
+
+import (
+	"fmt"
+	"game/infrastructure/log"
+	"game/runtime/engines"
+	"sort"
+	"time"
+)
+
+// RoomSummary 管理接口展示的房间概况
+type RoomSummary struct {
+	RoomID        string   `json:"roomId"`
+	EngineType    int32    `json:"engineType"`
+	PlayerCount   int      `json:"playerCount"`
+	OnlineCount   int      `json:"onlineCount"`
+	Players       []string `json:"players"`
+	State         int      `json:"state"`
+	RoundWind     string   `json:"roundWind,omitempty"`
+	RoundNumber   int      `json:"roundNumber,omitempty"`
+	Honba         int      `json:"honba"`
+	CurrentTurn   int      `json:"currentTurn"`
+	TurnState     string   `json:"turnState,omitempty"`
+	CreatedAt     int64    `json:"createdAt"`     // 毫秒时间戳
+	UptimeSeconds int64    `json:"uptimeSeconds"` // 房间存活时长
+}
+
+// RoomSummaries 所有房间的概况，按创建时间排序
+// 对局状态来自引擎最近一次发布的快照，不进入引擎 actor
+func (rm *RoomManager) RoomSummaries(now time.Time) []RoomSummary {
+	rooms := rm.GetAllRooms()
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].CreatedAt.Before(rooms[j].CreatedAt) })
+
+	summaries := make([]RoomSummary, 0, len(rooms))
+	for _, room := range rooms {
+		summary := RoomSummary{
+			RoomID:        room.ID,
+			EngineType:    room.EngineType,
+			CreatedAt:     room.CreatedAt.UnixMilli(),
+			UptimeSeconds: int64(now.Sub(room.CreatedAt).Seconds()),
+			CurrentTurn:   -1,
+		}
+		for _, user := range room.GetAllPlayers() {
+			summary.Players = append(summary.Players, user.UserID)
+			if user.IsOnline {
+				summary.OnlineCount++
+			}
+		}
+		sort.Strings(summary.Players)
+		summary.PlayerCount = len(summary.Players)
+
+		if reporter, ok := room.Engine.(engines.StatusReporter); ok {
+			status := reporter.Status()
+			summary.State = int(status.State)
+			summary.RoundWind = status.RoundWind
+			summary.RoundNumber = status.RoundNumber
+			summary.Honba = status.Honba
+			summary.TurnState = status.TurnState
+			if status.State == engines.GameInProgress {
+				summary.CurrentTurn = status.CurrentTurn
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// ForceDestroyRoom 管理员强制解散房间，同步关闭引擎并清理玩家路由
+// 不经过结算和持久化，对局记录保持未完成状态
+func (w *Worker) ForceDestroyRoom(roomID string) error {
+	if roomID == "" {
+		return fmt.Errorf("房间 ID 不能为空")
+	}
+	if err := w.RoomManager.DeleteRoom(roomID); err != nil {
+		return err
+	}
+	log.Warn("Game Worker[%s] 管理员强制解散房间 %s", w.NodeID, roomID)
+	return nil
+}
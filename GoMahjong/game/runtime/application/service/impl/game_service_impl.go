@@ -2,7 +2,6 @@ package impl
 
 import (
 	"context"
-	"fmt"
 	"game/infrastructure/log"
 	"game/runtime"
 	"game/runtime/application/service"
@@ -40,7 +39,7 @@ func (s *GameServiceImpl) CreateRoom(ctx context.Context, req *service.CreateRoo
 	// 创建房间
 	room, err := s.roomManager.CreateRoom(req.Players, req.EngineType)
 	if err != nil {
-		log.Error(fmt.Sprintf("GameService 创建房间失败: %v", err))
+		log.Error("GameService 创建房间失败: %v", err)
 		return &service.CreateRoomResp{
 			Success: false,
 			Message: err.Error(),
@@ -51,7 +50,7 @@ func (s *GameServiceImpl) CreateRoom(ctx context.Context, req *service.CreateRoo
 	// 避免 GetPlayerConnector 的锁竞争，提升性能
 	// 如果 Engine 初始化失败，推送也会失败，这是合理的
 
-	log.Info(fmt.Sprintf("GameService 创建房间成功: %s, 玩家数: %d", room.ID, len(req.Players)))
+	log.Info("GameService 创建房间成功: %s, 玩家数: %d", room.ID, len(req.Players))
 
 	return &service.CreateRoomResp{
 		Success: true,
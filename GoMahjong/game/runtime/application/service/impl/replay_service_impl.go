@@ -0,0 +1,236 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"game/domain/entity"
+	"game/domain/repository"
+	"game/infrastructure/log"
+	"game/infrastructure/utils"
+	"game/runtime/application/service"
+	"slices"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ReplayServiceImpl struct {
+	repo repository.GameRecordRepository
+}
+
+// NewReplayService 创建 ReplayService 实例
+func NewReplayService(repo repository.GameRecordRepository) service.ReplayService {
+	return &ReplayServiceImpl{repo: repo}
+}
+
+// Reconstruct 按局号顺序重放所有局记录的事件，每个事件生成一个局面快照
+func (s *ReplayServiceImpl) Reconstruct(ctx context.Context, gameRecordID string) (*service.ReplayDTO, error) {
+	recordID, err := primitive.ObjectIDFromHex(gameRecordID)
+	if err != nil {
+		return nil, fmt.Errorf("无效的对局记录 ID %s: %w", gameRecordID, err)
+	}
+	record, err := s.repo.FindGameRecord(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	rounds, err := s.repo.FindRoundRecords(ctx, recordID)
+	if err != nil {
+		return nil, err
+	}
+	// 连庄时局号不变，同一局号按开始时间排序
+	sort.SliceStable(rounds, func(i, j int) bool {
+		if rounds[i].RoundNumber != rounds[j].RoundNumber {
+			return rounds[i].RoundNumber < rounds[j].RoundNumber
+		}
+		return rounds[i].StartTime.Before(rounds[j].StartTime)
+	})
+
+	seatCount := len(record.Players)
+	replay := &service.ReplayDTO{
+		GameRecordID: gameRecordID,
+		GameType:     record.GameType,
+		Players:      make([]service.ReplayPlayerDTO, 0, seatCount),
+	}
+	for _, p := range record.Players {
+		replay.Players = append(replay.Players, service.ReplayPlayerDTO{SeatIndex: p.SeatIndex, UserID: p.UserID})
+	}
+	sort.Slice(replay.Players, func(i, j int) bool { return replay.Players[i].SeatIndex < replay.Players[j].SeatIndex })
+
+	points := make([]int, seatCount)
+	for _, round := range rounds {
+		if start := round.StartPoints(seatCount); start != nil {
+			copy(points, start)
+		}
+		replay.Steps = append(replay.Steps, replayRound(round, seatCount, points)...)
+		if end := round.EndPoints(seatCount); end != nil {
+			copy(points, end)
+		}
+	}
+
+	if record.FinalResult != nil {
+		replay.FinalPoints = record.FinalResult.Points[:seatCount]
+		if !slices.Equal(points, replay.FinalPoints) {
+			log.Warn("牌谱回放点数与对局记录不一致: gameRecordID=%s, replay=%v, record=%v", gameRecordID, points, replay.FinalPoints)
+		}
+	}
+	return replay, nil
+}
+
+// replayState 重放过程中的局面
+type replayState struct {
+	round *entity.RoundRecord
+	dora  []service.ReplayTileDTO
+	seats []service.ReplaySeatDTO
+}
+
+func replayRound(round *entity.RoundRecord, seatCount int, points []int) []service.ReplayStepDTO {
+	st := &replayState{
+		round: round,
+		seats: make([]service.ReplaySeatDTO, seatCount),
+	}
+	for i := range st.seats {
+		st.seats[i].Points = points[i]
+	}
+
+	steps := make([]service.ReplayStepDTO, 0, len(round.Events))
+	for _, event := range round.Events {
+		st.apply(event)
+		step := st.snapshot(event)
+		if event.EventType == entity.EventTypeRoundEnd && round.RoundResult != nil {
+			step.Result = round.RoundResult
+			for i, p := range round.EndPoints(seatCount) {
+				step.Seats[i].Points = p
+			}
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+func (st *replayState) apply(event entity.RoundEvent) {
+	seat := event.SeatIndex
+	perSeat := event.EventType != entity.EventTypeRoundStart && event.EventType != entity.EventTypeRoundEnd
+	if seat >= len(st.seats) || (perSeat && seat < 0) {
+		log.Warn("牌谱事件座位越界: round=%d, seq=%d, seat=%d", st.round.RoundNumber, event.Sequence, seat)
+		return
+	}
+
+	switch event.EventType {
+	case entity.EventTypeRoundStart:
+		st.dora = toReplayTiles(event.Data["dora_indicators"])
+		for i, hand := range utils.ToSlice(event.Data["hands"]) {
+			if i < len(st.seats) {
+				st.seats[i].HandTiles = toReplayTiles(hand)
+			}
+		}
+	case entity.EventTypeDrawTile:
+		st.seats[seat].HandTiles = append(st.seats[seat].HandTiles, toReplayTile(event.Data["tile"]))
+	case entity.EventTypeDiscardTile:
+		tile := toReplayTile(event.Data["tile"])
+		st.removeFromHand(seat, tile)
+		st.seats[seat].DiscardPile = append(st.seats[seat].DiscardPile, tile)
+	case entity.EventTypeChi, entity.EventTypePeng, entity.EventTypeGang:
+		tiles := toReplayTiles(event.Data["tiles"])
+		for _, t := range tiles {
+			st.removeFromHand(seat, t) // 被鸣的那张不在手牌中，找不到时忽略
+		}
+		st.seats[seat].Melds = append(st.seats[seat].Melds, service.ReplayMeldDTO{
+			Type:  meldTypes[event.EventType],
+			Tiles: tiles,
+			From:  utils.ToInt(event.Data["from_seat"]),
+		})
+	case entity.EventTypeAnkan:
+		tiles := toReplayTiles(event.Data["tiles"])
+		for _, t := range tiles {
+			st.removeFromHand(seat, t)
+		}
+		st.seats[seat].Melds = append(st.seats[seat].Melds, service.ReplayMeldDTO{Type: "ANKAN", Tiles: tiles, From: -1})
+	case entity.EventTypeKakan:
+		tiles := toReplayTiles(event.Data["tiles"])
+		for _, t := range tiles {
+			st.removeFromHand(seat, t)
+		}
+		st.upgradePeng(seat, tiles, utils.ToInt(event.Data["from_seat"]))
+	case entity.EventTypeRiichi:
+		st.seats[seat].IsRiichi = true
+	case entity.EventTypeRon, entity.EventTypeTsumo, entity.EventTypeRoundEnd:
+		// 和牌与点数变化在 round_end 的快照中随局结果给出
+	default:
+		log.Debug("牌谱回放忽略未知事件: %s", event.EventType)
+	}
+}
+
+var meldTypes = map[string]string{
+	entity.EventTypeChi:  "CHI",
+	entity.EventTypePeng: "PENG",
+	entity.EventTypeGang: "GANG",
+}
+
+// upgradePeng 加杠：把同种牌的碰升级为加杠，找不到碰时按新副露处理
+func (st *replayState) upgradePeng(seat int, tiles []service.ReplayTileDTO, from int) {
+	if len(tiles) == 0 {
+		return
+	}
+	melds := st.seats[seat].Melds
+	for i := range melds {
+		if melds[i].Type == "PENG" && len(melds[i].Tiles) > 0 && melds[i].Tiles[0].Type == tiles[0].Type {
+			melds[i].Type = "KAKAN"
+			melds[i].Tiles = tiles
+			return
+		}
+	}
+	st.seats[seat].Melds = append(melds, service.ReplayMeldDTO{Type: "KAKAN", Tiles: tiles, From: from})
+}
+
+func (st *replayState) removeFromHand(seat int, tile service.ReplayTileDTO) bool {
+	hand := st.seats[seat].HandTiles
+	for i, t := range hand {
+		if t == tile {
+			st.seats[seat].HandTiles = append(hand[:i], hand[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// snapshot 深拷贝当前局面，后续事件不会影响已生成的快照
+func (st *replayState) snapshot(event entity.RoundEvent) service.ReplayStepDTO {
+	seats := make([]service.ReplaySeatDTO, len(st.seats))
+	for i, s := range st.seats {
+		seats[i] = service.ReplaySeatDTO{
+			HandTiles:   slices.Clone(s.HandTiles),
+			DiscardPile: slices.Clone(s.DiscardPile),
+			Melds:       make([]service.ReplayMeldDTO, len(s.Melds)),
+			IsRiichi:    s.IsRiichi,
+			Points:      s.Points,
+		}
+		for j, m := range s.Melds {
+			seats[i].Melds[j] = service.ReplayMeldDTO{Type: m.Type, Tiles: slices.Clone(m.Tiles), From: m.From}
+		}
+	}
+	return service.ReplayStepDTO{
+		RoundNumber:    st.round.RoundNumber,
+		RoundWind:      st.round.RoundWind,
+		DealerIndex:    st.round.DealerIndex,
+		Honba:          st.round.Honba,
+		Sequence:       event.Sequence,
+		EventType:      event.EventType,
+		SeatIndex:      event.SeatIndex,
+		DoraIndicators: slices.Clone(st.dora),
+		Seats:          seats,
+	}
+}
+
+func toReplayTile(value interface{}) service.ReplayTileDTO {
+	m := utils.ToMap(value)
+	return service.ReplayTileDTO{Type: utils.ToInt(m["type"]), ID: utils.ToInt(m["id"])}
+}
+
+func toReplayTiles(value interface{}) []service.ReplayTileDTO {
+	items := utils.ToSlice(value)
+	tiles := make([]service.ReplayTileDTO, 0, len(items))
+	for _, item := range items {
+		tiles = append(tiles, toReplayTile(item))
+	}
+	return tiles
+}
@@ -0,0 +1,162 @@
+package impl
+
+import (
+	"context"
+	"game/domain/entity"
+	"game/domain/repository"
+	"game/infrastructure/log"
+	"game/runtime/application/service"
+	"game/runtime/engines/mahjong"
+	"game/runtime/share"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMain(m *testing.M) {
+	log.InitLog("impl_test", "error")
+	os.Exit(m.Run())
+}
+
+// memoryGameRecords 内存中的对局记录仓储，saved 在 GamePersister 异步写完局记录后关闭
+type memoryGameRecords struct {
+	repository.GameRecordRepository
+	record *entity.GameRecord
+	rounds []*entity.RoundRecord
+	saved  chan struct{}
+}
+
+func (r *memoryGameRecords) SaveGameRecord(ctx context.Context, record *entity.GameRecord) error {
+	r.record = record
+	return nil
+}
+
+func (r *memoryGameRecords) SaveRoundRecords(ctx context.Context, rounds []*entity.RoundRecord) error {
+	r.rounds = rounds
+	close(r.saved)
+	return nil
+}
+
+func (r *memoryGameRecords) FindGameRecord(ctx context.Context, recordID primitive.ObjectID) (*entity.GameRecord, error) {
+	return r.record, nil
+}
+
+func (r *memoryGameRecords) FindRoundRecords(ctx context.Context, gameRecordID primitive.ObjectID) ([]*entity.RoundRecord, error) {
+	// 查询结果不保证局号顺序
+	rounds := slices.Clone(r.rounds)
+	slices.Reverse(rounds)
+	return rounds, nil
+}
+
+func tiles(types ...int) []share.Tile {
+	result := make([]share.Tile, len(types))
+	for i, t := range types {
+		result[i] = share.Tile{Type: t}
+	}
+	return result
+}
+
+// recordShortGame 用 GamePersister 记录两局：座位 1 放铳给座位 2，随后座位 1 立直自摸
+func recordShortGame(t *testing.T) (*memoryGameRecords, primitive.ObjectID) {
+	t.Helper()
+	repo := &memoryGameRecords{saved: make(chan struct{})}
+	userMap := make(map[string]*share.UserInfo)
+	for seat, userID := range []string{"u0", "u1", "u2", "u3"} {
+		userMap[userID] = &share.UserInfo{UserID: userID, SeatIndex: seat}
+	}
+	gp := mahjong.NewGamePersister(repo, nil, "room", userMap)
+	hands := [][]share.Tile{tiles(0, 1, 2), tiles(9, 10, 11), tiles(18, 19, 20), tiles(27, 28, 29)}
+	dora := tiles(30)
+
+	gp.StartRound(1, "East", 0, 0, hands, dora)
+	gp.RecordDiscardTile(0, share.Tile{Type: 2})
+	gp.RecordDrawTile(1, share.Tile{Type: 12})
+	gp.RecordDiscardTile(1, share.Tile{Type: 12})
+	gp.RecordRon(2, 1, share.Tile{Type: 12})
+	// 与引擎一致，记录的是结算前的点数
+	gp.CompleteRound("RON", nil, [4]int{0, -3900, 3900, 0}, [4]int{25000, 25000, 25000, 25000}, "", 1)
+
+	gp.StartRound(2, "East", 1, 0, hands, dora)
+	gp.RecordDrawTile(1, share.Tile{Type: 13})
+	gp.RecordRiichi(1)
+	gp.RecordDiscardTile(1, share.Tile{Type: 9})
+	gp.RecordTsumo(1, share.Tile{Type: 14})
+	gp.CompleteRound("TSUMO", nil, [4]int{-2000, 6000, -2000, -2000}, [4]int{25000, 20100, 28900, 25000}, "", 2)
+
+	final := [4]int{23000, 26100, 26900, 23000}
+	gp.FinalizeGame([]mahjong.PlayerRankingDTO{
+		{SeatIndex: 2, UserID: "u2", Points: final[2], Rank: 1},
+		{SeatIndex: 1, UserID: "u1", Points: final[1], Rank: 2},
+		{SeatIndex: 0, UserID: "u0", Points: final[0], Rank: 3},
+		{SeatIndex: 3, UserID: "u3", Points: final[3], Rank: 4},
+	}, final)
+	select {
+	case <-repo.saved:
+	case <-time.After(time.Second):
+		t.Fatalf("对局记录没有保存")
+	}
+	return repo, gp.GetGameRecordID()
+}
+
+func TestReplayReconstruct(t *testing.T) {
+	repo, recordID := recordShortGame(t)
+	replay, err := NewReplayService(repo).Reconstruct(context.Background(), recordID.Hex())
+	if err != nil {
+		t.Fatalf("Reconstruct: %v", err)
+	}
+
+	var rounds []int
+	for _, step := range replay.Steps {
+		if step.EventType == entity.EventTypeRoundStart {
+			rounds = append(rounds, step.RoundNumber)
+		}
+	}
+	if !slices.Equal(rounds, []int{1, 2}) {
+		t.Fatalf("应按局号顺序回放, got %v", rounds)
+	}
+
+	last := replay.Steps[len(replay.Steps)-1]
+	if last.EventType != entity.EventTypeRoundEnd || last.Result == nil {
+		t.Fatalf("最后一步应为局结束并带局结果, got %+v", last)
+	}
+	for seat, s := range last.Seats {
+		if s.Points != replay.FinalPoints[seat] {
+			t.Fatalf("座位 %d 回放点数 %d 与记录 %d 不一致", seat, s.Points, replay.FinalPoints[seat])
+		}
+	}
+
+	// 第一局座位 1 摸切后的局面
+	var discarded *service.ReplayStepDTO
+	for i := range replay.Steps {
+		step := &replay.Steps[i]
+		if step.RoundNumber == 1 && step.EventType == entity.EventTypeDiscardTile && step.SeatIndex == 1 {
+			discarded = step
+			break
+		}
+	}
+	if discarded == nil {
+		t.Fatalf("缺少第一局座位 1 的打牌快照")
+	}
+	seat := discarded.Seats[1]
+	if len(seat.HandTiles) != 3 || len(seat.DiscardPile) != 1 || seat.DiscardPile[0].Type != 12 {
+		t.Fatalf("摸切后手牌 %v 牌河 %v", seat.HandTiles, seat.DiscardPile)
+	}
+	if seat.Points != 25000 || discarded.Seats[0].DiscardPile[0].Type != 2 {
+		t.Fatalf("第一局开始点数或座位 0 牌河错误: %+v", discarded.Seats)
+	}
+	if !last.Seats[1].IsRiichi {
+		t.Fatalf("第二局座位 1 应已立直")
+	}
+
+	// 第二局开始时的点数是第一局结束的点数，结算前的点数已扣除立直的 1000 点
+	for _, step := range replay.Steps {
+		if step.RoundNumber == 2 && step.EventType == entity.EventTypeRoundStart {
+			if got := step.Seats[1].Points; got != 21100 {
+				t.Fatalf("第二局开始时座位 1 点数 = %d, want 21100", got)
+			}
+		}
+	}
+}
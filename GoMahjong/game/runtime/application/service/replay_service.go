@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"game/domain/entity"
+)
+
+// ReplayService 牌谱回放：根据持久化的局记录还原每一步的局面
+type ReplayService interface {
+	Reconstruct(ctx context.Context, gameRecordID string) (*ReplayDTO, error)
+}
+
+type ReplayTileDTO struct {
+	Type int `json:"type"`
+	ID   int `json:"id"`
+}
+
+type ReplayMeldDTO struct {
+	Type  string          `json:"type"` // CHI、PENG、GANG、ANKAN、KAKAN
+	Tiles []ReplayTileDTO `json:"tiles"`
+	From  int             `json:"from"` // 暗杠为 -1
+}
+
+// ReplaySeatDTO 某一步时单个座位的局面（回放下所有手牌可见）
+type ReplaySeatDTO struct {
+	HandTiles   []ReplayTileDTO `json:"handTiles"`
+	DiscardPile []ReplayTileDTO `json:"discardPile"`
+	Melds       []ReplayMeldDTO `json:"melds"`
+	IsRiichi    bool            `json:"isRiichi"`
+	Points      int             `json:"points"`
+}
+
+// ReplayStepDTO 执行完一个事件后的局面快照
+type ReplayStepDTO struct {
+	RoundNumber    int                 `json:"roundNumber"`
+	RoundWind      string              `json:"roundWind"`
+	DealerIndex    int                 `json:"dealerIndex"`
+	Honba          int                 `json:"honba"`
+	Sequence       int                 `json:"sequence"`
+	EventType      string              `json:"eventType"`
+	SeatIndex      int                 `json:"seatIndex"` // 事件的执行者，局开始/结束为 -1
+	DoraIndicators []ReplayTileDTO     `json:"doraIndicators"`
+	Seats          []ReplaySeatDTO     `json:"seats"`
+	Result         *entity.RoundResult `json:"result,omitempty"` // 仅 round_end
+}
+
+type ReplayPlayerDTO struct {
+	SeatIndex int    `json:"seatIndex"`
+	UserID    string `json:"userID"`
+}
+
+type ReplayDTO struct {
+	GameRecordID string            `json:"gameRecordID"`
+	GameType     string            `json:"gameType"`
+	Players      []ReplayPlayerDTO `json:"players"`
+	Steps        []ReplayStepDTO   `json:"steps"`
+	FinalPoints  []int             `json:"finalPoints"` // 来自对局记录，回放最后一步的点数应与之一致
+}
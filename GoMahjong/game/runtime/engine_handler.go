@@ -2,13 +2,85 @@ package game
 
 import (
 	"encoding/json"
-	"fmt"
 	"game/infrastructure/log"
+	"game/infrastructure/message/transfer"
 	"game/runtime/share"
 )
 
-// handleReconnect 处理断线重连消息
+// handleReconnect 处理断线重连消息，由引擎下发状态快照
 func (w *Worker) handleReconnect(data []byte) interface{} {
+	var event share.ReconnectEvent
+	err := json.Unmarshal(data, &event)
+	if err != nil {
+		log.Warn("handleReconnect json 解析失败")
+		return nil
+	}
+	room, exists := w.RoomManager.GetPlayerRoom(event.GetUserID())
+	if !exists {
+		log.Warn("Game Worker 玩家 %s 不在任何房间中", event.GetUserID())
+		return nil
+	}
+
+	room.Engine.NotifyEvent(&event)
+	return nil
+}
+
+// handleDisconnect 处理掉线通知，由引擎暂停该玩家的计时
+func (w *Worker) handleDisconnect(data []byte) any {
+	var event share.DisconnectEvent
+	err := json.Unmarshal(data, &event)
+	if err != nil {
+		log.Warn("handleDisconnect json 解析失败")
+		return nil
+	}
+	room, exists := w.RoomManager.GetPlayerRoom(event.GetUserID())
+	if !exists {
+		log.Warn("Game Worker 玩家 %s 不在任何房间中", event.GetUserID())
+		return nil
+	}
+
+	room.Engine.NotifyEvent(&event)
+	return nil
+}
+
+// handleSerializer 处理推送格式协商通知
+func (w *Worker) handleSerializer(data []byte) any {
+	var event share.SerializerEvent
+	err := json.Unmarshal(data, &event)
+	if err != nil {
+		log.Warn("handleSerializer json 解析失败")
+		return nil
+	}
+	room, exists := w.RoomManager.GetPlayerRoom(event.GetUserID())
+	if !exists {
+		log.Warn("Game Worker 玩家 %s 不在任何房间中", event.GetUserID())
+		return nil
+	}
+
+	room.Engine.NotifyEvent(&event)
+	return nil
+}
+
+// handleSpectate 处理观战请求，观战者加入被观战玩家所在的房间
+func (w *Worker) handleSpectate(data []byte) any {
+	var req transfer.SpectateReq
+	if err := json.Unmarshal(data, &req); err != nil || req.UserID == "" {
+		log.Warn("handleSpectate json 解析失败")
+		return nil
+	}
+	room, exists := w.RoomManager.GetPlayerRoom(req.TargetUserID)
+	if !exists {
+		log.Warn("Game Worker 被观战玩家 %s 不在任何房间中", req.TargetUserID)
+		return nil
+	}
+	if !room.AllowWatch && !req.Leave {
+		log.Warn("Game Worker 房间 %s 不允许观战", room.ID)
+		return nil
+	}
+
+	event := &share.SpectateEvent{ConnectorNodeID: req.ConnectorID, Leave: req.Leave}
+	event.UserID = req.UserID
+	room.Engine.NotifyEvent(event)
 	return nil
 }
 
@@ -21,7 +93,58 @@ func (w *Worker) handleDropTileHandler(data []byte) any {
 	}
 	room, exists := w.RoomManager.GetPlayerRoom(event.GetUserID())
 	if !exists {
-		log.Warn(fmt.Sprintf("Game Worker 玩家 %s 不在任何房间中", event.GetUserID()))
+		log.Warn("Game Worker 玩家 %s 不在任何房间中", event.GetUserID())
+		return nil
+	}
+
+	room.Engine.NotifyEvent(&event)
+	return nil
+}
+
+func (w *Worker) handleKyuushuuHandler(data []byte) any {
+	var event share.KyuushuuEvent
+	err := json.Unmarshal(data, &event)
+	if err != nil {
+		log.Warn("handleKyuushuuHandler json 解析失败")
+		return nil
+	}
+	room, exists := w.RoomManager.GetPlayerRoom(event.GetUserID())
+	if !exists {
+		log.Warn("Game Worker 玩家 %s 不在任何房间中", event.GetUserID())
+		return nil
+	}
+
+	room.Engine.NotifyEvent(&event)
+	return nil
+}
+
+func (w *Worker) handleKitaHandler(data []byte) any {
+	var event share.KitaEvent
+	err := json.Unmarshal(data, &event)
+	if err != nil {
+		log.Warn("handleKitaHandler json 解析失败")
+		return nil
+	}
+	room, exists := w.RoomManager.GetPlayerRoom(event.GetUserID())
+	if !exists {
+		log.Warn("Game Worker 玩家 %s 不在任何房间中", event.GetUserID())
+		return nil
+	}
+
+	room.Engine.NotifyEvent(&event)
+	return nil
+}
+
+func (w *Worker) handleScorePreviewHandler(data []byte) any {
+	var event share.ScorePreviewEvent
+	err := json.Unmarshal(data, &event)
+	if err != nil {
+		log.Warn("handleScorePreviewHandler json 解析失败")
+		return nil
+	}
+	room, exists := w.RoomManager.GetPlayerRoom(event.GetUserID())
+	if !exists {
+		log.Warn("Game Worker 玩家 %s 不在任何房间中", event.GetUserID())
 		return nil
 	}
 
@@ -38,7 +161,7 @@ func (w *Worker) handlePengTileHandler(data []byte) any {
 	}
 	room, exists := w.RoomManager.GetPlayerRoom(event.GetUserID())
 	if !exists {
-		log.Warn(fmt.Sprintf("Game Worker 玩家 %s 不在任何房间中", event.GetUserID()))
+		log.Warn("Game Worker 玩家 %s 不在任何房间中", event.GetUserID())
 		return nil
 	}
 
@@ -55,7 +178,7 @@ func (w *Worker) handleGangTileHandler(data []byte) any {
 	}
 	room, exists := w.RoomManager.GetPlayerRoom(event.GetUserID())
 	if !exists {
-		log.Warn(fmt.Sprintf("Game Worker 玩家 %s 不在任何房间中", event.GetUserID()))
+		log.Warn("Game Worker 玩家 %s 不在任何房间中", event.GetUserID())
 		return nil
 	}
 
@@ -7,7 +7,9 @@ import (
 type engineType int32
 
 const (
-	RIICHI_MAHJONG_4P_ENGINE engineType = iota // 立直麻将4人 游戏引擎
+	RIICHI_MAHJONG_4P_ENGINE        engineType = iota // 立直麻将4人 游戏引擎（半庄战）
+	RIICHI_MAHJONG_3P_ENGINE                          // 立直麻将3人（三麻），march 按 casual3 推断为 1
+	RIICHI_MAHJONG_4P_TONPUU_ENGINE                   // 立直麻将4人 东风战
 )
 
 type GameState int
@@ -19,6 +21,21 @@ const (
 	GameFinished                    // 结束
 )
 
+// EngineStatus 引擎公开的运行状态，供管理接口查询
+type EngineStatus struct {
+	State       GameState
+	RoundWind   string
+	RoundNumber int
+	Honba       int
+	CurrentTurn int
+	TurnState   string
+}
+
+// StatusReporter 能在 actor 之外并发安全地报告运行状态的引擎
+type StatusReporter interface {
+	Status() EngineStatus
+}
+
 // Engine 使用原型模式，每个游戏房间都有一个游戏引擎
 type Engine interface {
 	// InitializeEngine 初始化游戏引擎
@@ -0,0 +1,204 @@
+package mahjong
+
+import (
+	"game/infrastructure/log"
+	"game/runtime/share"
+)
+
+// BotView bot 决策时可见的信息，Player 只读
+type BotView struct {
+	Player    *PlayerImage
+	Visible   *[34]uint8      // 场上可见的牌（牌河、副露、宝牌指示牌、拔北），不含自己手牌
+	CanTsumo  bool            // 引擎判定：和牌型且有役
+	CanRiichi bool            // 引擎判定：满足立直条件
+	IsAka     func(Tile) bool // 按本局的赤牌规则判断赤牌，不使用赤牌的花色 ID 0 是普通牌
+}
+
+// BotMainDecision bot 出牌阶段的决定：自摸时忽略其他字段，立直时 Discard 为宣言牌
+type BotMainDecision struct {
+	Tsumo   bool
+	Riichi  bool
+	Discard Tile
+}
+
+// BotPolicy bot 座位的自动操作策略，超时时由引擎调用
+type BotPolicy interface {
+	// Main 出牌阶段的决定，Discard 必须是手中不受食替限制的牌
+	Main(view *BotView) BotMainDecision
+	// React 对他家打牌的反应，返回 nil 表示跳过
+	React(view *BotView, ops []*PlayerOperation) *PlayerOperation
+}
+
+// ShantenBotPolicy 基础策略：能和就和，打出后向听数最小、进张最多的牌，门清听牌且有进张时立直，不鸣牌
+type ShantenBotPolicy struct {
+	Searcher *Searcher
+}
+
+func NewShantenBotPolicy(searcher *Searcher) *ShantenBotPolicy {
+	return &ShantenBotPolicy{Searcher: searcher}
+}
+
+func (b *ShantenBotPolicy) Main(view *BotView) BotMainDecision {
+	player := view.Player
+	if view.CanTsumo {
+		return BotMainDecision{Tsumo: true}
+	}
+	// 立直后只能摸切
+	if player.IsRiichi && player.NewestTile != nil {
+		return BotMainDecision{Discard: *player.NewestTile}
+	}
+
+	h14, options := Hand34FromTiles(player.Tiles)
+	melds := len(player.Melds)
+	var shantens [34]int
+	bestShanten := -1
+	for i := 0; i < 34; i++ {
+		if h14[i] == 0 || player.IsKuikaeForbidden(TileType(i)) {
+			continue
+		}
+		h13 := h14
+		h13[i]--
+		shantens[i] = b.Searcher.ShantenAll(h13, melds)
+		if bestShanten < 0 || shantens[i] < bestShanten {
+			bestShanten = shantens[i]
+		}
+	}
+	// 向听数相同时比较进张
+	bestType, bestUkeire := -1, 0
+	for i := 0; i < 34 && bestShanten >= 0; i++ {
+		if h14[i] == 0 || player.IsKuikaeForbidden(TileType(i)) || shantens[i] != bestShanten {
+			continue
+		}
+		h13 := h14
+		h13[i]--
+		ukeire := b.ukeire(h13, melds, bestShanten, view.Visible)
+		if bestType < 0 || ukeire > bestUkeire {
+			bestType, bestUkeire = i, ukeire
+		}
+	}
+	if bestType < 0 {
+		// 全部受食替限制，交给引擎按默认方式出牌
+		return BotMainDecision{Discard: player.Tiles[len(player.Tiles)-1]}
+	}
+
+	return BotMainDecision{
+		Riichi:  view.CanRiichi && bestShanten == 0 && bestUkeire > 0,
+		Discard: pickPhysical(options[TileType(bestType)], view.IsAka),
+	}
+}
+
+func (b *ShantenBotPolicy) React(view *BotView, ops []*PlayerOperation) *PlayerOperation {
+	for _, op := range ops {
+		if op.Type == "HU" {
+			return op
+		}
+	}
+	return nil
+}
+
+// ukeire 能让向听数前进的进张数，扣除手牌和场上可见的牌
+func (b *ShantenBotPolicy) ukeire(h13 Hand34, melds, shanten int, visible *[34]uint8) int {
+	total := 0
+	for t := 0; t < 34; t++ {
+		if h13[t] >= 4 {
+			continue
+		}
+		work := h13
+		work[t]++
+		if b.Searcher.ShantenAll(work, melds) >= shanten {
+			continue
+		}
+		left := 4 - int(h13[t])
+		if visible != nil {
+			left -= int(visible[t])
+		}
+		total += max(0, left)
+	}
+	return total
+}
+
+// pickPhysical 同种牌中优先打出非赤牌
+func pickPhysical(tiles []Tile, isAka func(Tile) bool) Tile {
+	for _, t := range tiles {
+		if !isAka(t) {
+			return t
+		}
+	}
+	return tiles[0]
+}
+
+// isBotSeat 座位上是否是补位的 bot
+func (eg *RiichiMahjong4p) isBotSeat(seatIndex int) bool {
+	player := eg.Players[seatIndex]
+	if player == nil {
+		return false
+	}
+	userInfo, ok := eg.UserMap[player.UserID]
+	return ok && userInfo.IsBot
+}
+
+// botView 构建 bot 决策用的视图
+func (eg *RiichiMahjong4p) botView(seatIndex int) *BotView {
+	visible := eg.visibleTiles()
+	view := &BotView{
+		Player:  eg.Players[seatIndex],
+		Visible: &visible,
+		IsAka:   eg.DeckManager.IsAka,
+	}
+	if eg.TurnManager.GetState() == TurnStateWaitMain && seatIndex == eg.TurnManager.GetCurrentPlayer() {
+		view.CanTsumo = eg.canTsumo(seatIndex)
+		view.CanRiichi = eg.canRiichi(seatIndex) == nil
+	}
+	return view
+}
+
+// botMainTurn bot 出牌阶段按策略操作，返回 false 时由调用方按默认方式出牌
+func (eg *RiichiMahjong4p) botMainTurn(seatIndex int) bool {
+	player := eg.Players[seatIndex]
+	decision := eg.BotPolicy.Main(eg.botView(seatIndex))
+	userEvent := share.GameMessageEvent{UserID: player.UserID}
+	if decision.Tsumo {
+		log.Info("bot %d 自摸", seatIndex)
+		eg.handleTouchHuEvent(&share.TouchHuEvent{GameMessageEvent: userEvent})
+		return true
+	}
+	if player.IsKuikaeForbidden(decision.Discard.Type) {
+		log.Warn("bot %d 选择了食替牌 %v，改为默认出牌", seatIndex, decision.Discard)
+		return false
+	}
+	if decision.Riichi {
+		eg.handleRiichiEvent(&share.RiichiEvent{GameMessageEvent: userEvent})
+	}
+	firstTurn := player.FirstTurn
+	if !player.DiscardTile(decision.Discard) {
+		log.Warn("bot %d 手中没有 %v，改为默认出牌", seatIndex, decision.Discard)
+		return false
+	}
+	eg.finishAutoDiscard(seatIndex, firstTurn, decision.Discard)
+	return true
+}
+
+// visibleTiles 场上所有人可见的牌：牌河、副露、宝牌指示牌、拔北，不含任何人的暗手牌
+// 被鸣走的牌已从牌河移除，只在副露中计一次
+func (eg *RiichiMahjong4p) visibleTiles() [34]uint8 {
+	var visible [34]uint8
+	add := func(tiles []Tile) {
+		for _, t := range tiles {
+			visible[int(t.Type)]++
+		}
+	}
+	for _, p := range eg.Players {
+		if p == nil {
+			continue
+		}
+		add(p.DiscardPile)
+		add(p.Kita)
+		for _, m := range p.Melds {
+			add(m.Tiles)
+		}
+	}
+	if eg.DeckManager != nil {
+		add(eg.DeckManager.GetDoraIndicators())
+	}
+	return visible
+}
@@ -0,0 +1,147 @@
+package mahjong
+
+import (
+	"game/runtime/share"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// soloDrawLimit 单人摸打的最大巡数，超过时按未听牌计
+const soloDrawLimit = 60
+
+// drawsToTenpai 用种子洗出的牌山单人摸打，返回打出后听牌所需的巡数
+func drawsToTenpai(t *testing.T, searcher *Searcher, seed int64, discard func(p *PlayerImage, dm *DeckManager) Tile) int {
+	t.Helper()
+	dm := NewDeckManagerWithSeed(AkaRules{}, seed)
+	dm.InitRound()
+	p := NewPlayerImage("bot", 0, 25000)
+	for range 13 {
+		tile, _ := dm.Deal()
+		p.AddTile(tile)
+	}
+	for draws := 1; draws <= soloDrawLimit; draws++ {
+		tile, ok := dm.Draw()
+		if !ok {
+			break
+		}
+		p.DrawTile(tile)
+		if !p.DiscardTile(discard(p, dm)) {
+			t.Fatalf("打出的牌不在手中")
+		}
+		h13, _ := Hand34FromTiles(p.Tiles)
+		if searcher.ShantenAll(h13, 0) == 0 {
+			return draws
+		}
+	}
+	return soloDrawLimit
+}
+
+func TestShantenBotReachesTenpaiFaster(t *testing.T) {
+	searcher := NewSearcher()
+	policy := NewShantenBotPolicy(searcher)
+	botTotal, randomTotal, botWins := 0, 0, 0
+	for seed := int64(1); seed <= 20; seed++ {
+		bot := drawsToTenpai(t, searcher, seed, func(p *PlayerImage, dm *DeckManager) Tile {
+			return policy.Main(&BotView{Player: p, IsAka: dm.IsAka}).Discard
+		})
+		rng := rand.New(rand.NewSource(seed))
+		random := drawsToTenpai(t, searcher, seed, func(p *PlayerImage, dm *DeckManager) Tile {
+			return p.Tiles[rng.Intn(len(p.Tiles))]
+		})
+		botTotal += bot
+		randomTotal += random
+		if bot < random {
+			botWins++
+		}
+	}
+	t.Logf("bot 共 %d 巡，随机共 %d 巡，bot 更快 %d/20", botTotal, randomTotal, botWins)
+	if botTotal*2 >= randomTotal || botWins < 15 {
+		t.Fatalf("bot 听牌应明显快于随机出牌: bot 共 %d 巡，随机共 %d 巡，bot 更快 %d/20", botTotal, randomTotal, botWins)
+	}
+}
+
+func TestShantenBotDecisions(t *testing.T) {
+	eg := newTestEngine(t, noAkaRules())
+	policy := NewShantenBotPolicy(eg.Searcher)
+	view := func(p *PlayerImage) *BotView {
+		return &BotView{Player: p, IsAka: eg.DeckManager.IsAka}
+	}
+
+	// 能自摸时直接和牌
+	p := setHand(t, eg, 1, "123m456p789s1122z")
+	drawTsumo(p, parseTile(t, "1z"))
+	v := view(p)
+	v.CanTsumo = true
+	if d := policy.Main(v); !d.Tsumo {
+		t.Fatalf("能自摸时应和牌, got %+v", d)
+	}
+
+	// 打出孤立的北后听 14m，可以立直时立直
+	p = setHand(t, eg, 1, "23m456p789s111z55z")
+	drawTsumo(p, parseTile(t, "4z"))
+	v = view(p)
+	v.CanRiichi = true
+	if d := policy.Main(v); !d.Riichi || d.Discard.Type != North {
+		t.Fatalf("应打出北立直, got %+v", d)
+	}
+	if d := policy.Main(view(p)); d.Riichi || d.Discard.Type != North {
+		t.Fatalf("不满足立直条件时只打牌, got %+v", d)
+	}
+
+	// 立直后只能摸切
+	p.IsRiichi = true
+	drawn := parseTile(t, "9m")
+	p.Tiles[len(p.Tiles)-1] = drawn
+	p.NewestTile = &drawn
+	if d := policy.Main(view(p)); d.Discard != drawn {
+		t.Fatalf("立直后应摸切, got %+v", d)
+	}
+
+	// 只会荣和，不鸣牌
+	hu := &PlayerOperation{Type: "HU"}
+	peng := &PlayerOperation{Type: "PENG"}
+	if op := policy.React(view(p), []*PlayerOperation{peng, hu}); op != hu {
+		t.Fatalf("能荣和时应和牌, got %+v", op)
+	}
+	if op := policy.React(view(p), []*PlayerOperation{peng}); op != nil {
+		t.Fatalf("不应鸣牌, got %+v", op)
+	}
+}
+
+// 3 号座位坎张听 2s：庄家打出的 2s 被 2 号座位碰走后，三张 2s 都在副露中，进张只剩 1 张
+func TestVisibleTilesUkeire(t *testing.T) {
+	eg := newTestEngine(t, noAkaRules())
+	setDoraIndicator(eg, parseTile(t, "7z"))
+	setHand(t, eg, 0, "13579m13579p2s246z")
+	setHand(t, eg, 1, "13579m13579p246z")
+	setHand(t, eg, 2, "1357m22s13579p24z")
+	waiter := setHand(t, eg, 3, "234m567m345p88p13s")
+	ukeire := func() int {
+		visible := eg.visibleTiles()
+		h13, _ := Hand34FromTiles(waiter.Tiles)
+		waits, n := eg.Searcher.WaitsAndUkeire(h13, 0, &visible)
+		if !slices.Equal(waits, []TileType{So2}) {
+			t.Fatalf("应听 2s, got %v", waits)
+		}
+		return n
+	}
+
+	if n := ukeire(); n != 4 {
+		t.Fatalf("场上没有 2s 时进张 = %d, want 4", n)
+	}
+	dropTile(t, eg, 0, Tile{Type: So2, ID: 1})
+	if n := ukeire(); n != 3 {
+		t.Fatalf("牌河中有 1 张 2s 时进张 = %d, want 3", n)
+	}
+	eg.handlePengEvent(&share.PengTileEvent{
+		GameMessageEvent: eg.replayUser(2),
+		Tiles:            toShareTiles(parseTiles(t, "22s")),
+	})
+	if visible := eg.visibleTiles(); visible[So2] != 3 {
+		t.Fatalf("被碰走的牌只在副露中计一次, got %d", visible[So2])
+	}
+	if n := ukeire(); n != 1 {
+		t.Fatalf("三张 2s 可见时进张 = %d, want 1", n)
+	}
+}
@@ -0,0 +1,75 @@
+package mahjong
+
+import "sync/atomic"
+
+// boundedCache 容量受限的缓存，淘汰策略为 CLOCK（二次机会，近似 LRU）
+// 命中时只原子地置访问位，可以在读锁下调用 get；写入 put 需要调用方持有写锁
+// 缓存满时时钟指针跳过最近访问过的条目（清除其访问位），淘汰第一个未被访问的条目
+type boundedCache[V any] struct {
+	capacity int
+	index    map[string]int // key -> slots 下标
+	slots    []*cacheSlot[V]
+	hand     int // 时钟指针
+}
+
+type cacheSlot[V any] struct {
+	key   string
+	value V
+	used  atomic.Bool // 访问位
+}
+
+func newBoundedCache[V any](capacity int) *boundedCache[V] {
+	if capacity <= 0 {
+		capacity = DefaultSearcherCacheCapacity
+	}
+	return &boundedCache[V]{
+		capacity: capacity,
+		index:    make(map[string]int, min(capacity, 4096)),
+		slots:    make([]*cacheSlot[V], 0, min(capacity, 4096)),
+	}
+}
+
+// get 查询缓存，调用方至少持有读锁
+func (c *boundedCache[V]) get(key string) (V, bool) {
+	i, ok := c.index[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	slot := c.slots[i]
+	slot.used.Store(true)
+	return slot.value, true
+}
+
+// put 写入缓存，调用方持有写锁
+func (c *boundedCache[V]) put(key string, value V) {
+	if i, ok := c.index[key]; ok {
+		c.slots[i].value = value
+		c.slots[i].used.Store(true)
+		return
+	}
+	if len(c.slots) < c.capacity {
+		c.index[key] = len(c.slots)
+		c.slots = append(c.slots, &cacheSlot[V]{key: key, value: value})
+		return
+	}
+	for {
+		slot := c.slots[c.hand]
+		if slot.used.Load() {
+			slot.used.Store(false)
+			c.hand = (c.hand + 1) % c.capacity
+			continue
+		}
+		delete(c.index, slot.key)
+		slot.key = key
+		slot.value = value
+		c.index[key] = c.hand
+		c.hand = (c.hand + 1) % c.capacity
+		return
+	}
+}
+
+// len 当前缓存条目数，调用方至少持有读锁
+func (c *boundedCache[V]) len() int {
+	return len(c.slots)
+}
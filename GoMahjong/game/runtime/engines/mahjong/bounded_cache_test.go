@@ -0,0 +1,66 @@
+package mahjong
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// 缓存满时淘汰未被访问的条目，最近访问过的条目保留
+func TestBoundedCacheEviction(t *testing.T) {
+	c := newBoundedCache[int](3)
+	for i := 0; i < 3; i++ {
+		c.put(strconv.Itoa(i), i)
+	}
+	c.get("0")
+	c.get("0")
+	for i := 3; i < 10; i++ {
+		c.put(strconv.Itoa(i), i)
+		if c.len() > 3 || len(c.index) > 3 {
+			t.Fatalf("缓存超出容量: %d 条", len(c.index))
+		}
+	}
+	if v, ok := c.get("9"); !ok || v != 9 {
+		t.Fatalf("最新写入的条目应在缓存中")
+	}
+	for key, i := range c.index {
+		if c.slots[i].key != key {
+			t.Fatalf("索引 %s 指向了 %s", key, c.slots[i].key)
+		}
+	}
+}
+
+// 容量很小、频繁淘汰的 Searcher 与默认 Searcher 的结果一致
+func TestSearcherEvictionKeepsResults(t *testing.T) {
+	hands := []string{
+		"123m456p789s1122z", "2333m456p789s555z", "19m19p19s1234567z", "1122334455667z",
+		"111m456p789s23s55z", "13579m13579p246z", "1112345678999m", "234m567m345p78s88p",
+	}
+	small, full := NewSearcherWithCapacity(2), NewSearcher()
+	for round := 0; round < 3; round++ {
+		for _, s := range hands {
+			h, _ := Hand34FromTiles(parseTiles(t, s))
+			if got, want := small.ShantenAll(h, 0), full.ShantenAll(h, 0); got != want {
+				t.Fatalf("%s 向听数 %d, want %d", s, got, want)
+			}
+			gotWaits, _ := small.WaitsAndUkeire(h, 0, nil)
+			wantWaits, _ := full.WaitsAndUkeire(h, 0, nil)
+			if !slices.Equal(gotWaits, wantWaits) {
+				t.Fatalf("%s 听牌 %v, want %v", s, gotWaits, wantWaits)
+			}
+		}
+	}
+	if small.waitsCache.len() > 2 || small.shantenCache.len() > 2 {
+		t.Fatalf("缓存超出容量")
+	}
+}
+
+// 写入远多于容量的条目后缓存大小保持不变
+func BenchmarkBoundedCachePut(b *testing.B) {
+	c := newBoundedCache[int](1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.put(strconv.Itoa(i), i)
+	}
+	b.ReportMetric(float64(c.len()), "entries")
+}
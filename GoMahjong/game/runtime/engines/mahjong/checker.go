@@ -1,22 +1,147 @@
 package mahjong
 
-// canHu 检查玩家是否可以荣和
+import (
+	"fmt"
+	"sort"
+)
+
+// canHu 检查玩家是否可以荣和：手牌加上这张牌能和牌，且至少有一个役
 func (eg *RiichiMahjong4p) canHu(seatIndex int, tile Tile) bool {
-	// fixme: 实现荣和判定逻辑
-	// 需要检查玩家是否听牌且能形成和牌
+	return eg.canRonClaim(HuClaim{
+		WinnerSeat: seatIndex,
+		HasLoser:   true,
+		LoserSeat:  eg.TurnManager.GetCurrentPlayer(),
+		WinTile:    tile,
+		LastTile:   eg.isLastDraw(), // 与结算一致：河底牌的荣和计河底捞鱼
+	})
+}
+
+// canChankan 检查玩家是否可以抢杠；暗杠只有国士无双可以抢
+func (eg *RiichiMahjong4p) canChankan(seatIndex, kanSeat int, tile Tile, kanType string) bool {
+	player := eg.Players[seatIndex]
+	if player == nil {
+		return false
+	}
+	if kanType == "Ankan" {
+		if len(player.Melds) != 0 || eg.isFuriten(seatIndex) {
+			return false
+		}
+		h, _ := Hand34FromTiles(player.Tiles)
+		h[int(tile.Type)]++
+		return IsAgariKokushi(h)
+	}
+	return eg.canRonClaim(HuClaim{WinnerSeat: seatIndex, HasLoser: true, LoserSeat: kanSeat, WinTile: tile, Chankan: true})
+}
+
+// canRonClaim 荣和判定：和牌型 + 非振听 + 有役（宝牌不算役）
+func (eg *RiichiMahjong4p) canRonClaim(claim HuClaim) bool {
+	player := eg.Players[claim.WinnerSeat]
+	if player == nil || eg.Searcher == nil {
+		return false
+	}
+	h, _ := Hand34FromTiles(player.Tiles)
+	h[int(claim.WinTile.Type)]++
+	if !eg.Searcher.IsAgariAll(h, len(player.Melds)) {
+		return false
+	}
+	if eg.isFuriten(claim.WinnerSeat) {
+		return false
+	}
+	han, ym, _, _, _ := eg.evalClaimYakuman(claim, RoundEndRon)
+	return han > 0 || ym > 0
+}
+
+// canTsumo 自摸判定：和牌型 + 有役（宝牌不算役）
+func (eg *RiichiMahjong4p) canTsumo(seatIndex int) bool {
 	player := eg.Players[seatIndex]
-	if player == nil || !player.IsWaiting {
+	if player == nil || player.NewestTile == nil || eg.Searcher == nil {
 		return false
 	}
+	h, _ := Hand34FromTiles(player.Tiles)
+	if !eg.Searcher.IsAgariAll(h, len(player.Melds)) {
+		return false
+	}
+	claim := HuClaim{
+		WinnerSeat: seatIndex,
+		WinTile:    *player.NewestTile,
+		LastTile:   eg.isLastDraw() && !player.rinshanPending,
+		Rinshan:    player.rinshanPending,
+	}
+	han, ym, _, _, _ := eg.evalClaimYakuman(claim, RoundEndTsumo)
+	return han > 0 || ym > 0
+}
 
-	// 暂时返回 false，实际需要实现完整的和牌判定
+// discardSuggestions 打出后能听牌的候选，按进张数从多到少排列；进张扣除场上可见的牌
+// 立直后只能摸切，不给建议；受食替限制的牌不在候选中
+func (eg *RiichiMahjong4p) discardSuggestions(seatIndex int) []Candidate {
+	player := eg.Players[seatIndex]
+	if player == nil || player.IsRiichi || eg.Searcher == nil {
+		return nil
+	}
+	visible := eg.visibleTiles()
+	candidates := eg.Searcher.SeekCandidates(player.Tiles, len(player.Melds), &visible)
+	out := candidates[:0]
+	for _, c := range candidates {
+		if !player.IsKuikaeForbidden(c.DiscardType) {
+			out = append(out, c)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Ukeire > out[j].Ukeire
+	})
+	return out
+}
+
+// isFuriten 振听则不能荣和：放过了能荣和的牌（同巡振听、立直后振听），或听的牌中有自己打过的牌（舍张振听）
+func (eg *RiichiMahjong4p) isFuriten(seatIndex int) bool {
+	player := eg.Players[seatIndex]
+	if player == nil || eg.Searcher == nil {
+		return false
+	}
+	if player.missedRon {
+		return true
+	}
+	h13, _ := Hand34FromTiles(player.Tiles)
+	waits, _ := eg.Searcher.WaitsAndUkeire(h13, len(player.Melds), nil)
+	for _, tt := range waits {
+		if player.HasDiscardedTile(tt) {
+			return true
+		}
+	}
 	return false
 }
 
+// updateTenpai 按当前 13 张手牌（副露算作固定面子）计算听牌，写入 TenpaiWaits/TenpaiValid
+// 手中已有 4 张的牌不算听（WaitsAndUkeire 已排除），形式听牌即可，不要求有役
+func (eg *RiichiMahjong4p) updateTenpai(seatIndex int) {
+	player := eg.Players[seatIndex]
+	if player == nil {
+		return
+	}
+	player.TenpaiWaits = make(map[TileType]TenpaiWaitState)
+	player.TenpaiValid = false
+	if eg.Searcher == nil || len(player.Tiles)%3 != 1 {
+		return
+	}
+	h13, _ := Hand34FromTiles(player.Tiles)
+	waits, _ := eg.Searcher.WaitsAndUkeire(h13, len(player.Melds), nil)
+	furiten := player.missedRon
+	for _, tt := range waits {
+		if player.HasDiscardedTile(tt) {
+			furiten = true
+			break
+		}
+	}
+	for _, tt := range waits {
+		player.TenpaiWaits[tt] = TenpaiWaitState{Furiten: furiten}
+	}
+	player.TenpaiValid = true
+}
+
 // canGang 检查玩家是否可以明杠
 func (eg *RiichiMahjong4p) canGang(seatIndex int, tile Tile) bool {
 	player := eg.Players[seatIndex]
-	if player == nil {
+	if player == nil || player.IsRiichi || !eg.canDeclareKan() {
 		return false
 	}
 	count := 0
@@ -31,7 +156,7 @@ func (eg *RiichiMahjong4p) canGang(seatIndex int, tile Tile) bool {
 // canPeng 检查玩家是否可以碰
 func (eg *RiichiMahjong4p) canPeng(seatIndex int, tile Tile) bool {
 	player := eg.Players[seatIndex]
-	if player == nil {
+	if player == nil || player.IsRiichi {
 		return false
 	}
 	count := 0
@@ -43,16 +168,101 @@ func (eg *RiichiMahjong4p) canPeng(seatIndex int, tile Tile) bool {
 	return count >= 2
 }
 
-// canChi 检查玩家是否可以吃
+// canChi 检查玩家是否可以吃（是否下家由调用方判断）
 func (eg *RiichiMahjong4p) canChi(seatIndex int, tile Tile) bool {
-	// fixme: 实现吃判定逻辑
-	// 需要检查玩家手中是否能形成顺子
-	// 吃只能是下家操作
 	player := eg.Players[seatIndex]
-	if player == nil {
+	if player == nil || player.IsRiichi || !tile.Type.IsNumbered() {
 		return false
 	}
+	return len(eg.findChiCombinations(player.Tiles, tile)) > 0
+}
 
-	// 暂时返回 false，实际需要实现完整的吃牌判定
-	return false
+// canRiichiAnkan 立直后的暗杠：必须用刚摸到的牌开杠，且开杠前后听的牌不变
+func (eg *RiichiMahjong4p) canRiichiAnkan(seatIndex int, tileType TileType) bool {
+	player := eg.Players[seatIndex]
+	if player == nil || player.NewestTile == nil || player.NewestTile.Type != tileType || eg.Searcher == nil {
+		return false
+	}
+	h14, _ := Hand34FromTiles(player.Tiles)
+	if h14[int(tileType)] < 4 {
+		return false
+	}
+	before := h14
+	before[int(tileType)]--
+	after := h14
+	after[int(tileType)] -= 4
+
+	waitsBefore, _ := eg.Searcher.WaitsAndUkeire(before, len(player.Melds), nil)
+	waitsAfter, _ := eg.Searcher.WaitsAndUkeire(after, len(player.Melds)+1, nil)
+	if len(waitsBefore) == 0 || len(waitsBefore) != len(waitsAfter) {
+		return false
+	}
+	for i := range waitsBefore {
+		if waitsBefore[i] != waitsAfter[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// canKita 三麻拔北：牌山还有牌时可以把手中的北放到一边，立直后只能拔刚摸到的北
+func (eg *RiichiMahjong4p) canKita(seatIndex int) (Tile, bool) {
+	if eg.seats() != 3 || eg.DeckManager == nil || eg.DeckManager.Remaining() == 0 {
+		return Tile{}, false
+	}
+	player := eg.Players[seatIndex]
+	if player == nil || len(player.Tiles)%3 != 2 {
+		return Tile{}, false
+	}
+	if player.IsRiichi {
+		if player.NewestTile != nil && player.NewestTile.Type == North {
+			return *player.NewestTile, true
+		}
+		return Tile{}, false
+	}
+	for _, t := range player.Tiles {
+		if t.Type == North {
+			return t, true
+		}
+	}
+	return Tile{}, false
+}
+
+// canKyuushuu 检查玩家能否宣言九种九牌：第一巡无人鸣牌、手牌 14 张中有 9 种以上幺九牌
+func (eg *RiichiMahjong4p) canKyuushuu(seatIndex int) bool {
+	player := eg.Players[seatIndex]
+	if player == nil || !player.FirstTurn || len(player.Melds) != 0 || len(player.Tiles) != 14 {
+		return false
+	}
+	kinds := make(map[TileType]struct{}, 13)
+	for _, t := range player.Tiles {
+		if isYaochuTileType(t.Type) {
+			kinds[t.Type] = struct{}{}
+		}
+	}
+	return len(kinds) >= 9
+}
+
+// canRiichi 检查玩家是否满足立直条件：门清、未立直、点数 >= 1000、牌山还能再摸一巡、听牌
+func (eg *RiichiMahjong4p) canRiichi(seatIndex int) error {
+	player := eg.Players[seatIndex]
+	if player == nil {
+		return fmt.Errorf("玩家 %d 不存在", seatIndex)
+	}
+	if player.IsRiichi {
+		return fmt.Errorf("玩家 %d 已经立直", seatIndex)
+	}
+	if !isMenzen(player) {
+		return fmt.Errorf("玩家 %d 不是门清", seatIndex)
+	}
+	if player.Points < 1000 {
+		return fmt.Errorf("玩家 %d 点数不足 1000: %d", seatIndex, player.Points)
+	}
+	if eg.DeckManager == nil || eg.DeckManager.Remaining() < eg.seats() {
+		return fmt.Errorf("牌山不足一巡，不能立直")
+	}
+	if eg.Searcher == nil || len(eg.Searcher.SeekCandidates(player.Tiles, len(player.Melds), nil)) == 0 {
+		return fmt.Errorf("玩家 %d 没有听牌", seatIndex)
+	}
+	return nil
 }
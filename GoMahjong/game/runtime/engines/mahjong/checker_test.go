@@ -0,0 +1,302 @@
+package mahjong
+
+import (
+	"game/runtime/share"
+	"slices"
+	"testing"
+)
+
+// 只有河底捞鱼一个役的手牌：摸完牌山之前不能荣和，河底牌可以荣和并计河底
+func TestCanHuHouteiOnly(t *testing.T) {
+	eg := newTestEngine(t, noAkaRules())
+	setHand(t, eg, 1, "789m456p23s99s", meld(t, "Chi", "123m", 0))
+	discard := parseTile(t, "4s")
+	discarder := eg.Players[0]
+
+	if eg.canHu(1, discard) {
+		t.Fatalf("牌山未摸完时无役的手牌不应能荣和")
+	}
+
+	drainWall(eg)
+	if !eg.canHu(1, discard) {
+		t.Fatalf("河底牌应能以河底捞鱼荣和")
+	}
+	discarder.DiscardPile = append(discarder.DiscardPile, discard)
+	reactions := eg.calculateAvailableOperations(0)
+	reaction, ok := reactions[1]
+	if !ok || len(reaction.Operations) != 1 || reaction.Operations[0].Type != "HU" {
+		t.Fatalf("河底牌应只提供 HU 操作, got %+v", reaction)
+	}
+
+	claim := HuClaim{WinnerSeat: 1, HasLoser: true, LoserSeat: 0, WinTile: discard, LastTile: true}
+	han, _, _, yakus, _ := eg.evalClaimYakuman(claim, RoundEndRon)
+	if han != 1 || !hasYaku(yakus, YakuHoutei) {
+		t.Fatalf("应只计河底捞鱼 1 番, got han=%d yakus=%v", han, yakus)
+	}
+}
+
+// 河底牌不能吃、碰、杠，能荣和的玩家仍可以河底捞鱼
+func TestNoCallsOnHouteiDiscard(t *testing.T) {
+	eg := newTestEngine(t, noAkaRules())
+	setHand(t, eg, 1, "13579m13579p23s1z")
+	setHand(t, eg, 2, "444s13579m1357p1z")
+	setHand(t, eg, 3, "789m456p23s99s", meld(t, "Chi", "123m", 2))
+	discard := Tile{Type: So4, ID: 0}
+	eg.Players[0].DiscardPile = append(eg.Players[0].DiscardPile, discard)
+
+	reactions := eg.calculateAvailableOperations(0)
+	if !hasOperation(reactions[1], "CHI") || !hasOperation(reactions[2], "PENG") || !hasOperation(reactions[2], "GANG") {
+		t.Fatalf("牌山未摸完时应能吃、碰、杠, got %+v", reactions)
+	}
+	if _, ok := reactions[3]; ok {
+		t.Fatalf("牌山未摸完时 3 号座位无役不能荣和")
+	}
+
+	drainWall(eg)
+	reactions = eg.calculateAvailableOperations(0)
+	if len(reactions) != 1 {
+		t.Fatalf("河底牌只有能荣和的玩家可以反应, got %+v", reactions)
+	}
+	if reaction := reactions[3]; reaction == nil || len(reaction.Operations) != 1 || reaction.Operations[0].Type != "HU" {
+		t.Fatalf("3 号座位应能河底荣和, got %+v", reaction)
+	}
+}
+
+func hasOperation(reaction *PlayerReaction, opType string) bool {
+	if reaction == nil {
+		return false
+	}
+	for _, op := range reaction.Operations {
+		if op.Type == opType {
+			return true
+		}
+	}
+	return false
+}
+
+// dropTile 当前玩家打出手中的 tile
+func dropTile(t *testing.T, eg *RiichiMahjong4p, seat int, tile Tile) {
+	t.Helper()
+	if eg.TurnManager.GetCurrentPlayer() != seat || eg.TurnManager.GetState() != TurnStateWaitMain {
+		t.Fatalf("不是座位 %d 的出牌阶段", seat)
+	}
+	eg.handleDropTileEvent(&share.DropTileEvent{
+		GameMessageEvent: eg.replayUser(seat),
+		Tile:             share.Tile{Type: int(tile.Type), ID: tile.ID},
+	})
+	if n := len(eg.Players[seat].DiscardPile); n == 0 || eg.Players[seat].DiscardPile[n-1] != tile {
+		t.Fatalf("座位 %d 打出 %v 失败", seat, tile)
+	}
+}
+
+// passAll 所有可以反应的玩家超时跳过
+func passAll(eg *RiichiMahjong4p) {
+	for seat := range eg.Reactions {
+		eg.UserMap[eg.Players[seat].UserID].IsBot = false
+	}
+	for seat := range eg.Reactions {
+		if eg.TurnManager.GetState() == TurnStateWaitReactions {
+			eg.handleReactionTimeout(seat)
+		}
+	}
+}
+
+// setupFuritenTable 座位 2 听 1s/4s（白刻子有役），其他座位的手牌与索子无关，庄家手中有 4s
+func setupFuritenTable(t *testing.T, riichi bool) *RiichiMahjong4p {
+	t.Helper()
+	eg := newTestEngine(t, noAkaRules())
+	setHand(t, eg, 0, "13579m13579p4s246z")
+	setHand(t, eg, 1, "13579m1357p1s246z")
+	waiter := setHand(t, eg, 2, "234m456p23s555z88s")
+	waiter.IsRiichi = riichi
+	setHand(t, eg, 3, "13579m13579p246z")
+	return eg
+}
+
+func TestFuriten(t *testing.T) {
+	t.Run("discard", func(t *testing.T) {
+		eg := setupFuritenTable(t, false)
+		eg.Players[2].AddDiscardedTile(parseTile(t, "1s"))
+		if eg.canHu(2, parseTile(t, "4s")) {
+			t.Fatalf("听的牌中有自己打过的牌，不能荣和")
+		}
+	})
+
+	for _, riichi := range []bool{false, true} {
+		name := "temporary"
+		if riichi {
+			name = "riichi"
+		}
+		t.Run(name, func(t *testing.T) {
+			eg := setupFuritenTable(t, riichi)
+			dropTile(t, eg, 0, Tile{Type: So4, ID: 1})
+			if !hasOperation(eg.Reactions[2], "HU") {
+				t.Fatalf("座位 2 应能荣和 4s")
+			}
+			passAll(eg)
+
+			// 同一巡内下家打出的另一张和牌不能荣和
+			dropTile(t, eg, 1, Tile{Type: So1, ID: 1})
+			if hasOperation(eg.Reactions[2], "HU") {
+				t.Fatalf("放过荣和后同巡内不能荣和")
+			}
+			passAll(eg)
+
+			// 座位 2 摸牌后：未立直解除振听，立直则到本局结束都振听
+			waiter := eg.Players[2]
+			if eg.TurnManager.GetCurrentPlayer() != 2 || len(waiter.Tiles) != 14 {
+				t.Fatalf("应轮到座位 2 摸牌")
+			}
+			waiter.Tiles = waiter.Tiles[:13]
+			if got := eg.canHu(2, parseTile(t, "4s")); got == riichi {
+				t.Fatalf("摸牌后能否荣和 = %v, 立直=%v", got, riichi)
+			}
+		})
+	}
+}
+
+func TestRiichiEligibility(t *testing.T) {
+	tests := []struct {
+		name   string
+		hand   string
+		chi    string // 从 3 号座位吃的顺子，为空表示门清
+		points int
+		want   bool
+	}{
+		{name: "valid", hand: "234m567m345p78s88p1z", points: 25000, want: true},
+		{name: "open hand", hand: "567m345p78s88p1z", chi: "234m", points: 25000},
+		{name: "under 1000 points", hand: "234m567m345p78s88p1z", points: 900},
+		{name: "noten", hand: "234m567m345p79s18p1z", points: 25000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			var melds []Meld
+			if tt.chi != "" {
+				melds = append(melds, meld(t, "Chi", tt.chi, 3))
+			}
+			p := setHand(t, eg, 0, tt.hand, melds...)
+			p.Points = tt.points
+
+			eg.handleRiichiEvent(&share.RiichiEvent{GameMessageEvent: eg.replayUser(0)})
+			if p.IsRiichi != tt.want {
+				t.Fatalf("立直 = %v, want %v", p.IsRiichi, tt.want)
+			}
+			wantPoints, wantSticks := tt.points, 0
+			if tt.want {
+				wantPoints, wantSticks = tt.points-1000, 1
+			}
+			if p.Points != wantPoints || eg.Situation.RiichiSticks != wantSticks {
+				t.Fatalf("点数 %d 供托 %d, want %d %d", p.Points, eg.Situation.RiichiSticks, wantPoints, wantSticks)
+			}
+		})
+	}
+}
+
+// 只有海底摸月一个役的手牌：摸到最后一张牌才能自摸，之前自摸是错和
+func TestTsumoHaiteiOnly(t *testing.T) {
+	for _, lastTile := range []bool{false, true} {
+		eg := setupFuritenTable(t, false)
+		setHand(t, eg, 1, "789m456p23s99s", meld(t, "Chi", "123m", 0))
+		if lastTile {
+			for eg.DeckManager.Remaining() > 1 {
+				eg.DeckManager.Draw()
+			}
+		}
+		dropTile(t, eg, 0, Tile{Type: South, ID: 1})
+		passAll(eg)
+		if eg.TurnManager.GetCurrentPlayer() != 1 || eg.isLastDraw() != lastTile {
+			t.Fatalf("1 号座位应摸到海底牌=%v", lastTile)
+		}
+		replaceDraw(t, eg.Players[1], "4s")
+
+		eg.handleTouchHuEvent(&share.TouchHuEvent{GameMessageEvent: eg.replayUser(1)})
+		result := lastRoundResult(eg)
+		if !lastTile {
+			if result == nil || result.EndType != RoundEndChombo {
+				t.Fatalf("牌山未摸完时无役自摸应为错和, got %+v", result)
+			}
+			continue
+		}
+		if result == nil || result.EndType != RoundEndTsumo || !slices.Contains(result.Claims[0].Yaku, YakuHaitei.String()) {
+			t.Fatalf("海底牌应能以海底摸月自摸, got %+v", result)
+		}
+	}
+}
+
+// 第一巡 9 种幺九牌可以宣言九种九牌流局，8 种不行
+func TestKyuushuu(t *testing.T) {
+	tests := []struct {
+		name string
+		hand string
+		want bool
+	}{
+		{name: "nine types", hand: "19m19p19s123z234m56p", want: true},
+		{name: "eight types", hand: "19m19p19s12z234m567p"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			setHand(t, eg, 0, tt.hand).FirstTurn = true
+			if got := eg.canKyuushuu(0); got != tt.want {
+				t.Fatalf("canKyuushuu = %v, want %v", got, tt.want)
+			}
+
+			eg.handleKyuushuuEvent(&share.KyuushuuEvent{GameMessageEvent: eg.replayUser(0)})
+			result := lastRoundResult(eg)
+			if !tt.want {
+				if result != nil || eg.TurnManager.GetState() != TurnStateWaitMain {
+					t.Fatalf("不满足条件时不能流局, got %+v", result)
+				}
+				return
+			}
+			if result == nil || result.EndType != RoundEndKyuushuu || result.NextDealer != 0 || eg.Situation.Honba != 1 {
+				t.Fatalf("应九种九牌流局且庄家连庄、本场 +1, got %+v honba=%d", result, eg.Situation.Honba)
+			}
+			for seat, delta := range result.Delta {
+				if delta != 0 {
+					t.Fatalf("九种九牌流局不应有点数变化, 座位 %d delta=%d", seat, delta)
+				}
+			}
+		})
+	}
+}
+
+// 1 号座位 234m567m345p3456s1z：打东听 3s/6s 最优；场上打出、碰掉听的牌后进张减少，排序随之变化
+func TestDiscardSuggestions(t *testing.T) {
+	eg := newTestEngine(t, noAkaRules())
+	setDoraIndicator(eg, parseTile(t, "7z"))
+	p := setHand(t, eg, 1, "234m567m345p3456s")
+	drawTsumo(p, parseTile(t, "1z"))
+
+	suggestions := eg.discardSuggestions(1)
+	if len(suggestions) != 3 {
+		t.Fatalf("应有打东、3s、6s 三种听牌打法, got %+v", suggestions)
+	}
+	best := suggestions[0]
+	if best.DiscardType != East || !slices.Equal(best.Waits, []TileType{So3, So6}) || best.Ukeire != 6 {
+		t.Fatalf("打东听 3s/6s 共 6 张应排第一, got %+v", best)
+	}
+	for i := 1; i < len(suggestions); i++ {
+		if suggestions[i].Ukeire > suggestions[i-1].Ukeire {
+			t.Fatalf("应按进张数从多到少排列, got %+v", suggestions)
+		}
+	}
+
+	eg.Players[2].DiscardPile = append(eg.Players[2].DiscardPile, parseTile(t, "3s"))
+	eg.Players[3].DiscardPile = append(eg.Players[3].DiscardPile, parseTile(t, "6s"))
+	if got := eg.discardSuggestions(1)[0]; got.DiscardType != East || got.Ukeire != 4 {
+		t.Fatalf("打出的 3s、6s 应从进张中扣除, got %+v", got)
+	}
+
+	// 6s 全部可见后打东只剩 2 张 3s，不如单骑东的 3 张
+	eg.Players[3].Melds = append(eg.Players[3].Melds, meld(t, "Peng", "666s", 0))
+	if got := eg.discardSuggestions(1)[0]; got.DiscardType == East || got.Ukeire != 3 {
+		t.Fatalf("进张变化后应改为单骑东, got %+v", got)
+	}
+
+	p.IsRiichi = true
+	if got := eg.discardSuggestions(1); got != nil {
+		t.Fatalf("立直后不给打牌建议, got %+v", got)
+	}
+}
@@ -0,0 +1,58 @@
+package mahjong
+
+import "game/infrastructure/log"
+
+/*
+错和（chombo）：
+ 1. 宣言荣和/自摸时手牌不成和牌型、无役或振听，按错和处罚；ChomboBase 为 0 时不处罚，宣言直接忽略
+ 2. 错和者按自摸的分摊方式反向支付罚点（基本点 ChomboBase，默认满贯），不计本场
+ 3. 本局作废重来：庄家、本场不变，本局的立直棒退还给立直者
+*/
+
+// chomboEnabled 是否处罚错和
+func (eg *RiichiMahjong4p) chomboEnabled() bool {
+	return eg.Rules.ChomboBase > 0
+}
+
+// declareChombo 错和，结束本局；反应阶段的错和先关闭反应窗口，其他玩家的反应随之作废
+func (eg *RiichiMahjong4p) declareChombo(claim HuClaim) {
+	log.Warn("错和: seat=%d, tile=%v, hand=%v", claim.WinnerSeat, claim.WinTile, eg.Players[claim.WinnerSeat].Tiles)
+	if eg.TurnManager.GetState() == TurnStateWaitReactions {
+		eg.TurnManager.EnterChoosingPhase()
+		eg.bumpActionSeq()
+	}
+	eg.handleRoundOverEvent([]HuClaim{claim}, RoundEndChombo)
+}
+
+// LeadChomboEnding 错和结算：错和者向其他玩家支付罚点，退还本局的立直棒，庄家和本场不变
+func (eg *RiichiMahjong4p) LeadChomboEnding(claim HuClaim) {
+	var delta [4]int
+	offender := claim.WinnerSeat
+	dealer := eg.Situation.DealerIndex
+
+	// 与自摸的分摊方式相同，方向相反：庄家错和时闲家各得 ×2，闲家错和时庄家得 ×2、其余闲家得 ×1
+	dealerGain, childGain := tsumoPoints(eg.Rules.ChomboBase, offender == dealer, 0)
+	for i := 0; i < eg.seats(); i++ {
+		if i == offender {
+			continue
+		}
+		gain := childGain
+		if i == dealer {
+			gain = dealerGain
+		}
+		delta[i] += gain
+		delta[offender] -= gain
+	}
+
+	// 本局作废，本局立直的玩家取回立直棒，之前流局留下的立直棒继续留在场上
+	for i := 0; i < eg.seats(); i++ {
+		if p := eg.Players[i]; p != nil && p.IsRiichi && eg.Situation.RiichiSticks > 0 {
+			delta[i] += 1000
+			eg.Situation.RiichiSticks--
+		}
+	}
+
+	eg.broadcastRoundEnd(RoundEndChombo, []HuClaimDTO{}, delta, "错和", dealer)
+
+	eg.finalizeRound(delta)
+}
@@ -0,0 +1,68 @@
+package mahjong
+
+import (
+	"game/runtime/share"
+	"testing"
+)
+
+// 错和按满贯罚点反向分摊，本局立直棒退还，庄家和本场不变；关闭处罚时宣言直接忽略
+func TestChombo(t *testing.T) {
+	tests := []struct {
+		name      string
+		declare   func(t *testing.T, eg *RiichiMahjong4p)
+		wantDelta [4]int
+	}{
+		{
+			// 2 号座位嵌张听 4s，门清荣和无役
+			name: "yakuless ron",
+			declare: func(t *testing.T, eg *RiichiMahjong4p) {
+				setHand(t, eg, 0, "13579m13579p4s246z")
+				setHand(t, eg, 1, "13579m13579p246z").IsRiichi = true
+				setHand(t, eg, 2, "234m456p35s678s99m")
+				setHand(t, eg, 3, "1357m1357p44s246z") // 能碰 4s，打牌后进入反应阶段
+				dropTile(t, eg, 0, Tile{Type: So4, ID: 1})
+				eg.handleReactionHuEvent(&share.HuEvent{GameMessageEvent: eg.replayUser(2)})
+			},
+			wantDelta: [4]int{4000, 2000 + 1000, -8000, 2000},
+		},
+		{
+			// 庄家手牌不成和牌型时自摸
+			name: "incomplete tsumo",
+			declare: func(t *testing.T, eg *RiichiMahjong4p) {
+				drawTsumo(setHand(t, eg, 0, "13579m13579p246z"), parseTile(t, "1z"))
+				setHand(t, eg, 1, "13579m13579p246z").IsRiichi = true
+				eg.handleTouchHuEvent(&share.TouchHuEvent{GameMessageEvent: eg.replayUser(0)})
+			},
+			wantDelta: [4]int{-12000, 4000 + 1000, 4000, 4000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			eg.Situation.Honba = 1
+			eg.Situation.RiichiSticks = 2 // 本局 1 根，之前流局留下 1 根
+			tt.declare(t, eg)
+
+			result := lastRoundResult(eg)
+			if result == nil || result.EndType != RoundEndChombo {
+				t.Fatalf("应按错和结束, got %+v", result)
+			}
+			if result.Delta != tt.wantDelta {
+				t.Fatalf("delta = %v, want %v", result.Delta, tt.wantDelta)
+			}
+			if eg.Situation.DealerIndex != 0 || eg.Situation.Honba != 1 || eg.Situation.RiichiSticks != 1 {
+				t.Fatalf("错和后庄家、本场不变，只退还本局的立直棒, got %+v", eg.Situation)
+			}
+		})
+
+		t.Run(tt.name+" without penalty", func(t *testing.T) {
+			rules := noAkaRules()
+			rules.ChomboBase = 0
+			eg := newTestEngine(t, rules)
+			tt.declare(t, eg)
+			if result := lastRoundResult(eg); result != nil {
+				t.Fatalf("关闭处罚时不应结束本局, got %+v", result)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package mahjong
+
+import (
+	"encoding/json"
+	"fmt"
+	"game/infrastructure/log"
+)
+
+const (
+	SerializerJSON     = "json"     // 默认推送格式
+	SerializerProtobuf = "protobuf" // 见 game/api/gameplay.proto
+)
+
+// Codec 推送数据的编码方式，客户端在握手时协商（Sys.Serializer），connector 通知 game 节点后按玩家选择编码
+type Codec interface {
+	Name() string
+	Marshal(v any) ([]byte, error)
+}
+
+// protoMessage 可以按 gameplay.proto 编码的推送结构
+type protoMessage interface {
+	appendProto(b []byte) []byte
+}
+
+// JSONCodec 使用 encoding/json 编码
+type JSONCodec struct{}
+
+func (JSONCodec) Name() string { return SerializerJSON }
+
+func (JSONCodec) Marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+// ProtobufCodec 按 gameplay.proto 的字段编号编码，DTO 不是生成代码，由 push_proto.go 手写编码
+type ProtobufCodec struct{}
+
+func (ProtobufCodec) Name() string { return SerializerProtobuf }
+
+func (ProtobufCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(protoMessage)
+	if !ok {
+		return nil, fmt.Errorf("类型 %T 不支持 protobuf 编码", v)
+	}
+	return m.appendProto(nil), nil
+}
+
+var codecs = map[string]Codec{
+	SerializerJSON:     JSONCodec{},
+	SerializerProtobuf: ProtobufCodec{},
+}
+
+// RegisterCodec 注册推送编码（启动时调用，非并发安全）
+func RegisterCodec(c Codec) {
+	codecs[c.Name()] = c
+}
+
+// CodecByName 按协商的格式名查找编码，不支持时返回 false
+func CodecByName(name string) (Codec, bool) {
+	c, ok := codecs[name]
+	return c, ok
+}
+
+// codecFor 玩家协商的推送编码，未协商时使用引擎默认编码
+func (eg *RiichiMahjong4p) codecFor(userID string) Codec {
+	if userInfo, ok := eg.recipient(userID); ok && userInfo.Serializer != "" {
+		if c, ok := CodecByName(userInfo.Serializer); ok {
+			return c
+		}
+	}
+	if eg.Codec != nil {
+		return eg.Codec
+	}
+	return JSONCodec{}
+}
+
+// dispatchDTO 按玩家的推送编码分组，每种编码只序列化一次后推送
+func (eg *RiichiMahjong4p) dispatchDTO(users []string, connectorRoute, clientRoute string, v any) {
+	groups := make(map[string][]string, 1)
+	used := make(map[string]Codec, 1)
+	for _, userID := range users {
+		c := eg.codecFor(userID)
+		groups[c.Name()] = append(groups[c.Name()], userID)
+		used[c.Name()] = c
+	}
+	for name, userIDs := range groups {
+		data, err := used[name].Marshal(v)
+		if err != nil {
+			log.Error("dispatchDTO: %s 序列化失败, route: %s, err: %v", name, clientRoute, err)
+			continue
+		}
+		eg.dispatchPush(userIDs, connectorRoute, clientRoute, data)
+	}
+}
@@ -0,0 +1,177 @@
+package mahjong
+
+import (
+	"encoding/json"
+	"google.golang.org/protobuf/encoding/protowire"
+	"reflect"
+	"testing"
+)
+
+// decodeProto 按 gameplay.proto 的字段编号逐个读取字段，varint 交给 onVarint，子消息和字符串交给 onBytes
+func decodeProto(t *testing.T, b []byte, onVarint func(protowire.Number, int), onBytes func(protowire.Number, []byte)) {
+	t.Helper()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("无法解析字段标签: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				t.Fatalf("字段 %d 无法解析: %v", num, protowire.ParseError(n))
+			}
+			onVarint(num, int(int32(v)))
+			b = b[n:]
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				t.Fatalf("字段 %d 无法解析: %v", num, protowire.ParseError(n))
+			}
+			onBytes(num, v)
+			b = b[n:]
+		default:
+			t.Fatalf("字段 %d 的类型 %v 不在 gameplay.proto 中", num, typ)
+		}
+	}
+}
+
+func decodeTile(t *testing.T, b []byte) Tile {
+	var tile Tile
+	decodeProto(t, b, func(num protowire.Number, v int) {
+		switch num {
+		case 1:
+			tile.Type = TileType(v)
+		case 2:
+			tile.ID = v
+		}
+	}, nil)
+	return tile
+}
+
+func decodeSituation(t *testing.T, b []byte) SituationDTO {
+	var d SituationDTO
+	decodeProto(t, b, func(num protowire.Number, v int) {
+		switch num {
+		case 1:
+			d.DealerIndex = v
+		case 3:
+			d.RoundNumber = v
+		case 4:
+			d.Honba = v
+		case 5:
+			d.RiichiSticks = v
+		}
+	}, func(num protowire.Number, v []byte) {
+		if num == 2 {
+			d.RoundWind = string(v)
+		}
+	})
+	return d
+}
+
+func decodeRoundStart(t *testing.T, b []byte) RoundStartDTO {
+	var d RoundStartDTO
+	decodeProto(t, b, func(num protowire.Number, v int) {
+		switch num {
+		case 4:
+			d.CurrentTurn = v
+		case 5:
+			d.Seq = v
+		case 6:
+			d.EventSeq = v
+		}
+	}, func(num protowire.Number, v []byte) {
+		switch num {
+		case 1:
+			d.DoraIndicators = append(d.DoraIndicators, decodeTile(t, v))
+		case 2:
+			d.Situation = decodeSituation(t, v)
+		case 3:
+			d.HandTiles = append(d.HandTiles, decodeTile(t, v))
+		}
+	})
+	return d
+}
+
+func decodeMeldAction(t *testing.T, b []byte) MeldActionDTO {
+	var d MeldActionDTO
+	decodeProto(t, b, func(num protowire.Number, v int) {
+		switch num {
+		case 2:
+			d.SeatIndex = v
+		case 3:
+			d.FromSeat = v
+		case 5:
+			d.Seq = v
+		case 6:
+			d.EventSeq = v
+		}
+	}, func(num protowire.Number, v []byte) {
+		switch num {
+		case 1:
+			d.ActionType = string(v)
+		case 4:
+			d.Tiles = append(d.Tiles, decodeTile(t, v))
+		}
+	})
+	return d
+}
+
+// 同一个 DTO 分别按 protobuf 和 JSON 编码，解码后与原值一致
+func TestCodecRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		dto    any
+		decode func(t *testing.T, b []byte) any
+		fresh  func() any
+	}{
+		{
+			name: "round start",
+			dto: RoundStartDTO{
+				DoraIndicators: parseTiles(t, "3p"),
+				Situation:      SituationDTO{DealerIndex: 2, RoundWind: "South", RoundNumber: 3, Honba: 1, RiichiSticks: 2},
+				HandTiles:      parseTiles(t, "1m0p789s1234z"),
+				CurrentTurn:    2,
+				Seq:            5,
+				EventSeq:       17,
+			},
+			decode: func(t *testing.T, b []byte) any { return decodeRoundStart(t, b) },
+			fresh:  func() any { return &RoundStartDTO{} },
+		},
+		{
+			name:   "meld action",
+			dto:    MeldActionDTO{ActionType: "CHI", SeatIndex: 1, FromSeat: 0, Tiles: parseTiles(t, "345s"), Seq: 9, EventSeq: 30},
+			decode: func(t *testing.T, b []byte) any { return decodeMeldAction(t, b) },
+			fresh:  func() any { return &MeldActionDTO{} },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := ProtobufCodec{}.Marshal(tt.dto)
+			if err != nil {
+				t.Fatalf("protobuf 编码失败: %v", err)
+			}
+			if got := tt.decode(t, b); !reflect.DeepEqual(got, tt.dto) {
+				t.Fatalf("protobuf 解码 = %+v, want %+v", got, tt.dto)
+			}
+
+			b, err = JSONCodec{}.Marshal(tt.dto)
+			if err != nil {
+				t.Fatalf("JSON 编码失败: %v", err)
+			}
+			got := tt.fresh()
+			if err := json.Unmarshal(b, got); err != nil {
+				t.Fatalf("JSON 解码失败: %v", err)
+			}
+			if got := reflect.ValueOf(got).Elem().Interface(); !reflect.DeepEqual(got, tt.dto) {
+				t.Fatalf("JSON 解码 = %+v, want %+v", got, tt.dto)
+			}
+		})
+	}
+
+	if _, err := (ProtobufCodec{}).Marshal(struct{}{}); err == nil {
+		t.Fatalf("不支持 protobuf 的类型应返回错误")
+	}
+}
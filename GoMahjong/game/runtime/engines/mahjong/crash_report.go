@@ -0,0 +1,57 @@
+package mahjong
+
+import (
+	"context"
+	"fmt"
+	"game/domain/entity"
+	"game/infrastructure/log"
+	"game/runtime/share"
+	"runtime/debug"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// recordRecentEvent 记录最近处理的事件，只保留 MaxRecentEvents 条
+func (eg *RiichiMahjong4p) recordRecentEvent(event share.GameEvent) {
+	if len(eg.recentEvents) >= MaxRecentEvents {
+		eg.recentEvents = append(eg.recentEvents[:0], eg.recentEvents[1:]...)
+	}
+	eg.recentEvents = append(eg.recentEvents, fmt.Sprintf("%s %+v", event.GetEventType(), event))
+}
+
+// buildCrashReport 收集崩溃现场
+// 队列持续溢出时在 actor 之外调用，此时 actor 已卡住，读到的状态仅供参考
+func (eg *RiichiMahjong4p) buildCrashReport(reason string) *entity.CrashReport {
+	report := &entity.CrashReport{
+		ID:           primitive.NewObjectID(),
+		RoomID:       eg.RoomID,
+		Reason:       reason,
+		RecentEvents: append([]string(nil), eg.recentEvents...),
+		Stack:        string(debug.Stack()),
+		CreatedAt:    time.Now(),
+	}
+	if eg.Situation != nil {
+		report.Situation = fmt.Sprintf("%+v", *eg.Situation)
+	}
+	if eg.TurnManager != nil {
+		report.TurnState = eg.turnStateString()
+		report.CurrentTurn = eg.TurnManager.GetCurrentPlayer()
+	}
+	return report
+}
+
+// saveCrashReport 异步写入崩溃报告，未注入仓储时只打日志
+func (eg *RiichiMahjong4p) saveCrashReport(reason string) {
+	report := eg.buildCrashReport(reason)
+	if eg.Worker == nil || eg.Worker.CrashReportRepository == nil {
+		log.Warn("未配置崩溃报告仓储, roomID=%s, 最近事件: %v", report.RoomID, report.RecentEvents)
+		return
+	}
+	repo := eg.Worker.CrashReportRepository
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = repo.SaveCrashReport(ctx, report)
+	}()
+}
@@ -0,0 +1,47 @@
+package mahjong
+
+import (
+	"context"
+	"game/domain/entity"
+	"game/runtime"
+	"game/runtime/share"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// crashReportRecorder 把写入的崩溃报告转发到 channel
+type crashReportRecorder struct {
+	reports chan *entity.CrashReport
+}
+
+func (r *crashReportRecorder) SaveCrashReport(ctx context.Context, report *entity.CrashReport) error {
+	r.reports <- report
+	return nil
+}
+
+func TestHappenDamageErrorSavesCrashReport(t *testing.T) {
+	eg := newTestEngine(t, noAkaRules())
+	repo := &crashReportRecorder{reports: make(chan *entity.CrashReport, 1)}
+	eg.Worker = &game.Worker{CrashReportRepository: repo}
+	setHand(t, eg, 0, "13579m13579p1246z")
+	eg.processEvent(&share.DropTileEvent{GameMessageEvent: eg.replayUser(0), Tile: share.Tile{Type: int(East), ID: 1}})
+
+	eg.HappenDamageError("测试崩坏")
+	var report *entity.CrashReport
+	select {
+	case report = <-repo.reports:
+	case <-time.After(time.Second):
+		t.Fatalf("房间崩坏时应写入崩溃报告")
+	}
+	if report.RoomID != "test" || report.Reason != "测试崩坏" || report.TurnState == "" || report.Situation == "" {
+		t.Fatalf("崩溃报告缺少现场信息: %+v", report)
+	}
+	if !slices.ContainsFunc(report.RecentEvents, func(e string) bool { return strings.HasPrefix(e, "DropTile") }) {
+		t.Fatalf("崩溃报告应包含最近处理的事件, got %v", report.RecentEvents)
+	}
+	if !strings.Contains(report.Stack, "HappenDamageError") {
+		t.Fatalf("崩溃报告应包含调用栈")
+	}
+}
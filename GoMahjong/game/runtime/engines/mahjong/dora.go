@@ -0,0 +1,91 @@
+package mahjong
+
+// IndicatorToDora 宝牌指示牌的下一张为宝牌：数牌 9→1 同花色循环，风牌 北→东，三元牌 中→白
+func IndicatorToDora(t Tile) TileType {
+	indicator := t.Type
+	switch {
+	case indicator.IsNumbered():
+		if numberIndex(indicator) == 8 {
+			return indicator - 8
+		}
+		return indicator + 1
+	case indicator >= East && indicator <= North:
+		if indicator == North {
+			return East
+		}
+		return indicator + 1
+	default:
+		if indicator == Red {
+			return White
+		}
+		return indicator + 1
+	}
+}
+
+// countDora 统计手牌与副露中命中宝牌的张数，同一宝牌重复出现时重复计算
+func countDora(tiles []Tile, melds []Meld, doras []TileType) int {
+	if len(doras) == 0 {
+		return 0
+	}
+	var counts [34]int
+	for _, t := range tiles {
+		counts[int(t.Type)]++
+	}
+	for _, m := range melds {
+		for _, t := range m.Tiles {
+			counts[int(t.Type)]++
+		}
+	}
+	n := 0
+	for _, d := range doras {
+		n += counts[int(d)]
+	}
+	return n
+}
+
+// claimTiles 和牌时的完整手牌（荣和时补上点到的牌）
+func claimTiles(claim HuClaim, winner *PlayerImage) []Tile {
+	tiles := make([]Tile, 0, len(winner.Tiles)+1)
+	tiles = append(tiles, winner.Tiles...)
+	if claim.HasLoser {
+		tiles = append(tiles, claim.WinTile)
+	}
+	return tiles
+}
+
+// countAkaDora 统计手牌与副露中的赤宝牌张数
+func (dm *DeckManager) countAkaDora(tiles []Tile, melds []Meld) int {
+	n := 0
+	for _, t := range tiles {
+		if dm.IsAka(t) {
+			n++
+		}
+	}
+	for _, m := range melds {
+		for _, t := range m.Tiles {
+			if dm.IsAka(t) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// countClaimDora 分别统计和牌的宝牌、里宝牌（仅立直时计算）、赤宝牌张数
+func (eg *RiichiMahjong4p) countClaimDora(claim HuClaim) (dora int, ura int, aka int) {
+	if eg.DeckManager == nil || claim.WinnerSeat < 0 || claim.WinnerSeat >= 4 {
+		return 0, 0, 0
+	}
+	winner := eg.Players[claim.WinnerSeat]
+	if winner == nil {
+		return 0, 0, 0
+	}
+	// 拔北的牌本身各算一枚宝牌，北为宝牌时再按指示牌计算
+	tiles := append(claimTiles(claim, winner), winner.Kita...)
+	dora = countDora(tiles, winner.Melds, eg.DeckManager.ActiveDora()) + len(winner.Kita)
+	if winner.IsRiichi {
+		ura = countDora(tiles, winner.Melds, eg.DeckManager.ActiveUraDora())
+	}
+	aka = eg.DeckManager.countAkaDora(tiles, winner.Melds)
+	return dora, ura, aka
+}
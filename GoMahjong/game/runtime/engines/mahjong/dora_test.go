@@ -0,0 +1,87 @@
+package mahjong
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIndicatorToDora(t *testing.T) {
+	tests := []struct {
+		indicator string
+		want      string
+	}{
+		{indicator: "1m", want: "2m"},
+		{indicator: "9m", want: "1m"},
+		{indicator: "9p", want: "1p"},
+		{indicator: "9s", want: "1s"},
+		{indicator: "1z", want: "2z"},
+		{indicator: "4z", want: "1z"},
+		{indicator: "5z", want: "6z"},
+		{indicator: "7z", want: "5z"},
+	}
+	for _, tt := range tests {
+		if got := IndicatorToDora(parseTile(t, tt.indicator)); got != parseTile(t, tt.want).Type {
+			t.Errorf("指示牌 %s 的宝牌 = %v, want %s", tt.indicator, got, tt.want)
+		}
+	}
+}
+
+// ActiveDora 只包含已翻开的指示牌；三麻指示牌 1 万时宝牌为 9 万
+func TestActiveDora(t *testing.T) {
+	for _, sanma := range []bool{false, true} {
+		dm := NewDeckManagerWithSeed(AkaRules{}, 7)
+		if sanma {
+			dm = NewSanmaDeckManager(AkaRules{})
+		}
+		dm.InitRound()
+		dm.wang.DoraIndicators[0] = parseTile(t, "1m")
+		dm.wang.DoraIndicators[1] = parseTile(t, "7z")
+		dm.RevealDoraIndicator()
+		dm.RevealDoraIndicator()
+
+		want := []TileType{Man2, White}
+		if sanma {
+			want[0] = Man9
+		}
+		if got := dm.ActiveDora(); !slices.Equal(got, want) {
+			t.Errorf("三麻=%v ActiveDora = %v, want %v", sanma, got, want)
+		}
+	}
+}
+
+// 赤 5 既算赤宝牌，也按指示牌算宝牌；里宝牌只在立直时计算
+func TestCountClaimDora(t *testing.T) {
+	eg := newTestEngine(t, DefaultEngineRules())
+	setDoraIndicator(eg, parseTile(t, "4m"))
+	eg.DeckManager.wang.UraDoraIndicators[0] = parseTile(t, "1s")
+	eg.DeckManager.RevealUraDoraIndicator()
+	p := setHand(t, eg, 1, "340m567m345p678s2s")
+	claim := HuClaim{WinnerSeat: 1, HasLoser: true, LoserSeat: 0, WinTile: parseTile(t, "2s")}
+
+	if dora, ura, aka := eg.countClaimDora(claim); dora != 2 || ura != 0 || aka != 1 {
+		t.Fatalf("未立直: dora=%d ura=%d aka=%d, want 2 0 1", dora, ura, aka)
+	}
+	p.IsRiichi = true
+	if dora, ura, aka := eg.countClaimDora(claim); dora != 2 || ura != 2 || aka != 1 {
+		t.Fatalf("立直: dora=%d ura=%d aka=%d, want 2 2 1", dora, ura, aka)
+	}
+}
+
+// 立直 + 断幺九 + 两枚赤宝牌 = 4 番，赤宝牌单独列出
+func TestAkaDoraHan(t *testing.T) {
+	eg := newTestEngine(t, DefaultEngineRules())
+	setDoraIndicator(eg, parseTile(t, "7z"))
+	p := setHand(t, eg, 1, "340m067p345s678s2s")
+	p.IsRiichi = true
+	claim := HuClaim{WinnerSeat: 1, HasLoser: true, LoserSeat: 0, WinTile: parseTile(t, "2s")}
+
+	han, fu, base, yakus, div := eg.callHuPoints(claim, RoundEndRon)
+	if han != 4 || fu != 40 || base != basePoints(4, 40) {
+		t.Fatalf("got %d 番 %d 符 base=%d, want 4 番 40 符", han, fu, base)
+	}
+	dto := eg.convertHuClaimToDTOWithFanFu(claim, RoundEndRon, han, fu, ronPoints(base, false, 0), yakus, div)
+	want := []string{"Riichi", "Tanyao", "AkaDora x2"}
+	if !slices.Equal(dto.Yaku, want) {
+		t.Fatalf("yaku = %v, want %v", dto.Yaku, want)
+	}
+}
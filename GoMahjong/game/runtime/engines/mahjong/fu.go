@@ -0,0 +1,305 @@
+package mahjong
+
+// MentsuKind 面子种类
+type MentsuKind int
+
+const (
+	MentsuShuntsu MentsuKind = iota // 顺子
+	MentsuKoutsu                    // 刻子
+	MentsuKantsu                    // 杠子
+)
+
+// WaitKind 听牌形式
+type WaitKind int
+
+const (
+	WaitRyanmen WaitKind = iota // 两面
+	WaitKanchan                 // 嵌张
+	WaitPenchan                 // 边张
+	WaitShanpon                 // 双碰
+	WaitTanki                   // 单骑
+)
+
+// Mentsu 面子，First 为顺子的最小牌或刻子/杠子的牌
+type Mentsu struct {
+	Kind  MentsuKind
+	First TileType
+	Open  bool // 副露得来，或荣和时由点到的牌补成的刻子（明刻）
+}
+
+// HandDivision 和牌的一种拆解方式（雀头 + 4 面子 + 听牌形式），同一手牌可能有多种拆解
+type HandDivision struct {
+	Pair    TileType
+	Mentsu  []Mentsu // 包含副露
+	Wait    WaitKind
+	Chiitoi bool // 七对子型，此时 Mentsu 为空
+}
+
+// divideHand 枚举和牌的所有拆解（七对子型 + 标准型雀头 + 4 面子），副露直接作为固定面子
+func divideHand(ctx *YakuContext) []HandDivision {
+	if ctx == nil || ctx.Winner == nil {
+		return nil
+	}
+	fixed := meldsToMentsu(ctx.Winner.Melds)
+	need := 4 - len(fixed)
+	if need < 0 {
+		return nil
+	}
+
+	var h Hand34
+	for _, t := range ctx.Winner.Tiles {
+		h[int(t.Type)]++
+	}
+	if ctx.Claim.HasLoser {
+		h[int(ctx.Claim.WinTile.Type)]++
+	}
+	winTT := ctx.Claim.WinTile.Type
+	isRon := ctx.Claim.HasLoser
+
+	var out []HandDivision
+	// 七对子：门清，7 种不同的对子（四张相同的牌不能当两对）
+	if len(fixed) == 0 {
+		pairs := 0
+		for i := 0; i < 34; i++ {
+			if h[i] == 2 {
+				pairs++
+			}
+		}
+		if pairs == 7 {
+			out = append(out, HandDivision{Pair: winTT, Wait: WaitTanki, Chiitoi: true})
+		}
+	}
+	for j := 0; j < 34; j++ {
+		if h[j] < 2 {
+			continue
+		}
+		work := h
+		work[j] -= 2
+		var groups [][]Mentsu
+		collectMentsu(&work, need, nil, &groups)
+		for _, g := range groups {
+			// 和了牌可能落在雀头或任一含有它的手牌面子上，每种落点对应一种听牌形式
+			if TileType(j) == winTT {
+				out = append(out, newDivision(TileType(j), fixed, g, -1, WaitTanki, isRon))
+			}
+			for k, m := range g {
+				wait, ok := waitOfMentsu(m, winTT)
+				if !ok {
+					continue
+				}
+				out = append(out, newDivision(TileType(j), fixed, g, k, wait, isRon))
+			}
+		}
+	}
+	return out
+}
+
+func newDivision(pair TileType, fixed, concealed []Mentsu, winIdx int, wait WaitKind, isRon bool) HandDivision {
+	ms := make([]Mentsu, 0, len(fixed)+len(concealed))
+	ms = append(ms, fixed...)
+	for k, m := range concealed {
+		if k == winIdx && isRon && m.Kind == MentsuKoutsu {
+			m.Open = true // 荣和补成的刻子算明刻
+		}
+		ms = append(ms, m)
+	}
+	return HandDivision{Pair: pair, Mentsu: ms, Wait: wait}
+}
+
+// waitOfMentsu 和了牌落在该面子上时的听牌形式
+func waitOfMentsu(m Mentsu, winTT TileType) (WaitKind, bool) {
+	switch m.Kind {
+	case MentsuKoutsu:
+		if m.First == winTT {
+			return WaitShanpon, true
+		}
+	case MentsuShuntsu:
+		n := numberIndex(m.First)
+		switch winTT {
+		case m.First + 1:
+			return WaitKanchan, true
+		case m.First:
+			if n == 6 {
+				return WaitPenchan, true // 789 听 7
+			}
+			return WaitRyanmen, true
+		case m.First + 2:
+			if n == 0 {
+				return WaitPenchan, true // 123 听 3
+			}
+			return WaitRyanmen, true
+		}
+	}
+	return 0, false
+}
+
+// collectMentsu 回溯收集所有面子拆解（与 canFormMelds 相同的搜索顺序，但不在首个解处停止）
+func collectMentsu(h *Hand34, need int, cur []Mentsu, out *[][]Mentsu) {
+	if need == 0 {
+		for i := 0; i < 34; i++ {
+			if (*h)[i] != 0 {
+				return
+			}
+		}
+		*out = append(*out, append([]Mentsu(nil), cur...))
+		return
+	}
+
+	i := -1
+	for k := 0; k < 34; k++ {
+		if (*h)[k] > 0 {
+			i = k
+			break
+		}
+	}
+	if i == -1 {
+		return
+	}
+	// 刻子
+	if (*h)[i] >= 3 {
+		(*h)[i] -= 3
+		collectMentsu(h, need-1, append(cur, Mentsu{Kind: MentsuKoutsu, First: TileType(i)}), out)
+		(*h)[i] += 3
+	}
+	// 顺子（仅数牌）
+	if isNumberTile(i) && i+2 < 34 && suitOf(i) == suitOf(i+1) && suitOf(i) == suitOf(i+2) {
+		if (*h)[i+1] > 0 && (*h)[i+2] > 0 {
+			(*h)[i]--
+			(*h)[i+1]--
+			(*h)[i+2]--
+			collectMentsu(h, need-1, append(cur, Mentsu{Kind: MentsuShuntsu, First: TileType(i)}), out)
+			(*h)[i]++
+			(*h)[i+1]++
+			(*h)[i+2]++
+		}
+	}
+}
+
+// meldsToMentsu 副露转换为固定面子
+func meldsToMentsu(melds []Meld) []Mentsu {
+	out := make([]Mentsu, 0, len(melds))
+	for _, m := range melds {
+		if len(m.Tiles) == 0 {
+			continue
+		}
+		first := m.Tiles[0].Type
+		for _, t := range m.Tiles {
+			if t.Type < first {
+				first = t.Type
+			}
+		}
+		switch m.Type {
+		case "Chi":
+			out = append(out, Mentsu{Kind: MentsuShuntsu, First: first, Open: true})
+		case "Peng":
+			out = append(out, Mentsu{Kind: MentsuKoutsu, First: first, Open: true})
+		case "Gang", "Kakan":
+			out = append(out, Mentsu{Kind: MentsuKantsu, First: first, Open: true})
+		case "Ankan":
+			out = append(out, Mentsu{Kind: MentsuKantsu, First: first, Open: false})
+		}
+	}
+	return out
+}
+
+// computeFu 计算符数，优先使用 ctx.Division，否则取所有拆解中的最高符
+// 役满不计符，han >= 13 时直接返回 0
+func computeFu(ctx *YakuContext, han int, isTsumo bool) int {
+	if ctx == nil || ctx.Winner == nil || han >= 13 {
+		return 0
+	}
+	if ctx.Division != nil {
+		return fuOfDivision(ctx, ctx.Division, isTsumo)
+	}
+	best := 0
+	for _, div := range divideHand(ctx) {
+		if fu := fuOfDivision(ctx, &div, isTsumo); fu > best {
+			best = fu
+		}
+	}
+	return best
+}
+
+// fuOfDivision 按一种拆解计算符数
+func fuOfDivision(ctx *YakuContext, div *HandDivision, isTsumo bool) int {
+	// 七对子固定 25 符
+	if div.Chiitoi {
+		return 25
+	}
+	// 平和固定符数：自摸 20 符（不加自摸符），荣和 30 符
+	if checkPinfu(ctx, div) {
+		if isTsumo {
+			return 20
+		}
+		return 30
+	}
+
+	menzen := isMenzen(ctx.Winner)
+	fu := 20 // 副底
+
+	// 门清荣和 +10
+	if menzen && !isTsumo {
+		fu += 10
+	}
+
+	// 面子符：中张明刻 2，幺九 ×2，暗 ×2，杠子 ×4
+	for _, m := range div.Mentsu {
+		var mf int
+		switch m.Kind {
+		case MentsuKoutsu:
+			mf = 2
+		case MentsuKantsu:
+			mf = 8
+		default:
+			continue
+		}
+		if isYaochuTileType(m.First) {
+			mf *= 2
+		}
+		if !m.Open {
+			mf *= 2
+		}
+		fu += mf
+	}
+
+	// 雀头符：三元牌、场风、自风各 +2（连风牌 +4）
+	fu += pairFu(ctx, div.Pair)
+
+	// 听牌符：边张/嵌张/单骑 +2
+	switch div.Wait {
+	case WaitKanchan, WaitPenchan, WaitTanki:
+		fu += 2
+	}
+
+	// 自摸 +2（平和自摸除外，已在开头处理）
+	if isTsumo {
+		fu += 2
+	}
+
+	// 副露后没有任何符的荣和（鸣牌平和型）按 30 符计算
+	if !menzen && fu == 20 {
+		fu = 30
+	}
+
+	// 向上取整到10的倍数
+	return ((fu + 9) / 10) * 10
+}
+
+// pairFu 雀头符
+func pairFu(ctx *YakuContext, pair TileType) int {
+	fu := 0
+	switch pair {
+	case White, Green, Red:
+		fu += 2
+	}
+	if ctx.Situation == nil {
+		return fu
+	}
+	if pair == windTileType(ctx.Situation.RoundWind) {
+		fu += 2
+	}
+	if pair == windTileType(seatWindOf(ctx.Winner.SeatIndex, ctx.Situation.DealerIndex, ctx.Situation.Seats())) {
+		fu += 2
+	}
+	return fu
+}
@@ -0,0 +1,90 @@
+package mahjong
+
+import "testing"
+
+// 1 号座位为南家，东场
+func TestComputeFu(t *testing.T) {
+	tests := []struct {
+		name      string
+		hand      string
+		meldType  string // 副露，为空表示门清
+		meldTiles string
+		win       string
+		tsumo     bool
+		want      int
+	}{
+		// 20 + 门清荣和 10 + 中张暗刻 4 = 34
+		{name: "closed ron", hand: "222m567m345p78s88p", win: "6s", want: 40},
+		// 20 + 中张暗刻 4 + 自摸 2 = 26
+		{name: "closed tsumo", hand: "222m567m345p78s88p", win: "6s", tsumo: true, want: 30},
+		{name: "pinfu tsumo", hand: "234m567m345p78s88p", win: "6s", tsumo: true, want: 20},
+		{name: "pinfu ron", hand: "234m567m345p78s88p", win: "6s", want: 30},
+		{name: "open hand without fu", hand: "567m345p78s88p", meldType: "Chi", meldTiles: "234m", win: "6s", want: 30},
+		// 副露的平和型不是平和，自摸照样加 2 符：20 + 自摸 2 = 22
+		{name: "open hand without fu tsumo", hand: "567m345p78s88p", meldType: "Chi", meldTiles: "234m", win: "6s", tsumo: true, want: 30},
+		// 20 + 嵌张 2 + 自摸 2 = 24
+		{name: "kanchan tsumo", hand: "234m567m345p79s88p", win: "8s", tsumo: true, want: 30},
+		// 20 + 门清荣和 10 + 三元牌雀头 2 + 单骑 2 = 34
+		{name: "dragon pair tanki", hand: "234m567m345p678s5z", win: "5z", want: 40},
+		// 20 + 门清荣和 10 + 幺九暗杠 32 + 荣和补成的中张明刻 2 = 64
+		{name: "terminal ankan", hand: "567p345s6688s", meldType: "Ankan", meldTiles: "9999m", win: "8s", want: 70},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			var melds []Meld
+			if tt.meldType != "" {
+				melds = append(melds, meld(t, tt.meldType, tt.meldTiles, 0))
+			}
+			setHand(t, eg, 1, tt.hand, melds...)
+			var fu int
+			if tt.tsumo {
+				_, _, fu, _ = evalTsumo(t, eg, 1, tt.win)
+			} else {
+				_, _, fu, _ = evalRon(t, eg, 1, 0, tt.win)
+			}
+			if fu != tt.want {
+				t.Fatalf("符数 = %d, want %d", fu, tt.want)
+			}
+		})
+	}
+}
+
+// 副露的平和型手牌：荣和不加符，自摸加 2 符后进位
+// 20 + 三元牌明刻 4 + 中张明刻 2 ×2 + 自风雀头 2 = 30，自摸 32
+func TestComputeFuOpenTsumo(t *testing.T) {
+	for _, tsumo := range []bool{false, true} {
+		eg := newTestEngine(t, noAkaRules())
+		setHand(t, eg, 1, "78s22z", meld(t, "Peng", "777z", 0), meld(t, "Peng", "222m", 0), meld(t, "Peng", "333p", 0))
+		var fu int
+		want := 30
+		if tsumo {
+			_, _, fu, _ = evalTsumo(t, eg, 1, "6s")
+			want = 40
+		} else {
+			_, _, fu, _ = evalRon(t, eg, 1, 0, "6s")
+		}
+		if fu != want {
+			t.Fatalf("tsumo=%v 符数 = %d, want %d", tsumo, fu, want)
+		}
+	}
+}
+
+// 结算按符数计点：断幺九 1 番 40 符荣和，闲家 1300 点
+func TestLeadRonEndingUsesFu(t *testing.T) {
+	eg := newTestEngine(t, noAkaRules())
+	setDoraIndicator(eg, Tile{Type: Red, ID: 3})
+	setHand(t, eg, 1, "222m567m345p78s88p")
+	before := [4]int{}
+	for i, p := range eg.Players {
+		before[i] = p.Points
+	}
+
+	eg.LeadRonEnding([]HuClaim{{WinnerSeat: 1, HasLoser: true, LoserSeat: 2, WinTile: parseTile(t, "6s")}})
+	if got := eg.Players[1].Points - before[1]; got != 1300 {
+		t.Fatalf("和牌者得 %d 点, want 1300", got)
+	}
+	if got := before[2] - eg.Players[2].Points; got != 1300 {
+		t.Fatalf("放铳者付 %d 点, want 1300", got)
+	}
+}
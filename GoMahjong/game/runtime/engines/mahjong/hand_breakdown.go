@@ -0,0 +1,111 @@
+package mahjong
+
+import "sort"
+
+// 和牌拆解的展示：按计分采用的 HandDivision 把手牌（荣和时加上点到的牌）分配到各组，副露直接使用副露的牌
+
+// waitNames 听牌形式的稳定名称
+var waitNames = [...]string{
+	WaitRyanmen: "ryanmen",
+	WaitKanchan: "kanchan",
+	WaitPenchan: "penchan",
+	WaitShanpon: "shanpon",
+	WaitTanki:   "tanki",
+}
+
+// mentsuKindNames 面子种类的稳定名称
+var mentsuKindNames = [...]string{
+	MentsuShuntsu: "shuntsu",
+	MentsuKoutsu:  "koutsu",
+	MentsuKantsu:  "kantsu",
+}
+
+// waitName 听牌形式名称，没有拆解（国士无双）时为空
+func waitName(div *HandDivision) string {
+	if div == nil || int(div.Wait) >= len(waitNames) {
+		return ""
+	}
+	return waitNames[div.Wait]
+}
+
+// winningHandSets 和牌的拆解：手牌中的面子、雀头，最后是副露
+// div 为 nil 时（国士无双）整手牌为一组
+func (eg *RiichiMahjong4p) winningHandSets(claim HuClaim, div *HandDivision) []HandSetDTO {
+	if claim.WinnerSeat < 0 || claim.WinnerSeat >= len(eg.Players) || eg.Players[claim.WinnerSeat] == nil {
+		return nil
+	}
+	winner := eg.Players[claim.WinnerSeat]
+
+	tiles := append(make([]Tile, 0, len(winner.Tiles)+1), winner.Tiles...)
+	if claim.HasLoser {
+		tiles = append(tiles, claim.WinTile)
+	}
+	if div == nil {
+		sort.SliceStable(tiles, func(i, j int) bool { return tiles[i].Type < tiles[j].Type })
+		return []HandSetDTO{{Kind: "kokushi", Tiles: tiles}}
+	}
+
+	_, pool := Hand34FromTiles(tiles)
+	take := func(tt TileType, n int) []Tile {
+		avail := pool[tt]
+		if len(avail) < n {
+			n = len(avail)
+		}
+		taken := append([]Tile(nil), avail[:n]...)
+		pool[tt] = avail[n:]
+		return taken
+	}
+
+	melds := meldSets(winner.Melds)
+	sets := make([]HandSetDTO, 0, 7)
+	if div.Chiitoi {
+		for tt := TileType(0); int(tt) < 34; tt++ {
+			if len(pool[tt]) == 2 {
+				sets = append(sets, HandSetDTO{Kind: "pair", Tiles: take(tt, 2)})
+			}
+		}
+		return sets
+	}
+
+	// 拆解中副露排在前面，手牌中的面子从副露之后开始
+	concealed := div.Mentsu[min(len(melds), len(div.Mentsu)):]
+	for _, m := range concealed {
+		set := HandSetDTO{Kind: mentsuKindNames[m.Kind], Open: m.Open}
+		switch m.Kind {
+		case MentsuShuntsu:
+			for k := TileType(0); k < 3; k++ {
+				set.Tiles = append(set.Tiles, take(m.First+k, 1)...)
+			}
+		case MentsuKoutsu:
+			set.Tiles = take(m.First, 3)
+		case MentsuKantsu:
+			set.Tiles = take(m.First, 4)
+		}
+		sets = append(sets, set)
+	}
+	sets = append(sets, HandSetDTO{Kind: "pair", Tiles: take(div.Pair, 2)})
+	return append(sets, melds...)
+}
+
+// meldSets 副露转换为拆解中的组，与 meldsToMentsu 跳过相同的副露，保证和拆解中的固定面子一一对应
+func meldSets(melds []Meld) []HandSetDTO {
+	out := make([]HandSetDTO, 0, len(melds))
+	for _, m := range melds {
+		if len(m.Tiles) == 0 {
+			continue
+		}
+		var kind MentsuKind
+		switch m.Type {
+		case "Chi":
+			kind = MentsuShuntsu
+		case "Peng":
+			kind = MentsuKoutsu
+		case "Gang", "Kakan", "Ankan":
+			kind = MentsuKantsu
+		default:
+			continue
+		}
+		out = append(out, HandSetDTO{Kind: mentsuKindNames[kind], Tiles: m.Tiles, Open: m.Type != "Ankan"})
+	}
+	return out
+}
@@ -0,0 +1,95 @@
+package mahjong
+
+import (
+	"encoding/json"
+	"game/infrastructure/message/transfer"
+	"game/runtime/share"
+	"slices"
+	"testing"
+)
+
+// 庄家打出 4s，2 号座位荣和：回合结束推送和牌谱中带役种名称、听牌形式和手牌拆解（手牌中的面子、雀头、副露）
+func TestWinningHandBreakdown(t *testing.T) {
+	type set struct {
+		kind  string
+		tiles string
+		open  bool
+	}
+	tests := []struct {
+		name     string
+		riichi   bool
+		hand     string
+		melds    []Meld
+		wantYaku []string
+		wantSets []set
+	}{
+		{
+			name:     "closed",
+			riichi:   true,
+			hand:     "234m456p23s555z88s",
+			wantYaku: []string{"Riichi", "Yakuhai"},
+			wantSets: []set{{"shuntsu", "234m", false}, {"shuntsu", "456p", false}, {"shuntsu", "234s", false}, {"koutsu", "555z", false}, {"pair", "88s", false}},
+		},
+		{
+			name:     "open",
+			hand:     "234m456p23s88s",
+			melds:    []Meld{meld(t, "Peng", "555z", 0)},
+			wantYaku: []string{"Yakuhai"},
+			wantSets: []set{{"shuntsu", "234m", false}, {"shuntsu", "456p", false}, {"shuntsu", "234s", false}, {"pair", "88s", false}, {"koutsu", "555z", true}},
+		},
+	}
+	types := func(tiles []Tile) []TileType {
+		var out []TileType
+		for _, tile := range tiles {
+			out = append(out, tile.Type)
+		}
+		return out
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := setupFuritenTable(t, false)
+			waiter := setHand(t, eg, 2, tt.hand, tt.melds...)
+			waiter.IsRiichi = tt.riichi
+			player := eg.UserMap["a"]
+			player.IsBot, player.ConnectorNodeID = false, "conn-player"
+			batch := capturePushes(eg)
+
+			dropTile(t, eg, 0, Tile{Type: So4, ID: 1})
+			eg.handleReactionHuEvent(&share.HuEvent{GameMessageEvent: eg.replayUser(2)})
+
+			items := pushedTo(batch, "conn-player", transfer.GameplayRoundEnd)
+			if len(items) != 1 {
+				t.Fatalf("应推送一次回合结束, got %d", len(items))
+			}
+			var end RoundEndDTO
+			if err := json.Unmarshal(items[0].Data, &end); err != nil {
+				t.Fatalf("json.Unmarshal: %v", err)
+			}
+			if len(end.Claims) != 1 {
+				t.Fatalf("应有一家和牌, got %+v", end.Claims)
+			}
+			claim := end.Claims[0]
+			if !slices.Equal(claim.Yaku, tt.wantYaku) || claim.Wait != "ryanmen" {
+				t.Fatalf("yaku=%v wait=%s, want %v ryanmen", claim.Yaku, claim.Wait, tt.wantYaku)
+			}
+			if len(claim.Hand) != len(tt.wantSets) {
+				t.Fatalf("手牌拆解 %+v, want %d 组", claim.Hand, len(tt.wantSets))
+			}
+			for i, want := range tt.wantSets {
+				got := claim.Hand[i]
+				if got.Kind != want.kind || got.Open != want.open || !slices.Equal(types(got.Tiles), types(parseTiles(t, want.tiles))) {
+					t.Fatalf("第 %d 组 = %+v, want %+v", i, got, want)
+				}
+			}
+			// 和牌拆解包含点到的 4s
+			if !slices.ContainsFunc(claim.Hand[2].Tiles, func(tile Tile) bool { return tile == (Tile{Type: So4, ID: 1}) }) {
+				t.Fatalf("拆解应包含荣和的牌, got %+v", claim.Hand[2])
+			}
+
+			record := lastRoundResult(eg).Claims[0]
+			if !slices.Equal(record.Yaku, tt.wantYaku) || record.Wait != "ryanmen" || len(record.Hand) != len(tt.wantSets) {
+				t.Fatalf("牌谱中的和牌信息不对: %+v", record)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package mahjong
+
+import (
+	"game/domain/entity"
+	"game/infrastructure/log"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	log.InitLog("mahjong_test", "error")
+	os.Exit(m.Run())
+}
+
+// newTestEngine 按给定规则创建不带 actor 的四麻引擎并开始第一局（庄家为 0 号座位），测试直接改写玩家手牌
+func newTestEngine(t *testing.T, rules EngineRules) *RiichiMahjong4p {
+	t.Helper()
+	record := &entity.GameRecord{RoomID: "test", Seed: 7}
+	for i := 0; i < 4; i++ {
+		record.Players = append(record.Players, entity.PlayerInfo{UserID: string(rune('a' + i)), SeatIndex: i})
+	}
+	aka := rules.akaRules()
+	record.Aka = &entity.AkaRules{Man: aka.Man, Pin: aka.Pin, So: aka.So}
+	eg := newReplayEngine(record, rules)
+	eg.handleStartRoundEvent()
+	t.Cleanup(eg.Close)
+	return eg
+}
+
+// noAkaRules 不使用赤牌的默认规则，测试手牌中的 5 都是普通牌
+func noAkaRules() EngineRules {
+	rules := DefaultEngineRules()
+	rules.UseRedFive = false
+	return rules
+}
+
+// parseTiles 按 "123m456p789s1234567z" 的写法生成手牌，0 表示赤 5；同种牌的 ID 依次为 1、2、3，第四张为 0
+func parseTiles(t *testing.T, s string) []Tile {
+	t.Helper()
+	var tiles []Tile
+	used := make(map[TileType]int)
+	var digits []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= '0' && c <= '9' {
+			digits = append(digits, c)
+			continue
+		}
+		var base TileType
+		switch c {
+		case 'm':
+			base = Man1
+		case 'p':
+			base = Pin1
+		case 's':
+			base = So1
+		case 'z':
+			base = East
+		default:
+			t.Fatalf("无法解析的牌: %q", s)
+		}
+		for _, d := range digits {
+			if d == '0' {
+				tiles = append(tiles, Tile{Type: base + 4, ID: 0})
+				continue
+			}
+			tt := base + TileType(d-'1')
+			used[tt]++
+			tiles = append(tiles, Tile{Type: tt, ID: used[tt] % 4})
+		}
+		digits = digits[:0]
+	}
+	if len(digits) != 0 {
+		t.Fatalf("手牌缺少花色后缀: %q", s)
+	}
+	return tiles
+}
+
+// parseTile 解析一张牌
+func parseTile(t *testing.T, s string) Tile {
+	t.Helper()
+	tiles := parseTiles(t, s)
+	if len(tiles) != 1 {
+		t.Fatalf("不是一张牌: %q", s)
+	}
+	tiles[0].ID = 3 // 与 parseTiles 生成的前两张同种牌区分
+	return tiles[0]
+}
+
+// setHand 改写座位的手牌和副露，清除本巡摸到的牌
+func setHand(t *testing.T, eg *RiichiMahjong4p, seat int, hand string, melds ...Meld) *PlayerImage {
+	t.Helper()
+	p := eg.Players[seat]
+	p.Tiles = parseTiles(t, hand)
+	p.Melds = melds
+	p.NewestTile = nil
+	p.FirstTurn = false
+	return p
+}
+
+// meld 从 from 座位鸣牌的副露（暗杠的 from 为自己）
+func meld(t *testing.T, meldType, tiles string, from int) Meld {
+	t.Helper()
+	return Meld{Type: meldType, Tiles: parseTiles(t, tiles), From: from}
+}
+
+// drawTsumo 让座位摸到 tile 作为自摸牌
+func drawTsumo(p *PlayerImage, tile Tile) {
+	p.Tiles = append(p.Tiles, tile)
+	p.NewestTile = &tile
+}
+
+// drainWall 摸完牌山，之后打出的牌是河底牌
+func drainWall(eg *RiichiMahjong4p) {
+	for !eg.DeckManager.IsLastDraw() {
+		eg.DeckManager.Draw()
+	}
+}
+
+func hasYaku(yakus []Yaku, y Yaku) bool {
+	for _, got := range yakus {
+		if got == y {
+			return true
+		}
+	}
+	return false
+}
+
+// evalRon 座位 seat 以 win 荣和 loser 打出的牌，返回番数（不含宝牌）、役满倍数、符数与役
+func evalRon(t *testing.T, eg *RiichiMahjong4p, seat, loser int, win string) (int, int, int, []Yaku) {
+	t.Helper()
+	claim := HuClaim{WinnerSeat: seat, HasLoser: true, LoserSeat: loser, WinTile: parseTile(t, win)}
+	han, ym, fu, yakus, _ := eg.evalClaimYakuman(claim, RoundEndRon)
+	return han, ym, fu, yakus
+}
+
+// evalTsumo 座位 seat 摸到 win 自摸，返回值同 evalRon
+func evalTsumo(t *testing.T, eg *RiichiMahjong4p, seat int, win string) (int, int, int, []Yaku) {
+	t.Helper()
+	tile := parseTile(t, win)
+	drawTsumo(eg.Players[seat], tile)
+	claim := HuClaim{WinnerSeat: seat, WinTile: tile}
+	han, ym, fu, yakus, _ := eg.evalClaimYakuman(claim, RoundEndTsumo)
+	return han, ym, fu, yakus
+}
+
+// assertYakus yakus 包含 want 中的全部役，且不含 never 中的役
+func assertYakus(t *testing.T, yakus, want, never []Yaku) {
+	t.Helper()
+	for _, y := range want {
+		if !hasYaku(yakus, y) {
+			t.Fatalf("缺少 %v (yakus=%v)", y, yakus)
+		}
+	}
+	for _, y := range never {
+		if hasYaku(yakus, y) {
+			t.Fatalf("不应计 %v (yakus=%v)", y, yakus)
+		}
+	}
+}
+
+// lastRoundResult 最近一局的结算结果，局未结束时为 nil
+func lastRoundResult(eg *RiichiMahjong4p) *entity.RoundResult {
+	rounds := eg.Persister.rounds
+	if len(rounds) == 0 {
+		return nil
+	}
+	return rounds[len(rounds)-1].RoundResult
+}
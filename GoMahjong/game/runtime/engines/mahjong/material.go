@@ -64,7 +64,7 @@ const TileLimit = 136
 
 type Tile struct {
 	Type TileType
-	ID   int // 用于区分相同的牌（0-3）。对于数牌5，ID=0表示赤宝牌，ID=1-3表示普通牌
+	ID   int // 用于区分相同的牌（0-3）。对于数牌5，ID=0 及 ID>=RedFiveExtraID 表示赤宝牌，见 fiveIDs
 }
 
 // Wang 王牌结构（固定14张）
@@ -83,15 +83,22 @@ type Wang struct {
 }
 
 type DeckManager struct {
-	wall        []Tile
-	wallIndex   int
-	wang        Wang
-	remain34    [34]int
-	rng         *rand.Rand
-	useRedFives bool
+	wall      []Tile
+	wallIndex int
+	wang      Wang
+	remain34  [34]int
+	rng       *rand.Rand
+	seed      int64    // 洗牌随机种子，相同种子发出相同的牌（复盘、问题复现）
+	aka       AkaRules // 各花色赤 5 的张数
+	sanma     bool     // 三麻牌山：去掉 2-8 万
 }
 
-func NewDeckManager(useRedFives bool) *DeckManager {
+func NewDeckManager(aka AkaRules) *DeckManager {
+	return NewDeckManagerWithSeed(aka, time.Now().UnixNano())
+}
+
+// NewDeckManagerWithSeed 使用指定随机种子创建牌山管理
+func NewDeckManagerWithSeed(aka AkaRules, seed int64) *DeckManager {
 	return &DeckManager{
 		wall:      make([]Tile, 0, TileLimit),
 		wallIndex: 0,
@@ -103,14 +110,41 @@ func NewDeckManager(useRedFives bool) *DeckManager {
 			UraDoraIndicators: [5]Tile{},
 			uraDoraIndex:      0,
 		},
-		remain34:    [34]int{},
-		rng:         rand.New(rand.NewSource(time.Now().UnixNano())),
-		useRedFives: useRedFives,
+		remain34: [34]int{},
+		rng:      rand.New(rand.NewSource(seed)),
+		seed:     seed,
+		aka:      aka,
 	}
 }
 
+// Seed 洗牌随机种子
+func (dm *DeckManager) Seed() int64 {
+	return dm.seed
+}
+
+// Aka 本牌山的赤牌规则
+func (dm *DeckManager) Aka() AkaRules {
+	return dm.aka
+}
+
+// IsAka 按本牌山的赤牌规则判断是否为赤宝牌
+func (dm *DeckManager) IsAka(t Tile) bool {
+	return t.IsRedFive() && dm.aka.Count(t.Type) > 0
+}
+
+// NewSanmaDeckManager 三麻牌山管理，共 108 张
+func NewSanmaDeckManager(aka AkaRules) *DeckManager {
+	aka.Man = 0 // 没有 5 万，记录的赤牌规则也不含万子
+	dm := NewDeckManager(aka)
+	dm.sanma = true
+	return dm
+}
+
 func (dm *DeckManager) InitRound() {
-	deck := NewTileDeck(dm.useRedFives)
+	deck := NewTileDeck(dm.aka)
+	if dm.sanma {
+		deck = NewSanmaTileDeck(dm.aka)
+	}
 	dm.rng.Shuffle(len(deck.tiles), func(i, j int) {
 		deck.tiles[i], deck.tiles[j] = deck.tiles[j], deck.tiles[i]
 	})
@@ -126,6 +160,11 @@ func (dm *DeckManager) InitRound() {
 	for i := 0; i < 34; i++ {
 		dm.remain34[i] = 4
 	}
+	if dm.sanma {
+		for tt := Man2; tt <= Man8; tt++ {
+			dm.remain34[int(tt)] = 0
+		}
+	}
 
 	if len(deck.tiles) <= 14 {
 		return
@@ -156,6 +195,16 @@ func (dm *DeckManager) Deal() (Tile, bool) {
 	return dm.Draw()
 }
 
+// Remaining 牌山剩余可摸张数（不含王牌）
+func (dm *DeckManager) Remaining() int {
+	return len(dm.wall) - dm.wallIndex
+}
+
+// IsLastDraw 牌山已经摸完，即最近一次摸到的是海底牌
+func (dm *DeckManager) IsLastDraw() bool {
+	return len(dm.wall) > 0 && dm.wallIndex >= len(dm.wall)
+}
+
 // DrawKanTile 从岭上牌摸一张牌（开杠时使用）
 func (dm *DeckManager) DrawKanTile() (Tile, bool) {
 	if dm.wang.kanIndex >= 4 {
@@ -167,6 +216,17 @@ func (dm *DeckManager) DrawKanTile() (Tile, bool) {
 	return tile, true
 }
 
+// DrawKitaTile 拔北后补牌：从牌山末尾取一张，王牌保持 14 张，海底随之前移
+func (dm *DeckManager) DrawKitaTile() (Tile, bool) {
+	if dm.wallIndex >= len(dm.wall) {
+		return Tile{}, false
+	}
+	tile := dm.wall[len(dm.wall)-1]
+	dm.wall = dm.wall[:len(dm.wall)-1]
+	dm.remain34[int(tile.Type)]--
+	return tile, true
+}
+
 // RemainingKanTiles 返回剩余岭上牌数量
 func (dm *DeckManager) RemainingKanTiles() int {
 	return 4 - dm.wang.kanIndex
@@ -209,6 +269,31 @@ func (dm *DeckManager) GetUraDoraIndicators() []Tile {
 	return dm.wang.UraDoraIndicators[:dm.wang.uraDoraIndex]
 }
 
+// ActiveDora 当前已翻开的宝牌指示牌指向的宝牌
+func (dm *DeckManager) ActiveDora() []TileType {
+	return dm.indicatorsToDora(dm.GetDoraIndicators())
+}
+
+// ActiveUraDora 当前已翻开的里宝牌指示牌指向的宝牌
+func (dm *DeckManager) ActiveUraDora() []TileType {
+	return dm.indicatorsToDora(dm.GetUraDoraIndicators())
+}
+
+// indicatorsToDora 三麻没有 2-8 万，指示牌 1 万时宝牌为 9 万
+func (dm *DeckManager) indicatorsToDora(indicators []Tile) []TileType {
+	doras := make([]TileType, 0, len(indicators))
+	for _, ind := range indicators {
+		if dm.sanma && ind.Type == Man1 {
+			doras = append(doras, Man9)
+			continue
+		}
+		doras = append(doras, IndicatorToDora(ind))
+	}
+	return doras
+}
+
+// Visible34 已离开牌山的牌（摸牌、翻开的指示牌），包括其他玩家未公开的手牌
+// 只用于牌山统计；计算进张时玩家能看到的牌由引擎的 visibleTiles 统计（牌河、副露、拔北、宝牌指示牌）
 func (dm *DeckManager) Visible34(dst *[34]uint8) {
 	for i := 0; i < 34; i++ {
 		v := 4 - dm.remain34[i]
@@ -233,6 +318,15 @@ type Situation struct {
 	RoundWind    Wind // 场风
 	RoundNumber  int  // 局数(1-4)
 	RiichiSticks int  // 立直棒数量
+	SeatCount    int  // 座位数（四麻 4，三麻 3）
+}
+
+// Seats 座位数，未设置时按四麻处理
+func (s *Situation) Seats() int {
+	if s == nil || s.SeatCount <= 0 {
+		return 4
+	}
+	return s.SeatCount
 }
 
 type Meld struct {
@@ -246,37 +340,72 @@ type TileDeck struct {
 	index int // 当前摸牌位置
 }
 
-func NewTileDeck(useRedFives bool) *TileDeck {
+func NewTileDeck(aka AkaRules) *TileDeck {
 	deck := &TileDeck{
 		tiles: make([]Tile, 0, TileLimit),
 		index: 0,
 	}
-	deck.initializeTiles(useRedFives)
+	deck.initializeTiles(aka)
 	return deck
 }
 
-func (d *TileDeck) initializeTiles(useRedFives bool) {
+// NewSanmaTileDeck 三麻牌组：万子只保留 1 万和 9 万
+func NewSanmaTileDeck(aka AkaRules) *TileDeck {
+	deck := &TileDeck{
+		tiles: make([]Tile, 0, TileLimit),
+		index: 0,
+	}
+	deck.generateSuitTiles(Man1, Man1, aka)
+	deck.generateSuitTiles(Man9, Man9, aka)
+	deck.generateSuitTiles(Pin1, Pin9, aka)
+	deck.generateSuitTiles(So1, So9, aka)
+	deck.generateHonorTiles(East, Red)
+	return deck
+}
+
+func (d *TileDeck) initializeTiles(aka AkaRules) {
 	d.tiles = d.tiles[:0] // 清空切片
 	// 生成数牌（万、筒、索）
-	d.generateSuitTiles(Man1, Man9) // 万子
-	d.generateSuitTiles(Pin1, Pin9) // 筒子
-	d.generateSuitTiles(So1, So9)   // 索子
+	d.generateSuitTiles(Man1, Man9, aka) // 万子
+	d.generateSuitTiles(Pin1, Pin9, aka) // 筒子
+	d.generateSuitTiles(So1, So9, aka)   // 索子
 	// 生成字牌（风牌和箭牌）
 	d.generateHonorTiles(East, Red)
 }
 
-// generateSuitTiles 生成一种花色的数牌
-func (d *TileDeck) generateSuitTiles(start, end TileType) {
+// generateSuitTiles 生成一种花色的数牌，5 按赤牌规则分配 ID
+func (d *TileDeck) generateSuitTiles(start, end TileType, aka AkaRules) {
 	for tileType := start; tileType <= end; tileType++ {
-		for i := 0; i < 4; i++ {
+		ids := [4]int{0, 1, 2, 3}
+		if tileType.IsFive() {
+			ids = fiveIDs(aka.Count(tileType))
+		}
+		for _, id := range ids {
 			d.tiles = append(d.tiles, Tile{
 				Type: tileType,
-				ID:   i,
+				ID:   id,
 			})
 		}
 	}
 }
 
+// fiveIDs 一种 5 的 4 张牌的 ID：第一张赤牌为 0（与每色一张赤牌时一致），其余赤牌从 RedFiveExtraID 起编号，
+// 普通牌依次使用 1、2、3；没有赤牌时为 0-3，ID 0 是否为赤牌由 DeckManager.IsAka 按规则判定
+func fiveIDs(akaCount int) [4]int {
+	if akaCount <= 0 {
+		return [4]int{0, 1, 2, 3}
+	}
+	akaCount = min(akaCount, 4)
+	ids := [4]int{0}
+	for i := 1; i < akaCount; i++ {
+		ids[i] = RedFiveExtraID + i - 1
+	}
+	for i := akaCount; i < 4; i++ {
+		ids[i] = i - akaCount + 1
+	}
+	return ids
+}
+
 func (d *TileDeck) generateHonorTiles(start, end TileType) {
 	for tileType := start; tileType <= end; tileType++ {
 		// 每种字牌生成4张
@@ -320,9 +449,10 @@ func (w Wind) Next() Wind {
 	return (w + 1) % 4
 }
 
-// IsRedFive 判断是否为赤宝牌（ID=0且为数牌5）
+// IsRedFive 判断 ID 是否为赤牌编号（数牌 5 且 ID 为 0 或不小于 RedFiveExtraID）
+// 某花色不使用赤牌时其 ID 0 是普通牌，计算赤宝牌时使用 DeckManager.IsAka
 func (t Tile) IsRedFive() bool {
-	return t.ID == 0 && (t.Type == Man5 || t.Type == Pin5 || t.Type == So5)
+	return t.IsFive() && (t.ID == 0 || t.ID >= RedFiveExtraID)
 }
 
 // IsFive 判断是否为5牌（不区分赤普通）
@@ -339,16 +469,49 @@ const (
 	RoundEndDrawExhaustive = "DRAW_EXHAUSTIVE" // 常规荒牌流局
 	RoundEndDraw3Ron       = "DRAW_3RON"       // 三家点铳流局
 	RoundEndDraw4Kan       = "DRAW_4KAN"       // 四杠散了流局
+	RoundEndKyuushuu       = "DRAW_KYUUSHUU"   // 九种九牌流局
+	RoundEndSuufon         = "DRAW_SUUFON"     // 四风连打流局
 	RoundEndTsumo          = "TSUMO"           // 自摸
 	RoundEndRon            = "RON"             // 荣和
+	RoundEndChombo         = "CHOMBO"          // 错和，本局作废重来
+)
+
+// DrawReason 流局原因代码，随回合结束推送给客户端识别，取值只能追加不能调整顺序
+type DrawReason int
+
+const (
+	DrawReasonNone       DrawReason = iota // 和牌，不是流局
+	DrawReasonExhaustive                   // 荒牌流局
+	DrawReason3Ron                         // 三家点铳
+	DrawReason4Kan                         // 四杠散了
+	DrawReasonKyuushuu                     // 九种九牌
+	DrawReasonSuufon                       // 四风连打
+	DrawReasonChombo                       // 错和
 )
 
+var drawReasons = map[string]DrawReason{
+	RoundEndDrawExhaustive: DrawReasonExhaustive,
+	RoundEndDraw3Ron:       DrawReason3Ron,
+	RoundEndDraw4Kan:       DrawReason4Kan,
+	RoundEndKyuushuu:       DrawReasonKyuushuu,
+	RoundEndSuufon:         DrawReasonSuufon,
+	RoundEndChombo:         DrawReasonChombo,
+}
+
+// drawReasonOf 回合结束类型对应的流局原因，和牌为 DrawReasonNone
+func drawReasonOf(endType string) DrawReason {
+	return drawReasons[endType]
+}
+
 // HuClaim 约定 WinTile 的最后一张牌是 点到的/摸到的 牌
 type HuClaim struct {
 	WinnerSeat int
 	HasLoser   bool
 	LoserSeat  int
 	WinTile    Tile
+	Chankan    bool // 抢杠（荣和他家加杠的牌）
+	LastTile   bool // 海底摸月（自摸）/ 河底捞鱼（荣和）
+	Rinshan    bool // 岭上开花
 }
 
 type PlayerOperation struct {
@@ -0,0 +1,83 @@
+package mahjong
+
+import (
+	"slices"
+	"testing"
+)
+
+// dealAll 按种子洗牌后摸完整个牌山
+func dealAll(seed int64) []Tile {
+	dm := NewDeckManagerWithSeed(DefaultEngineRules().akaRules(), seed)
+	dm.InitRound()
+	var tiles []Tile
+	for {
+		tile, ok := dm.Draw()
+		if !ok {
+			return tiles
+		}
+		tiles = append(tiles, tile)
+	}
+}
+
+func TestDeckManagerSeed(t *testing.T) {
+	first := dealAll(42)
+	if len(first) != TileLimit-14 {
+		t.Fatalf("牌山可摸 %d 张, want %d", len(first), TileLimit-14)
+	}
+	if !slices.Equal(first, dealAll(42)) {
+		t.Fatalf("相同种子应发出相同的牌")
+	}
+	if slices.Equal(first, dealAll(43)) {
+		t.Fatalf("不同种子不应发出相同的牌")
+	}
+	if got := NewDeckManagerWithSeed(AkaRules{}, 42).Seed(); got != 42 {
+		t.Fatalf("Seed = %d, want 42", got)
+	}
+}
+
+// 不使用赤牌与筒子 3 张赤牌的牌组：136 张牌互不相同，赤牌张数与规则一致，普通 5 不计赤宝牌
+func TestAkaRulesDeck(t *testing.T) {
+	tests := []struct {
+		name    string
+		aka     AkaRules
+		wantAka map[TileType]int
+	}{
+		{name: "no aka", aka: AkaRules{}, wantAka: map[TileType]int{}},
+		{name: "3 pin", aka: AkaRules{Man: 1, Pin: 3, So: 1}, wantAka: map[TileType]int{Man5: 1, Pin5: 3, So5: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dm := NewDeckManagerWithSeed(tt.aka, 1)
+			tiles := NewTileDeck(tt.aka).tiles
+			if len(tiles) != TileLimit {
+				t.Fatalf("牌组 %d 张, want %d", len(tiles), TileLimit)
+			}
+			seen := make(map[Tile]bool)
+			akaCount := make(map[TileType]int)
+			var fives []Tile
+			for _, tile := range tiles {
+				if seen[tile] {
+					t.Fatalf("重复的牌 %v", tile)
+				}
+				seen[tile] = true
+				if dm.IsAka(tile) {
+					akaCount[tile.Type]++
+				}
+				if tile.Type == Pin5 {
+					fives = append(fives, tile)
+				}
+			}
+			if len(akaCount) != len(tt.wantAka) {
+				t.Fatalf("赤牌 %v, want %v", akaCount, tt.wantAka)
+			}
+			for tileType, want := range tt.wantAka {
+				if akaCount[tileType] != want {
+					t.Fatalf("赤牌 %v, want %v", akaCount, tt.wantAka)
+				}
+			}
+			if got := dm.countAkaDora(fives, nil); got != tt.wantAka[Pin5] {
+				t.Fatalf("4 张 5p 计 %d 张赤宝牌, want %d", got, tt.wantAka[Pin5])
+			}
+		})
+	}
+}
@@ -1,5 +1,10 @@
 package mahjong
 
+import (
+	"game/runtime/share"
+	"slices"
+)
+
 // calculateAvailableOperations 计算可用操作
 func (eg *RiichiMahjong4p) calculateAvailableOperations(excludeSeat int) map[int]*PlayerReaction {
 	reactions := make(map[int]*PlayerReaction)
@@ -11,7 +16,7 @@ func (eg *RiichiMahjong4p) calculateAvailableOperations(excludeSeat int) map[int
 	}
 	droppedTile := droppingPlayerObj.DiscardPile[len(droppingPlayerObj.DiscardPile)-1]
 	// 检查每个反应玩家的操作
-	for i := 0; i < 4; i++ {
+	for i := 0; i < eg.seats(); i++ {
 		if i == excludeSeat {
 			continue
 		}
@@ -23,14 +28,21 @@ func (eg *RiichiMahjong4p) calculateAvailableOperations(excludeSeat int) map[int
 				Tiles: []Tile{droppedTile},
 			})
 		}
+		// 河底牌打出后只能荣和，不能再鸣牌
+		if eg.isLastDraw() {
+			if len(playerOps) > 0 {
+				reactions[i] = &PlayerReaction{Operations: playerOps}
+			}
+			continue
+		}
 		// 检查是否可以明杠
 		gangOps := eg.getGangOptions(i, droppedTile)
 		playerOps = append(playerOps, gangOps...)
 		// 检查是否可以碰
 		pengOps := eg.getPengOptions(i, droppedTile)
 		playerOps = append(playerOps, pengOps...)
-		// 检查是否可以吃（只有下家可以吃）
-		if (droppingPlayer+1)%4 == i {
+		// 检查是否可以吃（只有下家可以吃，三麻不能吃）
+		if eg.seats() == 4 && eg.nextSeat(droppingPlayer) == i {
 			chiOps := eg.getChiOptions(i, droppedTile)
 			playerOps = append(playerOps, chiOps...)
 		}
@@ -65,8 +77,18 @@ func (eg *RiichiMahjong4p) getPengOptions(seatIndex int, droppedTile Tile) []*Pl
 	if len(matchingTiles) < 2 {
 		return ops
 	}
+	// 只有赤牌与普通牌是不同的选择，相同的组合只保留一个
+	seen := make(map[[2]bool]struct{})
 	for i := 0; i < len(matchingTiles); i++ {
 		for j := i + 1; j < len(matchingTiles); j++ {
+			key := [2]bool{eg.DeckManager.IsAka(matchingTiles[i]), eg.DeckManager.IsAka(matchingTiles[j])}
+			if key[0] && !key[1] {
+				key = [2]bool{false, true}
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
 			ops = append(ops, &PlayerOperation{
 				Type:  "PENG",
 				Tiles: []Tile{matchingTiles[i], matchingTiles[j]},
@@ -134,13 +156,74 @@ func (eg *RiichiMahjong4p) getChiOptions(seatIndex int, droppedTile Tile) []*Pla
 	return ops
 }
 
-// findChiCombinations 查找所有可能的吃牌组合
+// findChiCombinations 查找所有可能的吃牌组合，每个组合是需要从手牌中拿出的两张牌
+// 打出的牌可以作为顺子的左、中、右三个位置；赤牌与普通牌视为不同的选择
 func (eg *RiichiMahjong4p) findChiCombinations(hand []Tile, droppedTile Tile) [][]Tile {
 	var combos [][]Tile
-	// TODO: 实现吃牌组合查找逻辑
+	tt := droppedTile.Type
+	if !tt.IsNumbered() {
+		return combos
+	}
+	n := numberIndex(tt)
+
+	byType := make(map[TileType][]Tile)
+	for _, t := range hand {
+		byType[t.Type] = append(byType[t.Type], t)
+	}
+
+	// 与打出的牌组成顺子的另外两张牌的偏移
+	for _, offsets := range [][2]int{{-2, -1}, {-1, 1}, {1, 2}} {
+		lo, hi := n+offsets[0], n+offsets[1]
+		if lo < 0 || hi > 8 {
+			continue
+		}
+		a := byType[tt+TileType(offsets[0])]
+		b := byType[tt+TileType(offsets[1])]
+		seen := make(map[[2]bool]struct{})
+		for _, ta := range a {
+			for _, tb := range b {
+				key := [2]bool{eg.DeckManager.IsAka(ta), eg.DeckManager.IsAka(tb)}
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+				combos = append(combos, []Tile{ta, tb})
+			}
+		}
+	}
 	return combos
 }
 
+// selectOperation 按玩家选择的手牌（Type 和 ID 都要一致）查找操作，未指定手牌时返回该类型的第一个操作
+func selectOperation(ops []*PlayerOperation, opType string, chosen []share.Tile) *PlayerOperation {
+	tiles := make([]Tile, len(chosen))
+	for i, t := range chosen {
+		tiles[i] = toMahjongTile(t)
+	}
+	for _, op := range ops {
+		if op.Type != opType {
+			continue
+		}
+		if len(tiles) == 0 || sameTiles(op.Tiles, tiles) {
+			return op
+		}
+	}
+	return nil
+}
+
+// sameTiles 两组牌是否为同样的实体牌，不计顺序
+func sameTiles(a, b []Tile) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, t := range a {
+		if !slices.Contains(b, t) {
+			return false
+		}
+	}
+	return true
+}
+
 // isSameTile 判断两张牌是否相同
 func (eg *RiichiMahjong4p) isSameTile(tile1, tile2 Tile) bool {
 	if tile1.Type != tile2.Type {
@@ -0,0 +1,92 @@
+package mahjong
+
+import (
+	"game/runtime/share"
+	"slices"
+	"testing"
+)
+
+// setupReactionTable 庄家手中有 4s，其他座位按 hands 设置手牌，空字符串为与索子无关的手牌
+func setupReactionTable(t *testing.T, hands [3]string) *RiichiMahjong4p {
+	t.Helper()
+	eg := newTestEngine(t, noAkaRules())
+	setHand(t, eg, 0, "13579m13579p4s246z")
+	for i, hand := range hands {
+		if hand == "" {
+			hand = "13579m13579p246z"
+		}
+		setHand(t, eg, i+1, hand)
+	}
+	return eg
+}
+
+func opTiles(reaction *PlayerReaction, opType string) [][]Tile {
+	var tiles [][]Tile
+	if reaction == nil {
+		return tiles
+	}
+	for _, op := range reaction.Operations {
+		if op.Type == opType {
+			tiles = append(tiles, op.Tiles)
+		}
+	}
+	return tiles
+}
+
+// 下家手中有 2356s，打出的 4s 可以作为顺子的左、中、右三种吃法
+func TestChiOptions(t *testing.T) {
+	eg := setupReactionTable(t, [3]string{"13579m1357p2356s"})
+	dropTile(t, eg, 0, Tile{Type: So4, ID: 1})
+
+	p := eg.Players[1]
+	want := [][]Tile{{p.Tiles[9], p.Tiles[10]}, {p.Tiles[10], p.Tiles[11]}, {p.Tiles[11], p.Tiles[12]}}
+	got := opTiles(eg.Reactions[1], "CHI")
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Fatalf("吃牌选择 = %v, want %v", got, want)
+	}
+}
+
+// 2 号座位听 4s/8s 双碰，打出的 4s 同时可以碰和荣和
+func TestPengAndRonOptions(t *testing.T) {
+	eg := setupReactionTable(t, [3]string{"", "234m456p555z44s88s"})
+	dropTile(t, eg, 0, Tile{Type: So4, ID: 1})
+
+	reaction := eg.Reactions[2]
+	if !hasOperation(reaction, "HU") || !hasOperation(reaction, "PENG") {
+		t.Fatalf("应同时可以碰和荣和, got %+v", reaction)
+	}
+	p := eg.Players[2]
+	if got := opTiles(reaction, "PENG"); len(got) != 1 || !slices.Equal(got[0], p.Tiles[9:11]) {
+		t.Fatalf("碰应拿出手中的两张 4s, got %v", got)
+	}
+	if _, ok := eg.Reactions[1]; ok {
+		t.Fatalf("座位 1 不应有操作, got %+v", eg.Reactions[1])
+	}
+}
+
+// 下家手中有 4s、5s 和赤 5s，吃打出的 6s 时普通 5 与赤 5 是两个不同的选项，按玩家选择的实体牌执行
+func TestChiRedFiveChoice(t *testing.T) {
+	eg := newTestEngine(t, DefaultEngineRules())
+	setHand(t, eg, 0, "13579m13579p6s246z")
+	caller := setHand(t, eg, 1, "13579m1357p450s1z")
+	for seat := 2; seat < 4; seat++ {
+		setHand(t, eg, seat, "13579m13579p246z")
+	}
+	dropTile(t, eg, 0, Tile{Type: So6, ID: 1})
+
+	normal := []Tile{{Type: So4, ID: 1}, {Type: So5, ID: 1}}
+	red := []Tile{{Type: So4, ID: 1}, {Type: So5, ID: 0}}
+	got := opTiles(eg.Reactions[1], "CHI")
+	if len(got) != 2 || !slices.ContainsFunc(got, func(ts []Tile) bool { return sameTiles(ts, normal) }) ||
+		!slices.ContainsFunc(got, func(ts []Tile) bool { return sameTiles(ts, red) }) {
+		t.Fatalf("应分别提供普通 5 和赤 5 的吃法, got %v", got)
+	}
+
+	eg.handleChiEvent(&share.ChiEvent{GameMessageEvent: eg.replayUser(1), Tiles: toShareTiles(red)})
+	if len(caller.Melds) != 1 || !slices.Contains(caller.Melds[0].Tiles, red[1]) {
+		t.Fatalf("吃牌应使用选择的赤 5, got %+v", caller.Melds)
+	}
+	if slices.Contains(caller.Tiles, red[1]) || !slices.Contains(caller.Tiles, normal[1]) {
+		t.Fatalf("手中应留下普通 5, got %v", caller.Tiles)
+	}
+}
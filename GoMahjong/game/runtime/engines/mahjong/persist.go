@@ -5,6 +5,8 @@ import (
 	"game/domain/entity"
 	"game/domain/repository"
 	"game/infrastructure/log"
+	"game/infrastructure/message/transfer"
+	"game/infrastructure/metrics"
 	"game/runtime/share"
 	"sync"
 	"time"
@@ -16,6 +18,7 @@ import (
 // 负责在游戏过程中收集事件，游戏结束后异步写入数据库
 type GamePersister struct {
 	repo         repository.GameRecordRepository
+	leaderboard  repository.LeaderboardRepository // 可选，为 nil 时不更新排行榜
 	gameRecord   *entity.GameRecord
 	rounds       []*entity.RoundRecord // 所有回合的数组（游戏结束后一次性保存）
 	currentRound *entity.RoundRecord   // 当前回合（方便操作）
@@ -24,7 +27,7 @@ type GamePersister struct {
 }
 
 // NewGamePersister 创建持久化组件
-func NewGamePersister(repo repository.GameRecordRepository, roomID string, userMap map[string]*share.UserInfo) *GamePersister {
+func NewGamePersister(repo repository.GameRecordRepository, leaderboard repository.LeaderboardRepository, roomID string, userMap map[string]*share.UserInfo) *GamePersister {
 	// 构建玩家信息
 	players := make([]entity.PlayerInfo, 0, len(userMap))
 	for userID, userInfo := range userMap {
@@ -38,10 +41,11 @@ func NewGamePersister(repo repository.GameRecordRepository, roomID string, userM
 	gameRecord := entity.NewGameRecord(roomID, "riichi_mahjong_4p", players)
 
 	return &GamePersister{
-		repo:       repo,
-		gameRecord: gameRecord,
-		rounds:     make([]*entity.RoundRecord, 0, 8), // 预分配容量（通常一局游戏不超过8个回合）
-		closed:     false,
+		repo:        repo,
+		leaderboard: leaderboard,
+		gameRecord:  gameRecord,
+		rounds:      make([]*entity.RoundRecord, 0, 8), // 预分配容量（通常一局游戏不超过8个回合）
+		closed:      false,
 	}
 }
 
@@ -50,8 +54,17 @@ func (gp *GamePersister) GetGameRecordID() primitive.ObjectID {
 	return gp.gameRecord.ID
 }
 
-// StartRound 开始新的一局
-func (gp *GamePersister) StartRound(roundNumber int, roundWind string, dealerIndex, honba int) {
+// SetEngineConfig 记录重建引擎所需的洗牌种子、对局长度和赤牌规则，确定性复盘和导出牌谱时使用
+func (gp *GamePersister) SetEngineConfig(seed int64, gameLength int, aka AkaRules) {
+	gp.eventMu.Lock()
+	defer gp.eventMu.Unlock()
+	gp.gameRecord.Seed = seed
+	gp.gameRecord.GameLength = gameLength
+	gp.gameRecord.Aka = &entity.AkaRules{Man: aka.Man, Pin: aka.Pin, So: aka.So}
+}
+
+// StartRound 开始新的一局，记录配牌和宝牌指示牌，牌谱回放从这里还原初始局面
+func (gp *GamePersister) StartRound(roundNumber int, roundWind string, dealerIndex, honba int, hands [][]share.Tile, doraIndicators []share.Tile) {
 	if gp.closed {
 		return
 	}
@@ -72,12 +85,29 @@ func (gp *GamePersister) StartRound(roundNumber int, roundWind string, dealerInd
 	gp.rounds = append(gp.rounds, gp.currentRound)
 
 	// 记录回合开始事件
+	handData := make([][]map[string]interface{}, len(hands))
+	for i, hand := range hands {
+		handData[i] = tilesToData(hand)
+	}
 	gp.currentRound.AddEvent(entity.EventTypeRoundStart, -1, map[string]interface{}{
-		"dora_indicators": nil, // 客户端会从推送消息中获取
+		"dora_indicators": tilesToData(doraIndicators),
+		"hands":           handData,
 		"current_turn":    dealerIndex,
 	})
 }
 
+// tilesToData 牌列表转为事件数据
+func tilesToData(tiles []share.Tile) []map[string]interface{} {
+	data := make([]map[string]interface{}, len(tiles))
+	for i, t := range tiles {
+		data[i] = map[string]interface{}{
+			"type": t.Type,
+			"id":   t.ID,
+		}
+	}
+	return data
+}
+
 // RecordDrawTile 记录摸牌事件
 func (gp *GamePersister) RecordDrawTile(seatIndex int, tile share.Tile) {
 	if gp.closed || gp.currentRound == nil {
@@ -302,6 +332,8 @@ func (gp *GamePersister) CompleteRound(endType string, claims []HuClaimDTO, delt
 			Fu:     c.Fu,
 			Yaku:   c.Yaku,
 			Points: c.Points,
+			Hand:   handSetsToEntity(c.Hand),
+			Wait:   c.Wait,
 		})
 	}
 
@@ -315,6 +347,10 @@ func (gp *GamePersister) CompleteRound(endType string, claims []HuClaimDTO, delt
 	}
 
 	gp.currentRound.CompleteRound(result)
+	// 复盘的持久化组件没有仓储，不计入指标
+	if gp.repo != nil {
+		metrics.ObserveRoundDuration(gp.currentRound.EndTime.Sub(gp.currentRound.StartTime))
+	}
 
 	// 记录回合结束事件
 	gp.currentRound.AddEvent(entity.EventTypeRoundEnd, -1, map[string]interface{}{})
@@ -333,34 +369,65 @@ func (gp *GamePersister) FinalizeGame(finalRankings []PlayerRankingDTO, finalPoi
 	copy(rounds, gp.rounds) // 复制数组，避免在异步中访问时数据被修改
 	gp.eventMu.Unlock()
 
+	// 转换最终排名
+	rankings := make([]entity.PlayerRanking, 0, len(finalRankings))
+	for _, r := range finalRankings {
+		rankings = append(rankings, entity.PlayerRanking{
+			SeatIndex: r.SeatIndex,
+			UserID:    r.UserID,
+			Points:    r.Points,
+			Rank:      r.Rank,
+		})
+	}
+	gp.gameRecord.CompleteGame(&entity.GameFinalResult{
+		Rankings: rankings,
+		Points:   finalPoints,
+	})
+	gp.saveAsync(rounds, rankings)
+}
+
+// FlushPartial 对局未正常结束（崩溃、强制解散、节点关闭）时保存已收集的回合，游戏记录标记为 aborted
+// 未结束的当前局也会保存，没有局结果；不更新排行榜。正常结算后调用无效
+func (gp *GamePersister) FlushPartial() {
+	gp.eventMu.Lock()
+	if gp.closed {
+		gp.eventMu.Unlock()
+		return
+	}
+	gp.closed = true
+	rounds := make([]*entity.RoundRecord, len(gp.rounds))
+	copy(rounds, gp.rounds)
+	gp.eventMu.Unlock()
+
+	// 还没开局就解散的房间没有可保存的内容
+	if len(rounds) == 0 {
+		return
+	}
+	gp.gameRecord.AbortGame()
+	log.Warn("对局未正常结束，保存已完成的记录: gameRecordID=%s, rounds=%d", gp.gameRecord.ID.Hex(), len(rounds))
+	gp.saveAsync(rounds, nil)
+}
+
+// saveAsync 异步写入游戏记录和局记录，rankings 为空时不更新排行榜
+func (gp *GamePersister) saveAsync(rounds []*entity.RoundRecord, rankings []entity.PlayerRanking) {
+	// 没有仓储时只在内存中收集记录（确定性复盘）
+	if gp.repo == nil {
+		return
+	}
+
 	// 异步写入数据库
 	go func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		// 转换最终排名
-		rankings := make([]entity.PlayerRanking, 0, len(finalRankings))
-		for _, r := range finalRankings {
-			rankings = append(rankings, entity.PlayerRanking{
-				SeatIndex: r.SeatIndex,
-				UserID:    r.UserID,
-				Points:    r.Points,
-				Rank:      r.Rank,
-			})
-		}
-
-		// 设置游戏最终结果
-		finalResult := &entity.GameFinalResult{
-			Rankings: rankings,
-			Points:   finalPoints,
-		}
-		gp.gameRecord.CompleteGame(finalResult)
-
 		// 保存游戏记录（元数据）
 		if err := gp.repo.SaveGameRecord(ctx, gp.gameRecord); err != nil {
 			log.Error("保存游戏记录失败: %v", err)
 			return
 		}
+		if len(rankings) > 0 {
+			gp.updateLeaderboard(ctx, rankings)
+		}
 
 		// 批量保存所有局记录（每个小场一个文档）
 		if err := gp.repo.SaveRoundRecords(ctx, rounds); err != nil {
@@ -372,6 +439,38 @@ func (gp *GamePersister) FinalizeGame(finalRankings []PlayerRankingDTO, finalPoi
 	}()
 }
 
+// updateLeaderboard 结算排行榜积分，机器人不上榜但占据顺位
+func (gp *GamePersister) updateLeaderboard(ctx context.Context, rankings []entity.PlayerRanking) {
+	if gp.leaderboard == nil {
+		return
+	}
+	humans := make([]entity.PlayerRanking, 0, len(rankings))
+	for _, r := range rankings {
+		if !transfer.IsBotUser(r.UserID) {
+			humans = append(humans, r)
+		}
+	}
+	if err := gp.leaderboard.ApplyGameResult(ctx, humans, len(rankings)); err != nil {
+		log.Error("更新排行榜失败: gameRecordID=%s, err=%v", gp.gameRecord.ID.Hex(), err)
+	}
+}
+
+// handSetsToEntity 和牌拆解转换为牌谱结构
+func handSetsToEntity(sets []HandSetDTO) []entity.HandSet {
+	if len(sets) == 0 {
+		return nil
+	}
+	result := make([]entity.HandSet, len(sets))
+	for i, set := range sets {
+		tiles := make([]entity.Tile, len(set.Tiles))
+		for j, t := range set.Tiles {
+			tiles[j] = entity.Tile{Type: int(t.Type), ID: t.ID}
+		}
+		result[i] = entity.HandSet{Kind: set.Kind, Tiles: tiles, Open: set.Open}
+	}
+	return result
+}
+
 // SaveCurrentRound 保存当前局记录（用于中途保存，可选）
 // 注意：正常情况下不需要调用，游戏结束后会一次性保存所有回合
 func (gp *GamePersister) SaveCurrentRound() error {
@@ -0,0 +1,121 @@
+package mahjong
+
+import (
+	"context"
+	"game/domain/entity"
+	"game/domain/repository"
+	"game/runtime/share"
+	"testing"
+	"time"
+)
+
+// discardGameRecords 只接收写入，不保存
+type discardGameRecords struct {
+	repository.GameRecordRepository
+}
+
+func (discardGameRecords) SaveGameRecord(ctx context.Context, record *entity.GameRecord) error {
+	return nil
+}
+
+func (discardGameRecords) SaveRoundRecords(ctx context.Context, rounds []*entity.RoundRecord) error {
+	return nil
+}
+
+// recordingLeaderboard 记录一次结算
+type recordingLeaderboard struct {
+	repository.LeaderboardRepository
+	applied chan []entity.PlayerRanking
+	count   int
+}
+
+func (l *recordingLeaderboard) ApplyGameResult(ctx context.Context, rankings []entity.PlayerRanking, playerCount int) error {
+	l.count = playerCount
+	l.applied <- rankings
+	return nil
+}
+
+// 终局结算更新排行榜，bot 不上榜但按四人对局计算积分
+func TestFinalizeGameUpdatesLeaderboard(t *testing.T) {
+	leaderboard := &recordingLeaderboard{applied: make(chan []entity.PlayerRanking, 1)}
+	userMap := map[string]*share.UserInfo{
+		"u0": {UserID: "u0", SeatIndex: 0}, "bot_1": {UserID: "bot_1", SeatIndex: 1, IsBot: true},
+		"u2": {UserID: "u2", SeatIndex: 2}, "u3": {UserID: "u3", SeatIndex: 3},
+	}
+	gp := NewGamePersister(discardGameRecords{}, leaderboard, "room", userMap)
+	gp.FinalizeGame([]PlayerRankingDTO{
+		{SeatIndex: 1, UserID: "bot_1", Points: 40000, Rank: 1},
+		{SeatIndex: 0, UserID: "u0", Points: 30000, Rank: 2},
+		{SeatIndex: 2, UserID: "u2", Points: 20000, Rank: 3},
+		{SeatIndex: 3, UserID: "u3", Points: 10000, Rank: 4},
+	}, [4]int{30000, 40000, 20000, 10000})
+
+	select {
+	case rankings := <-leaderboard.applied:
+		if len(rankings) != 3 || leaderboard.count != 4 {
+			t.Fatalf("应只结算 3 名玩家、按 4 人对局计算, got %+v count=%d", rankings, leaderboard.count)
+		}
+		for _, r := range rankings {
+			if r.UserID == "bot_1" {
+				t.Fatalf("bot 不应上榜")
+			}
+			if r.UserID == "u0" && r.Rank != 2 {
+				t.Fatalf("玩家顺位应保持对局中的顺位, got %+v", r)
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("终局后没有更新排行榜")
+	}
+}
+
+// recordingGameRecords 把写入的游戏记录和局记录转发到 channel
+type recordingGameRecords struct {
+	repository.GameRecordRepository
+	records chan *entity.GameRecord
+	rounds  chan []*entity.RoundRecord
+}
+
+func (r *recordingGameRecords) SaveGameRecord(ctx context.Context, record *entity.GameRecord) error {
+	r.records <- record
+	return nil
+}
+
+func (r *recordingGameRecords) SaveRoundRecords(ctx context.Context, rounds []*entity.RoundRecord) error {
+	r.rounds <- rounds
+	return nil
+}
+
+// 对局中途关闭房间：已结束的局和进行中的局都会保存，游戏记录标记为 aborted
+func TestCloseMidGameFlushesPartialRounds(t *testing.T) {
+	eg := newTestEngine(t, DefaultEngineRules())
+	repo := &recordingGameRecords{records: make(chan *entity.GameRecord, 1), rounds: make(chan []*entity.RoundRecord, 1)}
+	eg.Persister.repo = repo
+	playBotUntil(t, eg, func() bool { return len(eg.Persister.rounds) == 2 })
+	if len(eg.Persister.rounds) != 2 {
+		t.Fatalf("第 2 局没有开始")
+	}
+
+	eg.Close()
+	var record *entity.GameRecord
+	var rounds []*entity.RoundRecord
+	select {
+	case record = <-repo.records:
+		rounds = <-repo.rounds
+	case <-time.After(time.Second):
+		t.Fatalf("关闭房间时应保存已收集的记录")
+	}
+	if record.Status != "aborted" || record.FinalResult != nil {
+		t.Fatalf("游戏记录应标记为 aborted 且没有终局结果, got status=%q", record.Status)
+	}
+	if len(rounds) != 2 || rounds[0].RoundResult == nil || rounds[1].RoundResult != nil || len(rounds[1].Events) == 0 {
+		t.Fatalf("应保存结束的第 1 局和进行中的第 2 局, got %d 局", len(rounds))
+	}
+
+	// 已经保存过，再次 flush 不会重复写入
+	eg.Persister.FlushPartial()
+	select {
+	case <-repo.records:
+		t.Fatalf("不应重复保存")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -1,18 +1,28 @@
 package mahjong
 
 type PlayerImage struct {
-	UserID         string
-	SeatIndex      int
-	Tiles          []Tile                // 手中的牌
-	DiscardPile    []Tile                // 弃牌堆
-	Melds          []Meld                // 碰、杠、吃的组合
-	IsRiichi       bool                  // 是否立直
-	IsWaiting      bool                  // 是否听牌
-	DiscardedTiles map[TileType]struct{} // 已弃的牌类型集合（用于振听判断），考虑到弃牌堆的牌有可能会被副露，需要额外维护
-	NewestTile     *Tile                 // 最新摸的牌（用于自摸和判断）
-	Points         int                   // 当前点数（初始25000或30000）
-	TenpaiWaits    map[TileType]TenpaiWaitState
-	TenpaiValid    bool
+	UserID          string
+	SeatIndex       int
+	Tiles           []Tile                // 手中的牌
+	DiscardPile     []Tile                // 弃牌堆
+	Melds           []Meld                // 碰、杠、吃的组合
+	IsRiichi        bool                  // 是否立直
+	DoubleRiichi    bool                  // 立直为两立直
+	IppatsuEligible bool                  // 立直后一巡内未被打断（一发判定）
+	riichiDeclared  bool                  // 已宣言立直但宣言牌尚未打出
+	rinshanPending  bool                  // 刚摸了岭上牌尚未打出（岭上开花判定）
+	missedRon       bool                  // 放过了能荣和的牌（同巡振听），下次摸牌时解除；立直中放过则到本局结束都不能荣和
+	IsWaiting       bool                  // 是否听牌
+	DiscardedTiles  map[TileType]struct{} // 已弃的牌类型集合（用于振听判断），考虑到弃牌堆的牌有可能会被副露，需要额外维护
+	NewestTile      *Tile                 // 最新摸的牌（用于自摸和判断）
+	Points          int                   // 当前点数（初始25000或30000）
+	TenpaiWaits     map[TileType]TenpaiWaitState
+	TenpaiValid     bool
+	FirstTurn       bool                  // 本局第一巡且尚未被鸣牌打断（天和、地和、人和判定）
+	KuikaeForbidden map[TileType]struct{} // 吃碰后本巡禁止打出的牌（食替），打牌后清空
+	PaoSeat         int                   // 包牌（责任支付）玩家座位，-1 表示无
+	PaoYaku         Yaku                  // 包牌对应的役满
+	Kita            []Tile                // 拔北的牌（三麻），每张算一枚宝牌
 }
 
 type TenpaiWaitState struct {
@@ -36,9 +46,19 @@ func NewPlayerImage(userID string, seatIndex int, initialPoints int) *PlayerImag
 		Points:         initialPoints,
 		TenpaiWaits:    make(map[TileType]TenpaiWaitState),
 		TenpaiValid:    false,
+		PaoSeat:        -1,
 	}
 }
 
+// DeclareRiichi 宣言立直，开启一发判定；第一巡未被鸣牌打断时为两立直
+func (p *PlayerImage) DeclareRiichi() {
+	p.IsRiichi = true
+	p.DoubleRiichi = p.FirstTurn
+	p.IsWaiting = true
+	p.IppatsuEligible = true
+	p.riichiDeclared = true
+}
+
 // AddDiscardedTile 记录已弃的牌（用于振听判断）
 func (p *PlayerImage) AddDiscardedTile(tile Tile) {
 	p.DiscardedTiles[tile.Type] = struct{}{}
@@ -81,11 +101,44 @@ func (p *PlayerImage) AddTile(tile Tile) {
 
 // DrawTile 摸牌
 func (p *PlayerImage) DrawTile(tile Tile) {
+	if !p.IsRiichi {
+		p.missedRon = false
+	}
 	p.Tiles = append(p.Tiles, tile)
 	newest := tile
 	p.NewestTile = &newest
 }
 
+// DrawRinshanTile 开杠后摸岭上牌，打出下一张牌前自摸即为岭上开花
+func (p *PlayerImage) DrawRinshanTile(tile Tile) {
+	p.DrawTile(tile)
+	p.rinshanPending = true
+}
+
+// SetKuikaeForbidden 鸣牌后设置本巡禁止打出的牌
+func (p *PlayerImage) SetKuikaeForbidden(types ...TileType) {
+	p.KuikaeForbidden = make(map[TileType]struct{}, len(types))
+	for _, tt := range types {
+		p.KuikaeForbidden[tt] = struct{}{}
+	}
+}
+
+// OnlyKuikaeTiles 手中只剩食替禁止打出的牌，此时只能打出其中一张（与超时自动出牌一致）
+func (p *PlayerImage) OnlyKuikaeTiles() bool {
+	for _, t := range p.Tiles {
+		if !p.IsKuikaeForbidden(t.Type) {
+			return false
+		}
+	}
+	return len(p.Tiles) > 0
+}
+
+// IsKuikaeForbidden 是否为食替禁止打出的牌
+func (p *PlayerImage) IsKuikaeForbidden(tileType TileType) bool {
+	_, exists := p.KuikaeForbidden[tileType]
+	return exists
+}
+
 func (p *PlayerImage) RemoveTile(tile Tile) bool {
 	for i := range p.Tiles {
 		if p.Tiles[i].Type == tile.Type && p.Tiles[i].ID == tile.ID {
@@ -96,30 +149,100 @@ func (p *PlayerImage) RemoveTile(tile Tile) bool {
 	return false
 }
 
+// HasTiles 给定的牌是否都在手中，按 Type 和 ID 精确匹配（区分赤牌），同一张手牌只能匹配一次
+func (p *PlayerImage) HasTiles(tiles ...Tile) bool {
+	used := make([]bool, len(p.Tiles))
+	for _, tile := range tiles {
+		found := false
+		for i := range p.Tiles {
+			if !used[i] && p.Tiles[i].Type == tile.Type && p.Tiles[i].ID == tile.ID {
+				used[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 func (p *PlayerImage) DiscardTile(tile Tile) bool {
 	if !p.RemoveTile(tile) {
 		return false
 	}
 	p.DiscardPile = append(p.DiscardPile, tile)
 	p.AddDiscardedTile(tile)
-	if p.NewestTile != nil && p.NewestTile.Type == tile.Type && p.NewestTile.ID == tile.ID {
-		p.NewestTile = nil
+	p.FirstTurn = false
+	p.rinshanPending = false
+	p.KuikaeForbidden = nil
+	// 立直宣言牌不消耗一发，此后自己再打出一张牌一发即失效
+	if p.riichiDeclared {
+		p.riichiDeclared = false
+	} else {
+		p.IppatsuEligible = false
 	}
+	// 打牌后本巡摸到的牌失效（手切时摸到的牌还在手中），避免鸣牌后被当作自摸牌
+	p.NewestTile = nil
 	return true
 }
 
+// DiscardNewestOrLast 自动出牌：优先打出刚摸的牌，否则从后往前打出第一张不受食替限制的牌
 func (p *PlayerImage) DiscardNewestOrLast() (Tile, bool) {
-	if len(p.Tiles) != 14 {
+	if len(p.Tiles)%3 != 2 {
 		return Tile{}, false
 	}
 	var tile Tile
-	if p.NewestTile != nil {
+	if p.NewestTile != nil && !p.IsKuikaeForbidden(p.NewestTile.Type) {
 		tile = *p.NewestTile
 	} else {
 		tile = p.Tiles[len(p.Tiles)-1]
+		for i := len(p.Tiles) - 1; i >= 0; i-- {
+			if !p.IsKuikaeForbidden(p.Tiles[i].Type) {
+				tile = p.Tiles[i]
+				break
+			}
+		}
 	}
 	if !p.DiscardTile(tile) {
 		return Tile{}, false
 	}
 	return tile, true
 }
+
+// checkPao 碰/大明杠后检查是否确定大三元、大四喜，确定时由放出该牌的玩家承担包牌责任
+func (p *PlayerImage) checkPao(from int) {
+	if p.PaoSeat >= 0 || len(p.Melds) == 0 {
+		return
+	}
+	last := p.Melds[len(p.Melds)-1]
+	if len(last.Tiles) == 0 {
+		return
+	}
+	tt := last.Tiles[0].Type
+	var lo, hi TileType
+	var need int
+	var yaku Yaku
+	switch {
+	case tt >= White && tt <= Red:
+		lo, hi, need, yaku = White, Red, 3, YakuDaisangen
+	case tt >= East && tt <= North:
+		lo, hi, need, yaku = East, North, 4, YakuDaisushi
+	default:
+		return
+	}
+	count := 0
+	for _, m := range p.Melds {
+		if m.Type == "Chi" || len(m.Tiles) == 0 {
+			continue
+		}
+		if t := m.Tiles[0].Type; t >= lo && t <= hi {
+			count++
+		}
+	}
+	if count == need {
+		p.PaoSeat = from
+		p.PaoYaku = yaku
+	}
+}
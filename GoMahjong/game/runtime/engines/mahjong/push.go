@@ -4,8 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"game/infrastructure/log"
-	"game/infrastructure/message/protocol"
 	"game/infrastructure/message/transfer"
+	"game/infrastructure/metrics"
 	"game/runtime/share"
 )
 
@@ -60,14 +60,19 @@ func (eg *RiichiMahjong4p) broadcastOperations(reactions map[int]*PlayerReaction
 			log.Warn("玩家 %d 没有 userID", seatIndex)
 			continue
 		}
-		data, err := json.Marshal(reaction.Operations)
-		if err != nil {
-			log.Warn("JSON序列化失败: %v", err)
-			continue
-		}
 
-		eg.dispatchPush([]string{userID}, transfer.GamePush, transfer.DispatchWaitReaction, data)
+		eg.dispatchDTO([]string{userID}, transfer.GamePush, transfer.DispatchWaitReaction, OperationListDTO(reaction.Operations))
+	}
+}
+
+// pushMainOperations 下发出牌阶段的可选操作（仅自己可见）
+func (eg *RiichiMahjong4p) pushMainOperations(seatIndex int, ops []*PlayerOperation) {
+	player := eg.Players[seatIndex]
+	if player == nil || player.UserID == "" {
+		log.Warn("pushMainOperations: 玩家 %d 没有 userID", seatIndex)
+		return
 	}
+	eg.dispatchDTO([]string{player.UserID}, transfer.GamePush, transfer.DispatchWaitMain, OperationListDTO(ops))
 }
 
 // broadcastRoundStart 推送回合开始（每个玩家收到不同的手牌）
@@ -87,6 +92,9 @@ func (eg *RiichiMahjong4p) broadcastRoundStart() {
 		RiichiSticks: eg.Situation.RiichiSticks,
 	}
 
+	// 同一次开局推送共用一个序号
+	eventSeq := eg.nextEventSeq()
+
 	// 为每个玩家推送（手牌内容不同）
 	for _, player := range eg.Players {
 		if player == nil || player.UserID == "" {
@@ -99,16 +107,28 @@ func (eg *RiichiMahjong4p) broadcastRoundStart() {
 			Situation:      situationDTO,
 			HandTiles:      make([]Tile, len(player.Tiles)),
 			CurrentTurn:    eg.TurnManager.GetCurrentPlayer(),
+			Seq:            eg.actionSeq,
+			EventSeq:       eventSeq,
 		}
 		copy(roundStart.HandTiles, player.Tiles)
 
-		data, err := json.Marshal(roundStart)
-		if err != nil {
-			log.Error("broadcastRoundStart: 序列化失败: %v", err)
-			continue
-		}
+		eg.dispatchDTO([]string{player.UserID}, transfer.GamePush, transfer.GameplayRoundStart, roundStart)
+	}
 
-		eg.dispatchPush([]string{player.UserID}, transfer.GamePush, transfer.GameplayRoundStart, data)
+	// 观战者看不到任何手牌
+	if len(eg.Spectators) > 0 {
+		spectators := make([]string, 0, len(eg.Spectators))
+		for userID := range eg.Spectators {
+			spectators = append(spectators, userID)
+		}
+		eg.dispatchDTO(spectators, transfer.GamePush, transfer.GameplayRoundStart, RoundStartDTO{
+			DoraIndicators: doraIndicators,
+			Situation:      situationDTO,
+			HandTiles:      []Tile{},
+			CurrentTurn:    eg.TurnManager.GetCurrentPlayer(),
+			Seq:            eg.actionSeq,
+			EventSeq:       eventSeq,
+		})
 	}
 
 	log.Info("broadcastRoundStart: 推送回合开始给所有玩家")
@@ -133,16 +153,12 @@ func (eg *RiichiMahjong4p) pushDrawTile(seatIndex int, tile Tile) {
 	}
 
 	drawTile := DrawTileDTO{
-		Tile: tile,
+		Tile:     tile,
+		Seq:      eg.actionSeq,
+		EventSeq: eg.nextEventSeq(),
 	}
 
-	data, err := json.Marshal(drawTile)
-	if err != nil {
-		log.Error("pushDrawTile: 序列化失败: %v", err)
-		return
-	}
-
-	eg.dispatchPush([]string{userID}, transfer.GamePush, transfer.GameplayDraw, data)
+	eg.dispatchDTO([]string{userID}, transfer.GamePush, transfer.GameplayDraw, drawTile)
 	log.Info("pushDrawTile: 推送摸牌给玩家 %d, tile: %v", seatIndex, tile)
 }
 
@@ -156,24 +172,28 @@ func (eg *RiichiMahjong4p) broadcastDiscard(seatIndex int, tile Tile) {
 	discardTile := DiscardTileDTO{
 		SeatIndex: seatIndex,
 		Tile:      tile,
+		Seq:       eg.actionSeq,
+		EventSeq:  eg.nextEventSeq(),
 	}
 
-	data, err := json.Marshal(discardTile)
-	if err != nil {
-		log.Error("broadcastDiscard: 序列化失败: %v", err)
-		return
-	}
+	userIDs := eg.publicUserIDs()
 
-	// 收集所有玩家ID
-	userIDs := make([]string, 0, 4)
-	for _, player := range eg.Players {
-		if player != nil && player.UserID != "" {
-			userIDs = append(userIDs, player.UserID)
-		}
+	eg.dispatchDTO(userIDs, transfer.GamePush, transfer.GameplayDiscard, discardTile)
+	log.Info("broadcastDiscard: 广播出牌，玩家 %d 打出 %v", seatIndex, tile)
+}
+
+// broadcastNewDora 广播新翻开的杠宝牌指示牌（所有玩家可见）
+func (eg *RiichiMahjong4p) broadcastNewDora(indicator Tile) {
+	newDora := NewDoraDTO{
+		Indicator:      indicator,
+		DoraIndicators: append([]Tile(nil), eg.DeckManager.GetDoraIndicators()...),
+		EventSeq:       eg.nextEventSeq(),
 	}
 
-	eg.dispatchPush(userIDs, transfer.GamePush, transfer.GameplayDiscard, data)
-	log.Info("broadcastDiscard: 广播出牌，玩家 %d 打出 %v", seatIndex, tile)
+	userIDs := eg.publicUserIDs()
+
+	eg.dispatchDTO(userIDs, transfer.GamePush, transfer.GameplayNewDora, newDora)
+	log.Info("broadcastNewDora: 广播杠宝牌指示牌 %v", indicator)
 }
 
 // broadcastRiichi 广播立直（所有玩家可见）
@@ -185,23 +205,13 @@ func (eg *RiichiMahjong4p) broadcastRiichi(seatIndex int) {
 
 	riichi := RiichiDTO{
 		SeatIndex: seatIndex,
+		Double:    eg.Players[seatIndex] != nil && eg.Players[seatIndex].DoubleRiichi,
+		EventSeq:  eg.nextEventSeq(),
 	}
 
-	data, err := json.Marshal(riichi)
-	if err != nil {
-		log.Error("broadcastRiichi: 序列化失败: %v", err)
-		return
-	}
-
-	// 收集所有玩家ID
-	userIDs := make([]string, 0, 4)
-	for _, player := range eg.Players {
-		if player != nil && player.UserID != "" {
-			userIDs = append(userIDs, player.UserID)
-		}
-	}
+	userIDs := eg.publicUserIDs()
 
-	eg.dispatchPush(userIDs, transfer.GamePush, transfer.GameplayRiichi, data)
+	eg.dispatchDTO(userIDs, transfer.GamePush, transfer.GameplayRiichi, riichi)
 	log.Info("broadcastRiichi: 广播立直，玩家 %d 立直", seatIndex)
 }
 
@@ -228,21 +238,11 @@ func (eg *RiichiMahjong4p) broadcastMeldAction(actionType string, seatIndex, fro
 		SeatIndex:  seatIndex,
 		FromSeat:   fromSeat,
 		Tiles:      tiles,
+		Seq:        eg.actionSeq,
+		EventSeq:   eg.nextEventSeq(),
 	}
 
-	data, err := json.Marshal(meldAction)
-	if err != nil {
-		log.Error("broadcastMeldAction: 序列化失败: %v", err)
-		return
-	}
-
-	// 收集所有玩家ID
-	userIDs := make([]string, 0, 4)
-	for _, player := range eg.Players {
-		if player != nil && player.UserID != "" {
-			userIDs = append(userIDs, player.UserID)
-		}
-	}
+	userIDs := eg.publicUserIDs()
 
 	route := transfer.GameplayChi
 	switch actionType {
@@ -254,7 +254,7 @@ func (eg *RiichiMahjong4p) broadcastMeldAction(actionType string, seatIndex, fro
 		route = transfer.GameplayGang
 	}
 
-	eg.dispatchPush(userIDs, transfer.GamePush, route, data)
+	eg.dispatchDTO(userIDs, transfer.GamePush, route, meldAction)
 	log.Info("broadcastMeldAction: 广播鸣牌，玩家 %d %s，来自玩家 %d", seatIndex, actionType, fromSeat)
 }
 
@@ -274,24 +274,77 @@ func (eg *RiichiMahjong4p) broadcastAnkan(seatIndex int, tiles []Tile) {
 		SeatIndex:  seatIndex,
 		FromSeat:   -1, // -1 表示暗杠
 		Tiles:      tiles,
+		Seq:        eg.actionSeq,
+		EventSeq:   eg.nextEventSeq(),
 	}
 
-	data, err := json.Marshal(ankanAction)
-	if err != nil {
-		log.Error("broadcastAnkan: 序列化失败: %v", err)
+	userIDs := eg.publicUserIDs()
+
+	eg.dispatchDTO(userIDs, transfer.GamePush, transfer.GameplayAnkan, ankanAction)
+	log.Info("broadcastAnkan: 广播暗杠，玩家 %d 暗杠", seatIndex)
+}
+
+// broadcastKita 广播拔北（所有玩家可见）
+func (eg *RiichiMahjong4p) broadcastKita(seatIndex int, tile Tile) {
+	kitaAction := MeldActionDTO{
+		ActionType: "KITA",
+		SeatIndex:  seatIndex,
+		FromSeat:   -1,
+		Tiles:      []Tile{tile},
+		Seq:        eg.actionSeq,
+		EventSeq:   eg.nextEventSeq(),
+	}
+
+	userIDs := eg.publicUserIDs()
+
+	eg.dispatchDTO(userIDs, transfer.GamePush, transfer.GameplayKita, kitaAction)
+	log.Info("broadcastKita: 广播拔北，玩家 %d 拔北", seatIndex)
+}
+
+// pushScorePreview 推送试算结果（仅自己可见）
+func (eg *RiichiMahjong4p) pushScorePreview(seatIndex int, preview ScorePreviewDTO) {
+	player := eg.Players[seatIndex]
+	if player == nil || player.UserID == "" {
 		return
 	}
+	eg.dispatchDTO([]string{player.UserID}, transfer.GamePush, transfer.GameplayScorePreview, preview)
+}
 
-	// 收集所有玩家ID
-	userIDs := make([]string, 0, 4)
-	for _, player := range eg.Players {
-		if player != nil && player.UserID != "" {
-			userIDs = append(userIDs, player.UserID)
-		}
+// pushDiscardSuggestion 新手模式下推送打牌建议（仅自己可见），没有能听牌的打法时不推送
+func (eg *RiichiMahjong4p) pushDiscardSuggestion(seatIndex int) {
+	player := eg.Players[seatIndex]
+	if player == nil || player.UserID == "" {
+		return
+	}
+	candidates := eg.discardSuggestions(seatIndex)
+	if len(candidates) == 0 {
+		return
+	}
+	suggestion := DiscardSuggestionDTO{Candidates: make([]DiscardCandidateDTO, 0, len(candidates))}
+	for _, c := range candidates {
+		suggestion.Candidates = append(suggestion.Candidates, DiscardCandidateDTO{
+			Discard: pickPhysical(c.DiscardOptions, eg.DeckManager.IsAka),
+			Waits:   c.Waits,
+			Ukeire:  c.Ukeire,
+		})
 	}
+	eg.dispatchDTO([]string{player.UserID}, transfer.GamePush, transfer.GameplayDiscardSuggestion, suggestion)
+}
 
-	eg.dispatchPush(userIDs, transfer.GamePush, transfer.GameplayAnkan, data)
-	log.Info("broadcastAnkan: 广播暗杠，玩家 %d 暗杠", seatIndex)
+// pushWaits 推送玩家当前 13 张手牌（副露算作固定面子）听的牌，仅自己可见
+// 立直宣言牌打出后、立直中暗杠后推送；新手模式下每次打牌后推送，未听牌时 Waits 为空
+func (eg *RiichiMahjong4p) pushWaits(seatIndex int) {
+	player := eg.Players[seatIndex]
+	if player == nil || player.UserID == "" || eg.isBotSeat(seatIndex) || eg.Searcher == nil {
+		return
+	}
+	if len(player.Tiles)%3 != 1 {
+		return
+	}
+	h13, _ := Hand34FromTiles(player.Tiles)
+	visible := eg.visibleTiles()
+	waits, ukeire := eg.Searcher.WaitsAndUkeire(h13, len(player.Melds), &visible)
+	eg.dispatchDTO([]string{player.UserID}, transfer.GamePush, transfer.GameplayWaits, WaitsDTO{Waits: waits, Ukeire: ukeire})
 }
 
 // broadcastKakan 广播加杠（所有玩家可见）
@@ -310,23 +363,13 @@ func (eg *RiichiMahjong4p) broadcastKakan(seatIndex, fromSeat int, tiles []Tile)
 		SeatIndex:  seatIndex,
 		FromSeat:   fromSeat, // 原碰的 From（表示来自哪个玩家）
 		Tiles:      tiles,
+		Seq:        eg.actionSeq,
+		EventSeq:   eg.nextEventSeq(),
 	}
 
-	data, err := json.Marshal(kakanAction)
-	if err != nil {
-		log.Error("broadcastKakan: 序列化失败: %v", err)
-		return
-	}
-
-	// 收集所有玩家ID
-	userIDs := make([]string, 0, 4)
-	for _, player := range eg.Players {
-		if player != nil && player.UserID != "" {
-			userIDs = append(userIDs, player.UserID)
-		}
-	}
+	userIDs := eg.publicUserIDs()
 
-	eg.dispatchPush(userIDs, transfer.GamePush, transfer.GameplayKakan, data)
+	eg.dispatchDTO(userIDs, transfer.GamePush, transfer.GameplayKakan, kakanAction)
 	log.Info("broadcastKakan: 广播加杠，玩家 %d 加杠，原碰来自玩家 %d", seatIndex, fromSeat)
 }
 
@@ -341,23 +384,12 @@ func (eg *RiichiMahjong4p) broadcastRon(winnerSeat, loserSeat int, winTile Tile)
 		WinnerSeat: winnerSeat,
 		LoserSeat:  loserSeat,
 		WinTile:    winTile,
+		EventSeq:   eg.nextEventSeq(),
 	}
 
-	data, err := json.Marshal(ron)
-	if err != nil {
-		log.Error("broadcastRon: 序列化失败: %v", err)
-		return
-	}
-
-	// 收集所有玩家ID
-	userIDs := make([]string, 0, 4)
-	for _, player := range eg.Players {
-		if player != nil && player.UserID != "" {
-			userIDs = append(userIDs, player.UserID)
-		}
-	}
+	userIDs := eg.publicUserIDs()
 
-	eg.dispatchPush(userIDs, transfer.GamePush, transfer.GameplayRon, data)
+	eg.dispatchDTO(userIDs, transfer.GamePush, transfer.GameplayRon, ron)
 	log.Info("broadcastRon: 广播荣和，玩家 %d 荣和，放铳玩家 %d", winnerSeat, loserSeat)
 }
 
@@ -371,23 +403,12 @@ func (eg *RiichiMahjong4p) broadcastTsumo(winnerSeat int, winTile Tile) {
 	tsumo := TsumoDTO{
 		WinnerSeat: winnerSeat,
 		WinTile:    winTile,
+		EventSeq:   eg.nextEventSeq(),
 	}
 
-	data, err := json.Marshal(tsumo)
-	if err != nil {
-		log.Error("broadcastTsumo: 序列化失败: %v", err)
-		return
-	}
+	userIDs := eg.publicUserIDs()
 
-	// 收集所有玩家ID
-	userIDs := make([]string, 0, 4)
-	for _, player := range eg.Players {
-		if player != nil && player.UserID != "" {
-			userIDs = append(userIDs, player.UserID)
-		}
-	}
-
-	eg.dispatchPush(userIDs, transfer.GamePush, transfer.GameplayTsumo, data)
+	eg.dispatchDTO(userIDs, transfer.GamePush, transfer.GameplayTsumo, tsumo)
 	log.Info("broadcastTsumo: 广播自摸，玩家 %d 自摸", winnerSeat)
 }
 
@@ -405,6 +426,9 @@ func (eg *RiichiMahjong4p) broadcastRoundEnd(endType string, claims []HuClaimDTO
 	if eg.Persister != nil {
 		eg.Persister.CompleteRound(endType, claims, delta, points, reason, nextDealer)
 	}
+	if eg.Worker != nil {
+		metrics.RoundEnded(roundEndKind(endType))
+	}
 
 	roundEnd := RoundEndDTO{
 		EndType:    endType,
@@ -412,27 +436,30 @@ func (eg *RiichiMahjong4p) broadcastRoundEnd(endType string, claims []HuClaimDTO
 		Delta:      delta,
 		Points:     points,
 		Reason:     reason,
+		DrawReason: drawReasonOf(endType),
 		NextDealer: nextDealer,
+		EventSeq:   eg.nextEventSeq(),
 	}
 
-	data, err := json.Marshal(roundEnd)
-	if err != nil {
-		log.Error("broadcastRoundEnd: 序列化失败: %v", err)
-		return
-	}
-
-	// 收集所有玩家ID
-	userIDs := make([]string, 0, 4)
-	for _, player := range eg.Players {
-		if player != nil && player.UserID != "" {
-			userIDs = append(userIDs, player.UserID)
-		}
-	}
+	userIDs := eg.publicUserIDs()
 
-	eg.dispatchPush(userIDs, transfer.GamePush, transfer.GameplayRoundEnd, data)
+	eg.dispatchDTO(userIDs, transfer.GamePush, transfer.GameplayRoundEnd, roundEnd)
 	log.Info("broadcastRoundEnd: 广播回合结束，类型: %s", endType)
 }
 
+// roundEndKind 回合结束类型在指标中的分类，各种流局合为一类
+func roundEndKind(endType string) string {
+	switch endType {
+	case RoundEndRon:
+		return metrics.RoundRon
+	case RoundEndTsumo:
+		return metrics.RoundTsumo
+	case RoundEndChombo:
+		return metrics.RoundChombo
+	}
+	return metrics.RoundDraw
+}
+
 // broadcastGameEnd 广播游戏结束
 func (eg *RiichiMahjong4p) broadcastGameEnd() {
 	// 计算排名
@@ -488,23 +515,12 @@ func (eg *RiichiMahjong4p) broadcastGameEnd() {
 
 	gameEnd := GameEndDTO{
 		FinalRanking: rankings,
+		EventSeq:     eg.nextEventSeq(),
 	}
 
-	data, err := json.Marshal(gameEnd)
-	if err != nil {
-		log.Error("broadcastGameEnd: 序列化失败: %v", err)
-		return
-	}
+	userIDs := eg.publicUserIDs()
 
-	// 收集所有玩家ID
-	userIDs := make([]string, 0, 4)
-	for _, player := range eg.Players {
-		if player != nil && player.UserID != "" {
-			userIDs = append(userIDs, player.UserID)
-		}
-	}
-
-	eg.dispatchPush(userIDs, transfer.GamePush, transfer.GameplayGameEnd, data)
+	eg.dispatchDTO(userIDs, transfer.GamePush, transfer.GameplayGameEnd, gameEnd)
 	log.Info("broadcastGameEnd: 广播游戏结束")
 }
 
@@ -518,59 +534,170 @@ func (eg *RiichiMahjong4p) broadcastStateUpdate() {
 		}
 	}
 
-	// 构建场况信息
-	situationDTO := SituationDTO{
-		DealerIndex:  eg.Situation.DealerIndex,
-		RoundWind:    eg.Situation.RoundWind.String(),
-		RoundNumber:  eg.Situation.RoundNumber,
-		Honba:        eg.Situation.Honba,
-		RiichiSticks: eg.Situation.RiichiSticks,
+	stateUpdate := GameStateUpdateDTO{
+		Situation:   eg.buildSituationDTO(),
+		CurrentTurn: eg.TurnManager.GetCurrentPlayer(),
+		TurnState:   eg.turnStateString(),
+		Points:      points,
+		EventSeq:    eg.nextEventSeq(),
+	}
+
+	userIDs := eg.publicUserIDs()
+
+	eg.dispatchDTO(userIDs, transfer.GamePush, transfer.GameplayStateUpdate, stateUpdate)
+	log.Info("broadcastStateUpdate: 广播状态更新")
+}
+
+// pushReconnectSnapshot 断线重连时下发该玩家可见的状态快照
+func (eg *RiichiMahjong4p) pushReconnectSnapshot(seatIndex int) {
+	player := eg.Players[seatIndex]
+	if player == nil || player.UserID == "" {
+		log.Warn("pushReconnectSnapshot: 玩家 %d 不存在", seatIndex)
+		return
+	}
+	snapshot := eg.buildPlayerSnapshot(seatIndex)
+	if snapshot == nil {
+		return
 	}
+	eg.dispatchDTO([]string{player.UserID}, transfer.GamePush, transfer.GameplayReconnectSnapshot, snapshot)
+	log.Info("pushReconnectSnapshot: 推送状态快照给玩家 %d", seatIndex)
+}
+
+// buildPlayerSnapshot 构建某个玩家可见的状态快照：自己的完整手牌，其他玩家只给副露/牌河/立直状态
+func (eg *RiichiMahjong4p) buildPlayerSnapshot(seatIndex int) *GameStateSnapshotDTO {
+	if seatIndex < 0 || seatIndex >= 4 || eg.Players[seatIndex] == nil {
+		return nil
+	}
+	snapshot := eg.buildPublicSnapshot()
+	if snapshot == nil {
+		return nil
+	}
+	snapshot.SeatIndex = seatIndex
+	snapshot.HandTiles = append([]Tile(nil), eg.Players[seatIndex].Tiles...)
 
-	// 获取回合状态字符串
-	turnStateStr := "idle"
+	// 该玩家当前待响应的操作
 	switch eg.TurnManager.GetState() {
-	case TurnStateWaitMain:
-		turnStateStr = "waitMain"
-	case TurnStateSelecting:
-		turnStateStr = "selecting"
 	case TurnStateWaitReactions:
-		turnStateStr = "waitReactions"
-	case TurnStateApplyOperation:
-		turnStateStr = "applyOperation"
+		if reaction, ok := eg.Reactions[seatIndex]; ok && !reaction.Responded {
+			snapshot.Operations = reaction.Operations
+		}
+	case TurnStateWaitMain:
+		if seatIndex == eg.TurnManager.GetCurrentPlayer() && eg.canKyuushuu(seatIndex) {
+			snapshot.Operations = []*PlayerOperation{{Type: "KYUUSHUU", Tiles: []Tile{}}}
+		}
 	}
+	return snapshot
+}
 
-	stateUpdate := GameStateUpdateDTO{
-		Situation:   situationDTO,
+// buildPublicSnapshot 构建所有人可见的状态快照：不含任何手牌，座位为 -1
+func (eg *RiichiMahjong4p) buildPublicSnapshot() *GameStateSnapshotDTO {
+	if eg.TurnManager == nil {
+		return nil
+	}
+	snapshot := &GameStateSnapshotDTO{
+		SeatIndex:   -1,
+		HandTiles:   []Tile{},
+		Situation:   eg.buildSituationDTO(),
 		CurrentTurn: eg.TurnManager.GetCurrentPlayer(),
-		TurnState:   turnStateStr,
-		Points:      points,
+		TurnState:   eg.turnStateString(),
+		Seq:         eg.actionSeq,
+		EventSeq:    eg.eventSeq,
+	}
+	if eg.DeckManager != nil {
+		snapshot.DoraIndicators = append([]Tile(nil), eg.DeckManager.GetDoraIndicators()...)
 	}
+	for i, p := range eg.Players {
+		if p == nil {
+			continue
+		}
+		snapshot.Players[i] = PlayerSnapshotDTO{
+			SeatIndex:   i,
+			UserID:      p.UserID,
+			Points:      p.Points,
+			HandCount:   len(p.Tiles),
+			Melds:       append([]Meld(nil), p.Melds...),
+			DiscardPile: append([]Tile(nil), p.DiscardPile...),
+			IsRiichi:    p.IsRiichi,
+		}
+	}
+	return snapshot
+}
 
-	data, err := json.Marshal(stateUpdate)
-	if err != nil {
-		log.Error("broadcastStateUpdate: 序列化失败: %v", err)
+// pushSpectateSnapshot 观战者加入时下发公开快照，开局前加入的等下一次回合开始推送
+func (eg *RiichiMahjong4p) pushSpectateSnapshot(userID string) {
+	snapshot := eg.buildPublicSnapshot()
+	if snapshot == nil {
 		return
 	}
+	eg.dispatchDTO([]string{userID}, transfer.GamePush, transfer.GameplaySpectateSnapshot, snapshot)
+	log.Info("pushSpectateSnapshot: 推送公开快照给观战者 %s", userID)
+}
 
-	// 收集所有玩家ID
-	userIDs := make([]string, 0, 4)
+// publicUserIDs 公开推送的接收者：入座的玩家和观战者
+func (eg *RiichiMahjong4p) publicUserIDs() []string {
+	userIDs := make([]string, 0, 4+len(eg.Spectators))
 	for _, player := range eg.Players {
 		if player != nil && player.UserID != "" {
 			userIDs = append(userIDs, player.UserID)
 		}
 	}
+	for userID := range eg.Spectators {
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs
+}
 
-	eg.dispatchPush(userIDs, transfer.GamePush, transfer.GameplayStateUpdate, data)
-	log.Info("broadcastStateUpdate: 广播状态更新")
+// recipient 推送接收者信息，先查玩家再查观战者
+func (eg *RiichiMahjong4p) recipient(userID string) (*share.UserInfo, bool) {
+	if userInfo, ok := eg.UserMap[userID]; ok {
+		return userInfo, true
+	}
+	userInfo, ok := eg.Spectators[userID]
+	return userInfo, ok
+}
+
+// buildSituationDTO 构建场况信息
+func (eg *RiichiMahjong4p) buildSituationDTO() SituationDTO {
+	return SituationDTO{
+		DealerIndex:  eg.Situation.DealerIndex,
+		RoundWind:    eg.Situation.RoundWind.String(),
+		RoundNumber:  eg.Situation.RoundNumber,
+		Honba:        eg.Situation.Honba,
+		RiichiSticks: eg.Situation.RiichiSticks,
+	}
+}
+
+// turnStateString 获取回合状态字符串
+func (eg *RiichiMahjong4p) turnStateString() string {
+	switch eg.TurnManager.GetState() {
+	case TurnStateWaitMain:
+		return "waitMain"
+	case TurnStateSelecting:
+		return "selecting"
+	case TurnStateWaitReactions:
+		return "waitReactions"
+	case TurnStateApplyOperation:
+		return "applyOperation"
+	}
+	return "idle"
 }
 
-// convertHuClaimToDTOWithFanFu 将 HuClaim 转换为 HuClaimDTO（使用已计算的番符和役列表）
-func (eg *RiichiMahjong4p) convertHuClaimToDTOWithFanFu(claim HuClaim, endKind string, han int, fu int, points int, yakus []Yaku) HuClaimDTO {
-	// 将 Yaku 转换为字符串（简化版，使用数字表示）
-	yakuStrs := make([]string, 0, len(yakus))
+// convertHuClaimToDTOWithFanFu 将 HuClaim 转换为 HuClaimDTO（使用已计算的番符、役列表和计分采用的拆解）
+func (eg *RiichiMahjong4p) convertHuClaimToDTOWithFanFu(claim HuClaim, endKind string, han int, fu int, points int, yakus []Yaku, div *HandDivision) HuClaimDTO {
+	yakuStrs := make([]string, 0, len(yakus)+3)
 	for _, yaku := range yakus {
-		yakuStrs = append(yakuStrs, fmt.Sprintf("Yaku%d", int(yaku)))
+		yakuStrs = append(yakuStrs, yaku.String())
+	}
+	// 宝牌类不是役，单独列出张数
+	dora, ura, aka := eg.countClaimDora(claim)
+	if dora > 0 {
+		yakuStrs = append(yakuStrs, fmt.Sprintf("Dora x%d", dora))
+	}
+	if ura > 0 {
+		yakuStrs = append(yakuStrs, fmt.Sprintf("UraDora x%d", ura))
+	}
+	if aka > 0 {
+		yakuStrs = append(yakuStrs, fmt.Sprintf("AkaDora x%d", aka))
 	}
 
 	return HuClaimDTO{
@@ -581,6 +708,8 @@ func (eg *RiichiMahjong4p) convertHuClaimToDTOWithFanFu(claim HuClaim, endKind s
 		Fu:         fu,
 		Yaku:       yakuStrs,
 		Points:     points,
+		Hand:       eg.winningHandSets(claim, div),
+		Wait:       waitName(div),
 	}
 }
 
@@ -596,12 +725,15 @@ func (eg *RiichiMahjong4p) dispatchPush(users []string, connectorRoute, clientRo
 		if userID == "" {
 			continue
 		}
-		// 从 UserMap 获取 connector 信息（无需加锁，因为 UserMap 在 actor 线程中）
-		userInfo, exists := eg.UserMap[userID]
+		// 从 UserMap/观战者获取 connector 信息（无需加锁，因为都在 actor 线程中）
+		userInfo, exists := eg.recipient(userID)
 		if !exists {
 			log.Warn("dispatchPush: 用户 %s 不在 UserMap 中", userID)
 			continue
 		}
+		if userInfo.IsBot {
+			continue
+		}
 		connectorNodeID := userInfo.ConnectorNodeID
 		if connectorNodeID == "" {
 			log.Warn("dispatchPush: 用户 %s 没有 connector 信息", userID)
@@ -611,23 +743,10 @@ func (eg *RiichiMahjong4p) dispatchPush(users []string, connectorRoute, clientRo
 	}
 
 	for connectorNodeID, userIDs := range connectorGroups {
-		packet := &transfer.ServicePacket{
-			Source:      eg.Worker.NodeID,
-			Destination: connectorNodeID,
-			Route:       connectorRoute, // 服务间路由
-			PushUser:    userIDs,        // 该 connector 下的所有用户
-			Body: &protocol.Message{
-				Type:  protocol.Push,
-				Route: clientRoute, // 客户端路由
-				Data:  data,
-			},
-		}
-		err := eg.Worker.PushMessage(packet)
-		if err != nil {
-			log.Warn("dispatchPush: 推送给 connector %s 失败: %v, users: %v", connectorNodeID, err, userIDs)
+		if eg.addToPushBatch(connectorNodeID, connectorRoute, clientRoute, userIDs, data) {
 			continue
 		}
-		log.Info("dispatchPush: 推送给 connector %s, users: %v, route: %s", connectorNodeID, userIDs, clientRoute)
+		eg.sendPushPacket(newPushPacket(eg.Worker.NodeID, connectorNodeID, connectorRoute, clientRoute, userIDs, data))
 	}
 }
 
@@ -639,6 +758,8 @@ type RoundStartDTO struct {
 	Situation      SituationDTO `json:"situation"`      // 场况信息
 	HandTiles      []Tile       `json:"handTiles"`      // 自己的手牌（仅自己可见）
 	CurrentTurn    int          `json:"currentTurn"`    // 当前出牌玩家座位
+	Seq            int          `json:"seq"`            // 当前操作序号
+	EventSeq       int          `json:"eventSeq"`       // 推送序号，每次推送对局事件 +1，客户端据此排序、去重
 }
 
 // SituationDTO 场况信息
@@ -652,18 +773,31 @@ type SituationDTO struct {
 
 // DrawTileDTO 摸牌信息
 type DrawTileDTO struct {
-	Tile Tile `json:"tile"` // 摸到的牌
+	Tile     Tile `json:"tile"`     // 摸到的牌
+	Seq      int  `json:"seq"`      // 当前操作序号，出牌、立直等操作回传
+	EventSeq int  `json:"eventSeq"` // 推送序号
 }
 
 // DiscardTileDTO 出牌信息
 type DiscardTileDTO struct {
 	SeatIndex int  `json:"seatIndex"` // 出牌玩家座位
 	Tile      Tile `json:"tile"`      // 打出的牌
+	Seq       int  `json:"seq"`       // 当前操作序号，鸣牌、荣和等反应回传
+	EventSeq  int  `json:"eventSeq"`  // 推送序号
+}
+
+// NewDoraDTO 新翻开的杠宝牌指示牌
+type NewDoraDTO struct {
+	Indicator      Tile   `json:"indicator"`      // 新翻开的指示牌
+	DoraIndicators []Tile `json:"doraIndicators"` // 当前全部宝牌指示牌
+	EventSeq       int    `json:"eventSeq"`       // 推送序号
 }
 
 // RiichiDTO 立直信息
 type RiichiDTO struct {
-	SeatIndex int `json:"seatIndex"` // 立直玩家座位
+	SeatIndex int  `json:"seatIndex"` // 立直玩家座位
+	Double    bool `json:"double"`    // 是否为两立直
+	EventSeq  int  `json:"eventSeq"`  // 推送序号
 }
 
 // MeldActionDTO 鸣牌信息（吃、碰、明杠）
@@ -672,6 +806,8 @@ type MeldActionDTO struct {
 	SeatIndex  int    `json:"seatIndex"`  // 鸣牌玩家座位
 	FromSeat   int    `json:"fromSeat"`   // 来自哪个玩家
 	Tiles      []Tile `json:"tiles"`      // 副露的牌
+	Seq        int    `json:"seq"`        // 当前操作序号
+	EventSeq   int    `json:"eventSeq"`   // 推送序号
 }
 
 // RonDTO 荣和信息
@@ -679,12 +815,14 @@ type RonDTO struct {
 	WinnerSeat int  `json:"winnerSeat"` // 和牌玩家座位
 	LoserSeat  int  `json:"loserSeat"`  // 放铳玩家座位
 	WinTile    Tile `json:"winTile"`    // 和牌
+	EventSeq   int  `json:"eventSeq"`   // 推送序号
 }
 
 // TsumoDTO 自摸信息
 type TsumoDTO struct {
 	WinnerSeat int  `json:"winnerSeat"` // 和牌玩家座位
 	WinTile    Tile `json:"winTile"`    // 和牌
+	EventSeq   int  `json:"eventSeq"`   // 推送序号
 }
 
 // RoundEndDTO 回合结束信息
@@ -693,24 +831,64 @@ type RoundEndDTO struct {
 	Claims     []HuClaimDTO `json:"claims"`     // 和牌信息（如果有）
 	Delta      [4]int       `json:"delta"`      // 点数变化
 	Points     [4]int       `json:"points"`     // 当前点数
-	Reason     string       `json:"reason"`     // 流局原因（如果有）
+	Reason     string       `json:"reason"`     // 流局原因（如果有），仅用于展示
+	DrawReason DrawReason   `json:"drawReason"` // 流局原因代码，和牌时为 0
 	NextDealer int          `json:"nextDealer"` // 下一局庄家（-1表示游戏结束）
+	EventSeq   int          `json:"eventSeq"`   // 推送序号
 }
 
 // HuClaimDTO 和牌信息
 type HuClaimDTO struct {
-	WinnerSeat int      `json:"winnerSeat"` // 和牌玩家座位
-	LoserSeat  int      `json:"loserSeat"`  // 放铳玩家座位（荣和时有值）
-	WinTile    Tile     `json:"winTile"`    // 和牌
-	Han        int      `json:"han"`        // 番数
-	Fu         int      `json:"fu"`         // 符数
-	Yaku       []string `json:"yaku"`       // 役列表
-	Points     int      `json:"points"`     // 点数
+	WinnerSeat int          `json:"winnerSeat"` // 和牌玩家座位
+	LoserSeat  int          `json:"loserSeat"`  // 放铳玩家座位（荣和时有值）
+	WinTile    Tile         `json:"winTile"`    // 和牌
+	Han        int          `json:"han"`        // 番数
+	Fu         int          `json:"fu"`         // 符数
+	Yaku       []string     `json:"yaku"`       // 役列表
+	Points     int          `json:"points"`     // 点数
+	Hand       []HandSetDTO `json:"hand"`       // 计分采用的手牌拆解：手牌中的面子、雀头、副露
+	Wait       string       `json:"wait"`       // 听牌形式，国士无双为空
+}
+
+// HandSetDTO 和牌拆解中的一组牌
+type HandSetDTO struct {
+	Kind  string `json:"kind"`  // pair/shuntsu/koutsu/kantsu，国士无双整手牌为一组 kokushi
+	Tiles []Tile `json:"tiles"` // 组成该组的牌
+	Open  bool   `json:"open"`  // 副露，或荣和时由点到的牌补成的明刻
+}
+
+// ScorePreviewDTO 试算结果，点数为 0 表示不能和牌或无役
+type ScorePreviewDTO struct {
+	WinTile Tile     `json:"winTile"` // 假设的和牌
+	Tsumo   bool     `json:"tsumo"`   // 是否按自摸计算
+	Han     int      `json:"han"`     // 番数
+	Fu      int      `json:"fu"`      // 符数
+	Yaku    []string `json:"yaku"`    // 役列表（含宝牌张数）
+	Points  int      `json:"points"`  // 和牌所得点数（含本场）
+}
+
+// DiscardSuggestionDTO 打牌建议，按进张数从多到少排列
+type DiscardSuggestionDTO struct {
+	Candidates []DiscardCandidateDTO `json:"candidates"`
+}
+
+// DiscardCandidateDTO 打出 Discard 后听 Waits，剩余 Ukeire 张
+type DiscardCandidateDTO struct {
+	Discard Tile       `json:"discard"` // 建议打出的牌
+	Waits   []TileType `json:"waits"`   // 听的牌种
+	Ukeire  int        `json:"ukeire"`  // 进张数（扣除手牌和场上可见的牌）
+}
+
+// WaitsDTO 当前听的牌种，以及剩余进张数（扣除手牌和场上可见的牌）
+type WaitsDTO struct {
+	Waits  []TileType `json:"waits"`
+	Ukeire int        `json:"ukeire"`
 }
 
 // GameEndDTO 游戏结束信息
 type GameEndDTO struct {
 	FinalRanking [4]*PlayerRankingDTO `json:"finalRanking"` // 最终排名
+	EventSeq     int                  `json:"eventSeq"`     // 推送序号
 }
 
 // PlayerRankingDTO 玩家排名
@@ -721,10 +899,36 @@ type PlayerRankingDTO struct {
 	Rank      int    `json:"rank"`      // 排名 (1-4)
 }
 
+// GameStateSnapshotDTO 断线重连状态快照（仅发给重连的玩家）
+type GameStateSnapshotDTO struct {
+	SeatIndex      int                  `json:"seatIndex"`      // 自己的座位
+	HandTiles      []Tile               `json:"handTiles"`      // 自己的手牌
+	Players        [4]PlayerSnapshotDTO `json:"players"`        // 所有玩家的公开信息（不含手牌）
+	Situation      SituationDTO         `json:"situation"`      // 场况信息
+	DoraIndicators []Tile               `json:"doraIndicators"` // 宝牌指示牌
+	CurrentTurn    int                  `json:"currentTurn"`    // 当前出牌玩家座位
+	TurnState      string               `json:"turnState"`      // 回合状态
+	Operations     []*PlayerOperation   `json:"operations"`     // 当前等待该玩家选择的操作
+	Seq            int                  `json:"seq"`            // 当前操作序号
+	EventSeq       int                  `json:"eventSeq"`       // 快照时最后一次推送的序号，客户端丢弃不大于它的推送
+}
+
+// PlayerSnapshotDTO 玩家公开信息
+type PlayerSnapshotDTO struct {
+	SeatIndex   int    `json:"seatIndex"`   // 座位索引
+	UserID      string `json:"userId"`      // 用户ID
+	Points      int    `json:"points"`      // 当前点数
+	HandCount   int    `json:"handCount"`   // 手牌张数
+	Melds       []Meld `json:"melds"`       // 副露
+	DiscardPile []Tile `json:"discardPile"` // 牌河
+	IsRiichi    bool   `json:"isRiichi"`    // 是否立直
+}
+
 // GameStateUpdateDTO 游戏状态更新
 type GameStateUpdateDTO struct {
 	Situation   SituationDTO `json:"situation"`   // 场况信息
 	CurrentTurn int          `json:"currentTurn"` // 当前出牌玩家座位
 	TurnState   string       `json:"turnState"`   // 回合状态
 	Points      [4]int       `json:"points"`      // 当前点数
+	EventSeq    int          `json:"eventSeq"`    // 推送序号
 }
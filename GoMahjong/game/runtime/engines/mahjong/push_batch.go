@@ -0,0 +1,116 @@
+package mahjong
+
+import (
+	"encoding/json"
+	"game/infrastructure/log"
+	"game/infrastructure/message/protocol"
+	"game/infrastructure/message/transfer"
+)
+
+// pushBatch 一次事件处理期间发往各 connector 的推送，事件处理完后每个 connector 只发一个包
+// 只在 actor 中使用，无需加锁
+type pushBatch struct {
+	connectors []string                            // connector 首次出现的顺序，保证 flush 顺序稳定
+	items      map[string][]transfer.PushBatchItem // connectorNodeID -> 按推送顺序排列的消息
+}
+
+// beginPushBatch 开始合并推送，未开启 CoalescePush 或已在合并中时不做处理
+// 返回 true 表示由本次调用开启，调用方负责 flushPushBatch
+func (eg *RiichiMahjong4p) beginPushBatch() bool {
+	if !eg.Rules.CoalescePush || eg.pushBatch != nil {
+		return false
+	}
+	eg.pushBatch = &pushBatch{items: make(map[string][]transfer.PushBatchItem)}
+	return true
+}
+
+// addToPushBatch 合并中时暂存推送，返回 false 表示需要立即发送
+// 只合并对局推送：匹配成功消息在 actor 之外发送，先判断路由，不读取 pushBatch
+func (eg *RiichiMahjong4p) addToPushBatch(connectorNodeID, connectorRoute, clientRoute string, userIDs []string, data []byte) bool {
+	if connectorRoute != transfer.GamePush {
+		return false
+	}
+	batch := eg.pushBatch
+	if batch == nil {
+		return false
+	}
+	if _, exists := batch.items[connectorNodeID]; !exists {
+		batch.connectors = append(batch.connectors, connectorNodeID)
+	}
+	batch.items[connectorNodeID] = append(batch.items[connectorNodeID], transfer.PushBatchItem{
+		Users: userIDs,
+		Route: clientRoute,
+		Data:  data,
+	})
+	return true
+}
+
+// flushPushBatch 结束合并并发送暂存的推送
+func (eg *RiichiMahjong4p) flushPushBatch() {
+	batch := eg.pushBatch
+	eg.pushBatch = nil
+	if batch == nil || eg.Worker == nil {
+		return
+	}
+	for _, packet := range batch.packets(eg.Worker.NodeID) {
+		eg.sendPushPacket(packet)
+	}
+}
+
+// packets 每个 connector 一个包：只有一条消息的 connector 按普通推送发送，多条时合并为 GamePushBatch
+func (batch *pushBatch) packets(source string) []*transfer.ServicePacket {
+	packets := make([]*transfer.ServicePacket, 0, len(batch.connectors))
+	for _, connectorNodeID := range batch.connectors {
+		items := batch.items[connectorNodeID]
+		if len(items) == 1 {
+			packets = append(packets, newPushPacket(source, connectorNodeID, transfer.GamePush, items[0].Route, items[0].Users, items[0].Data))
+			continue
+		}
+		data, err := json.Marshal(items)
+		if err != nil {
+			log.Error("flushPushBatch: 序列化失败, connector: %s, err: %v", connectorNodeID, err)
+			continue
+		}
+		packets = append(packets, newPushPacket(source, connectorNodeID, transfer.GamePushBatch, transfer.GamePushBatch, batchUsers(items), data))
+	}
+	return packets
+}
+
+// newPushPacket 构造发给 connector 的推送包
+func newPushPacket(source, connectorNodeID, connectorRoute, clientRoute string, userIDs []string, data []byte) *transfer.ServicePacket {
+	return &transfer.ServicePacket{
+		Source:      source,
+		Destination: connectorNodeID,
+		Route:       connectorRoute, // 服务间路由
+		PushUser:    userIDs,        // 该 connector 下的所有用户
+		Body: &protocol.Message{
+			Type:  protocol.Push,
+			Route: clientRoute, // 客户端路由
+			Data:  data,
+		},
+	}
+}
+
+// sendPushPacket 发送一个推送包给 connector
+func (eg *RiichiMahjong4p) sendPushPacket(packet *transfer.ServicePacket) {
+	if err := eg.Worker.PushMessage(packet); err != nil {
+		log.Warn("dispatchPush: 推送给 connector %s 失败: %v, users: %v", packet.Destination, err, packet.PushUser)
+		return
+	}
+	log.Info("dispatchPush: 推送给 connector %s, users: %v, route: %s", packet.Destination, packet.PushUser, packet.Body.Route)
+}
+
+// batchUsers 合并包涉及的所有用户（去重，保持首次出现顺序）
+func batchUsers(items []transfer.PushBatchItem) []string {
+	seen := make(map[string]bool)
+	var users []string
+	for _, item := range items {
+		for _, userID := range item.Users {
+			if !seen[userID] {
+				seen[userID] = true
+				users = append(users, userID)
+			}
+		}
+	}
+	return users
+}
@@ -0,0 +1,281 @@
+package mahjong
+
+import "google.golang.org/protobuf/encoding/protowire"
+
+// 推送 DTO 的 protobuf 编码，字段编号与 game/api/gameplay.proto 保持一致
+// proto3 规则：标量零值不写，repeated int32 使用 packed 编码
+
+func appendInt(b []byte, num protowire.Number, v int) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(int64(int32(v))))
+}
+
+func appendBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, 1)
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+// appendMessage 写入子消息（子消息为空也要写，用于区分 repeated 中的位置）
+func appendMessage(b []byte, num protowire.Number, m protoMessage) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, m.appendProto(nil))
+}
+
+func appendPackedInts(b []byte, num protowire.Number, vs []int) []byte {
+	var packed []byte
+	for _, v := range vs {
+		packed = protowire.AppendVarint(packed, uint64(int64(int32(v))))
+	}
+	if len(packed) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, packed)
+}
+
+func appendTiles(b []byte, num protowire.Number, tiles []Tile) []byte {
+	for _, t := range tiles {
+		b = appendMessage(b, num, t)
+	}
+	return b
+}
+
+func (t Tile) appendProto(b []byte) []byte {
+	b = appendInt(b, 1, int(t.Type))
+	return appendInt(b, 2, t.ID)
+}
+
+func (m Meld) appendProto(b []byte) []byte {
+	b = appendString(b, 1, m.Type)
+	b = appendTiles(b, 2, m.Tiles)
+	return appendInt(b, 3, m.From)
+}
+
+func (op *PlayerOperation) appendProto(b []byte) []byte {
+	if op == nil {
+		return b
+	}
+	b = appendString(b, 1, op.Type)
+	return appendTiles(b, 2, op.Tiles)
+}
+
+func appendOperations(b []byte, num protowire.Number, ops []*PlayerOperation) []byte {
+	for _, op := range ops {
+		b = appendMessage(b, num, op)
+	}
+	return b
+}
+
+// OperationListDTO 可选操作列表（JSON 下就是数组）
+type OperationListDTO []*PlayerOperation
+
+func (l OperationListDTO) appendProto(b []byte) []byte {
+	return appendOperations(b, 1, l)
+}
+
+func (d SituationDTO) appendProto(b []byte) []byte {
+	b = appendInt(b, 1, d.DealerIndex)
+	b = appendString(b, 2, d.RoundWind)
+	b = appendInt(b, 3, d.RoundNumber)
+	b = appendInt(b, 4, d.Honba)
+	return appendInt(b, 5, d.RiichiSticks)
+}
+
+func (d RoundStartDTO) appendProto(b []byte) []byte {
+	b = appendTiles(b, 1, d.DoraIndicators)
+	b = appendMessage(b, 2, d.Situation)
+	b = appendTiles(b, 3, d.HandTiles)
+	b = appendInt(b, 4, d.CurrentTurn)
+	b = appendInt(b, 5, d.Seq)
+	return appendInt(b, 6, d.EventSeq)
+}
+
+func (d DrawTileDTO) appendProto(b []byte) []byte {
+	b = appendMessage(b, 1, d.Tile)
+	b = appendInt(b, 2, d.Seq)
+	return appendInt(b, 3, d.EventSeq)
+}
+
+func (d DiscardTileDTO) appendProto(b []byte) []byte {
+	b = appendInt(b, 1, d.SeatIndex)
+	b = appendMessage(b, 2, d.Tile)
+	b = appendInt(b, 3, d.Seq)
+	return appendInt(b, 4, d.EventSeq)
+}
+
+func (d NewDoraDTO) appendProto(b []byte) []byte {
+	b = appendMessage(b, 1, d.Indicator)
+	b = appendTiles(b, 2, d.DoraIndicators)
+	return appendInt(b, 3, d.EventSeq)
+}
+
+func (d RiichiDTO) appendProto(b []byte) []byte {
+	b = appendInt(b, 1, d.SeatIndex)
+	b = appendBool(b, 2, d.Double)
+	return appendInt(b, 3, d.EventSeq)
+}
+
+func (d MeldActionDTO) appendProto(b []byte) []byte {
+	b = appendString(b, 1, d.ActionType)
+	b = appendInt(b, 2, d.SeatIndex)
+	b = appendInt(b, 3, d.FromSeat)
+	b = appendTiles(b, 4, d.Tiles)
+	b = appendInt(b, 5, d.Seq)
+	return appendInt(b, 6, d.EventSeq)
+}
+
+func (d RonDTO) appendProto(b []byte) []byte {
+	b = appendInt(b, 1, d.WinnerSeat)
+	b = appendInt(b, 2, d.LoserSeat)
+	b = appendMessage(b, 3, d.WinTile)
+	return appendInt(b, 4, d.EventSeq)
+}
+
+func (d TsumoDTO) appendProto(b []byte) []byte {
+	b = appendInt(b, 1, d.WinnerSeat)
+	b = appendMessage(b, 2, d.WinTile)
+	return appendInt(b, 3, d.EventSeq)
+}
+
+func (d HuClaimDTO) appendProto(b []byte) []byte {
+	b = appendInt(b, 1, d.WinnerSeat)
+	b = appendInt(b, 2, d.LoserSeat)
+	b = appendMessage(b, 3, d.WinTile)
+	b = appendInt(b, 4, d.Han)
+	b = appendInt(b, 5, d.Fu)
+	for _, y := range d.Yaku {
+		b = protowire.AppendTag(b, 6, protowire.BytesType)
+		b = protowire.AppendString(b, y)
+	}
+	b = appendInt(b, 7, d.Points)
+	for _, set := range d.Hand {
+		b = appendMessage(b, 8, set)
+	}
+	return appendString(b, 9, d.Wait)
+}
+
+func (d HandSetDTO) appendProto(b []byte) []byte {
+	b = appendString(b, 1, d.Kind)
+	b = appendTiles(b, 2, d.Tiles)
+	return appendBool(b, 3, d.Open)
+}
+
+func (d ScorePreviewDTO) appendProto(b []byte) []byte {
+	b = appendMessage(b, 1, d.WinTile)
+	b = appendBool(b, 2, d.Tsumo)
+	b = appendInt(b, 3, d.Han)
+	b = appendInt(b, 4, d.Fu)
+	for _, y := range d.Yaku {
+		b = protowire.AppendTag(b, 5, protowire.BytesType)
+		b = protowire.AppendString(b, y)
+	}
+	return appendInt(b, 6, d.Points)
+}
+
+func (d DiscardCandidateDTO) appendProto(b []byte) []byte {
+	b = appendMessage(b, 1, d.Discard)
+	waits := make([]int, len(d.Waits))
+	for i, w := range d.Waits {
+		waits[i] = int(w)
+	}
+	b = appendPackedInts(b, 2, waits)
+	return appendInt(b, 3, d.Ukeire)
+}
+
+func (d DiscardSuggestionDTO) appendProto(b []byte) []byte {
+	for _, c := range d.Candidates {
+		b = appendMessage(b, 1, c)
+	}
+	return b
+}
+
+func (d WaitsDTO) appendProto(b []byte) []byte {
+	waits := make([]int, len(d.Waits))
+	for i, w := range d.Waits {
+		waits[i] = int(w)
+	}
+	b = appendPackedInts(b, 1, waits)
+	return appendInt(b, 2, d.Ukeire)
+}
+
+func (d RoundEndDTO) appendProto(b []byte) []byte {
+	b = appendString(b, 1, d.EndType)
+	for _, c := range d.Claims {
+		b = appendMessage(b, 2, c)
+	}
+	b = appendPackedInts(b, 3, d.Delta[:])
+	b = appendPackedInts(b, 4, d.Points[:])
+	b = appendString(b, 5, d.Reason)
+	b = appendInt(b, 6, d.NextDealer)
+	b = appendInt(b, 7, int(d.DrawReason))
+	return appendInt(b, 8, d.EventSeq)
+}
+
+func (d *PlayerRankingDTO) appendProto(b []byte) []byte {
+	if d == nil {
+		return b
+	}
+	b = appendInt(b, 1, d.SeatIndex)
+	b = appendString(b, 2, d.UserID)
+	b = appendInt(b, 3, d.Points)
+	return appendInt(b, 4, d.Rank)
+}
+
+func (d GameEndDTO) appendProto(b []byte) []byte {
+	for _, r := range d.FinalRanking {
+		b = appendMessage(b, 1, r)
+	}
+	return appendInt(b, 2, d.EventSeq)
+}
+
+func (d PlayerSnapshotDTO) appendProto(b []byte) []byte {
+	b = appendInt(b, 1, d.SeatIndex)
+	b = appendString(b, 2, d.UserID)
+	b = appendInt(b, 3, d.Points)
+	b = appendInt(b, 4, d.HandCount)
+	for _, m := range d.Melds {
+		b = appendMessage(b, 5, m)
+	}
+	b = appendTiles(b, 6, d.DiscardPile)
+	return appendBool(b, 7, d.IsRiichi)
+}
+
+func (d *GameStateSnapshotDTO) appendProto(b []byte) []byte {
+	if d == nil {
+		return b
+	}
+	b = appendInt(b, 1, d.SeatIndex)
+	b = appendTiles(b, 2, d.HandTiles)
+	for _, p := range d.Players {
+		b = appendMessage(b, 3, p)
+	}
+	b = appendMessage(b, 4, d.Situation)
+	b = appendTiles(b, 5, d.DoraIndicators)
+	b = appendInt(b, 6, d.CurrentTurn)
+	b = appendString(b, 7, d.TurnState)
+	b = appendOperations(b, 8, d.Operations)
+	b = appendInt(b, 9, d.Seq)
+	return appendInt(b, 10, d.EventSeq)
+}
+
+func (d GameStateUpdateDTO) appendProto(b []byte) []byte {
+	b = appendMessage(b, 1, d.Situation)
+	b = appendInt(b, 2, d.CurrentTurn)
+	b = appendString(b, 3, d.TurnState)
+	b = appendPackedInts(b, 4, d.Points[:])
+	return appendInt(b, 5, d.EventSeq)
+}
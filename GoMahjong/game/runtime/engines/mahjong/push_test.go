@@ -0,0 +1,405 @@
+package mahjong
+
+import (
+	"encoding/json"
+	"expvar"
+	"game/infrastructure/message/transfer"
+	"game/runtime"
+	"game/runtime/share"
+	"maps"
+	"slices"
+	"testing"
+)
+
+// 庄家打出 4s 后 2 号座位可以荣和：快照只含自己的手牌和待选操作，其他玩家只有张数和公开信息
+func TestBuildPlayerSnapshot(t *testing.T) {
+	eg := setupFuritenTable(t, false)
+	dropTile(t, eg, 0, Tile{Type: So4, ID: 1})
+
+	snapshot := eg.buildPlayerSnapshot(2)
+	if snapshot == nil || snapshot.SeatIndex != 2 || !slices.Equal(snapshot.HandTiles, eg.Players[2].Tiles) {
+		t.Fatalf("快照应包含自己的手牌, got %+v", snapshot)
+	}
+	if snapshot.TurnState != eg.turnStateString() || snapshot.CurrentTurn != 0 {
+		t.Fatalf("快照的回合状态不对, got %s %d", snapshot.TurnState, snapshot.CurrentTurn)
+	}
+	if !slices.ContainsFunc(snapshot.Operations, func(op *PlayerOperation) bool { return op.Type == "HU" }) {
+		t.Fatalf("快照应包含待选的荣和操作, got %+v", snapshot.Operations)
+	}
+	for seat, p := range snapshot.Players {
+		if p.HandCount != len(eg.Players[seat].Tiles) || !slices.Equal(p.DiscardPile, eg.Players[seat].DiscardPile) {
+			t.Fatalf("座位 %d 的公开信息不对, got %+v", seat, p)
+		}
+	}
+	if len(snapshot.DoraIndicators) != 1 {
+		t.Fatalf("应有 1 张宝牌指示牌, got %v", snapshot.DoraIndicators)
+	}
+
+	// 其他座位没有待选操作，公开快照不含手牌
+	if ops := eg.buildPlayerSnapshot(1).Operations; len(ops) != 0 {
+		t.Fatalf("座位 1 不应有待选操作, got %+v", ops)
+	}
+	if public := eg.buildPublicSnapshot(); public.SeatIndex != -1 || len(public.HandTiles) != 0 || len(public.Operations) != 0 {
+		t.Fatalf("公开快照不应包含手牌和操作, got %+v", public)
+	}
+}
+
+// capturePushes 开启推送合并且不 flush，测试直接读取暂存的推送
+func capturePushes(eg *RiichiMahjong4p) *pushBatch {
+	eg.pushBatch = &pushBatch{items: make(map[string][]transfer.PushBatchItem)}
+	return eg.pushBatch
+}
+
+// pushedTo 发往 connector 的推送，按客户端路由过滤
+func pushedTo(batch *pushBatch, connectorNodeID, route string) []transfer.PushBatchItem {
+	var items []transfer.PushBatchItem
+	for _, item := range batch.items[connectorNodeID] {
+		if item.Route == route {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// 观战者收到开局、出牌等公开推送，但看不到任何手牌和摸到的牌
+func TestSpectatorPushes(t *testing.T) {
+	eg := newTestEngine(t, noAkaRules())
+	player := eg.UserMap["b"]
+	player.IsBot, player.ConnectorNodeID = false, "conn-player"
+
+	batch := capturePushes(eg)
+	join := &share.SpectateEvent{ConnectorNodeID: "conn-spectator"}
+	join.UserID = "s"
+	eg.handleSpectateEvent(join)
+	seated := &share.SpectateEvent{ConnectorNodeID: "conn-spectator"}
+	seated.UserID = "a"
+	eg.handleSpectateEvent(seated)
+	if len(eg.Spectators) != 1 || eg.Spectators["s"] == nil {
+		t.Fatalf("入座的玩家不能观战自己的房间, spectators=%v", eg.Spectators)
+	}
+
+	snapshots := pushedTo(batch, "conn-spectator", transfer.GameplaySpectateSnapshot)
+	if len(snapshots) != 1 {
+		t.Fatalf("观战者加入时应收到一次公开快照, got %d", len(snapshots))
+	}
+	var snapshot GameStateSnapshotDTO
+	if err := json.Unmarshal(snapshots[0].Data, &snapshot); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if len(snapshot.HandTiles) != 0 || snapshot.Players[1].HandCount != len(eg.Players[1].Tiles) {
+		t.Fatalf("公开快照只应包含手牌张数, got %+v", snapshot)
+	}
+
+	eg.broadcastRoundStart()
+	var spectatorStart, playerStart RoundStartDTO
+	for _, tt := range []struct {
+		connector string
+		dto       *RoundStartDTO
+	}{{"conn-spectator", &spectatorStart}, {"conn-player", &playerStart}} {
+		items := pushedTo(batch, tt.connector, transfer.GameplayRoundStart)
+		if len(items) != 1 {
+			t.Fatalf("%s 应收到一次开局推送, got %d", tt.connector, len(items))
+		}
+		if err := json.Unmarshal(items[0].Data, tt.dto); err != nil {
+			t.Fatalf("json.Unmarshal: %v", err)
+		}
+	}
+	if len(spectatorStart.HandTiles) != 0 {
+		t.Fatalf("观战者不应收到手牌, got %v", spectatorStart.HandTiles)
+	}
+	if !slices.Equal(playerStart.HandTiles, eg.Players[1].Tiles) {
+		t.Fatalf("玩家应收到自己的手牌, got %v", playerStart.HandTiles)
+	}
+
+	// 摸牌只推送给自己，出牌推送给所有人
+	eg.pushDrawTile(1, Tile{Type: So4, ID: 1})
+	eg.broadcastDiscard(1, Tile{Type: So4, ID: 1})
+	if len(pushedTo(batch, "conn-spectator", transfer.GameplayDraw)) != 0 {
+		t.Fatalf("观战者不应收到摸牌推送")
+	}
+	if len(pushedTo(batch, "conn-player", transfer.GameplayDraw)) != 1 {
+		t.Fatalf("玩家应收到自己的摸牌推送")
+	}
+	discards := pushedTo(batch, "conn-spectator", transfer.GameplayDiscard)
+	if len(discards) != 1 {
+		t.Fatalf("观战者应收到出牌推送, got %d", len(discards))
+	}
+	var discard DiscardTileDTO
+	if err := json.Unmarshal(discards[0].Data, &discard); err != nil || discard.SeatIndex != 1 || discard.Tile != (Tile{Type: So4, ID: 1}) {
+		t.Fatalf("出牌推送错误: %+v err=%v", discard, err)
+	}
+
+	leave := &share.SpectateEvent{Leave: true}
+	leave.UserID = "s"
+	eg.handleSpectateEvent(leave)
+	eg.broadcastDiscard(2, Tile{Type: So5, ID: 1})
+	if len(pushedTo(batch, "conn-spectator", transfer.GameplayDiscard)) != 1 {
+		t.Fatalf("离开后不应再收到推送")
+	}
+}
+
+// 新手模式下轮到玩家出牌时推送打牌建议，关闭时不推送
+func TestDiscardSuggestionPush(t *testing.T) {
+	for _, beginner := range []bool{false, true} {
+		rules := noAkaRules()
+		rules.BeginnerMode = beginner
+		eg := newTestEngine(t, rules)
+		player := eg.UserMap["b"]
+		player.IsBot, player.ConnectorNodeID = false, "conn-player"
+		setHand(t, eg, 0, "13579m13579p4s246z")
+		setHand(t, eg, 1, "234m567m345p3456s")
+
+		batch := capturePushes(eg)
+		dropTile(t, eg, 0, Tile{Type: Man9, ID: 1})
+		passAll(eg)
+		items := pushedTo(batch, "conn-player", transfer.GameplayDiscardSuggestion)
+		if !beginner {
+			if len(items) != 0 {
+				t.Fatalf("未开启新手模式时不应推送打牌建议")
+			}
+			continue
+		}
+		if len(items) != 1 {
+			t.Fatalf("新手模式下应推送一次打牌建议, got %d", len(items))
+		}
+		var suggestion DiscardSuggestionDTO
+		if err := json.Unmarshal(items[0].Data, &suggestion); err != nil || len(suggestion.Candidates) == 0 {
+			t.Fatalf("打牌建议错误: %+v err=%v", suggestion, err)
+		}
+		want := eg.discardSuggestions(1)
+		if got := suggestion.Candidates[0]; got.Discard.Type != want[0].DiscardType || got.Ukeire != want[0].Ukeire {
+			t.Fatalf("推送的建议应与计算结果一致, got %+v want %+v", got, want[0])
+		}
+	}
+}
+
+// 每种流局推送对应的原因代码，和牌时为 DrawReasonNone
+func TestRoundEndDrawReason(t *testing.T) {
+	tests := []struct {
+		endType string
+		want    DrawReason
+	}{
+		{RoundEndDrawExhaustive, DrawReasonExhaustive},
+		{RoundEndDraw3Ron, DrawReason3Ron},
+		{RoundEndDraw4Kan, DrawReason4Kan},
+		{RoundEndKyuushuu, DrawReasonKyuushuu},
+		{RoundEndSuufon, DrawReasonSuufon},
+		{RoundEndRon, DrawReasonNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.endType, func(t *testing.T) {
+			eg := setupFuritenTable(t, false)
+			player := eg.UserMap["a"]
+			player.IsBot, player.ConnectorNodeID = false, "conn-player"
+			batch := capturePushes(eg)
+			if tt.endType == RoundEndRon {
+				dropTile(t, eg, 0, Tile{Type: So4, ID: 1})
+				eg.handleReactionHuEvent(&share.HuEvent{GameMessageEvent: eg.replayUser(2)})
+			} else {
+				eg.handleRoundOverEvent(nil, tt.endType)
+			}
+
+			items := pushedTo(batch, "conn-player", transfer.GameplayRoundEnd)
+			if len(items) != 1 {
+				t.Fatalf("应推送一次回合结束, got %d", len(items))
+			}
+			var end RoundEndDTO
+			if err := json.Unmarshal(items[0].Data, &end); err != nil {
+				t.Fatalf("json.Unmarshal: %v", err)
+			}
+			if end.EndType != tt.endType || end.DrawReason != tt.want {
+				t.Fatalf("endType=%s drawReason=%d, want %s %d", end.EndType, end.DrawReason, tt.endType, tt.want)
+			}
+		})
+	}
+}
+
+// 开局和一次打牌的推送按 connector 合并：每个 connector 一个包，拆包后每个玩家收到的消息与顺序不变
+func TestPushBatchPackets(t *testing.T) {
+	eg := newTestEngine(t, noAkaRules())
+	for userID, connector := range map[string]string{"a": "conn-1", "b": "conn-1", "c": "conn-2"} {
+		eg.UserMap[userID].IsBot, eg.UserMap[userID].ConnectorNodeID = false, connector
+	}
+	batch := capturePushes(eg)
+	eg.broadcastRoundStart()
+	dropTile(t, eg, 0, eg.Players[0].Tiles[0])
+
+	// 不合并时每条推送按 connector 各发一个包
+	unbatched := 0
+	want := make(map[string][]transfer.PushBatchItem)
+	for _, connector := range batch.connectors {
+		unbatched += len(batch.items[connector])
+		for _, item := range batch.items[connector] {
+			for _, userID := range item.Users {
+				want[userID] = append(want[userID], item)
+			}
+		}
+	}
+	packets := batch.packets("game-1")
+	if len(packets) != 2 || unbatched <= len(packets) {
+		t.Fatalf("应合并为 2 个包, got %d 个 (不合并 %d 个)", len(packets), unbatched)
+	}
+
+	got := make(map[string][]transfer.PushBatchItem)
+	for _, packet := range packets {
+		if packet.Source != "game-1" || packet.Route != transfer.GamePushBatch || packet.Body.Route != transfer.GamePushBatch {
+			t.Fatalf("多条消息应使用合并路由, got %+v", packet)
+		}
+		var items []transfer.PushBatchItem
+		if err := json.Unmarshal(packet.Body.Data, &items); err != nil {
+			t.Fatalf("json.Unmarshal: %v", err)
+		}
+		for _, item := range items {
+			for _, userID := range item.Users {
+				if !slices.Contains(packet.PushUser, userID) {
+					t.Fatalf("包的推送用户应包含 %s, got %v", userID, packet.PushUser)
+				}
+				got[userID] = append(got[userID], item)
+			}
+		}
+	}
+	for _, userID := range []string{"a", "b", "c"} {
+		if len(got[userID]) == 0 || !slices.EqualFunc(got[userID], want[userID], func(x, y transfer.PushBatchItem) bool {
+			return x.Route == y.Route && slices.Equal(x.Data, y.Data)
+		}) {
+			t.Fatalf("%s 拆包后的消息不一致: got %d 条, want %d 条", userID, len(got[userID]), len(want[userID]))
+		}
+	}
+
+	// 只有一条消息的 connector 按普通推送发送
+	batch = capturePushes(eg)
+	eg.dispatchPush([]string{"c"}, transfer.GamePush, transfer.GameplayDraw, []byte(`{}`))
+	packets = batch.packets("game-1")
+	if len(packets) != 1 || packets[0].Route != transfer.GamePush || packets[0].Body.Route != transfer.GameplayDraw {
+		t.Fatalf("单条消息不应合并, got %+v", packets)
+	}
+}
+
+// 打完一局并开始下一局：每个玩家收到的对局推送序号严格递增，快照带上最后一个序号但不推进
+func TestEventSeqMonotonic(t *testing.T) {
+	eg := newTestEngine(t, DefaultEngineRules())
+	for _, user := range eg.UserMap {
+		user.IsBot, user.ConnectorNodeID = false, "conn-"+user.UserID
+	}
+	batch := capturePushes(eg)
+	playBotUntil(t, eg, func() bool { return len(eg.Persister.rounds) == 2 })
+	if len(eg.Persister.rounds) != 2 {
+		t.Fatalf("第 2 局没有开始")
+	}
+
+	for _, user := range eg.UserMap {
+		last := 0
+		routes := make(map[string]bool)
+		for _, item := range batch.items[user.ConnectorNodeID] {
+			var stamped struct {
+				EventSeq *int `json:"eventSeq"`
+			}
+			// 操作列表是 JSON 数组，不带序号
+			if json.Unmarshal(item.Data, &stamped) != nil || stamped.EventSeq == nil {
+				continue
+			}
+			if *stamped.EventSeq <= last {
+				t.Fatalf("%s 收到的 %s 序号 %d 不大于上一条 %d", user.UserID, item.Route, *stamped.EventSeq, last)
+			}
+			last = *stamped.EventSeq
+			routes[item.Route] = true
+		}
+		for _, route := range []string{transfer.GameplayDiscard, transfer.GameplayRoundEnd, transfer.GameplayRoundStart} {
+			if !routes[route] {
+				t.Fatalf("%s 没有收到带序号的 %s 推送", user.UserID, route)
+			}
+		}
+		if last != eg.eventSeq {
+			t.Fatalf("%s 最后一条序号 %d, 引擎 %d", user.UserID, last, eg.eventSeq)
+		}
+	}
+
+	seq := eg.eventSeq
+	if snapshot := eg.buildPlayerSnapshot(0); snapshot.EventSeq != seq || eg.eventSeq != seq {
+		t.Fatalf("快照应带上最后一个序号 %d 且不推进, got %d", seq, snapshot.EventSeq)
+	}
+}
+
+// 立直宣言牌打出后推送听牌，与 Searcher 对 13 张手牌的计算一致；立直前打牌不推送
+func TestRiichiWaitsPush(t *testing.T) {
+	eg := newTestEngine(t, noAkaRules())
+	player := eg.UserMap["a"]
+	player.IsBot, player.ConnectorNodeID = false, "conn-player"
+	dealer := setHand(t, eg, 0, "234m567m345p78s88p1z")
+	for seat := 1; seat < 4; seat++ {
+		setHand(t, eg, seat, "13579m13579p246z")
+	}
+
+	batch := capturePushes(eg)
+	eg.handleRiichiEvent(&share.RiichiEvent{GameMessageEvent: eg.replayUser(0)})
+	if len(pushedTo(batch, "conn-player", transfer.GameplayWaits)) != 0 {
+		t.Fatalf("宣言牌打出前不应推送听牌")
+	}
+	dropTile(t, eg, 0, Tile{Type: East, ID: 1})
+	items := pushedTo(batch, "conn-player", transfer.GameplayWaits)
+	if len(items) != 1 {
+		t.Fatalf("宣言牌打出后应推送一次听牌, got %d", len(items))
+	}
+	var got WaitsDTO
+	if err := json.Unmarshal(items[0].Data, &got); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+
+	h13, _ := Hand34FromTiles(dealer.Tiles)
+	visible := eg.visibleTiles()
+	waits, ukeire := eg.Searcher.WaitsAndUkeire(h13, 0, &visible)
+	if !slices.Equal(got.Waits, []TileType{So6, So9}) || !slices.Equal(got.Waits, waits) || got.Ukeire != ukeire {
+		t.Fatalf("推送的听牌 %+v, Searcher %v %d", got, waits, ukeire)
+	}
+}
+
+// gameStat 读取 metrics 中 "game" 的计数
+func gameStat(t *testing.T, name string) int64 {
+	t.Helper()
+	stats := expvar.Get("game").(*expvar.Map)
+	if name == "round_seconds" {
+		return stats.Get(name).(*expvar.Map).Get("le_inf").(*expvar.Int).Value()
+	}
+	return stats.Get(name).(*expvar.Int).Value()
+}
+
+// 线上对局的每局结束按结束方式计数并记录时长，对局结束计数；复盘（没有 worker 和仓储）不计入
+func TestRoundMetrics(t *testing.T) {
+	names := []string{"rounds", "rounds_ron", "rounds_draw", "round_seconds", "games_ended"}
+	snapshot := func() map[string]int64 {
+		values := make(map[string]int64, len(names))
+		for _, name := range names {
+			values[name] = gameStat(t, name)
+		}
+		return values
+	}
+
+	before := snapshot()
+	replay := setupFuritenTable(t, false)
+	replay.handleRoundOverEvent(nil, RoundEndDraw4Kan)
+	replay.handlerGameOverEvent()
+	if got := snapshot(); !maps.Equal(got, before) {
+		t.Fatalf("复盘不应计入指标, %v -> %v", before, got)
+	}
+
+	live := func() *RiichiMahjong4p {
+		eg := setupFuritenTable(t, false)
+		eg.Worker = &game.Worker{}
+		eg.Persister.repo = &recordingGameRecords{}
+		capturePushes(eg)
+		return eg
+	}
+	live().handleRoundOverEvent(nil, RoundEndDraw4Kan)
+	eg := live()
+	dropTile(t, eg, 0, Tile{Type: So4, ID: 1})
+	eg.handleReactionHuEvent(&share.HuEvent{GameMessageEvent: eg.replayUser(2)})
+	eg.handlerGameOverEvent()
+
+	want := map[string]int64{"rounds": 2, "rounds_ron": 1, "rounds_draw": 1, "round_seconds": 2, "games_ended": 1}
+	got := snapshot()
+	for _, name := range names {
+		if got[name]-before[name] != want[name] {
+			t.Fatalf("%s 增加 %d, want %d", name, got[name]-before[name], want[name])
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package mahjong
+
+import (
+	"context"
+	"fmt"
+	"game/domain/entity"
+	"game/domain/repository"
+	"game/infrastructure/utils"
+	"game/runtime/engines"
+	"game/runtime/share"
+	"slices"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DeterministicReplay 确定性复盘：用对局记录中的洗牌种子重建引擎，按记录重新执行玩家的操作，
+// 校验每一局的结果和终局点数与记录一致，用于排查结算问题和验证引擎改动没有改变既有牌局
+// 只支持四麻；规则使用构造时传入的规则，需与对局时一致
+type DeterministicReplay struct {
+	repo  repository.GameRecordRepository
+	rules EngineRules
+}
+
+func NewDeterministicReplay(repo repository.GameRecordRepository, rules EngineRules) *DeterministicReplay {
+	return &DeterministicReplay{repo: repo, rules: rules}
+}
+
+// Run 复盘一场对局，结果与记录不一致时返回错误，成功时返回复盘得到的终局点数
+func (r *DeterministicReplay) Run(ctx context.Context, gameRecordID string) ([4]int, error) {
+	recordID, err := primitive.ObjectIDFromHex(gameRecordID)
+	if err != nil {
+		return [4]int{}, fmt.Errorf("无效的对局记录 ID %s: %w", gameRecordID, err)
+	}
+	record, err := r.repo.FindGameRecord(ctx, recordID)
+	if err != nil {
+		return [4]int{}, err
+	}
+	rounds, err := r.repo.FindRoundRecords(ctx, recordID)
+	if err != nil {
+		return [4]int{}, err
+	}
+	return r.Replay(record, rounds)
+}
+
+// Replay 按记录复盘，rounds 不要求有序
+func (r *DeterministicReplay) Replay(record *entity.GameRecord, rounds []*entity.RoundRecord) ([4]int, error) {
+	if record.Seed == 0 {
+		return [4]int{}, fmt.Errorf("对局记录 %s 没有洗牌种子", record.ID.Hex())
+	}
+	if len(record.Players) != 4 {
+		return [4]int{}, fmt.Errorf("确定性复盘只支持四麻，对局人数: %d", len(record.Players))
+	}
+	rounds = slices.Clone(rounds)
+	sort.SliceStable(rounds, func(i, j int) bool { return rounds[i].StartTime.Before(rounds[j].StartTime) })
+
+	eg := newReplayEngine(record, r.rules)
+	defer eg.Close()
+	replayed := eg.Persister
+
+	for i, round := range rounds {
+		situation := eg.Situation
+		if situation.DealerIndex != round.DealerIndex || situation.Honba != round.Honba || situation.RoundWind.String() != round.RoundWind {
+			return [4]int{}, fmt.Errorf("第 %d 局场况不一致: 复盘 %s%d局 %d本场 庄家%d, 记录 %s%d局 %d本场 庄家%d", i+1,
+				situation.RoundWind, situation.RoundNumber, situation.Honba, situation.DealerIndex,
+				round.RoundWind, round.RoundNumber, round.Honba, round.DealerIndex)
+		}
+		eg.handleStartRoundEvent()
+		if err := eg.replayRound(round); err != nil {
+			return [4]int{}, fmt.Errorf("第 %d 局: %w", i+1, err)
+		}
+		if err := compareRoundResult(replayed.rounds[i].RoundResult, round.RoundResult); err != nil {
+			return [4]int{}, fmt.Errorf("第 %d 局: %w", i+1, err)
+		}
+		// 下一局由引擎投递 StartRound，复盘中直接按记录开局
+		eg.drainReplayEvents()
+	}
+
+	var final [4]int
+	for i, p := range eg.Players {
+		if p != nil {
+			final[i] = p.Points
+		}
+	}
+	if record.FinalResult != nil && final != record.FinalResult.Points {
+		return final, fmt.Errorf("终局点数不一致: 复盘 %v, 记录 %v", final, record.FinalResult.Points)
+	}
+	return final, nil
+}
+
+// newReplayEngine 按对局记录的座位创建引擎，不启动 actor 和计时回调，由复盘同步驱动
+// 所有座位按 bot 处理，不产生推送；持久化组件不带仓储，只在内存中收集复盘结果
+func newReplayEngine(record *entity.GameRecord, rules EngineRules) *RiichiMahjong4p {
+	// 牌山按对局时的赤牌规则生成，与当前配置无关
+	rules.UseRedFive = true
+	rules.Aka = RecordAkaRules(record)
+	eg := NewRiichiMahjong4p(nil, GameLength(record.GameLength), rules)
+	eg.RoomID = record.RoomID
+	eg.UserMap = make(map[string]*share.UserInfo, len(record.Players))
+	eg.Spectators = make(map[string]*share.UserInfo)
+	eg.gameEvents = make(chan share.GameEvent, 256)
+	eg.criticalEvents = make(chan share.GameEvent, 64)
+	eg.gameDone = make(chan struct{})
+
+	tickers := [4]*PlayerTicker{}
+	for _, p := range record.Players {
+		eg.UserMap[p.UserID] = &share.UserInfo{UserID: p.UserID, SeatIndex: p.SeatIndex, IsBot: true}
+		eg.Players[p.SeatIndex] = NewPlayerImage(p.UserID, p.SeatIndex, eg.InitialPoint)
+		tickers[p.SeatIndex] = NewPlayerTicker(rules.MaxRoundTime)
+	}
+	eg.TurnManager = NewTurnManager(tickers, eg.seats(), rules.MaxRoundTime)
+	eg.DeckManager = NewDeckManagerWithSeed(rules.akaRules(), record.Seed)
+	eg.Persister = NewGamePersister(nil, nil, record.RoomID, eg.UserMap)
+	eg.State = engines.GameInProgress
+	return eg
+}
+
+// replayRound 按顺序重新执行一局的玩家操作，摸牌、配牌由牌山决定，只做校验
+func (eg *RiichiMahjong4p) replayRound(round *entity.RoundRecord) error {
+	for _, ev := range round.Events {
+		seat := ev.SeatIndex
+		switch ev.EventType {
+		case entity.EventTypeRoundStart:
+			for i, hand := range utils.ToSlice(ev.Data["hands"]) {
+				if p := eg.Players[i]; p != nil && !slices.Equal(p.Tiles, replayTiles(hand)) {
+					return fmt.Errorf("座位 %d 配牌不一致，洗牌种子或牌山规则与对局时不同", i)
+				}
+			}
+		case entity.EventTypeDrawTile:
+			// 上一张打牌的反应窗口（如下家可以吃但跳过）要先关闭，摸牌才会发生
+			eg.closeReplayReactions("")
+			tile := replayTile(ev.Data["tile"])
+			if p := eg.Players[seat]; p == nil || p.NewestTile == nil || *p.NewestTile != tile {
+				return fmt.Errorf("第 %d 个事件: 座位 %d 摸牌不一致，记录为 %v", ev.Sequence, seat, tile)
+			}
+		case entity.EventTypeDiscardTile:
+			eg.closeReplayReactions("")
+			eg.processEvent(&share.DropTileEvent{GameMessageEvent: eg.replayUser(seat), Tile: toShareTile(replayTile(ev.Data["tile"]))})
+		case entity.EventTypeRiichi:
+			eg.processEvent(&share.RiichiEvent{GameMessageEvent: eg.replayUser(seat)})
+		case entity.EventTypeChi:
+			// 记录的副露第一张是被鸣的牌
+			tiles := replayTiles(ev.Data["tiles"])
+			eg.processEvent(&share.ChiEvent{GameMessageEvent: eg.replayUser(seat), Tiles: toShareTiles(tiles[1:])})
+		case entity.EventTypePeng:
+			tiles := replayTiles(ev.Data["tiles"])
+			eg.processEvent(&share.PengTileEvent{GameMessageEvent: eg.replayUser(seat), Tiles: toShareTiles(tiles[1:])})
+		case entity.EventTypeGang:
+			eg.processEvent(&share.GangEvent{GameMessageEvent: eg.replayUser(seat)})
+		case entity.EventTypeAnkan:
+			tiles := replayTiles(ev.Data["tiles"])
+			eg.processEvent(&share.AnkanEvent{GameMessageEvent: eg.replayUser(seat), Tile: toShareTile(tiles[0])})
+		case entity.EventTypeKakan:
+			// 加杠的牌追加在碰的副露之后
+			tiles := replayTiles(ev.Data["tiles"])
+			eg.processEvent(&share.KakanEvent{GameMessageEvent: eg.replayUser(seat), Tile: toShareTile(tiles[len(tiles)-1])})
+		case entity.EventTypeRon:
+			// 荣和在宣言时记录，多家荣和时最后一家宣言后才结算
+			eg.processEvent(&share.RongHuEvent{GameMessageEvent: eg.replayUser(seat)})
+		case entity.EventTypeTsumo:
+			eg.processEvent(&share.TouchHuEvent{GameMessageEvent: eg.replayUser(seat)})
+		case entity.EventTypeRoundEnd:
+			if round.RoundResult != nil {
+				eg.closeReplayReactions(round.RoundResult.EndType)
+			}
+		}
+	}
+	return nil
+}
+
+// closeReplayReactions 记录中没有跳过操作，反应窗口还开着时其余玩家视为跳过
+// 九种九牌没有单独的事件，局结束时按记录的结果补上宣言；只发了和牌没有宣言荣和的三家和了同理
+func (eg *RiichiMahjong4p) closeReplayReactions(endType string) {
+	state := eg.TurnManager.GetState()
+	switch {
+	case endType == RoundEndKyuushuu && state == TurnStateWaitMain:
+		eg.processEvent(&share.KyuushuuEvent{GameMessageEvent: eg.replayUser(eg.TurnManager.GetCurrentPlayer())})
+		return
+	case endType == RoundEndDraw3Ron && state == TurnStateWaitReactions:
+		var ronSeats []int
+		for seat, reaction := range eg.Reactions {
+			if slices.ContainsFunc(reaction.Operations, func(op *PlayerOperation) bool { return op.Type == "HU" }) {
+				ronSeats = append(ronSeats, seat)
+			}
+		}
+		for _, seat := range ronSeats {
+			eg.processEvent(&share.HuEvent{GameMessageEvent: eg.replayUser(seat)})
+		}
+	}
+	if eg.TurnManager.GetState() == TurnStateWaitReactions {
+		eg.handleReactionComplete()
+	}
+}
+
+// replayUser 座位对应的事件发起者
+func (eg *RiichiMahjong4p) replayUser(seat int) share.GameMessageEvent {
+	if seat < 0 || seat >= len(eg.Players) || eg.Players[seat] == nil {
+		return share.GameMessageEvent{}
+	}
+	return share.GameMessageEvent{UserID: eg.Players[seat].UserID}
+}
+
+// drainReplayEvents 丢弃引擎自己投递的开局事件
+func (eg *RiichiMahjong4p) drainReplayEvents() {
+	for {
+		select {
+		case <-eg.criticalEvents:
+		case <-eg.gameEvents:
+		default:
+			return
+		}
+	}
+}
+
+// compareRoundResult 比较复盘与记录的一局结果
+func compareRoundResult(replayed, recorded *entity.RoundResult) error {
+	if recorded == nil {
+		return nil
+	}
+	if replayed == nil {
+		return fmt.Errorf("复盘未结束，记录为 %s", recorded.EndType)
+	}
+	if replayed.EndType != recorded.EndType || replayed.Delta != recorded.Delta || replayed.Points != recorded.Points {
+		return fmt.Errorf("结果不一致: 复盘 %s delta=%v points=%v, 记录 %s delta=%v points=%v",
+			replayed.EndType, replayed.Delta, replayed.Points, recorded.EndType, recorded.Delta, recorded.Points)
+	}
+	return nil
+}
+
+func replayTile(value interface{}) Tile {
+	m := utils.ToMap(value)
+	return Tile{Type: TileType(utils.ToInt(m["type"])), ID: utils.ToInt(m["id"])}
+}
+
+func replayTiles(value interface{}) []Tile {
+	items := utils.ToSlice(value)
+	tiles := make([]Tile, 0, len(items))
+	for _, item := range items {
+		tiles = append(tiles, replayTile(item))
+	}
+	return tiles
+}
+
+func toShareTile(t Tile) share.Tile {
+	return share.Tile{Type: int(t.Type), ID: t.ID}
+}
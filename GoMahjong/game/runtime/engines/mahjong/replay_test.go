@@ -0,0 +1,76 @@
+package mahjong
+
+import (
+	"game/domain/entity"
+	"strings"
+	"testing"
+)
+
+// playBotGame 所有座位都按超时自动操作，同步打完整场对局（持久化组件收到终局结果为止）
+func playBotGame(t *testing.T, eg *RiichiMahjong4p) {
+	t.Helper()
+	playBotUntil(t, eg, func() bool { return eg.Persister.closed })
+	if !eg.Persister.closed {
+		t.Fatalf("对局没有结束: %d 局 %+v", len(eg.Persister.rounds), *eg.Situation)
+	}
+}
+
+// playBotUntil 所有座位都按超时自动操作直到 done 成立，引擎投递的开局事件直接处理
+func playBotUntil(t *testing.T, eg *RiichiMahjong4p, done func() bool) {
+	t.Helper()
+	for step := 0; step < 20000 && !done(); step++ {
+		select {
+		case ev := <-eg.criticalEvents:
+			eg.processEvent(ev)
+			continue
+		case ev := <-eg.gameEvents:
+			eg.processEvent(ev)
+			continue
+		default:
+		}
+		switch eg.TurnManager.GetState() {
+		case TurnStateWaitMain:
+			eg.handleTimeoutEvent(&TimeoutEvent{SeatIndex: eg.TurnManager.GetCurrentPlayer()})
+		case TurnStateWaitReactions:
+			for seat := range eg.Reactions {
+				if eg.TurnManager.GetState() == TurnStateWaitReactions {
+					eg.handleTimeoutEvent(&TimeoutEvent{SeatIndex: seat})
+				}
+			}
+		default:
+			t.Fatalf("对局停在了 %s 阶段", eg.turnStateString())
+		}
+	}
+}
+
+// bot 打完一场半庄后按记录的种子和操作复盘，每局结果与终局点数都一致；记录被改动时复盘报错
+func TestDeterministicReplay(t *testing.T) {
+	record := &entity.GameRecord{RoomID: "test", Seed: 20240501}
+	for i := 0; i < 4; i++ {
+		record.Players = append(record.Players, entity.PlayerInfo{UserID: string(rune('a' + i)), SeatIndex: i})
+	}
+	rules := DefaultEngineRules()
+	eg := newReplayEngine(record, rules)
+	t.Cleanup(eg.Close)
+	eg.handleStartRoundEvent()
+	playBotGame(t, eg)
+
+	rounds := eg.Persister.rounds
+	record.FinalResult = eg.Persister.gameRecord.FinalResult
+	if len(rounds) < 4 || record.FinalResult == nil {
+		t.Fatalf("半庄至少 4 局且应有终局结果, got %d 局", len(rounds))
+	}
+
+	final, err := NewDeterministicReplay(nil, rules).Replay(record, rounds)
+	if err != nil {
+		t.Fatalf("复盘失败: %v", err)
+	}
+	if final != record.FinalResult.Points {
+		t.Fatalf("复盘终局点数 %v, 记录 %v", final, record.FinalResult.Points)
+	}
+
+	rounds[0].RoundResult.Delta[0] += 1000
+	if _, err := NewDeterministicReplay(nil, rules).Replay(record, rounds); err == nil || !strings.Contains(err.Error(), "第 1 局") {
+		t.Fatalf("记录被改动时复盘应报告第 1 局不一致, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package mahjong
+
+import (
+	"game/runtime"
+	"game/runtime/engines"
+)
+
+/*
+	三人麻将（三麻）与四麻共用同一套流程，差异都由座位数驱动：
+		1.去掉 2-8 万，共 108 张
+		2.只有东南西三家，庄家按 3 家轮转，每场 3 局
+		3.不能吃
+		4.北可以拔出（拔北），从牌山末尾补牌，每张拔北算一枚宝牌
+		5.自摸时没有北家的那份（自摸损），流局罚符总额 2000
+*/
+
+// RiichiMahjong3p 日麻三人游戏引擎
+type RiichiMahjong3p struct {
+	*RiichiMahjong4p
+}
+
+// NewRiichiMahjong3p 创建立直麻将 3 人引擎实例，点数由 rules 决定（默认见 DefaultSanmaEngineRules）
+func NewRiichiMahjong3p(worker *game.Worker, length GameLength, rules EngineRules) *RiichiMahjong3p {
+	eg := NewRiichiMahjong4p(worker, length, rules)
+	eg.Situation.SeatCount = 3
+	return &RiichiMahjong3p{RiichiMahjong4p: eg}
+}
+
+// Clone 克隆引擎实例（用于原型模式）
+func (eg *RiichiMahjong3p) Clone() engines.Engine {
+	return &RiichiMahjong3p{RiichiMahjong4p: eg.RiichiMahjong4p.Clone().(*RiichiMahjong4p)}
+}
@@ -0,0 +1,41 @@
+package mahjong
+
+import "testing"
+
+// 三麻牌山去掉 2-8 万共 108 张，去掉 14 张王牌后可摸 94 张
+func TestSanmaWall(t *testing.T) {
+	dm := NewSanmaDeckManager(DefaultEngineRules().akaRules())
+	dm.InitRound()
+	if got := dm.Remaining(); got != 108-14 {
+		t.Fatalf("三麻牌山可摸 %d 张, want %d", got, 108-14)
+	}
+	for {
+		tile, ok := dm.Draw()
+		if !ok {
+			break
+		}
+		if tile.Type >= Man2 && tile.Type <= Man8 {
+			t.Fatalf("三麻牌山中不应有 %v", tile)
+		}
+	}
+}
+
+// 三麻庄家按 3 家轮转，每场 3 局
+func TestSanmaDealerRotation(t *testing.T) {
+	eg := NewRiichiMahjong3p(nil, GameLengthHanchan, DefaultSanmaEngineRules())
+	for seat := 0; seat < 3; seat++ {
+		eg.Players[seat] = NewPlayerImage(string(rune('a'+seat)), seat, eg.InitialPoint)
+	}
+
+	for _, want := range []int{1, 2, 0} {
+		if got := eg.advanceDealer(false); got != want {
+			t.Fatalf("下一局庄家 = %d, want %d", got, want)
+		}
+		if eg.isGameEnd() {
+			t.Fatalf("东场打完前不应结束")
+		}
+	}
+	if eg.Situation.RoundWind != WindSouth || eg.Situation.RoundNumber != 1 || eg.Situation.DealerIndex != 0 {
+		t.Fatalf("东 3 局后应进入南 1 局, got %v %d 局 庄家 %d", eg.Situation.RoundWind, eg.Situation.RoundNumber, eg.Situation.DealerIndex)
+	}
+}
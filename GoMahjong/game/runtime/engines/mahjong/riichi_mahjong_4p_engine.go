@@ -3,27 +3,68 @@ package mahjong
 import (
 	"fmt"
 	"game/infrastructure/log"
+	"game/infrastructure/metrics"
 	"game/runtime"
 	"game/runtime/engines"
 	"game/runtime/share"
 
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
 const (
-	DefaultMaxRoundTime      = 30              // 每回合的最多分配时间
-	UseRedFive               = true            // 是否使用赤牌
-	DefaultRoundCompensation = 5               // 默认回合补偿
-	DefaultWaitStartTime     = 8 * time.Second // 等待游戏开始时间
-	DefaultInitialPoint      = 25000           // 默认初始点数
+	DefaultMaxRoundTime      = 30                     // 每回合的最多分配时间
+	DefaultMaxPauseTime      = 60 * time.Second       // 掉线暂停计时的累计上限，超过后按超时自动操作
+	DefaultBotThinkTime      = 1                      // bot 每次操作的固定计时（秒），到时按超时自动操作
+	DefaultEnqueueTimeout    = 100 * time.Millisecond // gameEvents 满时普通事件最多等待的时间
+	MaxEventOverflow         = 3                      // 普通事件连续入队超时的次数上限，超过视为房间崩坏
+	MaxRecentEvents          = 32                     // 崩溃报告中保留的最近事件数
+	DefaultUseRedFive        = true                   // 默认是否使用赤牌
+	RedFiveExtraID           = 4                      // 同种 5 有多张赤牌时，第二张起赤牌的起始 ID
+	DefaultKazoeYakuman      = true                   // 默认 13 番以上是否按累计役满计
+	UseKuitan                = true                   // 是否允许食断
+	UseRenhou                = false                  // 是否启用人和（按役满计）
+	DefaultRoundCompensation = 5                      // 默认回合补偿
+	DefaultReactionTime      = 5                      // 默认反应阶段（吃碰杠荣和）的计时（秒）
+	DefaultWaitStartTime     = 8 * time.Second        // 等待游戏开始时间
+	DefaultStatePushInterval = time.Second            // 状态更新推送的默认最小间隔
+	DefaultChomboBase        = ManganBasePoints       // 错和罚点的默认基本点（满贯）
+	DefaultInitialPoint      = 25000                  // 默认初始点数
+	DefaultTargetScore       = 30000                  // 默认结束所需点数（返点）
+	SanmaInitialPoint        = 35000                  // 三麻初始点数
+	SanmaTargetScore         = 40000                  // 三麻结束所需点数（返点）
 )
 
+// GameLength 对局长度
+type GameLength int
+
+const (
+	GameLengthHanchan  GameLength = iota // 半庄战：东南两场
+	GameLengthEastOnly                   // 东风战：只打东场
+)
+
+// EndWind 正常结束的场风
+func (l GameLength) EndWind() Wind {
+	if l == GameLengthEastOnly {
+		return WindEast
+	}
+	return WindSouth
+}
+
 func toMahjongTile(t share.Tile) Tile {
 	return Tile{Type: TileType(t.Type), ID: t.ID}
 }
 
+func toShareTiles(tiles []Tile) []share.Tile {
+	result := make([]share.Tile, len(tiles))
+	for i, t := range tiles {
+		result[i] = share.Tile{Type: int(t.Type), ID: t.ID}
+	}
+	return result
+}
+
 /*
 	注意：
 		1.有自摸，一定不能立直
@@ -31,7 +72,7 @@ func toMahjongTile(t share.Tile) Tile {
 		3.二杯口和4刻字同时出现，只算二杯口；清一色，吃不了二杯口
 		4.立直后，不可以吃、碰、明杠，但可以暗杠(有限制，听牌必须没有改变)
 		5.立直后如果进行了暗杠，则“一发”的役会立即失效
-		6.河底牌打出后，任然可以吃碰杠，用于改变听牌的形状，吃罚符
+		6.河底牌打出后，只能荣和，不能再吃碰杠
 
 	大概分了几个状态机
 		玩家操作前，需要有牌库(牌墙、王牌、手牌)初始化和发牌的逻辑，这是 1 个状态
@@ -62,8 +103,6 @@ func toMahjongTile(t share.Tile) Tile {
 
 	fixme 算法收集和响应，包括出牌者和非出牌者的收集和响应
 
-	fixme 抢杠算一役
-
 	fixme 立直，立直后只能暗杠，进入类似一种托管的状态，需要加额外逻辑处理
 	立直资格，具体来说，包括是否门清（len(Melds)==0 且无副露），当前不是最后巡/海底，点数 >= 1000，是否已经立直，立直宣言后扣棒、供托处理
 
@@ -76,18 +115,42 @@ type RiichiMahjong4p struct {
 	Worker          *game.Worker               // Game Worker（在 GameContainer 创建原型时注入）
 	RoomID          string                     // 房间 ID（用于请求销毁房间）
 	UserMap         map[string]*share.UserInfo // Room.UserMap 的引用，包含座位索引（Engine 和 Room 共用）
+	Spectators      map[string]*share.UserInfo // 观战者，只接收公开推送
 	Situation       *Situation                 // 游戏局面信息
 	Players         [4]*PlayerImage            // 座位索引 -> 玩家游戏状态
 	DeckManager     *DeckManager               // 牌库管理（含王牌、宝牌指示牌、remain34）
 	TurnManager     *TurnManager               // 回合管理
+	Searcher        *Searcher                  // 和牌/听牌搜索（带缓存，原型与克隆共用）
+	GameLength      GameLength                 // 对局长度（东风战/半庄战）
+	InitialPoint    int                        // 起始点数
+	TargetScore     int                        // 最后一场打完时有人达到该点数则游戏结束，否则进入延长战
+	MaxWind         Wind                       // 延长战最多打到的场风，该场风打完强制结束
+	Rules           EngineRules                // 计时、赤牌等可配置规则
 	roundStartTimer *time.Timer                // 开局延迟计时器（用于 Close 时停止）
 	lastDiscard     LastDiscard
+	pendingKan      PendingKan     // 等待抢杠判定的杠
+	pendingKanDora  int            // 明杠/加杠后打牌时才翻开的杠宝牌数
+	firstDiscards   []TileType     // 第一巡无人鸣牌时各家打出的牌（四风连打判定）
+	actionSeq       int            // 操作序号：每次局面推进（开局、打牌、反应结算、暗杠/加杠、拔北）后 +1，随推送下发
+	eventSeq        int            // 推送序号：整场对局单调递增，每次推送对局事件 +1，重连快照带上最后一个
+	recentEvents    []string       // 最近处理的事件，写入崩溃报告
 	Persister       *GamePersister // 持久化组件
-
-	gameEvents chan share.GameEvent
-	gameDone   chan struct{}
-	actorExit  chan struct{}
-	closed     atomic.Bool // 接收游戏事件的关闭开关
+	Codec           Codec          // 玩家未协商推送格式时使用的默认编码
+	BotPolicy       BotPolicy      // bot 座位超时时的自动操作策略，为空时摸切/跳过
+
+	gameEvents     chan share.GameEvent
+	criticalEvents chan share.GameEvent // 超时、开局等关键事件不会被丢弃，和牌宣言在有空位时也优先走这里
+	overflowCount  atomic.Int32         // 普通事件连续入队超时次数
+	gameDone       chan struct{}
+	actorExit      chan struct{}
+	closed         atomic.Bool                          // 接收游戏事件的关闭开关
+	status         atomic.Pointer[engines.EngineStatus] // 每处理完一个事件发布一次，供管理接口在 actor 之外读取
+	pushBatch      *pushBatch                           // 开启 CoalescePush 时，当前事件处理期间暂存的推送
+
+	// 状态更新节流，见 state_update.go，只在 actor 中读写
+	stateUpdateTimer *time.Timer // 当前节流窗口的推送计时，为空表示窗口外
+	stateUpdateGen   int         // 节流窗口编号，窗口结束或被取消时 +1
+	stateUpdateSeq   int         // 最近一次推送状态更新时的 actionSeq
 
 	// 反应阶段管理
 	Reactions map[int]*PlayerReaction // 玩家座位 → 反应信息
@@ -100,9 +163,17 @@ type LastDiscard struct {
 	Valid bool
 }
 
-// NewRiichiMahjong4p 创建立直麻将 4 人引擎实例
-func NewRiichiMahjong4p(worker *game.Worker) *RiichiMahjong4p {
-	return &RiichiMahjong4p{
+// PendingKan 已宣言但尚未摸岭上牌的杠，其他玩家可以抢杠
+type PendingKan struct {
+	Seat  int
+	Tile  Tile   // 加杠的牌，或暗杠的其中一张
+	Type  string // "Kakan" 或 "Ankan"
+	Valid bool
+}
+
+// NewRiichiMahjong4p 创建立直麻将 4 人引擎实例，延长战默认多打一场
+func NewRiichiMahjong4p(worker *game.Worker, length GameLength, rules EngineRules) *RiichiMahjong4p {
+	eg := &RiichiMahjong4p{
 		State:   engines.GameWaiting,
 		Worker:  worker,
 		RoomID:  "",
@@ -113,19 +184,33 @@ func NewRiichiMahjong4p(worker *game.Worker) *RiichiMahjong4p {
 			RoundWind:    WindEast,
 			RoundNumber:  1,
 			RiichiSticks: 0,
+			SeatCount:    4,
 		},
-		Players:   [4]*PlayerImage{},
-		Reactions: make(map[int]*PlayerReaction),
-	}
+		Players:      [4]*PlayerImage{},
+		Searcher:     NewSearcher(),
+		GameLength:   length,
+		InitialPoint: rules.InitialPoint,
+		TargetScore:  rules.TargetScore,
+		MaxWind:      length.EndWind().Next(),
+		Rules:        rules,
+		Reactions:    make(map[int]*PlayerReaction),
+		Codec:        JSONCodec{},
+	}
+	eg.Searcher.SetParallelism(rules.SearchWorkers)
+	eg.BotPolicy = NewShantenBotPolicy(eg.Searcher)
+	return eg
 }
 
 // InitializeEngine 初始化游戏引擎
 func (eg *RiichiMahjong4p) InitializeEngine(roomID string, userMap map[string]*share.UserInfo) error {
 	eg.RoomID = roomID
 	eg.UserMap = userMap
+	eg.Spectators = make(map[string]*share.UserInfo)
 
 	eg.closed.Store(false)
 	eg.gameEvents = make(chan share.GameEvent, 256)
+	eg.criticalEvents = make(chan share.GameEvent, 64)
+	eg.overflowCount.Store(0)
 	eg.gameDone = make(chan struct{})
 	eg.actorExit = make(chan struct{})
 	// 初始化 PlayerTicker 数组
@@ -133,26 +218,36 @@ func (eg *RiichiMahjong4p) InitializeEngine(roomID string, userMap map[string]*s
 	seatIndex := 0
 	for _, userInfo := range userMap {
 		userInfo.SeatIndex = seatIndex
-		ticker := NewPlayerTicker(DefaultMaxRoundTime)
+		ticker := NewPlayerTicker(eg.Rules.MaxRoundTime)
+		if userInfo.IsBot {
+			ticker = NewBotTicker(DefaultBotThinkTime)
+		}
 		ticker.SetOnTimeout(eg.makeTimeoutHandler(seatIndex))
 		ticker.SetOnStop(eg.makeStopHandler(seatIndex))
 		tickers[seatIndex] = ticker
 
-		eg.Players[seatIndex] = NewPlayerImage(userInfo.UserID, seatIndex, DefaultInitialPoint)
+		eg.Players[seatIndex] = NewPlayerImage(userInfo.UserID, seatIndex, eg.InitialPoint)
 		seatIndex++
 	}
-	eg.TurnManager = NewTurnManager(tickers)
+	eg.TurnManager = NewTurnManager(tickers, eg.seats(), eg.Rules.MaxRoundTime)
 	eg.State = engines.GameWaiting
+	eg.publishStatus()
+
+	if eg.DeckManager == nil {
+		eg.DeckManager = eg.newDeckManager()
+	}
 
 	// 初始化持久化组件
 	if eg.Worker != nil && eg.Worker.GameRecordRepository != nil {
-		eg.Persister = NewGamePersister(eg.Worker.GameRecordRepository, roomID, userMap)
+		eg.Persister = NewGamePersister(eg.Worker.GameRecordRepository, eg.Worker.LeaderboardRepository, roomID, userMap)
+		eg.Persister.SetEngineConfig(eg.DeckManager.Seed(), int(eg.GameLength), eg.DeckManager.Aka())
 	}
 
 	go eg.pushMatchSuccessMessage(userMap)
 
-	eg.roundStartTimer = time.AfterFunc(DefaultWaitStartTime, func() {
+	eg.roundStartTimer = time.AfterFunc(eg.Rules.WaitStartTime, func() {
 		eg.State = engines.GameInProgress
+		metrics.GameStarted()
 		eg.NotifyEvent(&StartRoundEvent{})
 	})
 	go eg.actorLoop()
@@ -168,15 +263,45 @@ func (eg *RiichiMahjong4p) actorLoop() {
 		}
 	}()
 	for {
+		// 关键事件优先处理
+		select {
+		case <-eg.gameDone:
+			return
+		case event := <-eg.criticalEvents:
+			eg.processEvent(event)
+			continue
+		default:
+		}
+
 		select {
 		case <-eg.gameDone:
 			return
+		case event := <-eg.criticalEvents:
+			eg.processEvent(event)
 		case event := <-eg.gameEvents:
 			eg.processEvent(event)
 		}
 	}
 }
 
+// isCriticalEvent 引擎内部产生、丢失后会让对局卡死的事件，数量受座位数限制
+func isCriticalEvent(event share.GameEvent) bool {
+	switch event.GetEventType() {
+	case "Timeout", "StartRound":
+		return true
+	}
+	return false
+}
+
+// isHuEvent 客户端的和牌宣言，需要优先处理，但数量由客户端决定，不能无限阻塞
+func isHuEvent(event share.GameEvent) bool {
+	switch event.GetEventType() {
+	case "Hu", "RongHu", "TouchHu":
+		return true
+	}
+	return false
+}
+
 func (eg *RiichiMahjong4p) NotifyEvent(event share.GameEvent) {
 	if event == nil {
 		return
@@ -185,14 +310,48 @@ func (eg *RiichiMahjong4p) NotifyEvent(event share.GameEvent) {
 		return
 	}
 
+	if isCriticalEvent(event) {
+		// 关键事件数量受座位数限制，不会填满队列，阻塞等待即可（包括 actor 自己投递 StartRound）
+		select {
+		case <-eg.gameDone:
+		case eg.criticalEvents <- event:
+		}
+		return
+	}
+
+	if isHuEvent(event) {
+		// 关键队列有空位时优先处理；被刷满时按普通事件入队，避免阻塞为所有房间分发消息的 goroutine
+		select {
+		case <-eg.gameDone:
+			return
+		case eg.criticalEvents <- event:
+			return
+		default:
+		}
+	}
+
 	select {
 	case <-eg.gameDone:
 		return
 	case eg.gameEvents <- event:
+		eg.overflowCount.Store(0)
 		return
 	default:
-		log.Warn("gameEvents 队列已满, eventType=%s", event.GetEventType())
-		return
+	}
+
+	// 队列已满：短暂等待 actor 消费，持续溢出说明 actor 卡住，直接销毁房间
+	timer := time.NewTimer(DefaultEnqueueTimeout)
+	defer timer.Stop()
+	select {
+	case <-eg.gameDone:
+	case eg.gameEvents <- event:
+		eg.overflowCount.Store(0)
+	case <-timer.C:
+		n := eg.overflowCount.Add(1)
+		log.Warn("gameEvents 队列已满，丢弃事件, eventType=%s, 连续溢出 %d 次", event.GetEventType(), n)
+		if n >= MaxEventOverflow {
+			eg.HappenDamageError(fmt.Sprintf("gameEvents 持续溢出 %d 次", n))
+		}
 	}
 }
 
@@ -201,9 +360,19 @@ func (eg *RiichiMahjong4p) processEvent(event share.GameEvent) {
 		log.Warn("事件为空")
 		return
 	}
+	defer eg.publishStatus()
+	if eg.beginPushBatch() {
+		defer eg.flushPushBatch()
+	}
+	defer eg.markStateDirty()
 
 	eventType := event.GetEventType()
 	log.Info("处理游戏事件: %s", eventType)
+	eg.recordRecentEvent(event)
+
+	if eg.isStaleAction(event) {
+		return
+	}
 
 	switch eventType {
 	case "DropTile":
@@ -242,10 +411,34 @@ func (eg *RiichiMahjong4p) processEvent(event share.GameEvent) {
 		if riichiEvent, ok := event.(*share.RiichiEvent); ok {
 			eg.handleRiichiEvent(riichiEvent)
 		}
+	case "Kyuushuu":
+		if kyuushuuEvent, ok := event.(*share.KyuushuuEvent); ok {
+			eg.handleKyuushuuEvent(kyuushuuEvent)
+		}
+	case "Kita":
+		if kitaEvent, ok := event.(*share.KitaEvent); ok {
+			eg.handleKitaEvent(kitaEvent)
+		}
+	case "ScorePreview":
+		if previewEvent, ok := event.(*share.ScorePreviewEvent); ok {
+			eg.handleScorePreviewEvent(previewEvent)
+		}
 	case "Reconnect":
 		if reconnectEvent, ok := event.(*share.ReconnectEvent); ok {
 			eg.handleReconnectEvent(reconnectEvent)
 		}
+	case "Disconnect":
+		if disconnectEvent, ok := event.(*share.DisconnectEvent); ok {
+			eg.handleDisconnectEvent(disconnectEvent)
+		}
+	case "Spectate":
+		if spectateEvent, ok := event.(*share.SpectateEvent); ok {
+			eg.handleSpectateEvent(spectateEvent)
+		}
+	case "Serializer":
+		if serializerEvent, ok := event.(*share.SerializerEvent); ok {
+			eg.handleSerializerEvent(serializerEvent)
+		}
 	case "Timeout":
 		if t, ok := event.(*TimeoutEvent); ok {
 			eg.handleTimeoutEvent(t)
@@ -254,6 +447,10 @@ func (eg *RiichiMahjong4p) processEvent(event share.GameEvent) {
 		if _, ok := event.(*StartRoundEvent); ok {
 			eg.handleStartRoundEvent()
 		}
+	case "StateUpdate":
+		if stateUpdateEvent, ok := event.(*StateUpdateEvent); ok {
+			eg.handleStateUpdateEvent(stateUpdateEvent)
+		}
 	default:
 		log.Warn("不支持的事件类型: %s", eventType)
 	}
@@ -283,14 +480,33 @@ func (eg *RiichiMahjong4p) handleTouchHuEvent(event *share.TouchHuEvent) {
 		log.Warn("获取玩家座位失败: %v", err)
 		return
 	}
+	if eg.TurnManager.GetState() != TurnStateWaitMain || seatIndex != eg.TurnManager.GetCurrentPlayer() {
+		log.Warn("不是玩家 %d 的出牌阶段，无法自摸", seatIndex)
+		return
+	}
 	p := eg.Players[seatIndex]
 	if p == nil || p.NewestTile == nil {
 		log.Warn("自摸结算失败: 玩家或 NewestTile 为空: seat=%d", seatIndex)
 		return
 	}
-	// 广播自摸（在结算前先广播）
+	valid := eg.canTsumo(seatIndex)
+	if !valid && !eg.chomboEnabled() {
+		log.Warn("玩家 %d 不能自摸，忽略宣言", seatIndex)
+		return
+	}
+	// 广播自摸（在结算前先广播），错和也按宣言记录，复盘时重新判定
 	eg.broadcastTsumo(seatIndex, *p.NewestTile)
-	eg.handleRoundOverEvent([]HuClaim{{WinnerSeat: seatIndex, WinTile: *p.NewestTile}}, RoundEndTsumo)
+	claim := HuClaim{
+		WinnerSeat: seatIndex,
+		WinTile:    *p.NewestTile,
+		LastTile:   eg.isLastDraw() && !p.rinshanPending, // 岭上牌不是海底牌
+		Rinshan:    p.rinshanPending,
+	}
+	if !valid {
+		eg.declareChombo(claim)
+		return
+	}
+	eg.handleRoundOverEvent([]HuClaim{claim}, RoundEndTsumo)
 }
 
 func (eg *RiichiMahjong4p) handleReconnectEvent(event *share.ReconnectEvent) {
@@ -298,14 +514,105 @@ func (eg *RiichiMahjong4p) handleReconnectEvent(event *share.ReconnectEvent) {
 		return
 	}
 	log.Info("处理断线重连: user=%s", event.GetUserID())
-	// fixme 下发该玩家可见的状态快照
+	seatIndex, err := eg.getSeatIndex(event.GetUserID())
+	if err != nil {
+		log.Warn("获取玩家座位失败: %v", err)
+		return
+	}
+	if userInfo := eg.UserMap[event.GetUserID()]; userInfo != nil {
+		connectorNodeID := userInfo.ConnectorNodeID
+		if event.ConnectorNodeID != "" {
+			connectorNodeID = event.ConnectorNodeID
+		}
+		if !userInfo.IsOnline || userInfo.ConnectorNodeID != connectorNodeID {
+			userInfo.SetOnline(connectorNodeID)
+		}
+	}
+	// 掉线期间暂停的计时继续走
+	if eg.TurnManager != nil && eg.TurnManager.GetPlayerTicker(seatIndex).Resume() {
+		log.Info("玩家 %d 重连，恢复计时", seatIndex)
+	}
+	eg.pushReconnectSnapshot(seatIndex)
+}
+
+// handleDisconnectEvent 玩家掉线：标记离线，并暂停其正在进行的计时
+func (eg *RiichiMahjong4p) handleDisconnectEvent(event *share.DisconnectEvent) {
+	if event == nil {
+		return
+	}
+	log.Info("处理玩家掉线: user=%s", event.GetUserID())
+	userInfo, exists := eg.UserMap[event.GetUserID()]
+	if !exists {
+		log.Warn("掉线玩家 %s 不在 UserMap 中", event.GetUserID())
+		return
+	}
+	userInfo.SetOffline()
+	eg.pauseIfOffline(userInfo.SeatIndex)
+}
+
+// handleSerializerEvent 记录玩家协商的推送格式，不支持的格式回退到默认编码
+func (eg *RiichiMahjong4p) handleSerializerEvent(event *share.SerializerEvent) {
+	if event == nil {
+		return
+	}
+	userInfo, exists := eg.UserMap[event.GetUserID()]
+	if !exists {
+		log.Warn("玩家 %s 不在 UserMap 中", event.GetUserID())
+		return
+	}
+	if _, ok := CodecByName(event.Serializer); !ok {
+		log.Warn("玩家 %s 协商了不支持的推送格式: %s", event.GetUserID(), event.Serializer)
+		userInfo.Serializer = ""
+		return
+	}
+	userInfo.Serializer = event.Serializer
+	log.Info("玩家 %s 推送格式: %s", event.GetUserID(), event.Serializer)
+}
+
+// handleSpectateEvent 观战者加入时下发公开快照，离开时移除；已入座的玩家不能观战自己的房间
+func (eg *RiichiMahjong4p) handleSpectateEvent(event *share.SpectateEvent) {
+	if event == nil || event.GetUserID() == "" {
+		return
+	}
+	userID := event.GetUserID()
+	if event.Leave {
+		delete(eg.Spectators, userID)
+		log.Info("观战者 %s 离开", userID)
+		return
+	}
+	if _, exists := eg.UserMap[userID]; exists {
+		log.Warn("玩家 %s 已在对局中，不能观战", userID)
+		return
+	}
+	if spectator, exists := eg.Spectators[userID]; exists {
+		spectator.SetOnline(event.ConnectorNodeID)
+	} else {
+		eg.Spectators[userID] = share.NewUserInfo(userID, event.ConnectorNodeID)
+	}
+	log.Info("观战者 %s 加入，当前观战人数: %d", userID, len(eg.Spectators))
+	eg.pushSpectateSnapshot(userID)
+}
+
+// pauseIfOffline 出牌玩家离线时暂停其计时，暂停额度用完后按正常计时走
+func (eg *RiichiMahjong4p) pauseIfOffline(seatIndex int) {
+	if eg.TurnManager == nil || eg.Players[seatIndex] == nil {
+		return
+	}
+	userInfo := eg.UserMap[eg.Players[seatIndex].UserID]
+	if userInfo == nil || userInfo.IsOnline || userInfo.IsBot {
+		return
+	}
+	if eg.TurnManager.GetPlayerTicker(seatIndex).Pause() {
+		log.Info("玩家 %d 离线，暂停计时", seatIndex)
+	}
 }
 
 // fixme TurnManager 需要重新初始化，TurnManager 提供开放重新初始化的方法
 func (eg *RiichiMahjong4p) handleStartRoundEvent() {
 	log.Info("新的一局游戏开始：%#v", eg.Situation)
+	eg.bumpActionSeq()
 	if eg.DeckManager == nil {
-		eg.DeckManager = NewDeckManager(UseRedFive)
+		eg.DeckManager = eg.newDeckManager()
 	}
 
 	eg.DeckManager.InitRound()
@@ -314,23 +621,33 @@ func (eg *RiichiMahjong4p) handleStartRoundEvent() {
 
 	// 记录回合开始
 	if eg.Persister != nil {
+		hands := make([][]share.Tile, len(eg.Players))
+		for i, player := range eg.Players {
+			if player != nil {
+				hands[i] = toShareTiles(player.Tiles)
+			}
+		}
 		eg.Persister.StartRound(
 			eg.Situation.RoundNumber,
 			eg.Situation.RoundWind.String(),
 			eg.Situation.DealerIndex,
 			eg.Situation.Honba,
+			hands,
+			toShareTiles(eg.DeckManager.GetDoraIndicators()),
 		)
 	}
 
 	// 推送回合开始
 	eg.broadcastRoundStart()
+	eg.pushStateUpdateNow()
 
-	eg.DropTurn(eg.Situation.DealerIndex, true)
+	// 发牌时庄家已经拿到第 14 张牌，不再摸牌
+	eg.DropTurn(eg.Situation.DealerIndex, false)
 }
 
 // distributeCard 发牌
 func (eg *RiichiMahjong4p) distributeCard() {
-	for i := 0; i < 4; i++ {
+	for i := 0; i < eg.seats(); i++ {
 		p := eg.Players[i]
 		if p == nil {
 			continue
@@ -340,15 +657,24 @@ func (eg *RiichiMahjong4p) distributeCard() {
 		p.DiscardPile = p.DiscardPile[:0]
 		p.Melds = p.Melds[:0]
 		p.IsRiichi = false
+		p.DoubleRiichi = false
 		p.IsWaiting = false
+		p.IppatsuEligible = false
+		p.riichiDeclared = false
+		p.rinshanPending = false
+		p.missedRon = false
 		p.NewestTile = nil
 		p.DiscardedTiles = make(map[TileType]struct{})
 		p.TenpaiWaits = make(map[TileType]TenpaiWaitState)
 		p.TenpaiValid = false
+		p.FirstTurn = true
+		p.KuikaeForbidden = nil
+		p.PaoSeat = -1
+		p.Kita = p.Kita[:0]
 	}
 
 	for r := 0; r < 13; r++ {
-		for i := 0; i < 4; i++ {
+		for i := 0; i < eg.seats(); i++ {
 			t, ok := eg.DeckManager.Deal()
 			if !ok {
 				log.Warn("发牌失败: 牌山不足")
@@ -363,7 +689,7 @@ func (eg *RiichiMahjong4p) distributeCard() {
 	}
 
 	dealer := eg.Situation.DealerIndex
-	if dealer >= 0 && dealer < 4 {
+	if dealer >= 0 && dealer < eg.seats() {
 		t, ok := eg.DeckManager.Deal()
 		if !ok {
 			log.Warn("庄家补牌失败: 牌山不足")
@@ -395,10 +721,26 @@ func (eg *RiichiMahjong4p) DropTurn(seatIndex int, needTile bool) {
 			eg.pushDrawTile(seatIndex, t)
 		}
 	}
-	if err := eg.TurnManager.EnterDropPhase(seatIndex, DefaultRoundCompensation); err != nil {
+	if err := eg.TurnManager.EnterDropPhase(seatIndex, eg.Rules.RoundCompensation); err != nil {
 		eg.HappenDamageError("DropTurn 异常")
 		return
 	}
+	eg.pauseIfOffline(seatIndex)
+	var ops []*PlayerOperation
+	// 第一巡满足九种九牌时，提供流局选项
+	if eg.canKyuushuu(seatIndex) {
+		ops = append(ops, &PlayerOperation{Type: "KYUUSHUU", Tiles: []Tile{}})
+	}
+	// 三麻手中有北时，可以拔北
+	if north, ok := eg.canKita(seatIndex); ok {
+		ops = append(ops, &PlayerOperation{Type: "KITA", Tiles: []Tile{north}})
+	}
+	if len(ops) > 0 {
+		eg.pushMainOperations(seatIndex, ops)
+	}
+	if eg.Rules.BeginnerMode && !eg.isBotSeat(seatIndex) {
+		eg.pushDiscardSuggestion(seatIndex)
+	}
 }
 
 // fixme 回合结束，根据是否流局，进行番符计算，番符计算的逻辑较为复杂，必须由 RiichiMahjong4p 调用，尽量不能独立出组件
@@ -415,9 +757,13 @@ func (eg *RiichiMahjong4p) handleRoundOverEvent(claims []HuClaim, endKind string
 	case RoundEndDrawExhaustive:
 		eg.LeadNormalDrawEnding()
 	case RoundEndDraw3Ron:
-		eg.LeadHalfwayDrawEnding("三家点铳")
+		eg.LeadHalfwayDrawEnding(RoundEndDraw3Ron, "三家点铳")
 	case RoundEndDraw4Kan:
-		eg.LeadHalfwayDrawEnding("四杠散了")
+		eg.LeadHalfwayDrawEnding(RoundEndDraw4Kan, "四杠散了")
+	case RoundEndKyuushuu:
+		eg.LeadHalfwayDrawEnding(RoundEndKyuushuu, "九种九牌")
+	case RoundEndSuufon:
+		eg.LeadHalfwayDrawEnding(RoundEndSuufon, "四风连打")
 	case RoundEndTsumo:
 		if len(claims) == 0 {
 			eg.HappenDamageError("自摸结算 claims 为空")
@@ -430,6 +776,12 @@ func (eg *RiichiMahjong4p) handleRoundOverEvent(claims []HuClaim, endKind string
 			return
 		}
 		eg.LeadRonEnding(claims)
+	case RoundEndChombo:
+		if len(claims) == 0 {
+			eg.HappenDamageError("错和结算 claims 为空")
+			return
+		}
+		eg.LeadChomboEnding(claims[0])
 	default:
 		log.Warn("未知回合结束类型: %s", endKind)
 		return
@@ -444,13 +796,19 @@ func (eg *RiichiMahjong4p) LeadNormalDrawEnding() {
 	dealerTenpai := false
 	dealer := eg.Situation.DealerIndex
 
-	for i := 0; i < 4; i++ {
+	for i := 0; i < eg.seats(); i++ {
 		p := eg.Players[i]
 		if p == nil {
 			notenSeats = append(notenSeats, i)
 			continue
 		}
+		eg.updateTenpai(i)
 		isTenpai := p.TenpaiValid && len(p.TenpaiWaits) > 0
+		if p.IsRiichi && !isTenpai {
+			eg.handleRiichiNoten(i)
+		}
+		// 立直宣言时已校验听牌，立直者按听牌处理
+		isTenpai = isTenpai || p.IsRiichi
 		if isTenpai {
 			tenpaiSeats = append(tenpaiSeats, i)
 			if i == dealer {
@@ -461,9 +819,11 @@ func (eg *RiichiMahjong4p) LeadNormalDrawEnding() {
 		}
 	}
 
-	if len(tenpaiSeats) > 0 && len(tenpaiSeats) < 4 {
-		winEach := 3000 / len(tenpaiSeats)
-		loseEach := 3000 / len(notenSeats)
+	// 罚符总额：四麻 3000，三麻 2000
+	if len(tenpaiSeats) > 0 && len(notenSeats) > 0 {
+		pool := 1000 * (eg.seats() - 1)
+		winEach := pool / len(tenpaiSeats)
+		loseEach := pool / len(notenSeats)
 		for _, s := range tenpaiSeats {
 			delta[s] += winEach
 		}
@@ -472,38 +832,42 @@ func (eg *RiichiMahjong4p) LeadNormalDrawEnding() {
 		}
 	}
 
-	nextDealer := eg.Situation.DealerIndex
-	if dealerTenpai {
-		eg.Situation.Honba++
-	} else {
-		eg.Situation.Honba = 0
-		eg.Situation.DealerIndex = (eg.Situation.DealerIndex + 1) % 4
-		eg.Situation.RoundNumber++
-		nextDealer = eg.Situation.DealerIndex
-	}
+	nextDealer := eg.advanceDealer(dealerTenpai)
 
 	// 广播回合结束
 	eg.broadcastRoundEnd(RoundEndDrawExhaustive, []HuClaimDTO{}, delta, "荒牌流局", nextDealer)
 
-	eg.finalizeRound(delta, -1)
+	eg.finalizeRound(delta)
 }
 
-// LeadHalfwayDrawEnding 中途流局，不需要罚符
-func (eg *RiichiMahjong4p) LeadHalfwayDrawEnding(reason string) {
-	var delta [4]int
-	eg.Situation.Honba++
-	nextDealer := eg.Situation.DealerIndex
+// handleRiichiNoten 错立直：立直者流局时没有听牌，立直棒不退还
+// 立直宣言已校验听牌，正常不会走到这里，罚则预留给错和（chombo）处理
+func (eg *RiichiMahjong4p) handleRiichiNoten(seatIndex int) {
+	log.Warn("玩家 %d 立直但流局时没有听牌，手牌: %v", seatIndex, eg.Players[seatIndex].Tiles)
+}
 
-	// 根据 reason 确定流局类型
-	endType := RoundEndDraw3Ron
-	if reason == "四杠散了" {
-		endType = RoundEndDraw4Kan
-	}
+// LeadHalfwayDrawEnding 中途流局，不需要罚符，庄家连庄
+func (eg *RiichiMahjong4p) LeadHalfwayDrawEnding(endType string, reason string) {
+	var delta [4]int
+	nextDealer := eg.advanceDealer(true)
 
 	// 广播回合结束
 	eg.broadcastRoundEnd(endType, []HuClaimDTO{}, delta, reason, nextDealer)
 
-	eg.finalizeRound(delta, -1)
+	eg.finalizeRound(delta)
+}
+
+// advanceDealer 结算后推进局面，返回下一局的庄家
+// 连庄（庄家和牌、庄家听牌流局、中途流局）时本场 +1；否则本场清零，庄家轮换到下家，局数 +1
+func (eg *RiichiMahjong4p) advanceDealer(dealerKeeps bool) int {
+	if dealerKeeps {
+		eg.Situation.Honba++
+		return eg.Situation.DealerIndex
+	}
+	eg.Situation.Honba = 0
+	eg.Situation.DealerIndex = eg.nextSeat(eg.Situation.DealerIndex)
+	eg.Situation.RoundNumber++
+	return eg.Situation.DealerIndex
 }
 
 // LeadRonEnding 荣和
@@ -529,37 +893,41 @@ func (eg *RiichiMahjong4p) LeadRonEnding(claims []HuClaim) {
 		}
 
 		// 计算和牌点数
-		han, fu, points, yakus := eg.callHuPoints(c, RoundEndRon)
-		if points == 0 {
-			// 没有有效和牌，跳过
+		han, fu, base, yakus, div := eg.callHuPoints(c, RoundEndRon)
+		if base == 0 {
+			// 和牌操作只发给能和牌的玩家，无效宣言在 handleReactionHuEvent 中按错和处理，不会走到这里
+			log.Error("荣和结算时无役，跳过: seat=%d, tile=%v", c.WinnerSeat, c.WinTile)
 			continue
 		}
+		points := ronPoints(base, c.WinnerSeat == dealer, eg.Situation.Honba)
 
-		// 荣和：放铳玩家支付全部点数
+		// 荣和：放铳玩家支付全部点数，本场（300×本场）在多家荣和时向每位和牌者各付一次
+		// 供托只归 stickWinner，在所有和牌者结算后计入
 		delta[c.WinnerSeat] += points
 		if c.HasLoser {
 			delta[c.LoserSeat] -= points
 		}
+		// 包牌：包牌役满部分由放铳者与责任者各付一半
+		if c.HasLoser && winner != nil && winner.PaoSeat >= 0 && winner.PaoSeat != c.LoserSeat {
+			if paoBase := paoBasePoints(base, yakus, winner.PaoYaku); paoBase > 0 {
+				half := ronPoints(paoBase, c.WinnerSeat == dealer, 0) / 2
+				delta[c.LoserSeat] += half
+				delta[winner.PaoSeat] -= half
+			}
+		}
 
 		// 转换为 DTO
-		claimDTO := eg.convertHuClaimToDTOWithFanFu(c, RoundEndRon, han, fu, points, yakus)
+		claimDTO := eg.convertHuClaimToDTOWithFanFu(c, RoundEndRon, han, fu, points, yakus, div)
 		claimDTOs = append(claimDTOs, claimDTO)
 	}
 
-	nextDealer := eg.Situation.DealerIndex
-	if dealerWin {
-		eg.Situation.Honba++
-	} else {
-		eg.Situation.Honba = 0
-		eg.Situation.DealerIndex = (eg.Situation.DealerIndex + 1) % 4
-		eg.Situation.RoundNumber++
-		nextDealer = eg.Situation.DealerIndex
-	}
+	eg.collectRiichiSticks(&delta, stickWinner)
+	nextDealer := eg.advanceDealer(dealerWin)
 
 	// 广播回合结束
 	eg.broadcastRoundEnd(RoundEndRon, claimDTOs, delta, "", nextDealer)
 
-	eg.finalizeRound(delta, stickWinner)
+	eg.finalizeRound(delta)
 }
 
 // LeadTsumoEnding 自摸
@@ -578,66 +946,64 @@ func (eg *RiichiMahjong4p) LeadTsumoEnding(claim HuClaim) {
 	}
 
 	// 计算和牌点数
-	han, fu, points, yakus := eg.callHuPoints(claim, RoundEndTsumo)
-	if points == 0 {
+	han, fu, base, yakus, div := eg.callHuPoints(claim, RoundEndTsumo)
+	if base == 0 {
 		// 没有有效和牌
 		return
 	}
 
-	// 自摸：其他玩家支付点数
-	if winner == dealer {
-		// 庄家自摸：每人支付相同点数
-		payEach := points
-		for i := 0; i < 4; i++ {
-			if i == winner {
-				continue
-			}
-			delta[i] -= payEach
-			delta[winner] += payEach
+	// 包牌：包牌役满部分（含本场）由责任者按荣和点数全额支付，其余部分照常分摊
+	honba := eg.Situation.Honba
+	if winnerPlayer != nil && winnerPlayer.PaoSeat >= 0 {
+		if paoBase := paoBasePoints(base, yakus, winnerPlayer.PaoYaku); paoBase > 0 {
+			pay := ronPoints(paoBase, winner == dealer, honba)
+			delta[winnerPlayer.PaoSeat] -= pay
+			delta[winner] += pay
+			base -= paoBase
+			honba = 0
 		}
-	} else {
-		// 闲家自摸：闲家每人支付基础点数，庄家支付2倍
-		basePoints := points // 闲家每人支付的点数
-		dealerPay := basePoints * 2
-		for i := 0; i < 4; i++ {
+	}
+
+	// 自摸：其他玩家支付点数（庄家自摸时 dealerPay 不会被使用），三麻没有北家的那份（自摸损）
+	if base > 0 {
+		dealerPay, childPay := tsumoPoints(base, winner == dealer, honba)
+		for i := 0; i < eg.seats(); i++ {
 			if i == winner {
 				continue
 			}
+			pay := childPay
 			if i == dealer {
-				delta[i] -= dealerPay
-				delta[winner] += dealerPay
-			} else {
-				delta[i] -= basePoints
-				delta[winner] += basePoints
+				pay = dealerPay
 			}
+			delta[i] -= pay
+			delta[winner] += pay
 		}
 	}
+	points := delta[winner]
+	eg.collectRiichiSticks(&delta, winner)
 
-	nextDealer := eg.Situation.DealerIndex
-	if winner == dealer {
-		eg.Situation.Honba++
-	} else {
-		eg.Situation.Honba = 0
-		eg.Situation.DealerIndex = (eg.Situation.DealerIndex + 1) % 4
-		eg.Situation.RoundNumber++
-		nextDealer = eg.Situation.DealerIndex
-	}
+	nextDealer := eg.advanceDealer(winner == dealer)
 
 	// 转换为 DTO 并广播回合结束
-	claimDTO := eg.convertHuClaimToDTOWithFanFu(claim, RoundEndTsumo, han, fu, points, yakus)
+	claimDTO := eg.convertHuClaimToDTOWithFanFu(claim, RoundEndTsumo, han, fu, points, yakus, div)
 	eg.broadcastRoundEnd(RoundEndTsumo, []HuClaimDTO{claimDTO}, delta, "", nextDealer)
 
-	eg.finalizeRound(delta, winner)
+	eg.finalizeRound(delta)
 }
 
-// finalizeRound 统一结果清算入口 fixme 若西入，强制游戏结束
-func (eg *RiichiMahjong4p) finalizeRound(delta [4]int, stickWinner int) {
-	if eg.Situation == nil {
+// collectRiichiSticks 场上的立直棒（含之前流局留下的）计入和牌者的点数变化，广播和记录的 delta 与实际结算一致
+func (eg *RiichiMahjong4p) collectRiichiSticks(delta *[4]int, winner int) {
+	if winner < 0 || winner >= 4 || eg.Situation.RiichiSticks == 0 {
 		return
 	}
-	if stickWinner >= 0 && stickWinner < 4 && eg.Situation.RiichiSticks > 0 {
-		delta[stickWinner] += eg.Situation.RiichiSticks * 1000
-		eg.Situation.RiichiSticks = 0
+	delta[winner] += eg.Situation.RiichiSticks * 1000
+	eg.Situation.RiichiSticks = 0
+}
+
+// finalizeRound 统一结果清算入口，delta 已包含供托
+func (eg *RiichiMahjong4p) finalizeRound(delta [4]int) {
+	if eg.Situation == nil {
+		return
 	}
 	for i := 0; i < 4; i++ {
 		p := eg.Players[i]
@@ -648,53 +1014,131 @@ func (eg *RiichiMahjong4p) finalizeRound(delta [4]int, stickWinner int) {
 			p.AddPoints(delta[i])
 		}
 	}
+	eg.pushStateUpdateNow()
 	for i := 0; i < 4; i++ {
 		p := eg.Players[i]
 		if p != nil && p.Points < 0 {
+			eg.settleLeftoverSticks()
 			eg.handlerGameOverEvent()
 			return
 		}
 	}
 
-	// 判断是否游戏结束
-	gameEnd := false
-	if eg.Situation.RoundNumber > 4 {
-		maxPoints := -1
-		for i := 0; i < 4; i++ {
-			p := eg.Players[i]
-			if p == nil {
-				continue
-			}
-			if p.Points > maxPoints {
-				maxPoints = p.Points
-			}
-		}
-		if maxPoints >= 30000 {
-			gameEnd = true
-		} else {
-			eg.Situation.RoundNumber = 1
-			//eg.Situation.RoundWind = nextWind(eg.Situation.RoundWind)
-		}
-	}
-
-	if gameEnd {
+	if eg.isGameEnd() {
+		eg.settleLeftoverSticks()
 		eg.handlerGameOverEvent()
 		return
 	}
 
 	eg.Reactions = make(map[int]*PlayerReaction)
 	eg.clearLastDiscard()
+	eg.pendingKan = PendingKan{}
+	eg.pendingKanDora = 0
+	eg.firstDiscards = nil
 	eg.NotifyEvent(&StartRoundEvent{})
 }
 
-// evalClaimYakuman 返回 番数、三倍满(1)|役满(2)、役种
-func (eg *RiichiMahjong4p) evalClaimYakuman(claim HuClaim, endKind string) (int, int, []Yaku) {
+// settleLeftoverSticks 流局留下的立直棒会累积到下一局，游戏结束时仍在场上的由第一名获得
+// 同分时座位靠前（离起家近）的优先，与终局排名一致
+func (eg *RiichiMahjong4p) settleLeftoverSticks() {
+	if eg.Situation.RiichiSticks == 0 {
+		return
+	}
+	top := -1
+	for i := 0; i < eg.seats(); i++ {
+		p := eg.Players[i]
+		if p != nil && (top < 0 || p.Points > eg.Players[top].Points) {
+			top = i
+		}
+	}
+	if top < 0 {
+		return
+	}
+	eg.Players[top].AddPoints(eg.Situation.RiichiSticks * 1000)
+	log.Info("游戏结束，剩余 %d 根立直棒归第一名玩家 %d", eg.Situation.RiichiSticks, top)
+	eg.Situation.RiichiSticks = 0
+}
+
+// isGameEnd 推进场风并判断游戏是否结束
+// 最后一场（东风战为东场，半庄战为南场）打完后有人达到 TargetScore 即结束，否则进入延长战，
+// 延长战中任意一局结束后有人达到 TargetScore 即结束，MaxWind 场打完强制结束
+func (eg *RiichiMahjong4p) isGameEnd() bool {
+	maxPoints := -1
+	for i := 0; i < 4; i++ {
+		p := eg.Players[i]
+		if p != nil && p.Points > maxPoints {
+			maxPoints = p.Points
+		}
+	}
+	reached := maxPoints >= eg.TargetScore
+
+	if eg.Situation.RoundNumber > eg.seats() {
+		finished := eg.Situation.RoundWind
+		eg.Situation.RoundNumber = 1
+		eg.Situation.RoundWind = finished.Next()
+		if finished >= eg.MaxWind {
+			return true
+		}
+		return finished >= eg.endWind() && reached
+	}
+	// 延长战：突然死亡
+	return eg.Situation.RoundWind > eg.endWind() && reached
+}
+
+// endWind 正常结束的场风
+func (eg *RiichiMahjong4p) endWind() Wind {
+	return eg.GameLength.EndWind()
+}
+
+// evalClaimYakuman 返回 番数、三倍满(1)|役满(2)、符数、役种、采用的拆解
+// 同一手牌可能有多种拆解，逐一计算后取得分最高的一种；国士无双等非标准型拆解为 nil
+func (eg *RiichiMahjong4p) evalClaimYakuman(claim HuClaim, endKind string) (int, int, int, []Yaku, *HandDivision) {
 	var winner *PlayerImage
 	if claim.WinnerSeat >= 0 && claim.WinnerSeat < 4 {
 		winner = eg.Players[claim.WinnerSeat]
 	}
 	ctx := &YakuContext{Claim: claim, Winner: winner, Situation: eg.Situation, EndKind: endKind}
+	isTsumo := endKind == RoundEndTsumo
 
+	divisions := divideHand(ctx)
+	if len(divisions) == 0 {
+		// 国士无双等非标准型，没有面子拆解
+		han, ym, results := evalYakuRegistry(ctx)
+		return han, ym, computeFu(ctx, han, isTsumo), results, nil
+	}
+
+	// 宝牌与拆解无关，但会拉开封顶后相同的得分（6 番 40 符与 7 番 30 符都是跳满，加一枚宝牌后分别是跳满和倍满），比较时计入
+	dora, ura, aka := eg.countClaimDora(claim)
+	bonus := dora + ura + aka
+	score := func(han, fu int) int {
+		if han == 0 {
+			return 0 // 宝牌不是役
+		}
+		return basePoints(han+bonus, fu)
+	}
+
+	bestHan, bestYm, bestFu := 0, 0, 0
+	var bestResults []Yaku
+	var bestDivision *HandDivision
+	for i := range divisions {
+		ctx.Division = &divisions[i]
+		han, ym, results := evalYakuRegistry(ctx)
+		fu := computeFu(ctx, han, isTsumo)
+		better := bestResults == nil || ym > bestYm
+		if !better && ym == bestYm {
+			// 得分相同时取番数高、再取符数高的拆解，结果与拆解的枚举顺序无关
+			s, bs := score(han, fu), score(bestHan, bestFu)
+			better = s > bs || (s == bs && (han > bestHan || (han == bestHan && fu > bestFu)))
+		}
+		if better {
+			bestHan, bestYm, bestFu, bestResults, bestDivision = han, ym, fu, results, &divisions[i]
+		}
+	}
+	return bestHan, bestYm, bestFu, bestResults, bestDivision
+}
+
+// evalYakuRegistry 按当前上下文跑一遍役种注册表
+func evalYakuRegistry(ctx *YakuContext) (int, int, []Yaku) {
 	results := make([]Yaku, 0, 8)
 	hanSum := 0
 	yakumanMultSum := 0
@@ -709,6 +1153,7 @@ func (eg *RiichiMahjong4p) evalClaimYakuman(claim HuClaim, endKind string) (int,
 	return hanSum, yakumanMultSum, results
 }
 
+// selectStickWinnerRonA 多家荣和时按巡目顺序离放铳者最近的和牌者，供托归其所有，头跳时只有其和牌
 func selectStickWinnerRonA(claims []HuClaim) int {
 	if len(claims) == 0 {
 		return -1
@@ -730,34 +1175,45 @@ func selectStickWinnerRonA(claims []HuClaim) int {
 	return best
 }
 
-// CheckFourKanDraw 检查4杠散了流局
+// CheckFourKanDraw 检查4杠散了流局：4 个杠分属两名以上玩家时流局
+// 一名玩家独自开了 4 个杠（四杠子听牌）不流局，但之后任何人都不能再开杠
 func (eg *RiichiMahjong4p) CheckFourKanDraw() bool {
-	// 统计所有玩家的杠数
-	totalKans := 0
+	totalKans, owners := eg.countKans()
+	return totalKans >= 4 && owners >= 2
+}
+
+// maxKansPerRound 一局最多 4 个杠，与岭上牌张数相同；一名玩家独自开了 4 个杠时同样不能开第 5 个
+const maxKansPerRound = 4
+
+// canDeclareKan 场上杠数已达上限或岭上牌已摸完时不能再开杠，在改动手牌和副露之前检查
+func (eg *RiichiMahjong4p) canDeclareKan() bool {
+	totalKans, _ := eg.countKans()
+	if totalKans >= maxKansPerRound {
+		return false
+	}
+	return eg.DeckManager != nil && eg.DeckManager.CanKan()
+}
+
+// countKans 统计场上的杠数，以及开过杠的玩家数
+func (eg *RiichiMahjong4p) countKans() (int, int) {
+	totalKans, owners := 0, 0
 	for i := 0; i < 4; i++ {
 		player := eg.Players[i]
 		if player == nil {
 			continue
 		}
+		kans := 0
 		for _, meld := range player.Melds {
 			if meld.Type == "Gang" || meld.Type == "Kakan" || meld.Type == "Ankan" {
-				totalKans++
+				kans++
 			}
 		}
-	}
-
-	// 如果有4个杠，检查岭上牌是否足够
-	if totalKans >= 4 {
-		if eg.DeckManager == nil {
-			return false
-		}
-		// 需要4张岭上牌，如果剩余不足4张，则流局
-		if eg.DeckManager.RemainingKanTiles() < 4 {
-			return true
+		if kans > 0 {
+			totalKans += kans
+			owners++
 		}
 	}
-
-	return false
+	return totalKans, owners
 }
 
 // revealUraDoraIndicators 翻开里宝牌指示牌（立直和牌时使用）
@@ -765,9 +1221,9 @@ func (eg *RiichiMahjong4p) revealUraDoraIndicators() {
 	if eg.DeckManager == nil {
 		return
 	}
-	// 翻开与已翻开的宝牌指示牌数量相同的里宝牌指示牌
+	// 翻开与已翻开的宝牌指示牌（含杠宝牌）数量相同的里宝牌指示牌，多家和牌时只翻一次
 	doraCount := len(eg.DeckManager.GetDoraIndicators())
-	for i := 0; i < doraCount; i++ {
+	for len(eg.DeckManager.GetUraDoraIndicators()) < doraCount {
 		_, ok := eg.DeckManager.RevealUraDoraIndicator()
 		if !ok {
 			break // 里宝牌指示牌已全部翻开
@@ -775,9 +1231,31 @@ func (eg *RiichiMahjong4p) revealUraDoraIndicators() {
 	}
 }
 
+// revealKanDora 翻开一张杠宝牌指示牌并广播
+func (eg *RiichiMahjong4p) revealKanDora() {
+	if eg.DeckManager == nil {
+		return
+	}
+	indicator, ok := eg.DeckManager.RevealDoraIndicator()
+	if !ok {
+		return
+	}
+	eg.broadcastNewDora(indicator)
+}
+
+// flushPendingKanDora 翻开明杠/加杠后尚未翻开的杠宝牌（打牌后，或连续开杠前）
+func (eg *RiichiMahjong4p) flushPendingKanDora() {
+	for ; eg.pendingKanDora > 0; eg.pendingKanDora-- {
+		eg.revealKanDora()
+	}
+}
+
 // fixme 游戏结束，生命周期结束，通知结果，自毁回调
 func (eg *RiichiMahjong4p) handlerGameOverEvent() {
 	log.Info("游戏结束")
+	if eg.Worker != nil {
+		metrics.GameEnded()
+	}
 	// 广播游戏结束
 	eg.broadcastGameEnd()
 	eg.Terminate()
@@ -811,31 +1289,56 @@ func (eg *RiichiMahjong4p) handleDropTileEvent(event *share.DropTileEvent) {
 		log.Warn("不是当前玩家的回合，当前玩家: %d, 事件玩家: %d", eg.TurnManager.GetCurrentPlayer(), seatIndex)
 		return
 	}
-	ticker := eg.TurnManager.GetPlayerTicker(seatIndex)
-	ok := ticker.Stop()
-	if !ok {
-		log.Warn("handleDropTileEvent 已经超时处理, %v", event)
-		return
-	}
-
-	// 处理出牌逻辑
 	player := eg.Players[seatIndex]
 	if player == nil {
 		log.Warn("玩家 %d 不存在", seatIndex)
 		return
 	}
 
+	// 出牌校验放在停止计时之前，被拒绝的出牌不影响该玩家的超时自动出牌
 	tile := toMahjongTile(event.GetTile())
-	if !player.DiscardTile(tile) {
+	// 立直后只能摸切（宣言牌除外）
+	if player.IsRiichi && !player.riichiDeclared {
+		if player.NewestTile == nil || player.NewestTile.Type != tile.Type || player.NewestTile.ID != tile.ID {
+			log.Warn("玩家 %d 已立直，只能打出刚摸到的牌: %v", seatIndex, tile)
+			return
+		}
+	}
+	if player.IsKuikaeForbidden(tile.Type) && !player.OnlyKuikaeTiles() {
+		log.Warn("玩家 %d 鸣牌后不能打出食替牌: %v", seatIndex, tile)
+		return
+	}
+	if !player.HasTiles(tile) {
 		log.Warn("玩家 %d 手中没有该牌: %v", seatIndex, tile)
 		return
 	}
+
+	ticker := eg.TurnManager.GetPlayerTicker(seatIndex)
+	_, ok := ticker.Stop()
+	if !ok {
+		log.Warn("handleDropTileEvent 已经超时处理, %v", event)
+		return
+	}
+
+	// 处理出牌逻辑
+	firstTurn := player.FirstTurn
+	riichiDiscard := player.riichiDeclared
+	if !player.DiscardTile(tile) {
+		eg.HappenDamageError(fmt.Sprintf("出牌失败: seat=%d, tile=%v", seatIndex, tile))
+		return
+	}
+	eg.recordFirstDiscard(firstTurn, tile)
 	eg.setLastDiscard(seatIndex, tile)
 
 	log.Info("玩家 %d 出牌: %v", seatIndex, tile)
 
 	// 广播出牌（所有玩家可见）
+	eg.bumpActionSeq()
 	eg.broadcastDiscard(seatIndex, tile)
+	eg.flushPendingKanDora()
+	if riichiDiscard || eg.Rules.BeginnerMode {
+		eg.pushWaits(seatIndex)
+	}
 
 	eg.waitReaction(seatIndex)
 }
@@ -852,11 +1355,19 @@ func (eg *RiichiMahjong4p) waitReaction(excludeSeat int) {
 	eg.Reactions = reactions
 
 	if len(eg.Reactions) == 0 {
+		if eg.isSuufonRenda() {
+			eg.handleRoundOverEvent(nil, RoundEndSuufon)
+			return
+		}
 		nextPlayer := eg.TurnManager.NextTurn()
 		eg.DropTurn(nextPlayer, true)
 		return
 	}
+	eg.startReactionWindow()
+}
 
+// startReactionWindow 下发可选操作并启动反应玩家的计时
+func (eg *RiichiMahjong4p) startReactionWindow() {
 	// 下发操作给客户端
 	eg.broadcastOperations(eg.Reactions)
 
@@ -867,10 +1378,9 @@ func (eg *RiichiMahjong4p) waitReaction(excludeSeat int) {
 	eg.TurnManager.EnterReactingPhase()
 
 	for seatIndex := range eg.Reactions {
+		// 反应计时独立于出牌的总剩余时间，考虑鸣牌不会占用之后出牌的时间
 		ticker := eg.TurnManager.GetPlayerTicker(seatIndex)
-		allocatedTime := ticker.Available + 3
-		ticker.SetAvailable(allocatedTime)
-		if err := ticker.Start(allocatedTime); err != nil {
+		if err := ticker.StartReaction(eg.Rules.ReactionTime); err != nil {
 			log.Error("启动反应计时失败 (座位 %d): %v", seatIndex, err)
 		}
 	}
@@ -879,12 +1389,16 @@ func (eg *RiichiMahjong4p) waitReaction(excludeSeat int) {
 // recordPlayerResponse 记录玩家响应
 func (eg *RiichiMahjong4p) recordPlayerResponse(seatIndex int, chosenOp *PlayerOperation) {
 	ticker := eg.TurnManager.GetPlayerTicker(seatIndex)
-	ok := ticker.Stop()
+	_, ok := ticker.Stop()
 	if !ok {
 		log.Warn("recordPlayerResponse 响应已经超时处理, %v", chosenOp)
 		return
 	}
+	eg.applyPlayerResponse(seatIndex, chosenOp)
+}
 
+// applyPlayerResponse 记录响应，所有响应收集完后结算反应阶段
+func (eg *RiichiMahjong4p) applyPlayerResponse(seatIndex int, chosenOp *PlayerOperation) {
 	reaction, exists := eg.Reactions[seatIndex]
 	if !exists {
 		log.Warn("玩家 %d 不在反应列表中", seatIndex)
@@ -932,17 +1446,10 @@ func (eg *RiichiMahjong4p) handlePengEvent(event *share.PengTileEvent) {
 		return
 	}
 
-	// 查找碰牌操作
-	var pengOp *PlayerOperation
-	for _, op := range reaction.Operations {
-		if op.Type == "PENG" {
-			pengOp = op
-			break
-		}
-	}
-
+	// 查找玩家选择的碰牌操作
+	pengOp := selectOperation(reaction.Operations, "PENG", event.GetTiles())
 	if pengOp == nil {
-		log.Warn("玩家 %d 没有碰牌操作", seatIndex)
+		log.Warn("玩家 %d 没有对应的碰牌操作: %v", seatIndex, event.GetTiles())
 		return
 	}
 
@@ -1001,6 +1508,12 @@ func (eg *RiichiMahjong4p) handleChiEvent(event *share.ChiEvent) {
 		return
 	}
 
+	// 只有出牌者的下家可以吃
+	if !eg.isChiSeat(seatIndex) {
+		log.Warn("玩家 %d 不是出牌者的下家，不能吃", seatIndex)
+		return
+	}
+
 	reaction, exists := eg.Reactions[seatIndex]
 
 	if !exists {
@@ -1008,17 +1521,10 @@ func (eg *RiichiMahjong4p) handleChiEvent(event *share.ChiEvent) {
 		return
 	}
 
-	// 查找吃牌操作
-	var chiOp *PlayerOperation
-	for _, op := range reaction.Operations {
-		if op.Type == "CHI" {
-			chiOp = op
-			break
-		}
-	}
-
+	// 查找玩家选择的吃牌操作
+	chiOp := selectOperation(reaction.Operations, "CHI", event.GetTiles())
 	if chiOp == nil {
-		log.Warn("玩家 %d 没有吃牌操作", seatIndex)
+		log.Warn("玩家 %d 没有对应的吃牌操作: %v", seatIndex, event.GetTiles())
 		return
 	}
 
@@ -1036,21 +1542,22 @@ func (eg *RiichiMahjong4p) handleReactionHuEvent(event *share.HuEvent) {
 		log.Warn("获取玩家座位失败: %v", err)
 		return
 	}
-	reaction, exists := eg.Reactions[seatIndex]
-	if !exists {
-		log.Warn("玩家 %d 不在反应列表中", seatIndex)
-		return
-	}
-	// 查找和牌操作
+	// 查找和牌操作，没有和牌操作（不成和牌型、无役或振听）时按错和处理
 	var huOp *PlayerOperation
-	for _, op := range reaction.Operations {
-		if op.Type == "HU" {
-			huOp = op
-			break
+	if reaction, exists := eg.Reactions[seatIndex]; exists {
+		for _, op := range reaction.Operations {
+			if op.Type == "HU" {
+				huOp = op
+				break
+			}
 		}
 	}
 	if huOp == nil {
-		log.Warn("玩家 %d 没有和牌操作", seatIndex)
+		if !eg.chomboEnabled() || !eg.lastDiscard.Valid || seatIndex == eg.lastDiscard.Seat {
+			log.Warn("玩家 %d 没有和牌操作", seatIndex)
+			return
+		}
+		eg.declareChombo(HuClaim{WinnerSeat: seatIndex, HasLoser: true, LoserSeat: eg.lastDiscard.Seat, WinTile: eg.lastDiscard.Tile})
 		return
 	}
 
@@ -1083,6 +1590,17 @@ func (eg *RiichiMahjong4p) handleAnkanEvent(event *share.AnkanEvent) {
 		return
 	}
 
+	// 海底牌不能开杠
+	if eg.isLastDraw() {
+		log.Warn("玩家 %d 摸到海底牌，无法暗杠", seatIndex)
+		return
+	}
+	if !eg.canDeclareKan() {
+		totalKans, _ := eg.countKans()
+		log.Warn("场上已有 %d 个杠或岭上牌已摸完，拒绝玩家 %d 暗杠", totalKans, seatIndex)
+		return
+	}
+
 	tile := toMahjongTile(event.GetTile())
 
 	// 检查手牌中是否有四张相同的牌
@@ -1096,6 +1614,11 @@ func (eg *RiichiMahjong4p) handleAnkanEvent(event *share.AnkanEvent) {
 		log.Warn("玩家 %d 手牌中没有四张 %v，无法暗杠", seatIndex, tile)
 		return
 	}
+	// 立直后的暗杠不能改变听牌
+	if player.IsRiichi && !eg.canRiichiAnkan(seatIndex, tile.Type) {
+		log.Warn("玩家 %d 已立直，暗杠 %v 会改变听牌", seatIndex, tile)
+		return
+	}
 
 	// 移除四张相同牌
 	removedCount := 0
@@ -1120,6 +1643,22 @@ func (eg *RiichiMahjong4p) handleAnkanEvent(event *share.AnkanEvent) {
 		From:  -1, // -1 表示暗杠
 	})
 
+	// 广播暗杠（所有玩家可见）
+	eg.bumpActionSeq()
+	eg.broadcastAnkan(seatIndex, ankanTiles)
+
+	// 国士无双可以抢暗杠
+	if eg.waitChankan(seatIndex, ankanTiles[0], "Ankan") {
+		return
+	}
+	eg.completeAnkan(seatIndex)
+}
+
+// completeAnkan 暗杠成立（无人抢杠），摸岭上牌后继续出牌
+func (eg *RiichiMahjong4p) completeAnkan(seatIndex int) {
+	player := eg.Players[seatIndex]
+	eg.interruptByCall()
+
 	// 检查4杠散了流局
 	if eg.CheckFourKanDraw() {
 		eg.handleRoundOverEvent(nil, RoundEndDraw4Kan)
@@ -1138,31 +1677,38 @@ func (eg *RiichiMahjong4p) handleAnkanEvent(event *share.AnkanEvent) {
 		return
 	}
 
+	// 暗杠立即翻开杠宝牌
+	eg.flushPendingKanDora()
+	eg.revealKanDora()
+
+	// 立直中的暗杠不改变听牌，摸岭上牌前按 13 张推送听牌
+	if player.IsRiichi {
+		eg.pushWaits(seatIndex)
+	}
+
 	// 从岭上牌摸一张
 	kanTile, ok := eg.DeckManager.DrawKanTile()
 	if !ok {
 		eg.HappenDamageError("岭上牌为空，无法暗杠")
 		return
 	}
-	player.DrawTile(kanTile)
+	player.DrawRinshanTile(kanTile)
 
 	// 停止当前计时
 	ticker := eg.TurnManager.GetPlayerTicker(seatIndex)
 	ticker.Stop()
 
-	// 广播暗杠（所有玩家可见）
-	eg.broadcastAnkan(seatIndex, ankanTiles)
-
 	// 推送摸牌（仅自己可见）
 	eg.pushDrawTile(seatIndex, kanTile)
 
 	// 继续当前玩家的回合（暗杠后继续出牌）
-	if err := eg.TurnManager.EnterDropPhase(seatIndex, DefaultRoundCompensation); err != nil {
+	if err := eg.TurnManager.EnterDropPhase(seatIndex, eg.Rules.RoundCompensation); err != nil {
 		eg.HappenDamageError("暗杠后进入出牌阶段失败")
 		return
 	}
+	eg.pauseIfOffline(seatIndex)
 
-	log.Info("玩家 %d 暗杠成功，杠牌: %v", seatIndex, ankanTiles)
+	log.Info("玩家 %d 暗杠成功，杠牌: %v", seatIndex, player.Melds[len(player.Melds)-1].Tiles)
 }
 
 func (eg *RiichiMahjong4p) handleKakanEvent(event *share.KakanEvent) {
@@ -1191,6 +1737,17 @@ func (eg *RiichiMahjong4p) handleKakanEvent(event *share.KakanEvent) {
 		return
 	}
 
+	// 海底牌不能开杠
+	if eg.isLastDraw() {
+		log.Warn("玩家 %d 摸到海底牌，无法加杠", seatIndex)
+		return
+	}
+	if !eg.canDeclareKan() {
+		totalKans, _ := eg.countKans()
+		log.Warn("场上已有 %d 个杠或岭上牌已摸完，拒绝玩家 %d 加杠", totalKans, seatIndex)
+		return
+	}
+
 	tile := toMahjongTile(event.GetTile())
 
 	// 检查手牌中是否有这张牌
@@ -1220,6 +1777,23 @@ func (eg *RiichiMahjong4p) handleKakanEvent(event *share.KakanEvent) {
 	pengMeld.Type = "Kakan" // 或 "Gang"，根据你的设计
 	pengMeld.Tiles = append(pengMeld.Tiles, tile)
 
+	// 广播加杠（所有玩家可见）
+	eg.bumpActionSeq()
+	eg.broadcastKakan(seatIndex, pengMeld.From, pengMeld.Tiles)
+
+	// 其他玩家可以抢杠
+	if eg.waitChankan(seatIndex, tile, "Kakan") {
+		return
+	}
+	eg.completeKakan(seatIndex, pengMeldIndex)
+}
+
+// completeKakan 加杠成立（无人抢杠），摸岭上牌后继续出牌
+func (eg *RiichiMahjong4p) completeKakan(seatIndex int, pengMeldIndex int) {
+	player := eg.Players[seatIndex]
+	pengMeld := &player.Melds[pengMeldIndex]
+	tile := pengMeld.Tiles[len(pengMeld.Tiles)-1]
+
 	// 从岭上牌摸一张牌
 	if eg.DeckManager == nil {
 		eg.HappenDamageError("DeckManager 为空，无法摸岭上牌")
@@ -1250,6 +1824,10 @@ func (eg *RiichiMahjong4p) handleKakanEvent(event *share.KakanEvent) {
 		return
 	}
 
+	// 加杠的杠宝牌在打牌后翻开
+	eg.flushPendingKanDora()
+	eg.pendingKanDora++
+
 	// 从岭上牌摸一张
 	kanTile, ok := eg.DeckManager.DrawKanTile()
 	if !ok {
@@ -1260,23 +1838,22 @@ func (eg *RiichiMahjong4p) handleKakanEvent(event *share.KakanEvent) {
 		pengMeld.Tiles = pengMeld.Tiles[:len(pengMeld.Tiles)-1]
 		return
 	}
-	player.DrawTile(kanTile)
+	player.DrawRinshanTile(kanTile)
+	eg.interruptByCall()
 
 	// 停止当前计时
 	ticker := eg.TurnManager.GetPlayerTicker(seatIndex)
 	ticker.Stop()
 
-	// 广播加杠（所有玩家可见）
-	eg.broadcastKakan(seatIndex, pengMeld.From, pengMeld.Tiles)
-
 	// 推送摸牌（仅自己可见）
 	eg.pushDrawTile(seatIndex, kanTile)
 
 	// 继续当前玩家的回合（加杠后继续出牌）
-	if err := eg.TurnManager.EnterDropPhase(seatIndex, DefaultRoundCompensation); err != nil {
+	if err := eg.TurnManager.EnterDropPhase(seatIndex, eg.Rules.RoundCompensation); err != nil {
 		eg.HappenDamageError("加杠后进入出牌阶段失败")
 		return
 	}
+	eg.pauseIfOffline(seatIndex)
 
 	log.Info("玩家 %d 加杠成功，杠牌: %v", seatIndex, pengMeld.Tiles)
 }
@@ -1288,15 +1865,22 @@ func (eg *RiichiMahjong4p) handleRiichiEvent(event *share.RiichiEvent) {
 		log.Warn("获取玩家座位失败: %v", err)
 		return
 	}
+	if eg.TurnManager.GetState() != TurnStateWaitMain || seatIndex != eg.TurnManager.GetCurrentPlayer() {
+		log.Warn("不是玩家 %d 的出牌阶段，无法立直", seatIndex)
+		return
+	}
 	player := eg.Players[seatIndex]
 	if player == nil {
 		log.Warn("玩家 %d 不存在", seatIndex)
 		return
 	}
+	if err := eg.canRiichi(seatIndex); err != nil {
+		log.Warn("立直被拒绝: %v", err)
+		return
+	}
 
 	// 标记玩家为立直状态
-	player.IsRiichi = true
-	player.IsWaiting = true
+	player.DeclareRiichi()
 
 	// 扣除立直棒
 	player.AddPoints(-1000)
@@ -1308,6 +1892,118 @@ func (eg *RiichiMahjong4p) handleRiichiEvent(event *share.RiichiEvent) {
 	log.Info("玩家 %d 立直", seatIndex)
 }
 
+// handleKyuushuuEvent 九种九牌宣言，中途流局
+func (eg *RiichiMahjong4p) handleKyuushuuEvent(event *share.KyuushuuEvent) {
+	log.Info("处理九种九牌事件")
+	seatIndex, err := eg.getSeatIndex(event.GetUserID())
+	if err != nil {
+		log.Warn("获取玩家座位失败: %v", err)
+		return
+	}
+	if eg.TurnManager.GetState() != TurnStateWaitMain || seatIndex != eg.TurnManager.GetCurrentPlayer() {
+		log.Warn("不是玩家 %d 的出牌阶段，无法宣言九种九牌", seatIndex)
+		return
+	}
+	if !eg.canKyuushuu(seatIndex) {
+		log.Warn("玩家 %d 不满足九种九牌条件", seatIndex)
+		return
+	}
+	log.Info("玩家 %d 宣言九种九牌", seatIndex)
+	eg.handleRoundOverEvent(nil, RoundEndKyuushuu)
+}
+
+// handleKitaEvent 拔北：把一张北放到一边，从牌山末尾补牌后继续出牌
+func (eg *RiichiMahjong4p) handleKitaEvent(event *share.KitaEvent) {
+	log.Info("处理拔北事件")
+	seatIndex, err := eg.getSeatIndex(event.GetUserID())
+	if err != nil {
+		log.Warn("获取玩家座位失败: %v", err)
+		return
+	}
+	if eg.TurnManager.GetState() != TurnStateWaitMain || seatIndex != eg.TurnManager.GetCurrentPlayer() {
+		log.Warn("不是玩家 %d 的出牌阶段，无法拔北", seatIndex)
+		return
+	}
+	north, ok := eg.canKita(seatIndex)
+	if !ok {
+		log.Warn("玩家 %d 不能拔北", seatIndex)
+		return
+	}
+	ticker := eg.TurnManager.GetPlayerTicker(seatIndex)
+	if _, ok := ticker.Stop(); !ok {
+		log.Warn("handleKitaEvent 已经超时处理, %v", event)
+		return
+	}
+
+	player := eg.Players[seatIndex]
+	if !player.RemoveTile(north) {
+		eg.HappenDamageError(fmt.Sprintf("拔北找不到手牌: %v", north))
+		return
+	}
+	player.Kita = append(player.Kita, north)
+	eg.bumpActionSeq()
+	eg.broadcastKita(seatIndex, north)
+
+	replacement, ok := eg.DeckManager.DrawKitaTile()
+	if !ok {
+		eg.HappenDamageError("牌山为空，无法拔北补牌")
+		return
+	}
+	player.DrawRinshanTile(replacement)
+	eg.pushDrawTile(seatIndex, replacement)
+	eg.DropTurn(seatIndex, false)
+}
+
+// handleScorePreviewEvent 试算和牌点数，结果只推送给请求者，任何阶段都可以请求
+func (eg *RiichiMahjong4p) handleScorePreviewEvent(event *share.ScorePreviewEvent) {
+	seatIndex, err := eg.getSeatIndex(event.GetUserID())
+	if err != nil {
+		log.Warn("获取玩家座位失败: %v", err)
+		return
+	}
+	tile := toMahjongTile(event.Tile)
+	han, fu, points, yaku := eg.previewScore(seatIndex, tile, event.Tsumo)
+	eg.pushScorePreview(seatIndex, ScorePreviewDTO{
+		WinTile: tile,
+		Tsumo:   event.Tsumo,
+		Han:     han,
+		Fu:      fu,
+		Yaku:    yaku,
+		Points:  points,
+	})
+}
+
+// actionEventTypes 需要校验操作序号的玩家操作
+var actionEventTypes = map[string]struct{}{
+	"DropTile": {}, "Peng": {}, "Gang": {}, "Ankan": {}, "Kakan": {}, "Chi": {},
+	"Hu": {}, "RongHu": {}, "TouchHu": {}, "Riichi": {}, "Kyuushuu": {}, "Kita": {},
+}
+
+// isStaleAction 玩家操作带的序号不是当前序号时丢弃（网络重试导致的重复打牌、过期的鸣牌等）
+// 序号为 0 的旧客户端不校验，仍由状态机和计时器兜底
+func (eg *RiichiMahjong4p) isStaleAction(event share.GameEvent) bool {
+	if _, ok := actionEventTypes[event.GetEventType()]; !ok {
+		return false
+	}
+	sequenced, ok := event.(interface{ GetSeq() int })
+	if !ok || sequenced.GetSeq() == 0 || sequenced.GetSeq() == eg.actionSeq {
+		return false
+	}
+	log.Warn("丢弃过期操作: %s, userID=%s, seq=%d, 当前 seq=%d", event.GetEventType(), event.GetUserID(), sequenced.GetSeq(), eg.actionSeq)
+	return true
+}
+
+// bumpActionSeq 局面推进，之前的操作全部过期；需在推送新局面之前调用，让推送带上新序号
+func (eg *RiichiMahjong4p) bumpActionSeq() {
+	eg.actionSeq++
+}
+
+// nextEventSeq 分配下一个推送序号，只在 actor 中调用
+func (eg *RiichiMahjong4p) nextEventSeq() int {
+	eg.eventSeq++
+	return eg.eventSeq
+}
+
 // makeTimeoutHandler 创建超时处理回调
 func (eg *RiichiMahjong4p) makeTimeoutHandler(seatIndex int) func() {
 	return func() {
@@ -1326,35 +2022,58 @@ func (eg *RiichiMahjong4p) makeStopHandler(seatIndex int) func() {
 	}
 }
 
-// handleDropTimeout 处理出牌超时
+// handleDropTimeout 处理出牌超时，bot 座位按策略操作，其他玩家自动打出摸到的手牌
 func (eg *RiichiMahjong4p) handleDropTimeout(seatIndex int) {
-	log.Info("玩家 %d 出牌超时，自动打出摸到的手牌", seatIndex)
-
 	player := eg.Players[seatIndex]
 	if player == nil || len(player.Tiles) == 0 {
 		eg.HappenDamageError(fmt.Sprintf("玩家 %d 手牌为空，无法出牌", seatIndex))
 		return
 	}
+	if eg.isBotSeat(seatIndex) && eg.BotPolicy != nil {
+		if eg.botMainTurn(seatIndex) {
+			return
+		}
+	} else {
+		log.Info("玩家 %d 出牌超时，自动打出摸到的手牌", seatIndex)
+	}
+
+	firstTurn := player.FirstTurn
 	tileToDiscard, ok := player.DiscardNewestOrLast()
 	if !ok {
 		eg.HappenDamageError("自动出牌失败")
 		return
 	}
-	log.Info("玩家 %d 自动打出牌: %v", seatIndex, tileToDiscard)
-	eg.setLastDiscard(seatIndex, tileToDiscard)
+	eg.finishAutoDiscard(seatIndex, firstTurn, tileToDiscard)
+}
+
+// finishAutoDiscard 自动出牌后的广播和反应阶段
+func (eg *RiichiMahjong4p) finishAutoDiscard(seatIndex int, firstTurn bool, tile Tile) {
+	eg.recordFirstDiscard(firstTurn, tile)
+	log.Info("玩家 %d 自动打出牌: %v", seatIndex, tile)
+	eg.setLastDiscard(seatIndex, tile)
+	eg.bumpActionSeq()
+	eg.broadcastDiscard(seatIndex, tile)
+	eg.flushPendingKanDora()
 	eg.waitReaction(seatIndex)
 }
 
-// handleReactionTimeout 处理反应超时
+// handleReactionTimeout 处理反应超时，bot 座位按策略选择，其他玩家自动跳过
 func (eg *RiichiMahjong4p) handleReactionTimeout(seatIndex int) {
-	log.Info("玩家 %d 反应超时，自动跳过", seatIndex)
-
-	// 超时时记录为跳过（选择第一个可用操作或跳过）
-	skipOp := &PlayerOperation{
+	chosen := &PlayerOperation{
 		Type:  "SKIP",
 		Tiles: []Tile{},
 	}
-	eg.recordPlayerResponse(seatIndex, skipOp)
+	if reaction, ok := eg.Reactions[seatIndex]; ok && eg.isBotSeat(seatIndex) && eg.BotPolicy != nil {
+		if op := eg.BotPolicy.React(eg.botView(seatIndex), reaction.Operations); op != nil {
+			chosen = op
+		}
+	}
+	log.Info("玩家 %d 反应超时，自动选择: %s", seatIndex, chosen.Type)
+	if chosen.Type == "HU" && eg.lastDiscard.Valid {
+		eg.broadcastRon(seatIndex, eg.lastDiscard.Seat, eg.lastDiscard.Tile)
+	}
+	// 计时已经超时结束，直接记录响应
+	eg.applyPlayerResponse(seatIndex, chosen)
 }
 
 // handleReactionComplete 处理玩家
@@ -1366,6 +2085,8 @@ func (eg *RiichiMahjong4p) handleReactionComplete() {
 		return
 	}
 	eg.TurnManager.EnterChoosingPhase()
+	eg.bumpActionSeq()
+	eg.markMissedRons()
 
 	ronSeats := make([]int, 0, 3)
 	for seatIndex, reaction := range eg.Reactions {
@@ -1373,18 +2094,32 @@ func (eg *RiichiMahjong4p) handleReactionComplete() {
 			ronSeats = append(ronSeats, seatIndex)
 		}
 	}
+	// Reactions 是 map，按座位排序，多家和牌时结算、推送和牌谱中的顺序固定
+	slices.Sort(ronSeats)
 	if len(ronSeats) > 0 {
-		if len(ronSeats) >= 3 {
-			log.Info("一炮三响，荒牌流局")
-			eg.handleRoundOverEvent(nil, RoundEndDraw3Ron)
-			return
+		// 被抢的杠不成立；抢暗杠（国士）不计抢杠役
+		chankan := eg.pendingKan.Valid && eg.pendingKan.Type == "Kakan"
+		if eg.pendingKan.Valid {
+			eg.cancelPendingKan()
 		}
 		claims := make([]HuClaim, 0, len(ronSeats))
 		for _, w := range ronSeats {
-			claims = append(claims, HuClaim{WinnerSeat: w, HasLoser: true, LoserSeat: eg.lastDiscard.Seat, WinTile: eg.lastDiscard.Tile})
+			claims = append(claims, HuClaim{WinnerSeat: w, HasLoser: true, LoserSeat: eg.lastDiscard.Seat, WinTile: eg.lastDiscard.Tile, Chankan: chankan, LastTile: !chankan && eg.isLastDraw()})
 		}
-		if len(ronSeats) == 2 {
-			log.Info("一炮两响，累计计算: winners=%v, loser=%d, tile=%v", ronSeats, eg.lastDiscard.Seat, eg.lastDiscard.Tile)
+		if len(claims) > 1 && eg.Rules.Atamahane {
+			head := selectStickWinnerRonA(claims)
+			log.Info("头跳: winners=%v, 只有 %d 和牌", ronSeats, head)
+			claims = []HuClaim{claims[slices.Index(ronSeats, head)]}
+			ronSeats = []int{head}
+		}
+		if len(ronSeats) >= 3 && !eg.Rules.TripleRon {
+			log.Info("一炮三响，荒牌流局")
+			eg.handleRoundOverEvent(nil, RoundEndDraw3Ron)
+			return
+		}
+		if len(ronSeats) >= 2 {
+			// 各家分别向放铳者收取点数和本场，供托只归离放铳者最近的一家
+			log.Info("一炮多响，累计计算: winners=%v, loser=%d, tile=%v", ronSeats, eg.lastDiscard.Seat, eg.lastDiscard.Tile)
 			eg.handleRoundOverEvent(claims, RoundEndRon)
 			return
 		}
@@ -1393,16 +2128,18 @@ func (eg *RiichiMahjong4p) handleReactionComplete() {
 		return
 	}
 
+	// 无人抢杠，杠成立
+	if eg.pendingKan.Valid {
+		eg.resumePendingKan()
+		return
+	}
+
 	// 执行吃碰杠选择算法
 	// 优先级：荣和 > 明杠 > 碰 > 吃
 	selectedAction := eg.selectBestReaction()
 
 	if selectedAction == nil {
-		// 没有有效的反应，进入下一个出牌阶段
-		nextPlayer := eg.TurnManager.NextTurn()
-		if err := eg.TurnManager.EnterDropPhase(nextPlayer, DefaultRoundCompensation); err != nil {
-			log.Error("进入出牌阶段失败: %v", err)
-		}
+		eg.passReactions()
 		return
 	}
 
@@ -1410,6 +2147,31 @@ func (eg *RiichiMahjong4p) handleReactionComplete() {
 	eg.executeReaction(selectedAction)
 }
 
+// markMissedRons 可以荣和却跳过、超时或选择了鸣牌的玩家进入振听
+func (eg *RiichiMahjong4p) markMissedRons() {
+	for seatIndex, reaction := range eg.Reactions {
+		if reaction.ChosenOp != nil && reaction.ChosenOp.Type == "HU" {
+			continue
+		}
+		for _, op := range reaction.Operations {
+			if op.Type == "HU" {
+				eg.Players[seatIndex].missedRon = true
+				break
+			}
+		}
+	}
+}
+
+// passReactions 无人鸣牌：检查四风连打，否则下家摸牌进入出牌阶段
+func (eg *RiichiMahjong4p) passReactions() {
+	if eg.isSuufonRenda() {
+		eg.handleRoundOverEvent(nil, RoundEndSuufon)
+		return
+	}
+	nextPlayer := eg.TurnManager.NextTurn()
+	eg.DropTurn(nextPlayer, true)
+}
+
 // selectBestReaction 选择最优的反应操
 // 优先级：荣和 > 明杠 > 碰 > 吃
 func (eg *RiichiMahjong4p) selectBestReaction() *ReactionAction {
@@ -1435,9 +2197,13 @@ func (eg *RiichiMahjong4p) selectBestReaction() *ReactionAction {
 			}
 		}
 	}
-	// 优先级 4：吃
+	// 优先级 4：吃（只有出牌者的下家可以吃）
 	for seatIndex, reaction := range eg.Reactions {
 		if reaction.ChosenOp != nil && reaction.ChosenOp.Type == "CHI" {
+			if !eg.isChiSeat(seatIndex) {
+				log.Warn("玩家 %d 不是出牌者的下家，忽略吃牌", seatIndex)
+				continue
+			}
 			log.Info("玩家 %d 吃", seatIndex)
 			return &ReactionAction{
 				Type:       "CHI",
@@ -1473,6 +2239,12 @@ func (eg *RiichiMahjong4p) executeReaction(action *ReactionAction) {
 		eg.HappenDamageError(fmt.Sprintf("鸣牌玩家不存在: %d", action.PlayerSeat))
 		return
 	}
+	// 先整体校验再移除手牌，避免只移除一部分后失败；伪造的鸣牌按无人鸣牌处理
+	if !caller.HasTiles(action.Tiles...) {
+		log.Warn("玩家 %d 的 %s 声明了不在手中的牌: %v，按无人鸣牌处理", action.PlayerSeat, action.Type, action.Tiles)
+		eg.passReactions()
+		return
+	}
 
 	switch action.Type {
 	case "PENG":
@@ -1489,12 +2261,19 @@ func (eg *RiichiMahjong4p) executeReaction(action *ReactionAction) {
 		discarderPlayer.DiscardPile = discarderPlayer.DiscardPile[:len(discarderPlayer.DiscardPile)-1]
 		meldTiles := []Tile{called, t1, t2}
 		caller.Melds = append(caller.Melds, Meld{Type: "Peng", Tiles: meldTiles, From: discarder})
+		caller.SetKuikaeForbidden(called.Type)
+		caller.checkPao(discarder)
+		eg.interruptByCall()
 		eg.clearLastDiscard()
 		// 广播碰牌
 		eg.broadcastMeldAction("PENG", action.PlayerSeat, discarder, meldTiles)
 		eg.DropTurn(action.PlayerSeat, false)
 		return
 	case "CHI":
+		if action.PlayerSeat != eg.nextSeat(discarder) {
+			eg.HappenDamageError(fmt.Sprintf("非下家吃牌: 出牌者=%d, 吃牌者=%d", discarder, action.PlayerSeat))
+			return
+		}
 		if len(action.Tiles) != 2 {
 			eg.HappenDamageError(fmt.Sprintf("鸣牌时 PENG 参数异常，应该是有两张牌, 实际是 %d 张牌", len(action.Tiles)))
 			return
@@ -1508,6 +2287,8 @@ func (eg *RiichiMahjong4p) executeReaction(action *ReactionAction) {
 		discarderPlayer.DiscardPile = discarderPlayer.DiscardPile[:len(discarderPlayer.DiscardPile)-1]
 		meldTiles := []Tile{called, t1, t2}
 		caller.Melds = append(caller.Melds, Meld{Type: "Chi", Tiles: meldTiles, From: discarder})
+		caller.SetKuikaeForbidden(chiKuikaeTypes(called.Type, t1.Type, t2.Type)...)
+		eg.interruptByCall()
 		eg.clearLastDiscard()
 		// 广播吃牌
 		eg.broadcastMeldAction("CHI", action.PlayerSeat, discarder, meldTiles)
@@ -1515,29 +2296,235 @@ func (eg *RiichiMahjong4p) executeReaction(action *ReactionAction) {
 		return
 
 	case "GANG":
-		if len(action.Tiles) != 3 {
-			eg.HappenDamageError(fmt.Sprintf("鸣牌时 GANG 参数异常，应该是有三张牌, 实际是 %d 张牌", len(action.Tiles)))
+		eg.executeDaiminkan(action.PlayerSeat, discarder, called, action.Tiles)
+		return
+	default:
+		eg.HappenDamageError(fmt.Sprintf("不支持的反应类型: %s", action.Type))
+		return
+	}
+}
+
+// executeDaiminkan 大明杠：用手中三张同种牌杠别人打出的牌，摸岭上牌后继续出牌
+// 与加杠一样，杠宝牌在打牌后（或连续开杠前）翻开；4 杠分属多家时流局
+func (eg *RiichiMahjong4p) executeDaiminkan(seatIndex, discarder int, called Tile, tiles []Tile) {
+	if len(tiles) != 3 {
+		eg.HappenDamageError(fmt.Sprintf("鸣牌时 GANG 参数异常，应该是有三张牌, 实际是 %d 张牌", len(tiles)))
+		return
+	}
+	for _, t := range tiles {
+		if t.Type != called.Type {
+			eg.HappenDamageError(fmt.Sprintf("GANG 的牌与被杠的牌不同: called=%v, tiles=%v", called, tiles))
 			return
 		}
-		t1 := action.Tiles[0]
-		t2 := action.Tiles[1]
-		t3 := action.Tiles[2]
-		if !caller.RemoveTile(t1) || !caller.RemoveTile(t2) || !caller.RemoveTile(t3) {
-			eg.HappenDamageError(fmt.Sprintf("GANG 找不到手牌: %v %v %v", t1, t2, t3))
+	}
+	if eg.DeckManager == nil {
+		eg.HappenDamageError("DeckManager 为空，无法摸岭上牌")
+		return
+	}
+	if !eg.DeckManager.CanKan() {
+		eg.HappenDamageError("岭上牌不足，无法明杠")
+		return
+	}
+
+	caller := eg.Players[seatIndex]
+	discarderPlayer := eg.Players[discarder]
+	// 先复制再移除，tiles 可能与手牌共用底层数组
+	meldTiles := []Tile{called, tiles[0], tiles[1], tiles[2]}
+	for _, t := range meldTiles[1:] {
+		if !caller.RemoveTile(t) {
+			eg.HappenDamageError(fmt.Sprintf("GANG 找不到手牌: %v", meldTiles[1:]))
 			return
 		}
-		discarderPlayer.DiscardPile = discarderPlayer.DiscardPile[:len(discarderPlayer.DiscardPile)-1]
-		meldTiles := []Tile{called, t1, t2, t3}
-		caller.Melds = append(caller.Melds, Meld{Type: "Gang", Tiles: meldTiles, From: discarder})
-		eg.clearLastDiscard()
-		// 广播明杠
-		eg.broadcastMeldAction("GANG", action.PlayerSeat, discarder, meldTiles)
-		eg.DropTurn(action.PlayerSeat, true)
+	}
+	discarderPlayer.DiscardPile = discarderPlayer.DiscardPile[:len(discarderPlayer.DiscardPile)-1]
+	caller.Melds = append(caller.Melds, Meld{Type: "Gang", Tiles: meldTiles, From: discarder})
+	caller.checkPao(discarder)
+	eg.interruptByCall()
+	eg.clearLastDiscard()
+	// 广播明杠
+	eg.broadcastMeldAction("GANG", seatIndex, discarder, meldTiles)
+
+	// 检查4杠散了流局
+	if eg.CheckFourKanDraw() {
+		eg.handleRoundOverEvent(nil, RoundEndDraw4Kan)
+		return
+	}
+
+	// 明杠的杠宝牌在打牌后翻开，之前未翻开的先翻开
+	eg.flushPendingKanDora()
+	eg.pendingKanDora++
+
+	// 明杠后从岭上摸牌，而不是从牌山摸牌；岭上开花由 rinshan 标记判定
+	kanTile, ok := eg.DeckManager.DrawKanTile()
+	if !ok {
+		eg.HappenDamageError("岭上牌为空，无法明杠")
+		return
+	}
+	caller.DrawRinshanTile(kanTile)
+	eg.pushDrawTile(seatIndex, kanTile)
+	eg.DropTurn(seatIndex, false)
+
+	log.Info("玩家 %d 明杠成功，杠牌: %v", seatIndex, meldTiles)
+}
+
+// waitChankan 杠宣言后检查其他玩家能否抢杠，能则进入反应阶段并返回 true
+// 加杠可以被荣和（抢杠一番），暗杠只有国士无双可以抢
+func (eg *RiichiMahjong4p) waitChankan(kanSeat int, tile Tile, kanType string) bool {
+	reactions := make(map[int]*PlayerReaction)
+	for i := 0; i < eg.seats(); i++ {
+		if i == kanSeat || !eg.canChankan(i, kanSeat, tile, kanType) {
+			continue
+		}
+		reactions[i] = &PlayerReaction{
+			Operations: []*PlayerOperation{{Type: "HU", Tiles: []Tile{tile}}},
+		}
+	}
+	if len(reactions) == 0 {
+		return false
+	}
+
+	log.Info("玩家 %d %s 可被抢杠: tile=%v, seats=%d", kanSeat, kanType, tile, len(reactions))
+	eg.pendingKan = PendingKan{Seat: kanSeat, Tile: tile, Type: kanType, Valid: true}
+	// 抢杠按荣和结算，放铳者为杠牌玩家
+	eg.setLastDiscard(kanSeat, tile)
+	eg.TurnManager.EnterSelectingPhase()
+	eg.Reactions = reactions
+	eg.startReactionWindow()
+	return true
+}
+
+// resumePendingKan 无人抢杠，继续完成杠
+func (eg *RiichiMahjong4p) resumePendingKan() {
+	pk := eg.pendingKan
+	eg.pendingKan = PendingKan{}
+	eg.clearLastDiscard()
+	eg.Reactions = make(map[int]*PlayerReaction)
+
+	player := eg.Players[pk.Seat]
+	if player == nil {
+		eg.HappenDamageError(fmt.Sprintf("杠牌玩家不存在: %d", pk.Seat))
 		return
+	}
+	switch pk.Type {
+	case "Kakan":
+		for i, meld := range player.Melds {
+			if meld.Type == "Kakan" && len(meld.Tiles) > 0 && meld.Tiles[0].Type == pk.Tile.Type {
+				eg.completeKakan(pk.Seat, i)
+				return
+			}
+		}
+		eg.HappenDamageError(fmt.Sprintf("找不到待完成的加杠: %v", pk.Tile))
+	case "Ankan":
+		eg.completeAnkan(pk.Seat)
 	default:
-		eg.HappenDamageError(fmt.Sprintf("不支持的反应类型: %s", action.Type))
+		eg.HappenDamageError(fmt.Sprintf("未知的杠类型: %s", pk.Type))
+	}
+}
+
+// cancelPendingKan 杠被抢，恢复杠牌玩家的副露：加杠退回为碰，暗杠的其余三张退回手牌
+func (eg *RiichiMahjong4p) cancelPendingKan() {
+	pk := eg.pendingKan
+	eg.pendingKan = PendingKan{}
+	player := eg.Players[pk.Seat]
+	if player == nil {
 		return
 	}
+	for i := len(player.Melds) - 1; i >= 0; i-- {
+		meld := &player.Melds[i]
+		if meld.Type != pk.Type || len(meld.Tiles) == 0 || meld.Tiles[0].Type != pk.Tile.Type {
+			continue
+		}
+		if pk.Type == "Kakan" {
+			meld.Type = "Peng"
+			meld.Tiles = meld.Tiles[:len(meld.Tiles)-1]
+			return
+		}
+		for _, t := range meld.Tiles {
+			if t.ID != pk.Tile.ID {
+				player.AddTile(t)
+			}
+		}
+		player.Melds = append(player.Melds[:i], player.Melds[i+1:]...)
+		return
+	}
+}
+
+// interruptByCall 任何鸣牌（含暗杠）都会打断所有玩家的第一巡和一发
+func (eg *RiichiMahjong4p) interruptByCall() {
+	for _, p := range eg.Players {
+		if p != nil {
+			p.FirstTurn = false
+			p.IppatsuEligible = false
+		}
+	}
+	eg.firstDiscards = nil
+}
+
+// recordFirstDiscard 记录第一巡打出的牌，firstTurn 为打牌前玩家是否处于第一巡
+func (eg *RiichiMahjong4p) recordFirstDiscard(firstTurn bool, tile Tile) {
+	if firstTurn {
+		eg.firstDiscards = append(eg.firstDiscards, tile.Type)
+	}
+}
+
+// isSuufonRenda 四风连打：第一巡无人鸣牌，四家打出同一种风牌
+func (eg *RiichiMahjong4p) isSuufonRenda() bool {
+	if len(eg.firstDiscards) != 4 {
+		return false
+	}
+	first := eg.firstDiscards[0]
+	if first < East || first > North {
+		return false
+	}
+	for _, tt := range eg.firstDiscards[1:] {
+		if tt != first {
+			return false
+		}
+	}
+	return true
+}
+
+// isLastDraw 牌山是否已摸完（海底/河底）
+func (eg *RiichiMahjong4p) isLastDraw() bool {
+	return eg.DeckManager != nil && eg.DeckManager.IsLastDraw()
+}
+
+// chiKuikaeTypes 吃牌后禁止打出的牌：吃到的同种牌，以及吃在两端时另一侧的筋牌
+// 例如用 56 吃 4 后不能打 4 和 7，用 23 吃 4 后不能打 4 和 1；嵌张吃没有筋牌限制
+func chiKuikaeTypes(called, t1, t2 TileType) []TileType {
+	types := []TileType{called}
+	lo, hi := min(t1, t2), max(t1, t2)
+	n := numberIndex(called)
+	switch {
+	case called < lo && n+3 <= 8:
+		types = append(types, called+3)
+	case called > hi && n-3 >= 0:
+		types = append(types, called-3)
+	}
+	return types
+}
+
+// isChiSeat 是否为最后出牌者的下家，三麻不能吃
+func (eg *RiichiMahjong4p) isChiSeat(seatIndex int) bool {
+	return eg.seats() == 4 && eg.lastDiscard.Valid && seatIndex == eg.nextSeat(eg.lastDiscard.Seat)
+}
+
+// seats 座位数（四麻 4，三麻 3）
+func (eg *RiichiMahjong4p) seats() int {
+	return eg.Situation.Seats()
+}
+
+// nextSeat 下家座位
+func (eg *RiichiMahjong4p) nextSeat(seatIndex int) int {
+	return (seatIndex + 1) % eg.seats()
+}
+
+// newDeckManager 按座位数创建牌山
+func (eg *RiichiMahjong4p) newDeckManager() *DeckManager {
+	if eg.seats() == 3 {
+		return NewSanmaDeckManager(eg.Rules.akaRules())
+	}
+	return NewDeckManager(eg.Rules.akaRules())
 }
 
 func (eg *RiichiMahjong4p) setLastDiscard(seat int, tile Tile) {
@@ -1588,24 +2575,59 @@ func (eg *RiichiMahjong4p) Clone() engines.Engine {
 		RoundWind:    eg.Situation.RoundWind,
 		RoundNumber:  eg.Situation.RoundNumber,
 		RiichiSticks: eg.Situation.RiichiSticks,
+		SeatCount:    eg.Situation.SeatCount,
 	}
 
 	clonedPlayers := [4]*PlayerImage{}
 
-	return &RiichiMahjong4p{
-		State:       engines.GameWaiting,
-		Worker:      eg.Worker,
-		UserMap:     nil,
-		Situation:   clonedSituation,
-		DeckManager: NewDeckManager(UseRedFive),
-		Players:     clonedPlayers,
-		TurnManager: nil,
+	cloned := &RiichiMahjong4p{
+		State:        engines.GameWaiting,
+		Worker:       eg.Worker,
+		UserMap:      nil,
+		Situation:    clonedSituation,
+		Players:      clonedPlayers,
+		TurnManager:  nil,
+		Searcher:     eg.Searcher,
+		GameLength:   eg.GameLength,
+		InitialPoint: eg.InitialPoint,
+		TargetScore:  eg.TargetScore,
+		MaxWind:      eg.MaxWind,
+		Rules:        eg.Rules,
+		Reactions:    make(map[int]*PlayerReaction),
+		Codec:        eg.Codec,
+		BotPolicy:    eg.BotPolicy,
+	}
+	cloned.DeckManager = cloned.newDeckManager()
+	return cloned
+}
+
+// publishStatus 发布当前运行状态，只在 actor 中调用
+func (eg *RiichiMahjong4p) publishStatus() {
+	status := &engines.EngineStatus{
+		State:       eg.State,
+		RoundWind:   eg.Situation.RoundWind.String(),
+		RoundNumber: eg.Situation.RoundNumber,
+		Honba:       eg.Situation.Honba,
+	}
+	if eg.TurnManager != nil {
+		status.CurrentTurn = eg.TurnManager.GetCurrentPlayer()
+		status.TurnState = eg.turnStateString()
+	}
+	eg.status.Store(status)
+}
+
+// Status 最近一次发布的运行状态
+func (eg *RiichiMahjong4p) Status() engines.EngineStatus {
+	if status := eg.status.Load(); status != nil {
+		return *status
 	}
+	return engines.EngineStatus{State: eg.State}
 }
 
 // HappenDamageError 发生游戏房间崩坏的重大事件
 func (eg *RiichiMahjong4p) HappenDamageError(err string) {
 	log.Warn("游戏房间崩坏: %s", err)
+	eg.saveCrashReport(err)
 	eg.Terminate()
 }
 
@@ -1634,7 +2656,13 @@ func (eg *RiichiMahjong4p) Close() {
 			<-eg.actorExit
 		}
 
-		close(eg.gameEvents) // 重点审查代码
+		// 不关闭 gameEvents/criticalEvents：发送方可能还阻塞在 NotifyEvent 的 select 中，关闭会 panic，
+		// gameDone 关闭后发送方都会退出，channel 交给 GC 回收
+
+		// actor 已退出，不会再有事件写入持久化组件；正常结算后已关闭，这里不会重复保存
+		if eg.Persister != nil {
+			eg.Persister.FlushPartial()
+		}
 
 		eg.Worker = nil
 		eg.State = engines.GameFinished
@@ -1642,6 +2670,7 @@ func (eg *RiichiMahjong4p) Close() {
 		if eg.roundStartTimer != nil {
 			eg.roundStartTimer.Stop()
 		}
+		eg.stopStateUpdateTimer()
 
 		if eg.TurnManager != nil {
 			eg.TurnManager.stopAllTickers()
@@ -1650,6 +2679,7 @@ func (eg *RiichiMahjong4p) Close() {
 		eg.Reactions = nil
 
 		eg.UserMap = nil
+		eg.Spectators = nil
 		eg.Players = [4]*PlayerImage{}
 		eg.DeckManager = nil
 	})
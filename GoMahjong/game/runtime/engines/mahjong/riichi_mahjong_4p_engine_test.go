@@ -0,0 +1,1063 @@
+package mahjong
+
+import (
+	"game/domain/entity"
+	"game/runtime/share"
+	"slices"
+	"testing"
+)
+
+// 2 号座位听 1s/4s（白刻子有役），庄家碰了 4s 后摸到第四张加杠，被 2 号座位抢杠
+func TestChankanOnKakan(t *testing.T) {
+	eg := setupFuritenTable(t, false)
+	setHand(t, eg, 0, "13579m135p4s24z", meld(t, "Peng", "444s", 2))
+	eg.Players[0].Melds[0].Tiles[0].ID = 0 // 与手中的 4s 区分
+
+	eg.handleKakanEvent(&share.KakanEvent{GameMessageEvent: eg.replayUser(0), Tile: share.Tile{Type: int(So4), ID: 1}})
+	if !hasOperation(eg.Reactions[2], "HU") {
+		t.Fatalf("加杠的牌应能被抢杠")
+	}
+	eg.handleReactionHuEvent(&share.HuEvent{GameMessageEvent: eg.replayUser(2)})
+
+	result := lastRoundResult(eg)
+	if result == nil || result.EndType != RoundEndRon || len(result.Claims) != 1 {
+		t.Fatalf("抢杠应按荣和结算, got %+v", result)
+	}
+	claim := result.Claims[0]
+	if claim.WinnerSeat != 2 || claim.LoserSeat != 0 || !slices.Contains(claim.Yaku, YakuChankan.String()) {
+		t.Fatalf("应由 2 号座位抢杠和牌并计抢杠, got %+v", claim)
+	}
+	if got := eg.Players[0].Melds[0]; got.Type != "Peng" || len(got.Tiles) != 3 {
+		t.Fatalf("被抢的加杠应退回为碰, got %+v", got)
+	}
+}
+
+// 普通手牌不能抢暗杠
+func TestAnkanNotRobbable(t *testing.T) {
+	eg := setupFuritenTable(t, false)
+	setHand(t, eg, 0, "13579m135p4444s24z")
+
+	eg.handleAnkanEvent(&share.AnkanEvent{GameMessageEvent: eg.replayUser(0), Tile: share.Tile{Type: int(So4), ID: 1}})
+	if len(eg.Reactions) != 0 {
+		t.Fatalf("普通手牌不能抢暗杠, reactions=%v", eg.Reactions)
+	}
+	p := eg.Players[0]
+	if len(p.Melds) != 1 || p.Melds[0].Type != "Ankan" || eg.TurnManager.GetState() != TurnStateWaitMain {
+		t.Fatalf("暗杠应成立并继续出牌, melds=%+v state=%v", p.Melds, eg.TurnManager.GetState())
+	}
+}
+
+// 庄家开杠后摸到的岭上牌和牌，计岭上开花
+func TestRinshanKaihou(t *testing.T) {
+	tests := []struct {
+		name string
+		hand string
+		kan  func(eg *RiichiMahjong4p)
+		peng bool
+	}{
+		{name: "ankan", hand: "4444s234m567p88s13m", kan: func(eg *RiichiMahjong4p) {
+			eg.handleAnkanEvent(&share.AnkanEvent{GameMessageEvent: eg.replayUser(0), Tile: share.Tile{Type: int(So4), ID: 1}})
+		}},
+		{name: "kakan", hand: "4s234m567p88s13m", peng: true, kan: func(eg *RiichiMahjong4p) {
+			eg.handleKakanEvent(&share.KakanEvent{GameMessageEvent: eg.replayUser(0), Tile: share.Tile{Type: int(So4), ID: 1}})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			var melds []Meld
+			if tt.peng {
+				melds = append(melds, meld(t, "Peng", "444s", 2))
+				melds[0].Tiles[0].ID = 0 // 与手中的 4s 区分
+			}
+			p := setHand(t, eg, 0, tt.hand, melds...)
+			for seat := 1; seat < 4; seat++ {
+				setHand(t, eg, seat, "13579m13579p246z")
+			}
+
+			tt.kan(eg)
+			if len(p.Melds) != 1 || len(p.Melds[0].Tiles) != 4 || !p.rinshanPending {
+				t.Fatalf("开杠后应摸岭上牌, melds=%+v", p.Melds)
+			}
+			replaceDraw(t, p, "2m")
+			eg.handleTouchHuEvent(&share.TouchHuEvent{GameMessageEvent: eg.replayUser(0)})
+
+			result := lastRoundResult(eg)
+			if result == nil || result.EndType != RoundEndTsumo || !slices.Contains(result.Claims[0].Yaku, YakuRinshan.String()) {
+				t.Fatalf("岭上牌自摸应计岭上开花, got %+v", result)
+			}
+		})
+	}
+}
+
+// 第一巡四家都打出东为四风连打；中途有人开杠则不算
+func TestSuufonRenda(t *testing.T) {
+	for _, kan := range []bool{false, true} {
+		name := "four east discards"
+		if kan {
+			name = "interrupted by ankan"
+		}
+		t.Run(name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			setHand(t, eg, 0, "13579m13579p1246z").FirstTurn = true
+			setHand(t, eg, 1, "13579m1357p1246z").FirstTurn = true
+			setHand(t, eg, 2, "13579m1357p1246z").FirstTurn = true
+			setHand(t, eg, 3, "13579m135p444s12z").FirstTurn = true
+
+			east := Tile{Type: East, ID: 1}
+			for seat := 0; seat < 4; seat++ {
+				if seat == 3 && kan {
+					replaceDraw(t, eg.Players[3], "4s")
+					eg.handleAnkanEvent(&share.AnkanEvent{GameMessageEvent: eg.replayUser(3), Tile: share.Tile{Type: int(So4), ID: 1}})
+					if len(eg.Players[3].Melds) != 1 {
+						t.Fatalf("座位 3 暗杠失败")
+					}
+				}
+				dropTile(t, eg, seat, east)
+				passAll(eg)
+			}
+
+			result := lastRoundResult(eg)
+			if kan {
+				if result != nil || eg.TurnManager.GetCurrentPlayer() != 0 {
+					t.Fatalf("开杠后不应四风连打, got %+v", result)
+				}
+				return
+			}
+			if result == nil || result.EndType != RoundEndSuufon || result.NextDealer != 0 || eg.Situation.Honba != 1 {
+				t.Fatalf("应四风连打流局且庄家连庄、本场 +1, got %+v honba=%d", result, eg.Situation.Honba)
+			}
+		})
+	}
+}
+
+// 第四个杠：一名玩家独占四个杠时继续对局且不能再开杠，分属两名玩家时四杠散了
+func TestFourKanDraw(t *testing.T) {
+	for _, solo := range []bool{true, false} {
+		name := "solo"
+		if !solo {
+			name = "two players"
+		}
+		t.Run(name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			if solo {
+				setHand(t, eg, 0, "4444s5p", meld(t, "Ankan", "1111m", 0), meld(t, "Ankan", "2222m", 0), meld(t, "Ankan", "3333m", 0))
+			} else {
+				setHand(t, eg, 0, "4444s567p9m", meld(t, "Ankan", "1111m", 0), meld(t, "Ankan", "2222m", 0))
+				setHand(t, eg, 1, "13579p2468s", meld(t, "Ankan", "3333m", 1))
+			}
+
+			eg.handleAnkanEvent(&share.AnkanEvent{GameMessageEvent: eg.replayUser(0), Tile: share.Tile{Type: int(So4), ID: 1}})
+			if total, _ := eg.countKans(); total != 4 {
+				t.Fatalf("场上应有 4 个杠, got %d", total)
+			}
+			result := lastRoundResult(eg)
+			if !solo {
+				if result == nil || result.EndType != RoundEndDraw4Kan {
+					t.Fatalf("四个杠分属两名玩家应流局, got %+v", result)
+				}
+				return
+			}
+			if result != nil || !eg.Players[0].rinshanPending || eg.TurnManager.GetState() != TurnStateWaitMain {
+				t.Fatalf("一名玩家独占四个杠不应流局, got %+v", result)
+			}
+			if eg.canDeclareKan() {
+				t.Fatalf("四个杠之后不能再开杠")
+			}
+		})
+	}
+}
+
+// 场上已有 4 个杠或岭上牌已摸完时拒绝开杠，手牌、副露和牌山都不变
+func TestFifthKanRejected(t *testing.T) {
+	fourAnkans := func(seat int) []Meld {
+		return []Meld{meld(t, "Ankan", "1111m", seat), meld(t, "Ankan", "2222m", seat), meld(t, "Ankan", "3333m", seat), meld(t, "Ankan", "4444m", seat)}
+	}
+	tests := []struct {
+		name  string
+		setup func(eg *RiichiMahjong4p)
+		kan   func(eg *RiichiMahjong4p)
+	}{
+		{name: "fifth ankan", setup: func(eg *RiichiMahjong4p) {
+			setHand(t, eg, 0, "4444s5p", fourAnkans(0)...)
+		}, kan: func(eg *RiichiMahjong4p) {
+			eg.handleAnkanEvent(&share.AnkanEvent{GameMessageEvent: eg.replayUser(0), Tile: share.Tile{Type: int(So4), ID: 1}})
+		}},
+		{name: "fifth kakan", setup: func(eg *RiichiMahjong4p) {
+			setHand(t, eg, 1, "5p", fourAnkans(1)...)
+			setHand(t, eg, 0, "4s567p9m", meld(t, "Peng", "444s", 2))
+			eg.Players[0].Melds[0].Tiles[0].ID = 0 // 与手中的 4s 区分
+		}, kan: func(eg *RiichiMahjong4p) {
+			eg.handleKakanEvent(&share.KakanEvent{GameMessageEvent: eg.replayUser(0), Tile: share.Tile{Type: int(So4), ID: 1}})
+		}},
+		{name: "rinshan exhausted", setup: func(eg *RiichiMahjong4p) {
+			setHand(t, eg, 0, "4444s234m567p88s1m")
+			eg.DeckManager.wang.kanIndex = 4
+		}, kan: func(eg *RiichiMahjong4p) {
+			eg.handleAnkanEvent(&share.AnkanEvent{GameMessageEvent: eg.replayUser(0), Tile: share.Tile{Type: int(So4), ID: 1}})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			tt.setup(eg)
+			p := eg.Players[0]
+			tiles, melds := slices.Clone(p.Tiles), len(p.Melds)
+			kanIndex := eg.DeckManager.wang.kanIndex
+
+			tt.kan(eg)
+			if !slices.Equal(p.Tiles, tiles) || len(p.Melds) != melds || p.rinshanPending {
+				t.Fatalf("拒绝开杠不应改动手牌和副露, tiles=%v melds=%+v", p.Tiles, p.Melds)
+			}
+			if eg.DeckManager.wang.kanIndex != kanIndex || lastRoundResult(eg) != nil || eg.TurnManager.GetState() != TurnStateWaitMain {
+				t.Fatalf("拒绝开杠后应继续出牌, state=%v", eg.TurnManager.GetState())
+			}
+		})
+	}
+}
+
+// 庄家打出 4s 后 2 号座位大明杠：摸岭上牌、杠宝牌等打牌后再翻；第四个杠分属两名玩家时四杠散了
+func TestDaiminkan(t *testing.T) {
+	for _, fourth := range []bool{false, true} {
+		name := "rinshan tsumo"
+		if fourth {
+			name = "four kan abort"
+		}
+		t.Run(name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			discard := Tile{Type: So4, ID: 0} // 与 2 号座位手中的 4s 区分
+			dealer := setHand(t, eg, 0, "13579m13579p246z")
+			dealer.Tiles = append(dealer.Tiles, discard)
+			setHand(t, eg, 1, "13579m13579p246z")
+			setHand(t, eg, 3, "13579m13579p246z")
+			caller := setHand(t, eg, 2, "444s234m567p88s13m")
+			if fourth {
+				setHand(t, eg, 1, "13579p2689s", meld(t, "Ankan", "3333m", 1))
+				caller = setHand(t, eg, 2, "444s567p9m", meld(t, "Ankan", "1111m", 2), meld(t, "Ankan", "2222m", 2))
+			}
+			doras := len(eg.DeckManager.GetDoraIndicators())
+
+			dropTile(t, eg, 0, discard)
+			if !hasOperation(eg.Reactions[2], "GANG") {
+				t.Fatalf("2 号座位应能明杠 4s")
+			}
+			eg.handleGangEvent(&share.GangEvent{GameMessageEvent: eg.replayUser(2)})
+			kan := caller.Melds[len(caller.Melds)-1]
+			if kan.Type != "Gang" || len(kan.Tiles) != 4 || kan.From != 0 || slices.Contains(dealer.DiscardPile, discard) {
+				t.Fatalf("应成立来自庄家的明杠, melds=%+v", caller.Melds)
+			}
+
+			result := lastRoundResult(eg)
+			if fourth {
+				if result == nil || result.EndType != RoundEndDraw4Kan {
+					t.Fatalf("明杠凑成分属两名玩家的四个杠应流局, got %+v", result)
+				}
+				return
+			}
+			if result != nil || !caller.rinshanPending || eg.TurnManager.GetCurrentPlayer() != 2 || eg.TurnManager.GetState() != TurnStateWaitMain {
+				t.Fatalf("明杠后应由 2 号座位摸岭上牌并出牌, got %+v", result)
+			}
+			if eg.pendingKanDora != 1 || len(eg.DeckManager.GetDoraIndicators()) != doras {
+				t.Fatalf("明杠的杠宝牌应在打牌后翻开, pending=%d", eg.pendingKanDora)
+			}
+
+			replaceDraw(t, caller, "2m")
+			eg.handleTouchHuEvent(&share.TouchHuEvent{GameMessageEvent: eg.replayUser(2)})
+			result = lastRoundResult(eg)
+			if result == nil || result.EndType != RoundEndTsumo || !slices.Contains(result.Claims[0].Yaku, YakuRinshan.String()) {
+				t.Fatalf("明杠后的岭上牌自摸应计岭上开花, got %+v", result)
+			}
+		})
+	}
+}
+
+// 荒牌流局时按手牌重新计算听牌：门清听牌、带副露听牌都算听牌，立直者一定算听牌
+func TestExhaustiveDrawTenpai(t *testing.T) {
+	const (
+		closedTenpai = "123m456p789s1122z"
+		noten        = "13579m2468p1357s"
+		wideNoten    = "147m258p369s1234z"
+	)
+	tests := []struct {
+		name       string
+		hands      [4]string
+		riichi     int // 立直的座位，-1 表示没有
+		wantDelta  [4]int
+		wantDealer int
+	}{
+		{
+			name:       "closed and melded tenpai",
+			hands:      [4]string{closedTenpai, noten, "456p789s11z22z", wideNoten},
+			riichi:     -1,
+			wantDelta:  [4]int{1500, -1500, 1500, -1500},
+			wantDealer: 0,
+		},
+		{
+			name:       "riichi counts as tenpai",
+			hands:      [4]string{noten, noten, "456p789s11z22z", wideNoten},
+			riichi:     1,
+			wantDelta:  [4]int{-1500, 1500, 1500, -1500},
+			wantDealer: 1,
+		},
+		{
+			name:       "all noten",
+			hands:      [4]string{noten, wideNoten, noten, wideNoten},
+			riichi:     -1,
+			wantDealer: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			for seat, hand := range tt.hands {
+				var melds []Meld
+				if len(parseTiles(t, hand)) == 10 {
+					melds = append(melds, meld(t, "Peng", "777m", (seat+1)%4))
+				}
+				setHand(t, eg, seat, hand, melds...)
+			}
+			if tt.riichi >= 0 {
+				eg.Players[tt.riichi].IsRiichi = true
+			}
+
+			eg.LeadNormalDrawEnding()
+			result := lastRoundResult(eg)
+			if result == nil || result.EndType != RoundEndDrawExhaustive {
+				t.Fatalf("应荒牌流局, got %+v", result)
+			}
+			if result.Delta != tt.wantDelta || result.NextDealer != tt.wantDealer {
+				t.Fatalf("罚符 %v 下一局庄家 %d, want %v %d", result.Delta, result.NextDealer, tt.wantDelta, tt.wantDealer)
+			}
+			for seat, hand := range tt.hands {
+				if p := eg.Players[seat]; hand == wideNoten && len(p.TenpaiWaits) != 0 {
+					t.Fatalf("座位 %d 没有听牌, got waits %v", seat, p.TenpaiWaits)
+				}
+			}
+			if waits := eg.Players[2].TenpaiWaits; tt.hands[2] != noten && len(waits) != 2 {
+				t.Fatalf("带副露的对碰应听 2 种牌, got %v", waits)
+			}
+		})
+	}
+}
+
+// 庄家立直后荒牌流局，立直棒留在场上，下一局由和牌者收走并计入点数变化
+func TestRiichiSticksCarryOver(t *testing.T) {
+	eg := newTestEngine(t, noAkaRules())
+	dealer := setHand(t, eg, 0, "234m567m345p78s88p1z")
+	for seat := 1; seat < 4; seat++ {
+		setHand(t, eg, seat, "13579m13579p246z")
+	}
+	eg.handleRiichiEvent(&share.RiichiEvent{GameMessageEvent: eg.replayUser(0)})
+	if !dealer.IsRiichi || eg.Situation.RiichiSticks != 1 {
+		t.Fatalf("庄家应立直并放出 1 根立直棒")
+	}
+
+	eg.LeadNormalDrawEnding()
+	result := lastRoundResult(eg)
+	if result == nil || result.Delta != [4]int{3000, -1000, -1000, -1000} {
+		t.Fatalf("只有庄家听牌, got %+v", result)
+	}
+	if dealer.Points != 27000 || eg.Situation.RiichiSticks != 1 || eg.Situation.Honba != 1 {
+		t.Fatalf("流局不退还立直棒: 点数 %d 供托 %d 本场 %d", dealer.Points, eg.Situation.RiichiSticks, eg.Situation.Honba)
+	}
+
+	// 下一局 2 号座位荣和庄家
+	eg.handleStartRoundEvent()
+	if eg.Situation.RiichiSticks != 1 || dealer.IsRiichi {
+		t.Fatalf("新的一局应保留供托并清除立直状态, sticks=%d", eg.Situation.RiichiSticks)
+	}
+	setHand(t, eg, 0, "13579m13579p4s246z")
+	setHand(t, eg, 1, "13579m1357p1s246z")
+	setHand(t, eg, 2, "234m456p23s555z88s")
+	setHand(t, eg, 3, "13579m13579p246z")
+	dropTile(t, eg, 0, Tile{Type: So4, ID: 1})
+	eg.handleReactionHuEvent(&share.HuEvent{GameMessageEvent: eg.replayUser(2)})
+
+	result = lastRoundResult(eg)
+	if result == nil || result.EndType != RoundEndRon {
+		t.Fatalf("2 号座位应荣和, got %+v", result)
+	}
+	if result.Delta[2] != -result.Delta[0]+1000 || eg.Situation.RiichiSticks != 0 {
+		t.Fatalf("和牌者应收走供托, delta=%v sticks=%d", result.Delta, eg.Situation.RiichiSticks)
+	}
+	if eg.Players[2].Points != result.Points[2]+result.Delta[2] {
+		t.Fatalf("记录的点数变化应与实际结算一致, points=%d result=%+v", eg.Players[2].Points, result)
+	}
+}
+
+// 游戏结束时仍在场上的立直棒归第一名，同分时座位靠前的优先
+func TestSettleLeftoverSticks(t *testing.T) {
+	eg := newTestEngine(t, noAkaRules())
+	for seat, points := range []int{20000, 30000, 30000, 18000} {
+		eg.Players[seat].Points = points
+	}
+	eg.Situation.RiichiSticks = 2
+
+	eg.settleLeftoverSticks()
+	if eg.Players[1].Points != 32000 || eg.Players[2].Points != 30000 || eg.Situation.RiichiSticks != 0 {
+		t.Fatalf("立直棒应归座位 1, points=%d/%d sticks=%d", eg.Players[1].Points, eg.Players[2].Points, eg.Situation.RiichiSticks)
+	}
+}
+
+// 一本场开始：庄家和牌、庄家听牌流局、中途流局连庄并加本场，闲家和牌、庄家未听流局时下庄并清空本场
+func TestDealerRenchan(t *testing.T) {
+	tests := []struct {
+		name      string
+		end       func(t *testing.T, eg *RiichiMahjong4p)
+		wantKeeps bool
+	}{
+		{name: "dealer tsumo", wantKeeps: true, end: func(t *testing.T, eg *RiichiMahjong4p) {
+			drawTsumo(setHand(t, eg, 0, "234m456p23s555z88s"), parseTile(t, "1s"))
+			eg.handleTouchHuEvent(&share.TouchHuEvent{GameMessageEvent: eg.replayUser(0)})
+		}},
+		{name: "non-dealer ron", end: func(t *testing.T, eg *RiichiMahjong4p) {
+			dropTile(t, eg, 0, Tile{Type: So4, ID: 1})
+			eg.handleReactionHuEvent(&share.HuEvent{GameMessageEvent: eg.replayUser(2)})
+		}},
+		{name: "dealer tenpai draw", wantKeeps: true, end: func(t *testing.T, eg *RiichiMahjong4p) {
+			setHand(t, eg, 0, "234m456p23s555z88s")
+			eg.handleRoundOverEvent(nil, RoundEndDrawExhaustive)
+		}},
+		{name: "dealer noten draw", end: func(t *testing.T, eg *RiichiMahjong4p) {
+			setHand(t, eg, 0, "13579m13579p246z")
+			eg.handleRoundOverEvent(nil, RoundEndDrawExhaustive)
+		}},
+		{name: "abortive draw", wantKeeps: true, end: func(t *testing.T, eg *RiichiMahjong4p) {
+			eg.handleRoundOverEvent(nil, RoundEndDraw4Kan)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := setupFuritenTable(t, false)
+			eg.Situation.Honba = 1
+			tt.end(t, eg)
+
+			result := lastRoundResult(eg)
+			if result == nil {
+				t.Fatalf("回合应已结束")
+			}
+			wantDealer, wantHonba, wantNumber := 1, 0, 2
+			if tt.wantKeeps {
+				wantDealer, wantHonba, wantNumber = 0, 2, 1
+			}
+			s := eg.Situation
+			if result.NextDealer != wantDealer || s.DealerIndex != wantDealer || s.Honba != wantHonba || s.RoundNumber != wantNumber {
+				t.Fatalf("下一局庄家 %d/%d 本场 %d 局数 %d, want %d 本场 %d 局数 %d",
+					result.NextDealer, s.DealerIndex, s.Honba, s.RoundNumber, wantDealer, wantHonba, wantNumber)
+			}
+		})
+	}
+}
+
+// endRoundDealerMoves 在 wind 场 number 局庄家下庄后判断游戏是否结束，top 为第一名的点数
+func endRoundDealerMoves(t *testing.T, length GameLength, wind Wind, number, top int) *RiichiMahjong4p {
+	t.Helper()
+	eg := NewRiichiMahjong4p(nil, length, DefaultEngineRules())
+	for seat := 0; seat < 4; seat++ {
+		eg.Players[seat] = NewPlayerImage(string(rune('a'+seat)), seat, eg.InitialPoint)
+	}
+	eg.Players[0].Points = top
+	eg.Situation.RoundWind = wind
+	eg.Situation.RoundNumber = number
+	eg.Situation.DealerIndex = number - 1
+	eg.advanceDealer(false)
+	return eg
+}
+
+func TestHanchanProgression(t *testing.T) {
+	tests := []struct {
+		name       string
+		wind       Wind
+		number     int
+		top        int
+		wantEnd    bool
+		wantWind   Wind
+		wantNumber int
+	}{
+		{name: "east 4 to south 1", wind: WindEast, number: 4, top: 31000, wantWind: WindSouth, wantNumber: 1},
+		{name: "south 3 continues", wind: WindSouth, number: 3, top: 31000, wantWind: WindSouth, wantNumber: 4},
+		{name: "south 4 ends", wind: WindSouth, number: 4, top: 31000, wantEnd: true},
+		{name: "south 4 to west 1", wind: WindSouth, number: 4, top: 29000, wantWind: WindWest, wantNumber: 1},
+		{name: "west sudden death", wind: WindWest, number: 2, top: 30000, wantEnd: true},
+		{name: "west 4 forced end", wind: WindWest, number: 4, top: 29000, wantEnd: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := endRoundDealerMoves(t, GameLengthHanchan, tt.wind, tt.number, tt.top)
+			if got := eg.isGameEnd(); got != tt.wantEnd {
+				t.Fatalf("isGameEnd = %v, want %v", got, tt.wantEnd)
+			}
+			if !tt.wantEnd && (eg.Situation.RoundWind != tt.wantWind || eg.Situation.RoundNumber != tt.wantNumber) {
+				t.Fatalf("下一局为 %v %d 局, want %v %d 局", eg.Situation.RoundWind, eg.Situation.RoundNumber, tt.wantWind, tt.wantNumber)
+			}
+		})
+	}
+}
+
+// 东风战东 4 局后有人达到目标点数即结束，半庄战进入南场；克隆的引擎保留对局长度
+func TestGameLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		length  GameLength
+		top     int
+		wantEnd bool
+	}{
+		{name: "east only ends", length: GameLengthEastOnly, top: 31000, wantEnd: true},
+		{name: "east only extends", length: GameLengthEastOnly, top: 29000},
+		{name: "hanchan continues", length: GameLengthHanchan, top: 31000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := endRoundDealerMoves(t, tt.length, WindEast, 4, tt.top)
+			cloned := eg.Clone().(*RiichiMahjong4p)
+			if cloned.GameLength != tt.length || cloned.MaxWind != tt.length.EndWind().Next() {
+				t.Fatalf("克隆后对局长度 %v 延长到 %v, want %v", cloned.GameLength, cloned.MaxWind, tt.length)
+			}
+			if got := eg.isGameEnd(); got != tt.wantEnd {
+				t.Fatalf("isGameEnd = %v, want %v", got, tt.wantEnd)
+			}
+			if !tt.wantEnd && (eg.Situation.RoundWind != WindSouth || eg.Situation.RoundNumber != 1) {
+				t.Fatalf("下一局应为南 1 局, got %v %d 局", eg.Situation.RoundWind, eg.Situation.RoundNumber)
+			}
+		})
+	}
+}
+
+// 只有出牌者的下家可以吃：2 号座位吃庄家的 4s 被拒绝，1 号座位可以吃
+func TestChiOnlyFromNextSeat(t *testing.T) {
+	eg := setupReactionTable(t, [3]string{"13579m1357p2356s", "13579m135p3445s"})
+	dropTile(t, eg, 0, Tile{Type: So4, ID: 1})
+	if hasOperation(eg.Reactions[2], "CHI") || !hasOperation(eg.Reactions[2], "PENG") {
+		t.Fatalf("2 号座位只能碰不能吃, got %+v", eg.Reactions[2])
+	}
+
+	eg.handleChiEvent(&share.ChiEvent{GameMessageEvent: eg.replayUser(2)})
+	if eg.Reactions[2].Responded {
+		t.Fatalf("2 号座位的吃牌应被拒绝")
+	}
+	eg.handleChiEvent(&share.ChiEvent{GameMessageEvent: eg.replayUser(1)})
+	if !eg.Reactions[1].Responded {
+		t.Fatalf("1 号座位应能吃")
+	}
+	eg.UserMap[eg.Players[2].UserID].IsBot = false
+	eg.handleReactionTimeout(2)
+
+	if melds := eg.Players[1].Melds; len(melds) != 1 || melds[0].Type != "Chi" || len(eg.Players[2].Melds) != 0 {
+		t.Fatalf("应由 1 号座位吃牌, got %+v", melds)
+	}
+	if eg.TurnManager.GetCurrentPlayer() != 1 || eg.TurnManager.GetState() != TurnStateWaitMain {
+		t.Fatalf("吃牌后应轮到 1 号座位出牌")
+	}
+}
+
+// 吃碰后本巡不能打出鸣到的同种牌，也不能打出吃牌顺子另一侧的筋牌
+func TestKuikae(t *testing.T) {
+	tests := []struct {
+		name      string
+		seat      int
+		hands     [3]string
+		call      func(eg *RiichiMahjong4p)
+		forbidden []string
+	}{
+		{name: "chi", seat: 1, hands: [3]string{"13579m135p45677s"}, forbidden: []string{"4s", "7s"}, call: func(eg *RiichiMahjong4p) {
+			eg.handleChiEvent(&share.ChiEvent{GameMessageEvent: eg.replayUser(1), Tiles: []share.Tile{{Type: int(So5), ID: 1}, {Type: int(So6), ID: 1}}})
+		}},
+		{name: "peng", seat: 2, hands: [3]string{"", "13579m13579p444s"}, forbidden: []string{"4s"}, call: func(eg *RiichiMahjong4p) {
+			eg.handlePengEvent(&share.PengTileEvent{GameMessageEvent: eg.replayUser(2)})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := setupReactionTable(t, tt.hands)
+			dropTile(t, eg, 0, Tile{Type: So4, ID: 1})
+			tt.call(eg)
+			for seat := range eg.Reactions {
+				if seat != tt.seat {
+					eg.UserMap[eg.Players[seat].UserID].IsBot = false
+					eg.handleReactionTimeout(seat)
+				}
+			}
+			p := eg.Players[tt.seat]
+			if len(p.Melds) != 1 || eg.TurnManager.GetCurrentPlayer() != tt.seat {
+				t.Fatalf("座位 %d 鸣牌失败, melds=%+v", tt.seat, p.Melds)
+			}
+
+			for _, s := range tt.forbidden {
+				tile := parseTile(t, s)
+				for _, held := range p.Tiles {
+					if held.Type == tile.Type {
+						tile = held
+					}
+				}
+				eg.handleDropTileEvent(&share.DropTileEvent{GameMessageEvent: eg.replayUser(tt.seat), Tile: share.Tile{Type: int(tile.Type), ID: tile.ID}})
+				if len(p.DiscardPile) != 0 {
+					t.Fatalf("鸣牌后不能打出 %s", s)
+				}
+			}
+			dropTile(t, eg, tt.seat, p.Tiles[0])
+		})
+	}
+}
+
+// 立直后只能摸切；暗杠不能改变听牌
+func TestRiichiRestrictions(t *testing.T) {
+	t.Run("tsumogiri", func(t *testing.T) {
+		eg := newTestEngine(t, noAkaRules())
+		p := setHand(t, eg, 0, "234m567m345p78s88p")
+		p.IsRiichi = true
+		drawTsumo(p, parseTile(t, "1z"))
+
+		eg.handleDropTileEvent(&share.DropTileEvent{GameMessageEvent: eg.replayUser(0), Tile: share.Tile{Type: int(Man2), ID: 1}})
+		if len(p.DiscardPile) != 0 {
+			t.Fatalf("立直后不能打出摸到的牌以外的牌")
+		}
+		dropTile(t, eg, 0, parseTile(t, "1z"))
+	})
+
+	tests := []struct {
+		name string
+		hand string
+		kan  string
+		want bool
+	}{
+		{name: "ankan keeps wait", hand: "111m456p789s23s55z", kan: "1m", want: true},
+		{name: "ankan changes wait", hand: "2333m456p789s555z", kan: "3m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			p := setHand(t, eg, 0, tt.hand)
+			p.IsRiichi = true
+			kan := parseTile(t, tt.kan)
+			drawTsumo(p, kan)
+
+			eg.handleAnkanEvent(&share.AnkanEvent{GameMessageEvent: eg.replayUser(0), Tile: share.Tile{Type: int(kan.Type), ID: kan.ID}})
+			if got := len(p.Melds) == 1; got != tt.want {
+				t.Fatalf("立直后暗杠 %s 成立 = %v, want %v", tt.kan, got, tt.want)
+			}
+		})
+	}
+}
+
+// setupPaoTable 1 号座位已碰白、发，碰庄家打出的中后确定大三元，由庄家承担包牌责任，单骑听 9s
+func setupPaoTable(t *testing.T) *RiichiMahjong4p {
+	t.Helper()
+	eg := newTestEngine(t, noAkaRules())
+	setHand(t, eg, 0, "13579m13579p2467z")
+	setHand(t, eg, 1, "77z234m9s1p", meld(t, "Peng", "555z", 3), meld(t, "Peng", "666z", 2))
+	setHand(t, eg, 2, "13579m13579p124z")
+	setHand(t, eg, 3, "13579m13579p134z")
+
+	dropTile(t, eg, 0, Tile{Type: Red, ID: 1})
+	eg.handlePengEvent(&share.PengTileEvent{GameMessageEvent: eg.replayUser(1)})
+	p := eg.Players[1]
+	if len(p.Melds) != 3 || p.PaoSeat != 0 || p.PaoYaku != YakuDaisangen {
+		t.Fatalf("碰中后应由庄家包牌, melds=%+v pao=%d", p.Melds, p.PaoSeat)
+	}
+	dropTile(t, eg, 1, parseTiles(t, "1p")[0])
+	return eg
+}
+
+func TestDaisangenPao(t *testing.T) {
+	t.Run("tsumo", func(t *testing.T) {
+		eg := setupPaoTable(t)
+		win := parseTile(t, "9s")
+		drawTsumo(eg.Players[1], win)
+		eg.LeadTsumoEnding(HuClaim{WinnerSeat: 1, WinTile: win})
+		if result := lastRoundResult(eg); result == nil || result.Delta != [4]int{-32000, 32000, 0, 0} {
+			t.Fatalf("包牌自摸应由责任者全额支付, got %+v", result)
+		}
+	})
+
+	t.Run("ron", func(t *testing.T) {
+		eg := setupPaoTable(t)
+		eg.LeadRonEnding([]HuClaim{{WinnerSeat: 1, HasLoser: true, LoserSeat: 2, WinTile: parseTile(t, "9s")}})
+		if result := lastRoundResult(eg); result == nil || result.Delta != [4]int{-16000, 32000, -16000, 0} {
+			t.Fatalf("包牌荣和应由放铳者与责任者各付一半, got %+v", result)
+		}
+	})
+}
+
+// 暗杠立即翻开杠宝牌，加杠在打牌后翻开
+func TestKanDoraReveal(t *testing.T) {
+	tests := []struct {
+		name      string
+		hand      string
+		peng      bool
+		immediate bool
+		kan       func(eg *RiichiMahjong4p)
+	}{
+		{name: "ankan", hand: "4444s234m567p88s13m", immediate: true, kan: func(eg *RiichiMahjong4p) {
+			eg.handleAnkanEvent(&share.AnkanEvent{GameMessageEvent: eg.replayUser(0), Tile: share.Tile{Type: int(So4), ID: 1}})
+		}},
+		{name: "kakan", hand: "4s234m567p88s13m", peng: true, kan: func(eg *RiichiMahjong4p) {
+			eg.handleKakanEvent(&share.KakanEvent{GameMessageEvent: eg.replayUser(0), Tile: share.Tile{Type: int(So4), ID: 1}})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := setupReactionTable(t, [3]string{})
+			var melds []Meld
+			if tt.peng {
+				melds = append(melds, meld(t, "Peng", "444s", 2))
+				melds[0].Tiles[0].ID = 0
+			}
+			p := setHand(t, eg, 0, tt.hand, melds...)
+
+			tt.kan(eg)
+			want := 1
+			if tt.immediate {
+				want = 2
+			}
+			if got := len(eg.DeckManager.GetDoraIndicators()); got != want {
+				t.Fatalf("开杠后宝牌指示牌 %d 张, want %d", got, want)
+			}
+			dropTile(t, eg, 0, p.Tiles[0])
+			if got := len(eg.DeckManager.GetDoraIndicators()); got != 2 {
+				t.Fatalf("打牌后宝牌指示牌 %d 张, want 2", got)
+			}
+		})
+	}
+}
+
+// gameEvents 被刷满时普通事件超时丢弃，和牌宣言和超时事件走关键队列不会丢失
+func TestNotifyEventOverflow(t *testing.T) {
+	eg := newTestEngine(t, noAkaRules())
+	for len(eg.criticalEvents) > 0 {
+		<-eg.criticalEvents
+	}
+	for len(eg.gameEvents) < cap(eg.gameEvents) {
+		eg.NotifyEvent(&share.DropTileEvent{GameMessageEvent: eg.replayUser(0)})
+	}
+	eg.NotifyEvent(&share.DropTileEvent{GameMessageEvent: eg.replayUser(0)})
+	if got := eg.overflowCount.Load(); got != 1 {
+		t.Fatalf("溢出的普通事件应被记录, overflow=%d", got)
+	}
+
+	eg.NotifyEvent(&share.HuEvent{GameMessageEvent: eg.replayUser(1)})
+	eg.NotifyEvent(&TimeoutEvent{SeatIndex: 2})
+	var got []string
+	for len(eg.criticalEvents) > 0 {
+		got = append(got, (<-eg.criticalEvents).GetEventType())
+	}
+	if !slices.Equal(got, []string{"Hu", "Timeout"}) {
+		t.Fatalf("关键队列中的事件 = %v, want [Hu Timeout]", got)
+	}
+}
+
+// 一名玩家 + 三个 bot 成桌：bot 座位只靠超时自动操作，一巡后轮回到玩家
+func TestBotSeatsAutoPlay(t *testing.T) {
+	eg := newTestEngine(t, noAkaRules())
+	setHand(t, eg, 0, "13579m13579p4s246z")
+	eg.UserMap[eg.Players[0].UserID].IsBot = false
+	for seat := 1; seat < 4; seat++ {
+		setHand(t, eg, seat, "13579m13579p246z")
+		if !eg.isBotSeat(seat) {
+			t.Fatalf("座位 %d 应为 bot", seat)
+		}
+	}
+
+	dropTile(t, eg, 0, Tile{Type: So4, ID: 1})
+	for i := 0; i < 20 && (eg.TurnManager.GetCurrentPlayer() != 0 || eg.TurnManager.GetState() != TurnStateWaitMain); i++ {
+		switch eg.TurnManager.GetState() {
+		case TurnStateWaitMain:
+			eg.handleTimeoutEvent(&TimeoutEvent{SeatIndex: eg.TurnManager.GetCurrentPlayer()})
+		case TurnStateWaitReactions:
+			for seat := range eg.Reactions {
+				eg.handleTimeoutEvent(&TimeoutEvent{SeatIndex: seat})
+			}
+		}
+	}
+	if eg.TurnManager.GetCurrentPlayer() != 0 || eg.TurnManager.GetState() != TurnStateWaitMain {
+		t.Fatalf("bot 自动操作后应轮回到玩家, current=%d state=%v", eg.TurnManager.GetCurrentPlayer(), eg.TurnManager.GetState())
+	}
+	for seat := 1; seat < 4; seat++ {
+		if n := len(eg.Players[seat].DiscardPile); n != 1 {
+			t.Fatalf("bot 座位 %d 应自动打出 1 张牌, got %d", seat, n)
+		}
+	}
+	if result := lastRoundResult(eg); result != nil {
+		t.Fatalf("不应结束本局, got %+v", result)
+	}
+}
+
+// 配置覆盖的起始点数、出牌时间和赤牌规则在原型克隆出的引擎和开局后都生效
+func TestEngineRulesOverride(t *testing.T) {
+	rules := noAkaRules()
+	rules.InitialPoint = 30000
+	rules.MaxRoundTime = 7
+
+	prototype := NewRiichiMahjong4p(nil, GameLengthHanchan, rules)
+	cloned := prototype.Clone().(*RiichiMahjong4p)
+	t.Cleanup(cloned.Close)
+	if cloned.Rules != rules || cloned.InitialPoint != 30000 {
+		t.Fatalf("克隆的引擎应沿用原型的规则, got %+v", cloned.Rules)
+	}
+	if cloned.DeckManager.Aka() != (AkaRules{}) {
+		t.Fatalf("不使用赤牌时牌山不应有赤 5, got %+v", cloned.DeckManager.Aka())
+	}
+
+	eg := newTestEngine(t, rules)
+	for seat, p := range eg.Players {
+		if p.Points != 30000 {
+			t.Fatalf("座位 %d 的起始点数 = %d, want 30000", seat, p.Points)
+		}
+	}
+	// 庄家的出牌计时 = 剩余时间 + 补偿，不超过单回合上限
+	if eg.TurnManager.MaxRoundTime != 7 || eg.TurnManager.Tickers[0].GetAvailable() != 7 {
+		t.Fatalf("出牌计时应按配置的上限分配, got max=%d available=%d",
+			eg.TurnManager.MaxRoundTime, eg.TurnManager.Tickers[0].GetAvailable())
+	}
+}
+
+// 运营配置的原型克隆出的每个房间都沿用同样的规则，且与原型互不影响
+func TestCloneKeepsRules(t *testing.T) {
+	rules := DefaultEngineRules()
+	rules.MaxRoundTime = 15
+	rules.ReactionTime = 3
+	rules.InitialPoint = 35000
+	rules.TargetScore = 40000
+	rules.Aka = AkaRules{Pin: 2, So: 1}
+	rules.KazoeYakuman = false
+	rules.Atamahane = true
+	rules.ChomboBase = 0
+	prototype := NewRiichiMahjong4p(nil, GameLengthEastOnly, rules)
+
+	first := prototype.Clone().(*RiichiMahjong4p)
+	second := prototype.Clone().(*RiichiMahjong4p)
+	t.Cleanup(first.Close)
+	t.Cleanup(second.Close)
+	for _, cloned := range []*RiichiMahjong4p{first, second} {
+		if cloned.Rules != rules || cloned.GameLength != GameLengthEastOnly || cloned.MaxWind != prototype.MaxWind {
+			t.Fatalf("克隆的规则应与原型一致, got %+v length=%d", cloned.Rules, cloned.GameLength)
+		}
+		if cloned.InitialPoint != 35000 || cloned.TargetScore != 40000 {
+			t.Fatalf("克隆的点数规则错误, got %d/%d", cloned.InitialPoint, cloned.TargetScore)
+		}
+		if cloned.DeckManager.Aka() != rules.Aka || cloned.Reactions == nil {
+			t.Fatalf("克隆应按规则创建牌山和反应表, aka=%+v", cloned.DeckManager.Aka())
+		}
+	}
+
+	first.Rules.ReactionTime = 10
+	first.Situation.Honba = 2
+	if second.Rules.ReactionTime != 3 || prototype.Rules.ReactionTime != 3 || prototype.Situation.Honba != 0 || second.Situation == first.Situation {
+		t.Fatalf("修改一个房间不应影响原型和其他房间")
+	}
+}
+
+// setupMultiRon winners 中的座位都听 1s/4s（役牌刻子有役），庄家手中有 4s
+func setupMultiRon(t *testing.T, rules EngineRules, winners ...int) *RiichiMahjong4p {
+	t.Helper()
+	hands := map[int]string{1: "234m567p23s777z99m", 2: "234m456p23s555z88s", 3: "345m678p23s666z99p"}
+	eg := newTestEngine(t, rules)
+	setHand(t, eg, 0, "13579m13579p4s246z")
+	for seat := 1; seat < 4; seat++ {
+		setHand(t, eg, seat, "13579m13579p246z")
+	}
+	for _, seat := range winners {
+		setHand(t, eg, seat, hands[seat])
+	}
+	return eg
+}
+
+// multiRon 庄家打出 4s，winners 依次宣言荣和
+func multiRon(t *testing.T, eg *RiichiMahjong4p, winners ...int) {
+	t.Helper()
+	dropTile(t, eg, 0, Tile{Type: So4, ID: 1})
+	for _, seat := range winners {
+		if !hasOperation(eg.Reactions[seat], "HU") {
+			t.Fatalf("座位 %d 应能荣和", seat)
+		}
+		eg.handleReactionHuEvent(&share.HuEvent{GameMessageEvent: eg.replayUser(seat)})
+	}
+}
+
+// 同一手一炮多响：默认双响各自结算、三响流局，开启 TripleRon 时三响各自结算；头跳时只有下家方向最近的一家和牌
+func TestMultiRon(t *testing.T) {
+	tests := []struct {
+		name        string
+		atamahane   bool
+		tripleRon   bool
+		winners     []int
+		wantEnd     string
+		wantWinners []int
+	}{
+		{name: "double ron pays both", winners: []int{2, 3}, wantEnd: RoundEndRon, wantWinners: []int{2, 3}},
+		{name: "double ron head bump", atamahane: true, winners: []int{2, 3}, wantEnd: RoundEndRon, wantWinners: []int{2}},
+		{name: "triple ron aborts", winners: []int{1, 2, 3}, wantEnd: RoundEndDraw3Ron},
+		{name: "triple ron head bump", atamahane: true, winners: []int{1, 2, 3}, wantEnd: RoundEndRon, wantWinners: []int{1}},
+		{name: "triple ron pays all", tripleRon: true, winners: []int{1, 2, 3}, wantEnd: RoundEndRon, wantWinners: []int{1, 2, 3}},
+		{name: "triple ron head bump overrides", atamahane: true, tripleRon: true, winners: []int{1, 2, 3}, wantEnd: RoundEndRon, wantWinners: []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := noAkaRules()
+			rules.Atamahane = tt.atamahane
+			rules.TripleRon = tt.tripleRon
+			eg := setupMultiRon(t, rules, tt.winners...)
+			multiRon(t, eg, tt.winners...)
+
+			result := lastRoundResult(eg)
+			if result == nil || result.EndType != tt.wantEnd || len(result.Claims) != len(tt.wantWinners) {
+				t.Fatalf("应以 %s 结束且 %d 家和牌, got %+v", tt.wantEnd, len(tt.wantWinners), result)
+			}
+			paid := 0
+			for i, claim := range result.Claims {
+				if claim.WinnerSeat != tt.wantWinners[i] || result.Delta[claim.WinnerSeat] != claim.Points {
+					t.Fatalf("和牌者 %d 收入 %d, want 座位 %d 收入 %d", claim.WinnerSeat, result.Delta[claim.WinnerSeat], tt.wantWinners[i], claim.Points)
+				}
+				paid += claim.Points
+			}
+			if result.Delta[0] != -paid {
+				t.Fatalf("放铳者支付 %d, want %d (delta=%v)", -result.Delta[0], paid, result.Delta)
+			}
+			for _, seat := range tt.winners {
+				if !slices.Contains(tt.wantWinners, seat) && result.Delta[seat] != 0 {
+					t.Fatalf("被头跳的座位 %d 不应有收支, delta=%v", seat, result.Delta)
+				}
+			}
+		})
+	}
+}
+
+// 两本场一炮双响：放铳者向两位和牌者各付 600 本场，供托只归下家方向最近的 2 号座位
+func TestMultiRonHonba(t *testing.T) {
+	settle := func(honba, sticks int) *entity.RoundResult {
+		eg := setupMultiRon(t, noAkaRules(), 2, 3)
+		eg.Situation.Honba = honba
+		eg.Situation.RiichiSticks = sticks
+		multiRon(t, eg, 2, 3)
+		result := lastRoundResult(eg)
+		if result == nil || len(result.Claims) != 2 {
+			t.Fatalf("应双响结算, got %+v", result)
+		}
+		return result
+	}
+	base := settle(0, 0)
+	result := settle(2, 1)
+
+	for i, claim := range result.Claims {
+		if claim.Points-base.Claims[i].Points != 600 {
+			t.Fatalf("座位 %d 的和牌点数应多 600 本场, got %d -> %d", claim.WinnerSeat, base.Claims[i].Points, claim.Points)
+		}
+	}
+	want := base.Delta
+	want[0] -= 1200
+	want[2] += 600 + 1000
+	want[3] += 600
+	if result.Delta != want {
+		t.Fatalf("delta = %v, want %v", result.Delta, want)
+	}
+}
+
+// 一本场两根供托一炮三响：流局时没有收支、供托留到下一局；三家各自结算时各收 300 本场，供托只归下家 1 号座位
+func TestTripleRonSticks(t *testing.T) {
+	settle := func(tripleRon bool, honba, sticks int) (*RiichiMahjong4p, *entity.RoundResult) {
+		rules := noAkaRules()
+		rules.TripleRon = tripleRon
+		eg := setupMultiRon(t, rules, 1, 2, 3)
+		eg.Situation.Honba = honba
+		eg.Situation.RiichiSticks = sticks
+		multiRon(t, eg, 1, 2, 3)
+		return eg, lastRoundResult(eg)
+	}
+
+	eg, result := settle(false, 1, 2)
+	if result == nil || result.EndType != RoundEndDraw3Ron || result.Delta != [4]int{} {
+		t.Fatalf("三家点铳应流局且没有收支, got %+v", result)
+	}
+	if eg.Situation.RiichiSticks != 2 {
+		t.Fatalf("流局后供托应保留, got %d", eg.Situation.RiichiSticks)
+	}
+
+	_, base := settle(true, 0, 0)
+	_, result = settle(true, 1, 2)
+	if result == nil || result.EndType != RoundEndRon || len(result.Claims) != 3 {
+		t.Fatalf("应三家各自结算, got %+v", result)
+	}
+	for i, claim := range result.Claims {
+		if claim.Points-base.Claims[i].Points != 300 {
+			t.Fatalf("座位 %d 的和牌点数应多 300 本场, got %d -> %d", claim.WinnerSeat, base.Claims[i].Points, claim.Points)
+		}
+	}
+	want := base.Delta
+	want[0] -= 900
+	want[1] += 300 + 2000
+	want[2] += 300
+	want[3] += 300
+	if result.Delta != want {
+		t.Fatalf("delta = %v, want %v", result.Delta, want)
+	}
+}
+
+// 网络重试导致同一打牌请求到达两次：局面推进后旧序号的请求被丢弃，不会在下一巡再打一张
+func TestDuplicateDiscard(t *testing.T) {
+	eg := newTestEngine(t, noAkaRules())
+	dealer := setHand(t, eg, 0, "13579m13579p1246z")
+	for seat := 1; seat < 4; seat++ {
+		setHand(t, eg, seat, "13579m13579p246z")
+	}
+
+	drop := &share.DropTileEvent{
+		GameMessageEvent: share.GameMessageEvent{UserID: dealer.UserID, Seq: eg.actionSeq},
+		Tile:             share.Tile{Type: int(East), ID: 1},
+	}
+	eg.processEvent(drop)
+	eg.processEvent(drop)
+	if len(dealer.DiscardPile) != 1 || len(dealer.Tiles) != 13 {
+		t.Fatalf("重复的打牌只应生效一次, discards=%v", dealer.DiscardPile)
+	}
+	passAll(eg)
+	for seat := 1; seat < 4; seat++ {
+		dropTile(t, eg, seat, Tile{Type: Man9, ID: 1})
+		passAll(eg)
+	}
+
+	// 轮到庄家时迟到的重复请求仍带着旧序号
+	if eg.TurnManager.GetCurrentPlayer() != 0 || drop.Seq == eg.actionSeq {
+		t.Fatalf("应轮到庄家且序号已推进, seq=%d", eg.actionSeq)
+	}
+	eg.processEvent(drop)
+	if len(dealer.DiscardPile) != 1 || len(dealer.Tiles) != 14 {
+		t.Fatalf("过期的打牌请求不应生效, discards=%v", dealer.DiscardPile)
+	}
+
+	drop.Seq = eg.actionSeq
+	drop.Tile = share.Tile{Type: int(South), ID: 1}
+	eg.processEvent(drop)
+	if len(dealer.DiscardPile) != 2 {
+		t.Fatalf("带当前序号的打牌应生效, discards=%v", dealer.DiscardPile)
+	}
+}
+
+// 2 号座位持有 5m（ID 2）和 ID 0 的 5m，伪造的碰牌声明了不在手中的 5m（ID 3），不能执行
+func TestForgedPeng(t *testing.T) {
+	eg := newTestEngine(t, noAkaRules())
+	setHand(t, eg, 0, "13579m13579p4s246z")
+	for seat := 1; seat < 4; seat++ {
+		setHand(t, eg, seat, "13579m13579p246z")
+	}
+	caller := setHand(t, eg, 2, "1379m13579p24z")
+	caller.Tiles = append(caller.Tiles, Tile{Type: Man5, ID: 2}, Tile{Type: Man5, ID: 0})
+	hand := slices.Clone(caller.Tiles)
+	forged := []Tile{{Type: Man5, ID: 2}, {Type: Man5, ID: 3}}
+
+	dropTile(t, eg, 0, Tile{Type: Man5, ID: 1})
+	if !hasOperation(eg.Reactions[2], "PENG") {
+		t.Fatalf("2 号座位应能碰 5m")
+	}
+	eg.handlePengEvent(&share.PengTileEvent{GameMessageEvent: eg.replayUser(2), Tiles: toShareTiles(forged)})
+	if eg.Reactions[2].Responded {
+		t.Fatalf("与待选操作不符的碰牌请求应被拒绝")
+	}
+
+	eg.TurnManager.EnterChoosingPhase()
+	eg.executeReaction(&ReactionAction{Type: "PENG", PlayerSeat: 2, Tiles: forged})
+	if len(caller.Melds) != 0 || !slices.Equal(caller.Tiles, hand) {
+		t.Fatalf("伪造的碰牌不应改动手牌, melds=%v tiles=%v", caller.Melds, caller.Tiles)
+	}
+	if eg.TurnManager.GetCurrentPlayer() != 1 || eg.TurnManager.GetState() != TurnStateWaitMain {
+		t.Fatalf("伪造的碰牌按无人鸣牌处理，应由下家摸牌")
+	}
+}
@@ -0,0 +1,93 @@
+package mahjong
+
+import (
+	"game/domain/entity"
+	"time"
+)
+
+// EngineRules 可按部署调整的对局规则，由配置注入，原型与克隆共用
+// 只包含值类型字段，Clone 按值复制即为深拷贝；新增切片或 map 字段时需要在 Clone 中单独复制
+type EngineRules struct {
+	MaxRoundTime      int           // 每回合的最多分配时间（秒）
+	RoundCompensation int           // 每回合补偿时间（秒）
+	ReactionTime      int           // 反应阶段（吃碰杠荣和）的计时（秒），不消耗出牌的总剩余时间
+	WaitStartTime     time.Duration // 等待游戏开始时间
+	InitialPoint      int           // 初始点数
+	TargetScore       int           // 结束所需点数（返点）
+	UseRedFive        bool          // 是否使用赤牌
+	Aka               AkaRules      // 各花色赤 5 的张数，UseRedFive 为 false 时不生效
+	KazoeYakuman      bool          // 13 番以上按累计役满计，否则按三倍满封顶
+	BeginnerMode      bool          // 新手模式：出牌阶段给玩家推送打牌建议
+	Atamahane         bool          // 头跳：多家荣和时只有按巡目顺序离放铳者最近的一家和牌，关闭时双响各自结算，三响见 TripleRon
+	TripleRon         bool          // 一炮三响时三家各自结算，关闭时三家点铳流局；头跳开启时不生效
+	CoalescePush      bool          // 合并一个事件内发往同一 connector 的推送，握手协议版本不支持合并帧的客户端由 connector 逐帧发送
+	SearchWorkers     int           // 计算打牌候选（立直判定、打牌建议）时并行的 goroutine 数，0 或 1 为串行
+	StatePushInterval time.Duration // 状态更新推送的最小间隔，间隔内的多次变化合并为一次推送；0 表示不推送
+	ChomboBase        int           // 错和罚点的基本点，按自摸的方式分摊（2000 为满贯）；0 表示不处罚，无效的和牌宣言直接忽略
+}
+
+// DefaultEngineRules 四麻默认规则
+func DefaultEngineRules() EngineRules {
+	return EngineRules{
+		MaxRoundTime:      DefaultMaxRoundTime,
+		RoundCompensation: DefaultRoundCompensation,
+		ReactionTime:      DefaultReactionTime,
+		WaitStartTime:     DefaultWaitStartTime,
+		InitialPoint:      DefaultInitialPoint,
+		TargetScore:       DefaultTargetScore,
+		UseRedFive:        DefaultUseRedFive,
+		Aka:               DefaultAkaRules(),
+		KazoeYakuman:      DefaultKazoeYakuman,
+		StatePushInterval: DefaultStatePushInterval,
+		ChomboBase:        DefaultChomboBase,
+	}
+}
+
+// AkaRules 各花色赤 5 的张数（0-4），三麻没有 5 万，Man 不生效
+type AkaRules struct {
+	Man int
+	Pin int
+	So  int
+}
+
+// DefaultAkaRules 每种花色一张赤 5
+func DefaultAkaRules() AkaRules {
+	return AkaRules{Man: 1, Pin: 1, So: 1}
+}
+
+// Count 某种 5 的赤牌张数
+func (a AkaRules) Count(t TileType) int {
+	switch t {
+	case Man5:
+		return a.Man
+	case Pin5:
+		return a.Pin
+	case So5:
+		return a.So
+	}
+	return 0
+}
+
+// akaRules 实际使用的赤牌规则，不使用赤牌时每色 0 张
+func (r EngineRules) akaRules() AkaRules {
+	if !r.UseRedFive {
+		return AkaRules{}
+	}
+	return r.Aka
+}
+
+// RecordAkaRules 对局记录中保存的赤牌规则，没有保存规则的旧记录按默认规则
+func RecordAkaRules(record *entity.GameRecord) AkaRules {
+	if record == nil || record.Aka == nil {
+		return DefaultEngineRules().akaRules()
+	}
+	return AkaRules{Man: record.Aka.Man, Pin: record.Aka.Pin, So: record.Aka.So}
+}
+
+// DefaultSanmaEngineRules 三麻默认规则，只有点数不同
+func DefaultSanmaEngineRules() EngineRules {
+	rules := DefaultEngineRules()
+	rules.InitialPoint = SanmaInitialPoint
+	rules.TargetScore = SanmaTargetScore
+	return rules
+}
@@ -1,261 +1,144 @@
 package mahjong
 
-// callHuPoints 计算和牌点数（统一入口）
-// 返回：番数、符数、点数、役列表
-func (eg *RiichiMahjong4p) callHuPoints(claim HuClaim, endKind string) (han int, fu int, points int, yakus []Yaku) {
-	han, yakumanMult, yakus := eg.evalClaimYakuman(claim, endKind)
-	isDealer := claim.WinnerSeat == eg.Situation.DealerIndex
-	honba := eg.Situation.Honba
+const (
+	ManganBasePoints    = 2000 // 满贯
+	HanemanBasePoints   = 3000 // 跳满
+	BaimanBasePoints    = 4000 // 倍满
+	SanbaimanBasePoints = 6000 // 三倍满
+	YakumanBasePoints   = 8000 // 役满
+)
+
+// callHuPoints 计算和牌基本点（统一入口）
+// 返回：番数、符数、基本点、役列表、计分采用的手牌拆解，基本点为 0 表示无役
+func (eg *RiichiMahjong4p) callHuPoints(claim HuClaim, endKind string) (han int, fu int, base int, yakus []Yaku, div *HandDivision) {
+	han, yakumanMult, fu, yakus, div := eg.evalClaimYakuman(claim, endKind)
 
 	// 役满：固定点数
 	if yakumanMult > 0 {
-		base := 8000 * yakumanMult
-		if endKind == RoundEndRon {
-			if isDealer {
-				points = base * 6
-			} else {
-				points = base * 4
-			}
-			points += 300 * honba
-		} else {
-			if isDealer {
-				points = base * 2 // 每人支付
-			} else {
-				points = base // 闲家每人支付
-			}
-			points += 100 * honba // 自摸本场数每人+100
-		}
-		return han, 0, points, yakus
+		return han, 0, YakumanBasePoints * yakumanMult, yakus, div
 	}
-
-	// 满贯以上：固定点数
-	if han >= 5 {
-		points = eg.getFixedPoints(han, endKind, isDealer)
-		// 本场数：荣和+300，自摸+100（每人）
-		if endKind == RoundEndRon {
-			points += 300 * honba
-		} else {
-			points += 100 * honba
-		}
-		return han, 0, points, yakus
+	if han == 0 {
+		return 0, fu, 0, yakus, div
 	}
 
-	// 普通和牌（<5番）：需要计算符数
-	fu = eg.calculateFu(claim, endKind)
-	basePoints := eg.calculateBasePoints(han, fu)
+	// 宝牌不是役，有役时才计入番数
+	dora, ura, aka := eg.countClaimDora(claim)
+	han += dora + ura + aka
 
-	if endKind == RoundEndRon {
-		// 荣和
-		if isDealer {
-			points = basePoints * 6
-		} else {
-			points = basePoints * 4
-		}
-	} else {
-		// 自摸
-		if isDealer {
-			points = basePoints * 2 // 每人支付
-		} else {
-			points = basePoints // 闲家每人支付
+	// 累计役满：没有真正的役满时 13 番以上按役满计，关闭时按三倍满封顶
+	if han >= 13 {
+		if eg.Rules.KazoeYakuman {
+			return han, fu, YakumanBasePoints, append(yakus, YakuKazoeYakuman), div
 		}
+		return han, fu, SanbaimanBasePoints, yakus, div
 	}
-	points += 100 * honba
-
-	return han, fu, points, yakus
+	return han, fu, basePoints(han, fu), yakus, div
 }
 
-// calculateBasePoints 计算基础点数
-func (eg *RiichiMahjong4p) calculateBasePoints(han int, fu int) int {
-	// 基础点数 = 符数 × 2^(2+番数)
-	base := fu * (1 << (2 + han))
+// previewScore 试算 seat 以 winTile 和牌时的番数、符数、点数（含本场）与役，不改变对局状态
+// 只对 13 张的手牌有效（出牌阶段需先决定打出哪张），不成和牌型或无役时点数为 0
+// 里宝牌和牌前不会翻开，因此不计入；振听不影响试算
+func (eg *RiichiMahjong4p) previewScore(seat int, winTile Tile, isTsumo bool) (han, fu, points int, yaku []string) {
+	if eg.Situation == nil || eg.Searcher == nil || seat < 0 || seat >= eg.seats() {
+		return 0, 0, 0, nil
+	}
+	player := eg.Players[seat]
+	if player == nil || len(player.Tiles)%3 != 1 {
+		return 0, 0, 0, nil
+	}
+	h, _ := Hand34FromTiles(player.Tiles)
+	h[int(winTile.Type)]++
+	if !eg.Searcher.IsAgariAll(h, len(player.Melds)) {
+		return 0, 0, 0, nil
+	}
 
-	// 向上取整到100的倍数
-	return roundUpTo100(base)
-}
+	claim := HuClaim{WinnerSeat: seat, HasLoser: !isTsumo, LoserSeat: -1, WinTile: winTile}
+	endKind := RoundEndRon
+	if isTsumo {
+		// 自摸时和牌在手牌中：评估期间换上摸入和牌后的副本，结束后还原
+		endKind = RoundEndTsumo
+		hypo := *player
+		hypo.Tiles = append(append(make([]Tile, 0, len(player.Tiles)+1), player.Tiles...), winTile)
+		hypo.NewestTile = &winTile
+		eg.Players[seat] = &hypo
+		defer func() { eg.Players[seat] = player }()
+	}
 
-// getFixedPoints 获取满贯以上的固定点数
-func (eg *RiichiMahjong4p) getFixedPoints(han int, endKind string, isDealer bool) int {
-	if endKind == RoundEndRon {
-		// 荣和
-		switch {
-		case han == 5: // 满贯
-			if isDealer {
-				return 12000
-			}
-			return 8000
-		case han >= 6 && han <= 7: // 跳满
-			if isDealer {
-				return 18000
-			}
-			return 12000
-		case han >= 8 && han <= 10: // 倍满
-			if isDealer {
-				return 24000
-			}
-			return 16000
-		case han >= 11 && han <= 12: // 三倍满
-			if isDealer {
-				return 36000
+	han, fu, base, yakus, div := eg.callHuPoints(claim, endKind)
+	if base == 0 {
+		return 0, fu, 0, nil
+	}
+	dealer := eg.Situation.DealerIndex
+	if isTsumo {
+		dealerPay, childPay := tsumoPoints(base, seat == dealer, eg.Situation.Honba)
+		for i := 0; i < eg.seats(); i++ {
+			switch i {
+			case seat:
+			case dealer:
+				points += dealerPay
+			default:
+				points += childPay
 			}
-			return 24000
-		default:
-			return 0
 		}
 	} else {
-		// 自摸（每人支付）
-		switch {
-		case han == 5: // 满贯
-			if isDealer {
-				return 4000 // 每人支付
-			}
-			return 2000 // 闲家每人支付
-		case han >= 6 && han <= 7: // 跳满
-			if isDealer {
-				return 6000 // 每人支付
-			}
-			return 3000 // 闲家每人支付
-		case han >= 8 && han <= 10: // 倍满
-			if isDealer {
-				return 8000 // 每人支付
-			}
-			return 4000 // 闲家每人支付
-		case han >= 11 && han <= 12: // 三倍满
-			if isDealer {
-				return 12000 // 每人支付
-			}
-			return 6000 // 闲家每人支付
-		default:
-			return 0
-		}
+		points = ronPoints(base, seat == dealer, eg.Situation.Honba)
 	}
+	return han, fu, points, eg.convertHuClaimToDTOWithFanFu(claim, endKind, han, fu, points, yakus, div).Yaku
 }
 
-// calculateFu 计算符数
-func (eg *RiichiMahjong4p) calculateFu(claim HuClaim, endKind string) int {
-	winner := eg.Players[claim.WinnerSeat]
-	if winner == nil {
+// basePoints 基本点 = 符数 × 2^(2+番数)，超过 2000 按满贯封顶，5 番以上按固定档位
+func basePoints(han int, fu int) int {
+	switch {
+	case han >= 13:
+		return YakumanBasePoints
+	case han >= 11:
+		return SanbaimanBasePoints
+	case han >= 8:
+		return BaimanBasePoints
+	case han >= 6:
+		return HanemanBasePoints
+	case han == 5:
+		return ManganBasePoints
+	case han <= 0:
 		return 0
 	}
-
-	fu := 20 // 副底
-
-	// 和牌方式
-	if endKind == RoundEndTsumo {
-		fu += 2 // 自摸+2符
-	}
-
-	// 检查是否有平和（平和固定30符荣和，20符自摸）
-	hasPinfu := eg.checkPinfu(claim, winner)
-	if hasPinfu {
-		if endKind == RoundEndRon {
-			return 30 // 平和荣和固定30符
-		}
-		return 20 // 平和自摸固定20符
+	base := fu * (1 << (2 + han))
+	if base > ManganBasePoints {
+		base = ManganBasePoints
 	}
-
-	// 雀头符数
-	fu += eg.calculatePairFu(claim, winner)
-
-	// 面子符数
-	fu += eg.calculateMeldFu(winner)
-
-	// 听牌形式符数（边张/嵌张/单骑）
-	fu += eg.calculateWaitFu(claim, winner)
-
-	// 向上取整到10的倍数
-	return ((fu + 9) / 10) * 10
+	return base
 }
 
-// checkPinfu 检查是否是平和
-func (eg *RiichiMahjong4p) checkPinfu(claim HuClaim, winner *PlayerImage) bool {
-	// 平和条件：
-	// 1. 门清（无副露）
-	// 2. 4个顺子 + 非役牌雀头
-	// 3. 两面听牌
-	// 4. 荣和时30符，自摸时20符
-
-	if len(winner.Melds) > 0 {
-		return false // 有副露，不是平和
+// ronPoints 荣和点数：闲家 ×4、庄家 ×6，向上取整到 100，本场每本 +300
+func ronPoints(base int, isDealer bool, honba int) int {
+	mult := 4
+	if isDealer {
+		mult = 6
 	}
-
-	// TODO: 需要根据实际和牌结构判断
-	// 这里简化处理，如果门清且没有刻子/杠子，可能是平和
-	// 实际应该检查是否真的是4顺子+非役牌雀头+两面听牌
-	return false // 暂时返回false，需要实现完整的平和判断
-}
-
-// calculatePairFu 计算雀头符数
-func (eg *RiichiMahjong4p) calculatePairFu(claim HuClaim, winner *PlayerImage) int {
-	// 雀头是自风/场风/三元牌时+2符
-	// 需要知道雀头是什么牌，这里简化处理
-
-	// TODO: 需要根据实际和牌结构判断雀头
-	// 暂时返回0，需要实现完整的雀头判断
-	return 0
+	return roundUpTo100(base*mult) + 300*honba
 }
 
-// calculateMeldFu 计算面子符数
-func (eg *RiichiMahjong4p) calculateMeldFu(winner *PlayerImage) int {
-	fu := 0
-
-	for _, meld := range winner.Melds {
-		isYaochu := eg.isYaochu(meld.Tiles[0].Type)
-		isAnkan := meld.Type == "Ankan"
-		isKakan := meld.Type == "Kakan"
-		isGang := meld.Type == "Gang"
-		isPeng := meld.Type == "Peng"
-
-		if isAnkan {
-			// 暗杠
-			if isYaochu {
-				fu += 32 // 幺九暗杠+32符
-			} else {
-				fu += 16 // 中张暗杠+16符
-			}
-		} else if isKakan || isGang {
-			// 明杠
-			if isYaochu {
-				fu += 16 // 幺九明杠+16符
-			} else {
-				fu += 8 // 中张明杠+8符
-			}
-		} else if isPeng {
-			// 明刻
-			if isYaochu {
-				fu += 4 // 幺九明刻+4符
-			} else {
-				fu += 2 // 中张明刻+2符
-			}
-		}
+// tsumoPoints 自摸时各家支付的点数，本场每人 +100
+// 庄家自摸：闲家每人支付 ×2，dealerPay 为 0
+// 闲家自摸：庄家支付 ×2，其余闲家支付 ×1
+func tsumoPoints(base int, isDealer bool, honba int) (dealerPay int, childPay int) {
+	if isDealer {
+		return 0, roundUpTo100(base*2) + 100*honba
 	}
-
-	// 手牌中的暗刻（需要统计手牌中的刻子）
-	// TODO: 需要根据实际和牌结构判断手牌中的暗刻
-	// 暂时简化处理
-
-	return fu
+	return roundUpTo100(base*2) + 100*honba, roundUpTo100(base) + 100*honba
 }
 
-// calculateWaitFu 计算听牌形式符数
-func (eg *RiichiMahjong4p) calculateWaitFu(claim HuClaim, winner *PlayerImage) int {
-	// 边张/嵌张/单骑+2符
-	// 两面/双碰+0符
-
-	// TODO: 需要根据实际听牌形式判断
-	// 暂时返回0，需要实现完整的听牌形式判断
-	return 0
-}
-
-// isYaochu 判断是否是幺九牌（1、9、字牌）
-func (eg *RiichiMahjong4p) isYaochu(tileType TileType) bool {
-	if tileType >= East && tileType <= Red {
-		return true // 字牌
+// paoBasePoints 包牌役满部分的基本点，和牌不含包牌役满时返回 0
+// 大四喜按双倍役满计，复合其他役满时只有包牌役满部分由责任者承担
+func paoBasePoints(base int, yakus []Yaku, paoYaku Yaku) int {
+	mult := 1
+	if paoYaku == YakuDaisushi {
+		mult = 2
 	}
-	// 数牌1、9
-	if tileType == Man1 || tileType == Man9 ||
-		tileType == Pin1 || tileType == Pin9 ||
-		tileType == So1 || tileType == So9 {
-		return true
+	for _, y := range yakus {
+		if y == paoYaku {
+			return min(base, YakumanBasePoints*mult)
+		}
 	}
-	return false
+	return 0
 }
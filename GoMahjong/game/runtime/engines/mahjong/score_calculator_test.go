@@ -0,0 +1,167 @@
+package mahjong
+
+import (
+	"game/runtime/share"
+	"slices"
+	"testing"
+)
+
+// setDoraIndicator 把第一张宝牌指示牌换成 indicator
+func setDoraIndicator(eg *RiichiMahjong4p, indicator Tile) {
+	eg.DeckManager.wang.DoraIndicators[0] = indicator
+}
+
+// 同一手牌有 6 番 40 符与 7 番 30 符两种拆解，封顶前都是跳满；加上赤宝牌后应按 7 番的拆解计为倍满
+func TestCallHuPointsComparesReadingsWithDora(t *testing.T) {
+	eg := newTestEngine(t, DefaultEngineRules())
+	setDoraIndicator(eg, Tile{Type: East, ID: 3})
+	setHand(t, eg, 1, "2344677778890p") // 0p 为赤 5
+	claim := HuClaim{WinnerSeat: 1, HasLoser: true, LoserSeat: 2, WinTile: parseTile(t, "3p")}
+
+	han, _, fu, _, _ := eg.evalClaimYakuman(claim, RoundEndRon)
+	if han != 7 || fu != 30 {
+		t.Fatalf("应采用 7 番 30 符的拆解, got %d 番 %d 符", han, fu)
+	}
+	han, _, base, _, _ := eg.callHuPoints(claim, RoundEndRon)
+	if han != 8 || base != BaimanBasePoints {
+		t.Fatalf("加赤宝牌后应为 8 番倍满, got %d 番 base=%d", han, base)
+	}
+}
+
+// 门清的断幺九 + 平和 + 二杯口 + 清一色为 11 番，立直凑成 12 番，两枚宝牌凑成 13 番
+func TestKazoeYakuman(t *testing.T) {
+	tests := []struct {
+		name      string
+		riichi    bool
+		indicator string
+		kazoe     bool
+		wantHan   int
+		wantBase  int
+	}{
+		{name: "12 han sanbaiman", riichi: true, indicator: "7z", kazoe: true, wantHan: 12, wantBase: SanbaimanBasePoints},
+		{name: "13 han kazoe", indicator: "1p", kazoe: true, wantHan: 13, wantBase: YakumanBasePoints},
+		{name: "13 han capped", indicator: "1p", wantHan: 13, wantBase: SanbaimanBasePoints},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := noAkaRules()
+			rules.KazoeYakuman = tt.kazoe
+			eg := newTestEngine(t, rules)
+			setDoraIndicator(eg, parseTile(t, tt.indicator))
+			setHand(t, eg, 1, "223344p55p67p678p").IsRiichi = tt.riichi
+			claim := HuClaim{WinnerSeat: 1, HasLoser: true, LoserSeat: 0, WinTile: parseTile(t, "8p")}
+
+			han, _, base, yakus, _ := eg.callHuPoints(claim, RoundEndRon)
+			if han != tt.wantHan || base != tt.wantBase {
+				t.Fatalf("got %d 番 base=%d, want %d 番 base=%d", han, base, tt.wantHan, tt.wantBase)
+			}
+			if hasYaku(yakus, YakuKazoeYakuman) != (base == YakumanBasePoints) {
+				t.Fatalf("累计役满时才应计入 KazoeYakuman (yakus=%v)", yakus)
+			}
+			assertYakus(t, yakus, []Yaku{YakuRyanpeiko, YakuChinitsu}, []Yaku{YakuIppeiko})
+		})
+	}
+}
+
+// 与通行点数表一致
+func TestRonPoints(t *testing.T) {
+	tests := []struct {
+		han, fu int
+		dealer  bool
+		honba   int
+		want    int
+	}{
+		{han: 1, fu: 30, want: 1000},
+		{han: 2, fu: 25, want: 1600},
+		{han: 3, fu: 40, want: 5200},
+		{han: 3, fu: 40, dealer: true, want: 7700},
+		{han: 4, fu: 30, want: 7700},
+		{han: 4, fu: 40, want: 8000},
+		{han: 5, fu: 30, want: 8000},
+		{han: 6, fu: 30, want: 12000},
+		{han: 8, fu: 30, dealer: true, want: 24000},
+		{han: 11, fu: 30, want: 24000},
+		{han: 13, fu: 30, want: 32000},
+		{han: 1, fu: 30, honba: 2, want: 1600},
+	}
+	for _, tt := range tests {
+		if got := ronPoints(basePoints(tt.han, tt.fu), tt.dealer, tt.honba); got != tt.want {
+			t.Errorf("%d 番 %d 符 庄家=%v %d 本场荣和 = %d, want %d", tt.han, tt.fu, tt.dealer, tt.honba, got, tt.want)
+		}
+	}
+}
+
+func TestTsumoPoints(t *testing.T) {
+	tests := []struct {
+		han, fu       int
+		dealer        bool
+		honba         int
+		wantDealerPay int
+		wantChildPay  int
+	}{
+		{han: 1, fu: 30, wantDealerPay: 500, wantChildPay: 300},
+		{han: 2, fu: 20, wantDealerPay: 700, wantChildPay: 400},
+		{han: 3, fu: 60, wantDealerPay: 3900, wantChildPay: 2000},
+		{han: 2, fu: 30, dealer: true, wantChildPay: 1000},
+		{han: 5, fu: 30, dealer: true, wantChildPay: 4000},
+		{han: 1, fu: 30, honba: 1, wantDealerPay: 600, wantChildPay: 400},
+	}
+	for _, tt := range tests {
+		dealerPay, childPay := tsumoPoints(basePoints(tt.han, tt.fu), tt.dealer, tt.honba)
+		if dealerPay != tt.wantDealerPay || childPay != tt.wantChildPay {
+			t.Errorf("%d 番 %d 符 庄家=%v %d 本场自摸 = %d/%d, want %d/%d", tt.han, tt.fu, tt.dealer, tt.honba,
+				dealerPay, childPay, tt.wantDealerPay, tt.wantChildPay)
+		}
+	}
+}
+
+// 2 号座位听 1s/4s（白刻子有役）：试算结果与实际和牌的结算一致，且试算不改变手牌
+func TestPreviewScore(t *testing.T) {
+	for _, tsumo := range []bool{false, true} {
+		name := "ron 4s"
+		if tsumo {
+			name = "tsumo 1s"
+		}
+		t.Run(name, func(t *testing.T) {
+			eg := setupFuritenTable(t, false)
+			win := Tile{Type: So4, ID: 1}
+			if tsumo {
+				win = parseTile(t, "1s")
+			}
+			hand := slices.Clone(eg.Players[2].Tiles)
+			han, fu, points, yaku := eg.previewScore(2, win, tsumo)
+			if points == 0 || !slices.Equal(eg.Players[2].Tiles, hand) {
+				t.Fatalf("试算应有点数且不改变手牌, got %d 点 hand=%v", points, eg.Players[2].Tiles)
+			}
+
+			if tsumo {
+				dropTile(t, eg, 0, Tile{Type: Man9, ID: 1})
+				passAll(eg)
+				dropTile(t, eg, 1, Tile{Type: Man9, ID: 1})
+				passAll(eg)
+				replaceDraw(t, eg.Players[2], "1s")
+				eg.handleTouchHuEvent(&share.TouchHuEvent{GameMessageEvent: eg.replayUser(2)})
+			} else {
+				dropTile(t, eg, 0, win)
+				eg.handleReactionHuEvent(&share.HuEvent{GameMessageEvent: eg.replayUser(2)})
+			}
+			result := lastRoundResult(eg)
+			if result == nil || len(result.Claims) != 1 {
+				t.Fatalf("应由 2 号座位和牌, got %+v", result)
+			}
+			claim := result.Claims[0]
+			if claim.Han != han || claim.Fu != fu || claim.Points != points || !slices.Equal(claim.Yaku, yaku) {
+				t.Fatalf("试算 %d 番 %d 符 %d 点 %v, 结算 %d 番 %d 符 %d 点 %v", han, fu, points, yaku, claim.Han, claim.Fu, claim.Points, claim.Yaku)
+			}
+			if result.Delta[2] != points {
+				t.Fatalf("和牌者收支 %d, want %d", result.Delta[2], points)
+			}
+		})
+	}
+
+	// 不成和牌型时点数为 0
+	eg := setupFuritenTable(t, false)
+	if _, _, points, yaku := eg.previewScore(2, parseTile(t, "5s"), false); points != 0 || yaku != nil {
+		t.Fatalf("5s 不能和牌, got %d 点 %v", points, yaku)
+	}
+}
@@ -13,23 +13,41 @@ type Candidate struct {
 	Ukeire         int        // 有效张数
 }
 
+// DefaultSearcherCacheCapacity 每种缓存默认最多保留的条目数
+const DefaultSearcherCacheCapacity = 100000
+
+// Searcher 原型与所有克隆的引擎共用，缓存容量受限，避免游戏节点长时间运行后内存无限增长
 type Searcher struct {
 	mu           sync.RWMutex
-	shantenCache map[string]int        // 向听数缓存
-	agariCache   map[string]bool       // 和牌缓存
-	waitsCache   map[string][]TileType // 听牌缓存
+	shantenCache *boundedCache[int]        // 向听数缓存
+	agariCache   *boundedCache[bool]       // 和牌缓存
+	waitsCache   *boundedCache[[]TileType] // 听牌缓存
+	parallelism  int                       // SeekCandidates 并行计算的 goroutine 数，<= 1 时串行
 }
 
 func NewSearcher() *Searcher {
+	return NewSearcherWithCapacity(DefaultSearcherCacheCapacity)
+}
+
+// NewSearcherWithCapacity 指定每种缓存的容量
+func NewSearcherWithCapacity(capacity int) *Searcher {
 	return &Searcher{
-		shantenCache: make(map[string]int, 4096),
-		agariCache:   make(map[string]bool, 4096),
-		waitsCache:   make(map[string][]TileType, 4096),
+		shantenCache: newBoundedCache[int](capacity),
+		agariCache:   newBoundedCache[bool](capacity),
+		waitsCache:   newBoundedCache[[]TileType](capacity),
 	}
 }
 
+// SetParallelism 设置 SeekCandidates 的并行度，只在创建引擎时调用
+func (s *Searcher) SetParallelism(workers int) {
+	s.parallelism = workers
+}
+
 // SeekCandidates 弃牌后,有哪些牌听牌，是否允许立直由引擎层判断
 func (s *Searcher) SeekCandidates(hand14 []Tile, fixedMelds int, visible *[34]uint8) []Candidate {
+	if s.parallelism > 1 {
+		return s.SeekCandidatesParallel(hand14, fixedMelds, visible, s.parallelism)
+	}
 	h14, discardOpts := Hand34FromTiles(hand14)
 	var out []Candidate
 	for i := 0; i < 34; i++ {
@@ -53,11 +71,57 @@ func (s *Searcher) SeekCandidates(hand14 []Tile, fixedMelds int, visible *[34]ui
 	return out
 }
 
+// SeekCandidatesParallel 与 SeekCandidates 结果相同（顺序也相同），最多 workers 个 goroutine 并行计算各弃牌的听牌
+// 缓存的读写都在 Searcher 的读写锁下进行，可以并发调用；visible 只读
+func (s *Searcher) SeekCandidatesParallel(hand14 []Tile, fixedMelds int, visible *[34]uint8, workers int) []Candidate {
+	h14, discardOpts := Hand34FromTiles(hand14)
+	var discards []int
+	for i := 0; i < 34; i++ {
+		if h14[i] > 0 {
+			discards = append(discards, i)
+		}
+	}
+	workers = min(workers, len(discards))
+
+	// 每种弃牌写入自己的位置，结束后按牌种顺序收集，与串行结果一致
+	var results [34]Candidate
+	jobs := make(chan int, len(discards))
+	for _, i := range discards {
+		jobs <- i
+	}
+	close(jobs)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				h13 := h14
+				h13[i]--
+				waits, ukeire := s.WaitsAndUkeire(h13, fixedMelds, visible)
+				results[i] = Candidate{DiscardType: TileType(i), Waits: waits, Ukeire: ukeire}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var out []Candidate
+	for _, i := range discards {
+		c := results[i]
+		if len(c.Waits) == 0 {
+			continue
+		}
+		c.DiscardOptions = discardOpts[TileType(i)]
+		out = append(out, c)
+	}
+	return out
+}
+
 // WaitsAndUkeire 枚举听牌 + 计算进张
 func (s *Searcher) WaitsAndUkeire(h13 Hand34, fixedMelds int, visible *[34]uint8) ([]TileType, int) {
 	key := h13.keyWithFixedMelds(fixedMelds)
 	s.mu.RLock()
-	if v, ok := s.waitsCache[key]; ok {
+	if v, ok := s.waitsCache.get(key); ok {
 		waits := make([]TileType, len(v))
 		copy(waits, v)
 		s.mu.RUnlock()
@@ -78,7 +142,7 @@ func (s *Searcher) WaitsAndUkeire(h13 Hand34, fixedMelds int, visible *[34]uint8
 	}
 
 	s.mu.Lock()
-	s.waitsCache[key] = append([]TileType(nil), waits...)
+	s.waitsCache.put(key, append([]TileType(nil), waits...))
 	s.mu.Unlock()
 
 	return waits, s.ukeireByWaits(h13, waits, visible)
@@ -106,7 +170,7 @@ func (s *Searcher) ukeireByWaits(h13 Hand34, waits []TileType, visible *[34]uint
 func (s *Searcher) IsAgariAll(h Hand34, fixedMelds int) bool {
 	key := h.keyWithFixedMelds(fixedMelds)
 	s.mu.RLock()
-	if v, ok := s.agariCache[key]; ok {
+	if v, ok := s.agariCache.get(key); ok {
 		s.mu.RUnlock()
 		return v
 	}
@@ -120,7 +184,7 @@ func (s *Searcher) IsAgariAll(h Hand34, fixedMelds int) bool {
 	}
 
 	s.mu.Lock()
-	s.agariCache[key] = ok
+	s.agariCache.put(key, ok)
 	s.mu.Unlock()
 	return ok
 }
@@ -268,7 +332,7 @@ var kokushiTiles = [13]int{
 func (s *Searcher) ShantenAll(h Hand34, fixedMelds int) int {
 	key := h.keyWithFixedMelds(fixedMelds)
 	s.mu.RLock()
-	if v, ok := s.shantenCache[key]; ok {
+	if v, ok := s.shantenCache.get(key); ok {
 		s.mu.RUnlock()
 		return v
 	}
@@ -285,7 +349,7 @@ func (s *Searcher) ShantenAll(h Hand34, fixedMelds int) int {
 	}
 
 	s.mu.Lock()
-	s.shantenCache[key] = best
+	s.shantenCache.put(key, best)
 	s.mu.Unlock()
 	return best
 }
@@ -333,7 +397,7 @@ func (s *Searcher) ShantenNormal(h Hand34, fixedMelds int) int {
 	return best
 }
 
-// dfsNormalShanten 普通牌型向听数搜索 m：当前已经形成的面子数(包含 fixedMelds)、p：雀头数（0/1）、t：搭子数（taatsu）、best：全局最小向听
+// dfsNormalShanten 普通牌型向听数搜索 m：当前已经形成的面子数(包含 fixedMelds，副露算作完成的面子，h 只含手中的牌)、p：雀头数（0/1）、t：搭子数（taatsu）、best：全局最小向听
 func dfsNormalShanten(h *Hand34, m int, p int, t int, best *int) {
 	if m > 4 {
 		return
@@ -373,6 +437,13 @@ func dfsNormalShanten(h *Hand34, m int, p int, t int, best *int) {
 			(*h)[i] += 2
 		}
 
+		// 对子也可以作为搭子（双碰），已有雀头时第二个对子只能这样计
+		if (*h)[i] >= 2 {
+			(*h)[i] -= 2
+			dfsNormalShanten(h, m, p, t+1, best)
+			(*h)[i] += 2
+		}
+
 		(*h)[i]--
 		dfsNormalShanten(h, m, p, t, best)
 		(*h)[i]++
@@ -403,6 +474,13 @@ func dfsNormalShanten(h *Hand34, m int, p int, t int, best *int) {
 		(*h)[i] += 2
 	}
 
+	// 对子作为搭子（双碰）
+	if (*h)[i] >= 2 {
+		(*h)[i] -= 2
+		dfsNormalShanten(h, m, p, t+1, best)
+		(*h)[i] += 2
+	}
+
 	if i+1 < 34 && suitOf(i) == suitOf(i+1) {
 		if (*h)[i] > 0 && (*h)[i+1] > 0 {
 			(*h)[i]--
@@ -0,0 +1,107 @@
+package mahjong
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// hand34 按 parseTiles 的写法生成 Hand34
+func hand34(t *testing.T, s string) Hand34 {
+	t.Helper()
+	h, _ := Hand34FromTiles(parseTiles(t, s))
+	return h
+}
+
+// 副露的面子算作完成的面子：同一手牌把一组顺子换成副露，向听数不变
+func TestShantenNormalFixedMelds(t *testing.T) {
+	s := NewSearcher()
+	tests := []struct {
+		closed string
+		open   string // closed 去掉 123m 之后手中的牌
+		want   int
+	}{
+		{closed: "123m456m789m11p22p", open: "456m789m11p22p", want: 0},
+		{closed: "123m456m789m1p5s9s1z", open: "456m789m1p5s9s1z", want: 2},
+		{closed: "123m456m78p35s1122z", open: "456m78p35s1122z", want: 1},
+		{closed: "123m47m258p369s157z", open: "47m258p369s157z", want: 6},
+	}
+	for _, tt := range tests {
+		closed := s.ShantenNormal(hand34(t, tt.closed), 0)
+		open := s.ShantenNormal(hand34(t, tt.open), 1)
+		if closed != tt.want || open != tt.want {
+			t.Errorf("%s: 门清 %d 向听, 副露 123m %d 向听, want %d", tt.closed, closed, open, tt.want)
+		}
+	}
+}
+
+// 随机手牌带 0-3 个副露：向听数为 0 当且仅当再摸一张可以和牌
+func TestShantenNormalTenpaiMatchesAgari(t *testing.T) {
+	s := NewSearcher()
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		fixed := i % 4
+		var h Hand34
+		for n := 0; n < 13-3*fixed; {
+			k := rng.Intn(34)
+			if h[k] < 4 {
+				h[k]++
+				n++
+			}
+		}
+		agari := false
+		for k := 0; k < 34 && !agari; k++ {
+			h[k]++
+			agari = IsAgariNormal(h, fixed)
+			h[k]--
+		}
+		if sh := s.ShantenNormal(h, fixed); (sh == 0) != agari {
+			t.Fatalf("%v 副露 %d: 向听 %d, 能否和牌 %v", h, fixed, sh, agari)
+		}
+	}
+}
+
+// randomHand14 从一副牌中随机取 14 张
+func randomHand14(rng *rand.Rand) []Tile {
+	tiles := NewTileDeck(AkaRules{}).tiles
+	rng.Shuffle(len(tiles), func(i, j int) { tiles[i], tiles[j] = tiles[j], tiles[i] })
+	return tiles[:14]
+}
+
+// 并行与串行枚举弃牌得到相同的候选（顺序也相同）
+func TestSeekCandidatesParallel(t *testing.T) {
+	serial, parallel := NewSearcher(), NewSearcher()
+	rng := rand.New(rand.NewSource(1))
+	hands := [][]Tile{parseTiles(t, "234m567m345p3456s1z"), parseTiles(t, "123m456m789m11p22p3s")}
+	for i := 0; i < 500; i++ {
+		hands = append(hands, randomHand14(rng))
+	}
+	for _, hand := range hands {
+		want := serial.SeekCandidates(hand, 0, nil)
+		got := parallel.SeekCandidatesParallel(hand, 0, nil, 4)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%v: 并行 %+v, 串行 %+v", hand, got, want)
+		}
+	}
+	if len(serial.SeekCandidates(hands[0], 0, nil)) == 0 {
+		t.Fatalf("打出 1z 后应听牌")
+	}
+}
+
+func BenchmarkSeekCandidates(b *testing.B) {
+	hand := []Tile{
+		{Type: Man2}, {Type: Man3}, {Type: Man4}, {Type: Man5}, {Type: Man6}, {Type: Man7},
+		{Type: Pin3}, {Type: Pin4}, {Type: Pin5}, {Type: So3}, {Type: So4}, {Type: So5}, {Type: So6}, {Type: East},
+	}
+	// 每次使用新的 Searcher，避免只测到缓存命中
+	b.Run("serial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			NewSearcher().SeekCandidates(hand, 0, nil)
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			NewSearcher().SeekCandidatesParallel(hand, 0, nil, 4)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package mahjong
+
+import (
+	"game/runtime/engines"
+	"game/runtime/share"
+	"time"
+)
+
+/*
+状态更新推送（gameplay.state.update）节流：
+ 1. 每处理完一个事件，局面有推进（actionSeq 变化）时开启一个节流窗口，窗口内的多次变化在窗口结束时合并为一次推送
+ 2. 开局、结算等重大变化立即推送，并取消窗口内尚未发出的推送
+ 3. 推送内容只有公开信息，玩家和观战者收到的相同
+*/
+
+// StateUpdateEvent 节流窗口结束，由 actor 推送合并后的状态更新
+type StateUpdateEvent struct {
+	share.GameMessageEvent
+	Gen int // 安排推送时的窗口编号，窗口被立即推送取消后到达的事件直接丢弃
+}
+
+func (e *StateUpdateEvent) GetEventType() string {
+	return "StateUpdate"
+}
+
+// stateUpdateEnabled 未配置推送间隔或没有 worker（复盘）时不推送状态更新
+func (eg *RiichiMahjong4p) stateUpdateEnabled() bool {
+	return eg.Rules.StatePushInterval > 0 && eg.Worker != nil
+}
+
+// markStateDirty 局面推进后调用，窗口外时开启节流窗口，窗口内不重复安排
+func (eg *RiichiMahjong4p) markStateDirty() {
+	if !eg.stateUpdateEnabled() || eg.State != engines.GameInProgress {
+		return
+	}
+	if eg.stateUpdateTimer != nil || eg.actionSeq == eg.stateUpdateSeq {
+		return
+	}
+	gen := eg.stateUpdateGen
+	eg.stateUpdateTimer = time.AfterFunc(eg.Rules.StatePushInterval, func() {
+		eg.NotifyEvent(&StateUpdateEvent{Gen: gen})
+	})
+}
+
+// handleStateUpdateEvent 节流窗口结束，推送一次状态更新
+func (eg *RiichiMahjong4p) handleStateUpdateEvent(event *StateUpdateEvent) {
+	if event.Gen != eg.stateUpdateGen {
+		return
+	}
+	eg.stopStateUpdateTimer()
+	if eg.State != engines.GameInProgress {
+		return
+	}
+	eg.sendStateUpdate()
+}
+
+// pushStateUpdateNow 开局、结算时立即推送状态更新，窗口内尚未发出的推送随之取消
+func (eg *RiichiMahjong4p) pushStateUpdateNow() {
+	eg.stopStateUpdateTimer()
+	if !eg.stateUpdateEnabled() {
+		return
+	}
+	eg.sendStateUpdate()
+}
+
+// stopStateUpdateTimer 结束当前节流窗口，已经投递的 StateUpdateEvent 因窗口编号不同被丢弃
+func (eg *RiichiMahjong4p) stopStateUpdateTimer() {
+	if eg.stateUpdateTimer == nil {
+		return
+	}
+	eg.stateUpdateTimer.Stop()
+	eg.stateUpdateTimer = nil
+	eg.stateUpdateGen++
+}
+
+// sendStateUpdate 推送并记录已推送的局面序号，局面没有再推进时不会开启新窗口
+func (eg *RiichiMahjong4p) sendStateUpdate() {
+	eg.stateUpdateSeq = eg.actionSeq
+	eg.broadcastStateUpdate()
+}
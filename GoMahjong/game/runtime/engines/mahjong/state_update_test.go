@@ -0,0 +1,80 @@
+package mahjong
+
+import (
+	"game/infrastructure/message/transfer"
+	"game/runtime"
+	"testing"
+	"time"
+)
+
+const testStatePushInterval = 50 * time.Millisecond
+
+// newStateUpdateEngine 开启状态更新节流的测试引擎，1 号座位是真人玩家
+func newStateUpdateEngine(t *testing.T) *RiichiMahjong4p {
+	t.Helper()
+	rules := noAkaRules()
+	rules.StatePushInterval = testStatePushInterval
+	eg := newTestEngine(t, rules)
+	eg.Worker = &game.Worker{}
+	player := eg.UserMap["b"]
+	player.IsBot, player.ConnectorNodeID = false, "conn-player"
+	return eg
+}
+
+// advance 模拟处理一个推进局面的事件
+func advance(eg *RiichiMahjong4p) {
+	eg.actionSeq++
+	eg.markStateDirty()
+}
+
+// 窗口内的一连串变化在窗口结束时合并为一次推送
+func TestStateUpdateThrottle(t *testing.T) {
+	eg := newStateUpdateEngine(t)
+	batch := capturePushes(eg)
+
+	for i := 0; i < 5; i++ {
+		advance(eg)
+	}
+	if got := pushedTo(batch, "conn-player", transfer.GameplayStateUpdate); len(got) != 0 {
+		t.Fatalf("窗口结束前不应推送, got %d", len(got))
+	}
+	select {
+	case event := <-eg.gameEvents:
+		eg.processEvent(event)
+	case <-time.After(time.Second):
+		t.Fatalf("窗口结束后应投递 StateUpdateEvent")
+	}
+	if got := pushedTo(batch, "conn-player", transfer.GameplayStateUpdate); len(got) != 1 {
+		t.Fatalf("一连串变化应合并为 1 次推送, got %d", len(got))
+	}
+
+	// 推送后局面没有再推进，不开启新窗口
+	eg.markStateDirty()
+	select {
+	case event := <-eg.gameEvents:
+		t.Fatalf("局面没有推进不应再推送, got %v", event.GetEventType())
+	case <-time.After(3 * testStatePushInterval):
+	}
+}
+
+// 开局立即推送，并取消窗口内尚未发出的推送
+func TestStateUpdateRoundStartImmediate(t *testing.T) {
+	eg := newStateUpdateEngine(t)
+	batch := capturePushes(eg)
+
+	advance(eg)
+	gen := eg.stateUpdateGen
+	eg.handleStartRoundEvent()
+	if got := pushedTo(batch, "conn-player", transfer.GameplayStateUpdate); len(got) != 1 {
+		t.Fatalf("开局应立即推送 1 次状态更新, got %d", len(got))
+	}
+	if eg.stateUpdateTimer != nil {
+		t.Fatalf("立即推送应结束当前窗口")
+	}
+
+	// 被取消的窗口已经投递的事件直接丢弃
+	eg.processEvent(&StateUpdateEvent{Gen: gen})
+	if got := pushedTo(batch, "conn-player", transfer.GameplayStateUpdate); len(got) != 1 {
+		t.Fatalf("被取消的窗口不应再推送, got %d", len(got))
+	}
+}
@@ -15,6 +15,7 @@ const (
 	StateRunning                    // 计时中
 	StateStopped                    // 已停止
 	StateTimeout                    // 已超时
+	StatePaused                     // 掉线暂停
 )
 
 type TurnState int // 空闲、收集、等待出牌者反应、等待非出牌者反应
@@ -27,40 +28,69 @@ const (
 	TurnStateApplyOperation                  // 棋牌格局归属改变，如洗牌、打牌、鸣牌
 )
 
+// TurnManager 回合管理，由引擎 actor 写入，计时器 goroutine 的回调也会读取 TurnPointer/State，所以需要加锁
+// 注意：持有 mu 时不能再操作计时器（计时器回调持有计时器的锁读取回合状态），否则可能死锁
 type TurnManager struct {
-	TurnPointer int       // 当前出牌玩家座位
-	State       TurnState // 当前回合状态
-	Tickers     [4]*PlayerTicker
+	TurnPointer  int       // 当前出牌玩家座位
+	State        TurnState // 当前回合状态
+	Tickers      [4]*PlayerTicker
+	SeatCount    int // 座位数（三麻时 Tickers[3] 为空）
+	MaxRoundTime int // 出牌阶段单回合分配时间上限（秒）
+
+	mu sync.RWMutex // 保护 TurnPointer、State
 }
 
 // NewTurnManager 创建新的回合管理器
-func NewTurnManager(tickers [4]*PlayerTicker) *TurnManager {
+func NewTurnManager(tickers [4]*PlayerTicker, seatCount int, maxRoundTime int) *TurnManager {
 	return &TurnManager{
-		TurnPointer: 0,
-		State:       TurnStateIdle,
-		Tickers:     tickers,
+		TurnPointer:  0,
+		State:        TurnStateIdle,
+		Tickers:      tickers,
+		SeatCount:    seatCount,
+		MaxRoundTime: maxRoundTime,
 	}
 }
 
 // NextTurn 下一个玩家出牌
 func (tm *TurnManager) NextTurn() int {
-	tm.TurnPointer = (tm.TurnPointer + 1) % 4
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.TurnPointer = (tm.TurnPointer + 1) % tm.SeatCount
 	return tm.TurnPointer
 }
 
 // GetCurrentPlayer 获取当前出牌玩家座位
 func (tm *TurnManager) GetCurrentPlayer() int {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
 	return tm.TurnPointer
 }
 
 // GetState 获取当前回合状态
 func (tm *TurnManager) GetState() TurnState {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
 	return tm.State
 }
 
+// setTurn 切换回合状态（不操作计时器）
+func (tm *TurnManager) setTurn(seatIndex int, state TurnState) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.TurnPointer = seatIndex
+	tm.State = state
+}
+
+// setState 切换回合状态，出牌玩家不变（不操作计时器）
+func (tm *TurnManager) setState(state TurnState) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.State = state
+}
+
 func (tm *TurnManager) stopAllTickers() {
-	for i := 0; i < 4; i++ {
-		if tm.Tickers[i].GetState() == StateRunning {
+	for i := 0; i < tm.SeatCount; i++ {
+		if state := tm.Tickers[i].GetState(); state == StateRunning || state == StatePaused {
 			tm.Tickers[i].Stop()
 		}
 	}
@@ -69,20 +99,19 @@ func (tm *TurnManager) stopAllTickers() {
 // EnterDropPhase 进入出牌阶段
 // roundCompensation: 本回合补偿时间（秒），默认 5 秒
 func (tm *TurnManager) EnterDropPhase(seatIndex int, roundCompensation int) error {
-	if seatIndex < 0 || seatIndex >= 4 {
+	if seatIndex < 0 || seatIndex >= tm.SeatCount {
 		return fmt.Errorf("无效的座位索引: %d", seatIndex)
 	}
 
 	tm.stopAllTickers()
-	tm.TurnPointer = seatIndex
-	tm.State = TurnStateWaitMain
+	tm.setTurn(seatIndex, TurnStateWaitMain)
 
 	// 启动出牌玩家的计时
 	// 分配时间 = 玩家总剩余时间 + 本回合补偿
 	ticker := tm.Tickers[seatIndex]
-	allocatedTime := ticker.Available + roundCompensation
-	if allocatedTime > DefaultMaxRoundTime {
-		allocatedTime = DefaultMaxRoundTime
+	allocatedTime := ticker.allocate(roundCompensation)
+	if allocatedTime > tm.MaxRoundTime {
+		allocatedTime = tm.MaxRoundTime
 	}
 	ticker.SetAvailable(allocatedTime)
 	if err := ticker.Start(allocatedTime); err != nil {
@@ -96,21 +125,21 @@ func (tm *TurnManager) EnterDropPhase(seatIndex int, roundCompensation int) erro
 // 此阶段不需要计时
 func (tm *TurnManager) EnterSelectingPhase() {
 	tm.stopAllTickers()
-	tm.State = TurnStateSelecting
+	tm.setState(TurnStateSelecting)
 }
 
 // EnterReactingPhase 进入等待反应阶段（吃碰杠）
 // 此阶段不需要计时
 func (tm *TurnManager) EnterReactingPhase() {
 	tm.stopAllTickers()
-	tm.State = TurnStateWaitReactions
+	tm.setState(TurnStateWaitReactions)
 }
 
 // EnterChoosingPhase 进入选择阶段（吃碰杠）
 // 此阶段不需要计时
 func (tm *TurnManager) EnterChoosingPhase() {
 	tm.stopAllTickers()
-	tm.State = TurnStateApplyOperation
+	tm.setState(TurnStateApplyOperation)
 }
 
 // GetPlayerTicker 获取玩家的计时器
@@ -121,7 +150,7 @@ func (tm *TurnManager) GetPlayerTicker(seatIndex int) *PlayerTicker {
 // GetAllPlayerTimerStates 获取所有玩家的计时器状态
 func (tm *TurnManager) GetAllPlayerTimerStates() [4]TickerState {
 	var states [4]TickerState
-	for i := 0; i < 4; i++ {
+	for i := 0; i < tm.SeatCount; i++ {
 		states[i] = tm.Tickers[i].GetState()
 	}
 	return states
@@ -129,13 +158,20 @@ func (tm *TurnManager) GetAllPlayerTimerStates() [4]TickerState {
 
 type PlayerTicker struct {
 	// 时间管理（单位：秒）
-	Available      int       // 总剩余时间（跨回合累计）
-	RoundStartTime time.Time // 本回合开始时间
+	Available      int           // 总剩余时间（跨回合累计）
+	RoundStartTime time.Time     // 本回合开始时间（含单调时钟读数）
+	duration       int           // 本回合分配的时间
+	left           time.Duration // 本回合尚未消耗的时间，暂停时结算，计时段从 RoundStartTime 开始
+	pausedAt       time.Time     // 本次暂停开始时间
+	pausedTotal    time.Duration // 累计暂停时间（跨回合），不超过 maxPause
+	maxPause       time.Duration // 累计暂停时间上限
+	fixed          int           // bot 每回合固定分配的时间（秒），0 表示按剩余时间 + 补偿分配
+	reaction       bool          // 本轮是反应计时，结算时不扣除总剩余时间
 
 	// 状态管理
 	State     TickerState
-	isRunning bool // 防止重复启动
-	ctx       context.Context
+	isRunning bool   // 防止重复启动
+	run       uint64 // 每次 Start 递增，计时 goroutine 只处理自己那一轮
 	cancel    context.CancelFunc
 
 	// 回调函数
@@ -153,7 +189,25 @@ func NewPlayerTicker(totalTime int) *PlayerTicker {
 		Available: totalTime,
 		State:     StateIdle,
 		isRunning: false,
+		maxPause:  DefaultMaxPauseTime,
+	}
+}
+
+// NewBotTicker 创建 bot 的计时器，每回合固定 thinkTime 秒后超时，走超时自动操作
+func NewBotTicker(thinkTime int) *PlayerTicker {
+	pt := NewPlayerTicker(thinkTime)
+	pt.fixed = thinkTime
+	return pt
+}
+
+// allocate 本回合分配的时间：总剩余时间 + 补偿；bot 固定分配
+func (pt *PlayerTicker) allocate(compensation int) int {
+	pt.RLock()
+	defer pt.RUnlock()
+	if pt.fixed > 0 {
+		return pt.fixed
 	}
+	return pt.Available + compensation
 }
 
 // Start 启动计时
@@ -163,79 +217,190 @@ func (pt *PlayerTicker) Start(duration int) error {
 	pt.Lock()
 	defer pt.Unlock()
 
-	if pt.isRunning {
-		return fmt.Errorf("计时已在运行，无法重复启动")
-	}
 	if pt.Available < duration {
 		return fmt.Errorf("剩余时间 %d 秒不足 %d 秒", pt.Available, duration)
 	}
+	return pt.start(duration, false)
+}
+
+// StartReaction 启动反应阶段（吃碰杠荣和）的计时，使用独立的反应时间，不消耗出牌的总剩余时间
+// bot 仍按固定时间计时
+func (pt *PlayerTicker) StartReaction(duration int) error {
+	pt.Lock()
+	defer pt.Unlock()
+
+	if pt.fixed > 0 {
+		duration = pt.fixed
+	}
+	return pt.start(duration, true)
+}
+
+// start 开始一轮计时，调用方持有锁
+func (pt *PlayerTicker) start(duration int, reaction bool) error {
+	if pt.isRunning {
+		return fmt.Errorf("计时已在运行，无法重复启动")
+	}
 
 	pt.isRunning = true
+	pt.reaction = reaction
+	pt.run++
+	pt.duration = duration
+	pt.left = time.Duration(duration) * time.Second
 	oldState := pt.State
 	pt.State = StateRunning
 	pt.RoundStartTime = time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(duration)*time.Second)
+	pt.cancel = cancel
 
 	// 触发状态变化回调
 	if pt.onStateChange != nil {
 		pt.onStateChange(oldState, StateRunning)
 	}
-	go pt.timerLoop(duration)
+	go pt.timerLoop(ctx, pt.run)
 
 	return nil
 }
 
-// timerLoop 计时循环（在 goroutine 中运行）
-func (pt *PlayerTicker) timerLoop(duration int) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(duration)*time.Second)
-	defer cancel()
+// timerLoop 计时循环（在 goroutine 中运行），只负责超时（含暂停额度耗尽）；主动停止的结算由 Stop 完成
+func (pt *PlayerTicker) timerLoop(ctx context.Context, run uint64) {
+	<-ctx.Done()
+
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return
+	}
+	pt.expire(run)
+}
+
+// expire 按超时结算本回合：扣除本回合全部分配时间并触发超时回调
+func (pt *PlayerTicker) expire(run uint64) {
 	pt.Lock()
-	pt.ctx = ctx
-	pt.cancel = cancel
+	if pt.run != run || !pt.isRunning {
+		// 已被 Stop 结算，或者已经开始了新一轮计时
+		pt.Unlock()
+		return
+	}
+	oldState := pt.State
+	if oldState == StatePaused {
+		pt.pausedTotal += time.Since(pt.pausedAt)
+	}
+	pt.State = StateTimeout
+	pt.isRunning = false
+	if !pt.reaction {
+		pt.Available = max(0, pt.Available-pt.duration)
+	}
+	pt.cancel()
+	pt.cancel = nil
+	onStateChange, onTimeout := pt.onStateChange, pt.onTimeout
 	pt.Unlock()
-	<-ctx.Done()
 
+	if onStateChange != nil {
+		onStateChange(oldState, StateTimeout)
+	}
+	if onTimeout != nil {
+		onTimeout()
+	}
+}
+
+// Stop 停止计时，按单调时钟结算本回合用时，只结算一次；暂停中的时间不计入用时
+// 返回剩余时间（秒）以及是否成功停止；已超时（哪怕超时回调尚未执行）或未在计时时返回 false
+func (pt *PlayerTicker) Stop() (int, bool) {
+	pt.Lock()
+	if !pt.isRunning || pt.cancel == nil {
+		remaining := pt.Available
+		pt.Unlock()
+		return remaining, false
+	}
+	left := pt.left
+	if pt.State == StateRunning {
+		left -= time.Since(pt.RoundStartTime)
+		if left <= 0 {
+			// 截止时间已到，交给计时 goroutine 按超时处理，避免超时与主动操作同时生效
+			remaining := pt.Available
+			pt.Unlock()
+			return remaining, false
+		}
+	} else {
+		pt.pausedTotal += time.Since(pt.pausedAt)
+	}
+	used := time.Duration(pt.duration)*time.Second - left
+
+	pt.cancel()
+	pt.cancel = nil
+	pt.isRunning = false
+	if !pt.reaction {
+		pt.Available = max(0, pt.Available-int(used/time.Second))
+	}
+	oldState := pt.State
+	pt.State = StateStopped
+	remaining := pt.Available
+	onStateChange, onStop := pt.onStateChange, pt.onStop
+	pt.Unlock()
+
+	if onStateChange != nil {
+		onStateChange(oldState, StateStopped)
+	}
+	if onStop != nil {
+		onStop()
+	}
+	return remaining, true
+}
+
+// Pause 玩家掉线时暂停计时，保留本回合剩余时间
+// 暂停期间改为按剩余的暂停额度计时，额度耗尽则按超时处理（执行自动操作）；额度已用完时不暂停，返回 false
+func (pt *PlayerTicker) Pause() bool {
 	pt.Lock()
 	defer pt.Unlock()
 
-	// 检查是否是超时还是被取消
-	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-		oldState := pt.State
-		pt.State = StateTimeout
-		pt.isRunning = false
-		pt.Available = 0
+	if !pt.isRunning || pt.State != StateRunning || pt.cancel == nil {
+		return false
+	}
+	budget := pt.maxPause - pt.pausedTotal
+	if budget <= 0 {
+		return false
+	}
+	left := pt.left - time.Since(pt.RoundStartTime)
+	if left <= 0 {
+		return false
+	}
 
-		if pt.onStateChange != nil {
-			pt.onStateChange(oldState, StateTimeout)
-		}
-		if pt.onTimeout != nil {
-			pt.onTimeout()
-		}
-	} else if errors.Is(ctx.Err(), context.Canceled) {
-		// 被取消处理（玩家操作）
-		usedTime := int(time.Since(pt.RoundStartTime).Seconds())
-		pt.Available = max(0, pt.Available-usedTime)
-		oldState := pt.State
-		pt.State = StateStopped
-		pt.isRunning = false
-
-		if pt.onStateChange != nil {
-			pt.onStateChange(oldState, StateStopped)
-		}
-		if pt.onStop != nil {
-			pt.onStop()
-		}
+	pt.cancel()
+	pt.run++
+	pt.left = left
+	pt.pausedAt = time.Now()
+	oldState := pt.State
+	pt.State = StatePaused
+	ctx, cancel := context.WithTimeout(context.Background(), budget)
+	pt.cancel = cancel
+
+	if pt.onStateChange != nil {
+		pt.onStateChange(oldState, StatePaused)
 	}
+	go pt.timerLoop(ctx, pt.run)
+	return true
 }
 
-// Stop 停止计时
-// 返回已用时间（秒）
-func (pt *PlayerTicker) Stop() bool {
+// Resume 玩家重连后恢复计时，继续使用暂停时保留的剩余时间
+func (pt *PlayerTicker) Resume() bool {
 	pt.Lock()
 	defer pt.Unlock()
-	if !pt.isRunning || pt.cancel == nil {
+
+	if !pt.isRunning || pt.State != StatePaused || pt.cancel == nil {
 		return false
 	}
+
 	pt.cancel()
+	pt.run++
+	pt.pausedTotal += time.Since(pt.pausedAt)
+	oldState := pt.State
+	pt.State = StateRunning
+	pt.RoundStartTime = time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), pt.left)
+	pt.cancel = cancel
+
+	if pt.onStateChange != nil {
+		pt.onStateChange(oldState, StateRunning)
+	}
+	go pt.timerLoop(ctx, pt.run)
 	return true
 }
 
@@ -246,6 +411,13 @@ func (pt *PlayerTicker) SetAvailable(Available int) int {
 	return pt.Available
 }
 
+// GetAvailable 获取总剩余时间（秒）
+func (pt *PlayerTicker) GetAvailable() int {
+	pt.RLock()
+	defer pt.RUnlock()
+	return pt.Available
+}
+
 // GetState 获取当前状态
 func (pt *PlayerTicker) GetState() TickerState {
 	pt.RLock()
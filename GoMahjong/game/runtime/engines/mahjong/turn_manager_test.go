@@ -0,0 +1,237 @@
+package mahjong
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestTurnManager(thinkTime int) *TurnManager {
+	var tickers [4]*PlayerTicker
+	for i := range tickers {
+		tickers[i] = NewBotTicker(thinkTime)
+	}
+	return NewTurnManager(tickers, 4, DefaultMaxRoundTime)
+}
+
+// 计时器超时回调与 actor 的回合切换并发读写回合状态，需要 go test -race 检查
+func TestTurnManagerConcurrentTimeout(t *testing.T) {
+	tm := newTestTurnManager(1)
+	var timeouts atomic.Int32
+	for i := range tm.Tickers {
+		tm.Tickers[i].SetOnTimeout(func() {
+			_ = tm.GetCurrentPlayer()
+			_ = tm.GetState()
+			timeouts.Add(1)
+		})
+	}
+	if err := tm.EnterDropPhase(0, 0); err != nil {
+		t.Fatalf("EnterDropPhase: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					_ = tm.GetCurrentPlayer()
+					_ = tm.GetState()
+				}
+			}
+		}()
+	}
+
+	// 超时触发前后 actor 不停切换回合状态（不重启计时，让出牌计时按时超时）
+	deadline := time.Now().Add(1500 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		tm.setState(TurnStateSelecting)
+		tm.NextTurn()
+		tm.setTurn(0, TurnStateWaitMain)
+	}
+	close(done)
+	wg.Wait()
+
+	if timeouts.Load() != 1 {
+		t.Fatalf("出牌计时应超时 1 次, got %d", timeouts.Load())
+	}
+	tm.EnterReactingPhase()
+	if tm.GetState() != TurnStateWaitReactions {
+		t.Fatalf("回合状态 = %v, want %v", tm.GetState(), TurnStateWaitReactions)
+	}
+}
+
+// 截止前停止按实际用时结算；截止后（无论超时回调是否已执行）停止返回 false，只按超时扣除一次
+func TestPlayerTickerStopOrdering(t *testing.T) {
+	t.Run("stop before timeout", func(t *testing.T) {
+		pt := NewPlayerTicker(5)
+		var timeouts atomic.Int32
+		pt.SetOnTimeout(func() { timeouts.Add(1) })
+		if err := pt.Start(1); err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+		time.Sleep(800 * time.Millisecond)
+		if remaining, ok := pt.Stop(); !ok || remaining != 5 {
+			t.Fatalf("Stop = %d, %v, want 5, true", remaining, ok)
+		}
+		time.Sleep(400 * time.Millisecond)
+		if timeouts.Load() != 0 || pt.GetState() != StateStopped {
+			t.Fatalf("停止后不应再超时, state=%v", pt.GetState())
+		}
+	})
+
+	t.Run("stop after deadline before callback", func(t *testing.T) {
+		pt := NewPlayerTicker(5)
+		timedOut := make(chan struct{}, 1)
+		pt.SetOnTimeout(func() { timedOut <- struct{}{} })
+		if err := pt.Start(1); err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+		// 截止时间已过，但计时 goroutine 还没有处理超时
+		pt.Lock()
+		pt.RoundStartTime = pt.RoundStartTime.Add(-2 * time.Second)
+		pt.Unlock()
+		if remaining, ok := pt.Stop(); ok || remaining != 5 {
+			t.Fatalf("Stop = %d, %v, want 5, false", remaining, ok)
+		}
+		select {
+		case <-timedOut:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("截止后停止不应取消超时")
+		}
+		if got := pt.GetAvailable(); got != 4 {
+			t.Fatalf("超时后剩余 %d 秒, want 4", got)
+		}
+	})
+
+	t.Run("stop after timeout", func(t *testing.T) {
+		pt := NewPlayerTicker(5)
+		timedOut := make(chan struct{}, 1)
+		pt.SetOnTimeout(func() { timedOut <- struct{}{} })
+		if err := pt.Start(1); err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+		<-timedOut
+		if remaining, ok := pt.Stop(); ok || remaining != 4 || pt.GetState() != StateTimeout {
+			t.Fatalf("Stop = %d, %v, want 4, false", remaining, ok)
+		}
+	})
+}
+
+// 暂停期间不消耗本回合时间，恢复后按剩余时间计时；暂停超过上限按超时处理
+func TestPlayerTickerPauseResume(t *testing.T) {
+	t.Run("resume extends deadline", func(t *testing.T) {
+		pt := NewPlayerTicker(5)
+		timedOut := make(chan time.Time, 1)
+		pt.SetOnTimeout(func() { timedOut <- time.Now() })
+		if err := pt.Start(1); err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+		time.Sleep(500 * time.Millisecond)
+		if !pt.Pause() {
+			t.Fatalf("Pause 失败")
+		}
+		time.Sleep(time.Second)
+		resumed := time.Now()
+		if !pt.Resume() {
+			t.Fatalf("暂停期间不应超时, state=%v", pt.GetState())
+		}
+		select {
+		case at := <-timedOut:
+			if waited := at.Sub(resumed); waited < 400*time.Millisecond || waited > 800*time.Millisecond {
+				t.Fatalf("恢复后 %v 超时, want 约 500ms", waited)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("恢复后没有超时")
+		}
+	})
+
+	t.Run("pause cap", func(t *testing.T) {
+		pt := NewPlayerTicker(5)
+		pt.maxPause = 300 * time.Millisecond
+		timedOut := make(chan struct{}, 1)
+		pt.SetOnTimeout(func() { timedOut <- struct{}{} })
+		if err := pt.Start(1); err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+		if !pt.Pause() {
+			t.Fatalf("Pause 失败")
+		}
+		select {
+		case <-timedOut:
+		case <-time.After(time.Second):
+			t.Fatalf("暂停超过上限应按超时处理")
+		}
+		if pt.Pause() {
+			t.Fatalf("超时后不能再暂停")
+		}
+	})
+}
+
+// bot 每回合固定分配思考时间，不累计剩余时间和补偿
+func TestBotTickerAllocate(t *testing.T) {
+	if got := NewBotTicker(1).allocate(3); got != 1 {
+		t.Fatalf("bot 分配时间 = %d, want 1", got)
+	}
+	if got := NewPlayerTicker(20).allocate(3); got != 23 {
+		t.Fatalf("玩家分配时间 = %d, want 23", got)
+	}
+}
+
+// 反应阶段使用独立的计时，无论超时还是主动停止都不消耗出牌的总剩余时间
+func TestPlayerTickerReactionKeepsAvailable(t *testing.T) {
+	t.Run("timeout", func(t *testing.T) {
+		pt := NewPlayerTicker(5)
+		timedOut := make(chan struct{}, 1)
+		pt.SetOnTimeout(func() { timedOut <- struct{}{} })
+		if err := pt.StartReaction(1); err != nil {
+			t.Fatalf("StartReaction: %v", err)
+		}
+		select {
+		case <-timedOut:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("反应计时没有超时")
+		}
+		if got := pt.GetAvailable(); got != 5 {
+			t.Fatalf("反应超时后剩余 %d 秒, want 5", got)
+		}
+		// 总剩余时间用完也能开始反应计时
+		if err := NewPlayerTicker(0).StartReaction(1); err != nil {
+			t.Fatalf("StartReaction: %v", err)
+		}
+	})
+
+	for _, reaction := range []bool{true, false} {
+		name := "reaction stop"
+		if !reaction {
+			name = "main turn stop"
+		}
+		t.Run(name, func(t *testing.T) {
+			pt := NewPlayerTicker(5)
+			start := pt.Start
+			if reaction {
+				start = pt.StartReaction
+			}
+			if err := start(5); err != nil {
+				t.Fatalf("start: %v", err)
+			}
+			// 本轮已经用掉 2 秒
+			pt.Lock()
+			pt.RoundStartTime = pt.RoundStartTime.Add(-2 * time.Second)
+			pt.Unlock()
+			want := 3
+			if reaction {
+				want = 5
+			}
+			if remaining, ok := pt.Stop(); !ok || remaining != want {
+				t.Fatalf("Stop = %d, %v, want %d, true", remaining, ok, want)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package mahjong
 
-import "math"
+import (
+	"fmt"
+	"math"
+)
 
 // Yaku 役种（和牌方式）
 type Yaku int
@@ -55,8 +58,77 @@ const (
 	YakuChuuren       // 九莲宝灯：同一种花色的1112345678999，加上任意一张同花色的牌
 	YakuJunseiChuuren // 纯正九莲宝灯：九莲宝灯听所有的9种牌
 	YakuKazoeYakuman  // 累计役满：手牌的番数累计达到或超过13番
+
+	// 以下为后续补充的役种，追加在末尾以保持已有役种编号不变
+	YakuDaisangen    // 大三元：三元牌三组刻子/杠子
+	YakuShousangen   // 小三元：三元牌两组刻子/杠子 + 三元牌雀头
+	YakuShousushi    // 小四喜：风牌三组刻子/杠子 + 风牌雀头
+	YakuRyuuiisou    // 绿一色：只由 23468 索和发组成
+	YakuTsuuiisou    // 字一色：只由字牌组成
+	YakuTenhou       // 天和：庄家第一巡自摸
+	YakuChiihou      // 地和：闲家第一巡无人鸣牌时自摸
+	YakuRenhou       // 人和：闲家第一巡摸牌前荣和（可选规则）
+	YakuIppatsu      // 一发：立直后一巡内无人鸣牌时和牌
+	YakuChankan      // 抢杠：荣和他家加杠的牌
+	YakuHaitei       // 海底摸月：摸最后一张牌自摸
+	YakuHoutei       // 河底捞鱼：荣和最后一张打出的牌
+	YakuRinshan      // 岭上开花：开杠后摸岭上牌自摸
+	YakuDoubleRiichi // 两立直：第一巡无人鸣牌时、第一张打牌前宣言立直（代替立直，2 番）
 )
 
+// yakuNames 役种的稳定名称，推送和牌谱中使用，客户端按名称显示；已有名称不能修改
+var yakuNames = [...]string{
+	YakuRiichi:        "Riichi",
+	YakuTsumo:         "Tsumo",
+	YakuPinfu:         "Pinfu",
+	YakuIppeiko:       "Ippeiko",
+	YakuRyanpeiko:     "Ryanpeiko",
+	YakuYakuhai:       "Yakuhai",
+	YakuTanyao:        "Tanyao",
+	YakuSanshoku:      "Sanshoku",
+	YakuIttsu:         "Ittsu",
+	YakuChanta:        "Chanta",
+	YakuJunchan:       "Junchan",
+	YakuHonroto:       "Honroto",
+	YakuChinroto:      "Chinroto",
+	YakuHonitsu:       "Honitsu",
+	YakuChinitsu:      "Chinitsu",
+	YakuToitoi:        "Toitoi",
+	YakuSananko:       "Sananko",
+	YakuSankantsu:     "Sankantsu",
+	YakuChiitoi:       "Chiitoi",
+	YakuKokushi:       "Kokushi",
+	YakuSuuankou:      "Suuankou",
+	YakuSuuankouTanki: "SuuankouTanki",
+	YakuDaisushi:      "Daisushi",
+	YakuKokushi13:     "Kokushi13",
+	YakuChuuren:       "Chuuren",
+	YakuJunseiChuuren: "JunseiChuuren",
+	YakuKazoeYakuman:  "KazoeYakuman",
+	YakuDaisangen:     "Daisangen",
+	YakuShousangen:    "Shousangen",
+	YakuShousushi:     "Shousushi",
+	YakuRyuuiisou:     "Ryuuiisou",
+	YakuTsuuiisou:     "Tsuuiisou",
+	YakuTenhou:        "Tenhou",
+	YakuChiihou:       "Chiihou",
+	YakuRenhou:        "Renhou",
+	YakuIppatsu:       "Ippatsu",
+	YakuChankan:       "Chankan",
+	YakuHaitei:        "Haitei",
+	YakuHoutei:        "Houtei",
+	YakuRinshan:       "Rinshan",
+	YakuDoubleRiichi:  "DoubleRiichi",
+}
+
+// String 役种名称，未登记名称的役种返回 Yaku<编号>
+func (y Yaku) String() string {
+	if y >= 0 && int(y) < len(yakuNames) && yakuNames[y] != "" {
+		return yakuNames[y]
+	}
+	return fmt.Sprintf("Yaku%d", int(y))
+}
+
 type RoundScoreDetail struct {
 	Yakus        []Yaku
 	YakumanValue int
@@ -67,6 +139,7 @@ type YakuContext struct {
 	Winner    *PlayerImage
 	Situation *Situation
 	EndKind   string
+	Division  *HandDivision // 当前评估的手牌拆解，非标准型（国士等）为 nil
 }
 
 type YakuChecker interface {
@@ -111,12 +184,60 @@ var RiichiMahjong4pYakuRegistry = []YakuChecker{
 		}
 		return 0, 0
 	}},
+	yakuCheckerFunc{id: YakuSuuankou, check: func(ctx *YakuContext) (int, int) {
+		if checkSuuankou(ctx) {
+			return 0, 1
+		}
+		return 0, 0
+	}},
 	yakuCheckerFunc{id: YakuDaisushi, check: func(ctx *YakuContext) (int, int) {
 		if checkDaisushi(ctx) {
 			return 0, 2
 		}
 		return 0, 0
 	}},
+	yakuCheckerFunc{id: YakuDaisangen, check: func(ctx *YakuContext) (int, int) {
+		if triplets, _ := countHonorGroups(ctx, White, Red); triplets == 3 {
+			return 0, 1
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuShousushi, check: func(ctx *YakuContext) (int, int) {
+		if triplets, pairs := countHonorGroups(ctx, East, North); triplets == 3 && pairs == 1 {
+			return 0, 1
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuRyuuiisou, check: func(ctx *YakuContext) (int, int) {
+		if checkRyuuiisou(ctx) {
+			return 0, 1
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuTsuuiisou, check: func(ctx *YakuContext) (int, int) {
+		if checkTsuuiisou(ctx) {
+			return 0, 1
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuTenhou, check: func(ctx *YakuContext) (int, int) {
+		if checkFirstTurnWin(ctx) && !ctx.Claim.HasLoser && isDealerWinner(ctx) {
+			return 0, 1
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuChiihou, check: func(ctx *YakuContext) (int, int) {
+		if checkFirstTurnWin(ctx) && !ctx.Claim.HasLoser && !isDealerWinner(ctx) {
+			return 0, 1
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuRenhou, check: func(ctx *YakuContext) (int, int) {
+		if UseRenhou && checkFirstTurnWin(ctx) && ctx.Claim.HasLoser && !isDealerWinner(ctx) {
+			return 0, 1
+		}
+		return 0, 0
+	}},
 	yakuCheckerFunc{id: YakuKokushi13, check: func(ctx *YakuContext) (int, int) {
 		if checkKokushi13(ctx) {
 			return 0, 2
@@ -129,46 +250,186 @@ var RiichiMahjong4pYakuRegistry = []YakuChecker{
 		}
 		return 0, 0
 	}},
+	yakuCheckerFunc{id: YakuChuuren, check: func(ctx *YakuContext) (int, int) {
+		if checkChuuren(ctx) {
+			return 0, 1
+		}
+		return 0, 0
+	}},
 
 	// 基本役
-	yakuCheckerFunc{id: YakuRiichi, check: func(ctx *YakuContext) (int, int) { return 0, 0 }},
-	yakuCheckerFunc{id: YakuTsumo, check: func(ctx *YakuContext) (int, int) { return 0, 0 }},
+	yakuCheckerFunc{id: YakuRiichi, check: func(ctx *YakuContext) (int, int) {
+		if ctx.Winner != nil && ctx.Winner.IsRiichi && !ctx.Winner.DoubleRiichi {
+			return 1, 0
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuDoubleRiichi, check: func(ctx *YakuContext) (int, int) {
+		if ctx.Winner != nil && ctx.Winner.IsRiichi && ctx.Winner.DoubleRiichi {
+			return 2, 0
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuIppatsu, check: func(ctx *YakuContext) (int, int) {
+		if ctx.Winner != nil && ctx.Winner.IsRiichi && ctx.Winner.IppatsuEligible {
+			return 1, 0
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuTsumo, check: func(ctx *YakuContext) (int, int) {
+		if ctx.Winner != nil && !ctx.Claim.HasLoser && isMenzen(ctx.Winner) {
+			return 1, 0
+		}
+		return 0, 0
+	}},
 
 	// 平和系
-	yakuCheckerFunc{id: YakuPinfu, check: func(ctx *YakuContext) (int, int) { return 0, 0 }},
-	yakuCheckerFunc{id: YakuIppeiko, check: func(ctx *YakuContext) (int, int) { return 0, 0 }},
-	yakuCheckerFunc{id: YakuRyanpeiko, check: func(ctx *YakuContext) (int, int) { return 0, 0 }},
+	yakuCheckerFunc{id: YakuPinfu, check: func(ctx *YakuContext) (int, int) {
+		if checkPinfu(ctx, ctx.Division) {
+			return 1, 0
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuIppeiko, check: func(ctx *YakuContext) (int, int) {
+		if countPeikou(ctx) == 1 {
+			return 1, 0
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuRyanpeiko, check: func(ctx *YakuContext) (int, int) {
+		if countPeikou(ctx) == 2 {
+			return 3, 0
+		}
+		return 0, 0
+	}},
 
 	// 役牌系
-	yakuCheckerFunc{id: YakuYakuhai, check: func(ctx *YakuContext) (int, int) { return 0, 0 }},
+	yakuCheckerFunc{id: YakuYakuhai, check: func(ctx *YakuContext) (int, int) {
+		return countYakuhaiHan(ctx), 0
+	}},
+	yakuCheckerFunc{id: YakuShousangen, check: func(ctx *YakuContext) (int, int) {
+		if triplets, pairs := countHonorGroups(ctx, White, Red); triplets == 2 && pairs == 1 {
+			return 2, 0
+		}
+		return 0, 0
+	}},
 
 	// 断幺系
-	yakuCheckerFunc{id: YakuTanyao, check: func(ctx *YakuContext) (int, int) { return 0, 0 }},
+	yakuCheckerFunc{id: YakuTanyao, check: func(ctx *YakuContext) (int, int) {
+		if checkTanyao(ctx) {
+			return 1, 0
+		}
+		return 0, 0
+	}},
 
 	// 顺子系
-	yakuCheckerFunc{id: YakuSanshoku, check: func(ctx *YakuContext) (int, int) { return 0, 0 }},
-	yakuCheckerFunc{id: YakuIttsu, check: func(ctx *YakuContext) (int, int) { return 0, 0 }},
+	yakuCheckerFunc{id: YakuSanshoku, check: func(ctx *YakuContext) (int, int) {
+		if checkSanshoku(ctx) {
+			return kuisagari(ctx, 2), 0
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuIttsu, check: func(ctx *YakuContext) (int, int) {
+		if checkIttsu(ctx) {
+			return kuisagari(ctx, 2), 0
+		}
+		return 0, 0
+	}},
 
 	// 带幺系
-	yakuCheckerFunc{id: YakuChanta, check: func(ctx *YakuContext) (int, int) { return 0, 0 }},
-	yakuCheckerFunc{id: YakuJunchan, check: func(ctx *YakuContext) (int, int) { return 0, 0 }},
+	yakuCheckerFunc{id: YakuChanta, check: func(ctx *YakuContext) (int, int) {
+		if ok, hasHonor := checkTerminalSets(ctx); ok && hasHonor {
+			return kuisagari(ctx, 2), 0
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuJunchan, check: func(ctx *YakuContext) (int, int) {
+		if ok, hasHonor := checkTerminalSets(ctx); ok && !hasHonor {
+			return kuisagari(ctx, 3), 0
+		}
+		return 0, 0
+	}},
 
 	// 老头系
-	yakuCheckerFunc{id: YakuHonroto, check: func(ctx *YakuContext) (int, int) { return 0, 0 }},
-	yakuCheckerFunc{id: YakuChinroto, check: func(ctx *YakuContext) (int, int) { return 0, 0 }},
+	yakuCheckerFunc{id: YakuHonroto, check: func(ctx *YakuContext) (int, int) {
+		if checkHonroutou(ctx) {
+			return 2, 0
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuChinroto, check: func(ctx *YakuContext) (int, int) {
+		if checkChinroutou(ctx) {
+			return 0, 1
+		}
+		return 0, 0
+	}},
 
 	// 清一色系
-	yakuCheckerFunc{id: YakuHonitsu, check: func(ctx *YakuContext) (int, int) { return 0, 0 }},
-	yakuCheckerFunc{id: YakuChinitsu, check: func(ctx *YakuContext) (int, int) { return 0, 0 }},
+	yakuCheckerFunc{id: YakuHonitsu, check: func(ctx *YakuContext) (int, int) {
+		if ok, hasHonor := checkFlush(ctx); ok && hasHonor {
+			return kuisagari(ctx, 3), 0
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuChinitsu, check: func(ctx *YakuContext) (int, int) {
+		if ok, hasHonor := checkFlush(ctx); ok && !hasHonor {
+			return kuisagari(ctx, 6), 0
+		}
+		return 0, 0
+	}},
 
 	// 刻子系
-	yakuCheckerFunc{id: YakuToitoi, check: func(ctx *YakuContext) (int, int) { return 0, 0 }},
-	yakuCheckerFunc{id: YakuSananko, check: func(ctx *YakuContext) (int, int) { return 0, 0 }},
+	yakuCheckerFunc{id: YakuToitoi, check: func(ctx *YakuContext) (int, int) {
+		if checkToitoi(ctx) {
+			return 2, 0
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuSananko, check: func(ctx *YakuContext) (int, int) {
+		if countAnkou(ctx) == 3 {
+			return 2, 0
+		}
+		return 0, 0
+	}},
 	yakuCheckerFunc{id: YakuSankantsu, check: func(ctx *YakuContext) (int, int) { return 0, 0 }},
 
 	// 特殊型
-	yakuCheckerFunc{id: YakuChiitoi, check: func(ctx *YakuContext) (int, int) { return 0, 0 }},
-	yakuCheckerFunc{id: YakuKokushi, check: func(ctx *YakuContext) (int, int) { return 0, 0 }},
+	yakuCheckerFunc{id: YakuChiitoi, check: func(ctx *YakuContext) (int, int) {
+		if ctx.Division != nil && ctx.Division.Chiitoi {
+			return 2, 0
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuKokushi, check: func(ctx *YakuContext) (int, int) {
+		if checkKokushi(ctx) {
+			return 0, 1
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuChankan, check: func(ctx *YakuContext) (int, int) {
+		if ctx.Claim.Chankan {
+			return 1, 0
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuHaitei, check: func(ctx *YakuContext) (int, int) {
+		if ctx.Claim.LastTile && !ctx.Claim.HasLoser {
+			return 1, 0
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuHoutei, check: func(ctx *YakuContext) (int, int) {
+		if ctx.Claim.LastTile && ctx.Claim.HasLoser {
+			return 1, 0
+		}
+		return 0, 0
+	}},
+	yakuCheckerFunc{id: YakuRinshan, check: func(ctx *YakuContext) (int, int) {
+		if ctx.Claim.Rinshan && !ctx.Claim.HasLoser {
+			return 1, 0
+		}
+		return 0, 0
+	}},
 }
 
 func isHonor(tt TileType) bool { return tt >= East }
@@ -212,45 +473,83 @@ func isKokushiTileType(tt TileType) bool {
 	}
 }
 
-// checkSuuankouTanki 四暗刻单骑
+// checkSuuankouTanki 四暗刻单骑：按拆解判断，暗杠也算暗刻
 func checkSuuankouTanki(ctx *YakuContext) bool {
-	if ctx == nil || ctx.Winner == nil {
+	if ctx == nil || ctx.Division == nil || ctx.Division.Wait != WaitTanki {
 		return false
 	}
-	if len(ctx.Winner.Melds) != 0 {
+	return countAnkou(ctx) == 4
+}
+
+// checkSuuankou check 四暗刻（非单骑）：荣和补成的刻子算明刻，所以双碰荣和只能是对对和+三暗刻
+func checkSuuankou(ctx *YakuContext) bool {
+	if ctx == nil || ctx.Division == nil || ctx.Division.Wait == WaitTanki {
+		return false
+	}
+	return countAnkou(ctx) == 4
+}
+
+// countHonorGroups 统计 [from, to] 范围内字牌的刻子/杠子数与对子数
+func countHonorGroups(ctx *YakuContext, from, to TileType) (int, int) {
+	counts, _ := buildTileTypeCountsForClaim(ctx)
+	triplets, pairs := 0, 0
+	for tt := from; tt <= to; tt++ {
+		switch {
+		case counts[tt] >= 3:
+			triplets++
+		case counts[tt] == 2:
+			pairs++
+		}
+	}
+	return triplets, pairs
+}
+
+// checkRyuuiisou check 绿一色：只由 2、3、4、6、8 索和发组成（无发也成立）
+func checkRyuuiisou(ctx *YakuContext) bool {
+	if ctx == nil || ctx.Winner == nil {
 		return false
 	}
 	counts, total := buildTileTypeCountsForClaim(ctx)
-	if total != 14 {
+	if total < 14 {
 		return false
 	}
-	trip := 0
-	pair := TileType(-1)
-	for _, c := range counts {
+	for tt, c := range counts {
 		if c == 0 {
 			continue
 		}
-		switch c {
-		case 2:
-			pair = 0
-		case 3, 4:
-			trip++
+		switch tt {
+		case So2, So3, So4, So6, So8, Green:
 		default:
 			return false
 		}
 	}
-	// need exactly one pair and four triplets/quads
-	pairCount := 0
-	for tt, c := range counts {
-		if c == 2 {
-			pair = tt
-			pairCount++
-		}
+	return true
+}
+
+// checkTsuuiisou check 字一色：只由字牌组成
+func checkTsuuiisou(ctx *YakuContext) bool {
+	if ctx == nil || ctx.Winner == nil {
+		return false
 	}
-	if trip != 4 || pairCount != 1 {
+	counts, total := buildTileTypeCountsForClaim(ctx)
+	if total < 14 {
 		return false
 	}
-	return pair == ctx.Claim.WinTile.Type
+	for tt, c := range counts {
+		if c > 0 && !isHonor(tt) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkFirstTurnWin 和牌者仍处于未被打断的第一巡
+func checkFirstTurnWin(ctx *YakuContext) bool {
+	return ctx != nil && ctx.Winner != nil && ctx.Winner.FirstTurn
+}
+
+func isDealerWinner(ctx *YakuContext) bool {
+	return ctx.Situation != nil && ctx.Winner.SeatIndex == ctx.Situation.DealerIndex
 }
 
 // checkDaisushi check 大四喜
@@ -259,6 +558,21 @@ func checkDaisushi(ctx *YakuContext) bool {
 	return counts[East] >= 3 && counts[South] >= 3 && counts[West] >= 3 && counts[North] >= 3
 }
 
+// checkKokushi 国士无双（非十三面听，十三面由 checkKokushi13 计双倍）
+func checkKokushi(ctx *YakuContext) bool {
+	if ctx == nil || ctx.Winner == nil || len(ctx.Winner.Melds) != 0 {
+		return false
+	}
+	var h Hand34
+	for _, t := range ctx.Winner.Tiles {
+		h[int(t.Type)]++
+	}
+	if ctx.Claim.HasLoser {
+		h[int(ctx.Claim.WinTile.Type)]++
+	}
+	return IsAgariKokushi(h) && !checkKokushi13(ctx)
+}
+
 // checkKokushi13 check 国士无双
 func checkKokushi13(ctx *YakuContext) bool {
 	if ctx == nil || ctx.Winner == nil {
@@ -298,68 +612,362 @@ func checkKokushi13(ctx *YakuContext) bool {
 	return true
 }
 
+// checkChuuren 九莲宝灯（非九面听，九面听由 checkJunseiChuuren 计双倍）
+func checkChuuren(ctx *YakuContext) bool {
+	c9, ok := chuurenCounts(ctx)
+	if !ok {
+		return false
+	}
+	for i := 0; i < 9; i++ {
+		if c9[i] < chuurenBase[i] {
+			return false
+		}
+	}
+	return !checkJunseiChuuren(ctx)
+}
+
 // checkJunseiChuuren check 纯正九莲宝灯
 func checkJunseiChuuren(ctx *YakuContext) bool {
-	if ctx == nil || ctx.Winner == nil {
+	c9, ok := chuurenCounts(ctx)
+	if !ok {
 		return false
 	}
-	if len(ctx.Winner.Melds) != 0 {
+	idx := numberIndex(ctx.Claim.WinTile.Type)
+	if idx < 0 {
 		return false
 	}
+	work := c9
+	work[idx]--
+	return work == chuurenBase
+}
+
+// chuurenBase 九莲宝灯的基本型 1112345678999
+var chuurenBase = [9]int{3, 1, 1, 1, 1, 1, 1, 1, 3}
+
+// chuurenCounts 门清 14 张全部为和牌张同一花色的数牌时，返回 1-9 各自的张数
+func chuurenCounts(ctx *YakuContext) ([9]int, bool) {
+	var c9 [9]int
+	if ctx == nil || ctx.Winner == nil {
+		return c9, false
+	}
+	if len(ctx.Winner.Melds) != 0 {
+		return c9, false
+	}
 	counts, total := buildTileTypeCountsForClaim(ctx)
 	if total != 14 {
-		return false
+		return c9, false
 	}
 	if isHonor(ctx.Claim.WinTile.Type) {
+		return c9, false
+	}
+
+	suit := suitOfTileType(ctx.Claim.WinTile.Type)
+	if suit < 0 {
+		return c9, false
+	}
+	for tt, c := range counts {
+		if c == 0 {
+			continue
+		}
+		if isHonor(tt) || suitOfTileType(tt) != suit {
+			return c9, false
+		}
+		n := numberIndex(tt)
+		if n < 0 {
+			return c9, false
+		}
+		c9[n] = c
+	}
+	return c9, true
+}
+
+// checkPinfu check 平和：门清、4 顺子、非役牌雀头、两面听
+func checkPinfu(ctx *YakuContext, div *HandDivision) bool {
+	if ctx == nil || ctx.Winner == nil || div == nil || div.Chiitoi {
+		return false
+	}
+	if len(ctx.Winner.Melds) != 0 {
 		return false
 	}
+	if div.Wait != WaitRyanmen {
+		return false
+	}
+	for _, m := range div.Mentsu {
+		if m.Kind != MentsuShuntsu {
+			return false
+		}
+	}
+	return pairFu(ctx, div.Pair) == 0
+}
 
+// countPeikou 门清手牌中相同顺子的组数，1 为一杯口，2 为二杯口（二杯口不再复合一杯口）
+func countPeikou(ctx *YakuContext) int {
+	if ctx == nil || ctx.Division == nil || !isMenzen(ctx.Winner) {
+		return 0
+	}
+	seen := make(map[TileType]int, 4)
+	for _, m := range ctx.Division.Mentsu {
+		if m.Kind == MentsuShuntsu && !m.Open {
+			seen[m.First]++
+		}
+	}
+	n := 0
+	for _, c := range seen {
+		n += c / 2
+	}
+	return n
+}
+
+// checkFlush 手牌与副露只含一种花色的数牌（可带字牌），返回是否成立及是否含字牌
+func checkFlush(ctx *YakuContext) (bool, bool) {
+	if ctx == nil || ctx.Winner == nil {
+		return false, false
+	}
+	counts, _ := buildTileTypeCountsForClaim(ctx)
 	suit := -1
-	var c9 [9]int
+	hasHonor := false
 	for tt, c := range counts {
 		if c == 0 {
 			continue
 		}
 		if isHonor(tt) {
-			return false
+			hasHonor = true
+			continue
 		}
 		s := suitOfTileType(tt)
-		if s < 0 {
-			return false
-		}
 		if suit == -1 {
 			suit = s
 		} else if suit != s {
+			return false, false
+		}
+	}
+	return suit != -1, hasHonor
+}
+
+// kuisagari 食下役：副露后番数 -1
+func kuisagari(ctx *YakuContext, han int) int {
+	if isMenzen(ctx.Winner) {
+		return han
+	}
+	return han - 1
+}
+
+// shuntsuSet 当前拆解中所有顺子（含吃）的起始牌集合
+func shuntsuSet(div *HandDivision) map[TileType]bool {
+	set := make(map[TileType]bool, 4)
+	if div == nil {
+		return set
+	}
+	for _, m := range div.Mentsu {
+		if m.Kind == MentsuShuntsu {
+			set[m.First] = true
+		}
+	}
+	return set
+}
+
+// checkSanshoku check 三色同顺：万、筒、索都有相同数字的顺子
+func checkSanshoku(ctx *YakuContext) bool {
+	if ctx == nil || ctx.Division == nil {
+		return false
+	}
+	set := shuntsuSet(ctx.Division)
+	for n := TileType(0); n <= 6; n++ {
+		if set[Man1+n] && set[Pin1+n] && set[So1+n] {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIttsu check 一气通贯：同一花色的 123、456、789
+func checkIttsu(ctx *YakuContext) bool {
+	if ctx == nil || ctx.Division == nil {
+		return false
+	}
+	set := shuntsuSet(ctx.Division)
+	for _, base := range []TileType{Man1, Pin1, So1} {
+		if set[base] && set[base+3] && set[base+6] {
+			return true
+		}
+	}
+	return false
+}
+
+// checkToitoi check 对对和：4 组刻子/杠子 + 雀头
+func checkToitoi(ctx *YakuContext) bool {
+	if ctx == nil || ctx.Division == nil || ctx.Division.Chiitoi {
+		return false
+	}
+	for _, m := range ctx.Division.Mentsu {
+		if m.Kind == MentsuShuntsu {
 			return false
 		}
-		n := numberIndex(tt)
-		if n < 0 {
+	}
+	return true
+}
+
+// countAnkou 暗刻数（含暗杠），荣和补成的刻子在拆解时已标记为明刻
+func countAnkou(ctx *YakuContext) int {
+	if ctx == nil || ctx.Division == nil {
+		return 0
+	}
+	n := 0
+	for _, m := range ctx.Division.Mentsu {
+		if m.Kind != MentsuShuntsu && !m.Open {
+			n++
+		}
+	}
+	return n
+}
+
+// checkTerminalSets 混全带幺九/纯全带幺九：每组面子和雀头都含幺九牌，且至少有一组顺子
+// 返回是否成立及是否含字牌（含字牌为混全，否则为纯全）
+func checkTerminalSets(ctx *YakuContext) (bool, bool) {
+	if ctx == nil || ctx.Division == nil || ctx.Division.Chiitoi {
+		return false, false
+	}
+	div := ctx.Division
+	if !isYaochuTileType(div.Pair) {
+		return false, false
+	}
+	hasHonor := isHonor(div.Pair)
+	hasShuntsu := false
+	for _, m := range div.Mentsu {
+		if m.Kind == MentsuShuntsu {
+			hasShuntsu = true
+			n := numberIndex(m.First)
+			if n != 0 && n != 6 {
+				return false, false
+			}
+			continue
+		}
+		if !isYaochuTileType(m.First) {
+			return false, false
+		}
+		if isHonor(m.First) {
+			hasHonor = true
+		}
+	}
+	return hasShuntsu, hasHonor
+}
+
+// checkHonroutou check 混老头：只由幺九牌和字牌组成（对对和或七对子型），全字牌/全老头属于役满不在此计
+func checkHonroutou(ctx *YakuContext) bool {
+	if ctx == nil || ctx.Division == nil {
+		return false
+	}
+	counts, _ := buildTileTypeCountsForClaim(ctx)
+	hasHonor, hasTerminal := false, false
+	for tt, c := range counts {
+		if c == 0 {
+			continue
+		}
+		if !isYaochuTileType(tt) {
 			return false
 		}
-		c9[n] = c
+		if isHonor(tt) {
+			hasHonor = true
+		} else {
+			hasTerminal = true
+		}
+	}
+	return hasHonor && hasTerminal
+}
+
+// checkChinroutou check 清老头：只由数牌 1、9 组成
+func checkChinroutou(ctx *YakuContext) bool {
+	if ctx == nil || ctx.Winner == nil {
+		return false
+	}
+	counts, total := buildTileTypeCountsForClaim(ctx)
+	if total < 14 {
+		return false
+	}
+	for tt, c := range counts {
+		if c == 0 {
+			continue
+		}
+		if isHonor(tt) || !isYaochuTileType(tt) {
+			return false
+		}
+	}
+	return true
+}
+
+// countYakuhaiHan 役牌番数：三元牌、场风、自风的刻子/杠子各 1 番，连风牌计 2 番
+func countYakuhaiHan(ctx *YakuContext) int {
+	if ctx == nil || ctx.Winner == nil || ctx.Situation == nil {
+		return 0
+	}
+	counts, _ := buildTileTypeCountsForClaim(ctx)
+	han := 0
+	for _, tt := range []TileType{White, Green, Red} {
+		if counts[tt] >= 3 {
+			han++
+		}
+	}
+	if counts[windTileType(ctx.Situation.RoundWind)] >= 3 {
+		han++
+	}
+	if counts[windTileType(seatWindOf(ctx.Winner.SeatIndex, ctx.Situation.DealerIndex, ctx.Situation.Seats()))] >= 3 {
+		han++
+	}
+	return han
+}
+
+// seatWindOf 根据庄家座位推算自风，三麻没有北家
+func seatWindOf(seatIndex, dealerIndex, seats int) Wind {
+	return Wind((seatIndex - dealerIndex + seats) % seats)
+}
+
+// windTileType 风位对应的字牌
+func windTileType(w Wind) TileType {
+	return East + TileType(w)
+}
+
+// checkTanyao check 断幺九，默认开启食断，副露中含幺九牌同样不成立
+func checkTanyao(ctx *YakuContext) bool {
+	if ctx == nil || ctx.Winner == nil {
+		return false
 	}
-	if suit == -1 {
+	if !UseKuitan && !isMenzen(ctx.Winner) {
 		return false
 	}
-	if suitOfTileType(ctx.Claim.WinTile.Type) != suit {
+	counts, total := buildTileTypeCountsForClaim(ctx)
+	if total < 14 {
 		return false
 	}
+	for tt, c := range counts {
+		if c > 0 && isYaochuTileType(tt) {
+			return false
+		}
+	}
+	return true
+}
 
-	base := [9]int{3, 1, 1, 1, 1, 1, 1, 1, 3}
-	idx := numberIndex(ctx.Claim.WinTile.Type)
-	if idx < 0 {
+// isMenzen 门清判定，暗杠不破坏门清
+func isMenzen(p *PlayerImage) bool {
+	if p == nil {
 		return false
 	}
-	work := c9
-	work[idx]--
-	for i := 0; i < 9; i++ {
-		if work[i] != base[i] {
+	for _, m := range p.Melds {
+		if m.Type != "Ankan" {
 			return false
 		}
 	}
 	return true
 }
 
+// isYaochuTileType 幺九牌（1、9、字牌）
+func isYaochuTileType(tt TileType) bool {
+	if isHonor(tt) {
+		return true
+	}
+	n := numberIndex(tt)
+	return n == 0 || n == 8
+}
+
 func buildTileTypeCountsForClaim(ctx *YakuContext) (map[TileType]int, int) {
 	counts := make(map[TileType]int, 34)
 	total := 0
@@ -0,0 +1,584 @@
+package mahjong
+
+import (
+	"game/runtime/share"
+	"slices"
+	"testing"
+)
+
+func TestTanyao(t *testing.T) {
+	tests := []struct {
+		name  string
+		hand  string
+		melds []string // 从 2 号座位吃的顺子
+		win   string
+		tsumo bool
+		want  bool
+	}{
+		{name: "closed", hand: "234m567p345s6688s", win: "8s", want: true},
+		{name: "closed tsumo", hand: "234m567p345s6688s", win: "6s", tsumo: true, want: true},
+		{name: "open 234 chi", hand: "567p345s6688s", melds: []string{"234m"}, win: "8s", want: true},
+		{name: "open 123 chi", hand: "567p345s6688s", melds: []string{"123m"}, win: "8s", want: false},
+		{name: "lone east", hand: "234m567p345s666s1z", win: "1z", want: false},
+		{name: "terminal pair", hand: "234m567p345s678s9p", win: "9p", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			var melds []Meld
+			for _, m := range tt.melds {
+				melds = append(melds, meld(t, "Chi", m, 2))
+			}
+			setHand(t, eg, 3, tt.hand, melds...)
+			var yakus []Yaku
+			if tt.tsumo {
+				_, _, _, yakus = evalTsumo(t, eg, 3, tt.win)
+			} else {
+				_, _, _, yakus = evalRon(t, eg, 3, 2, tt.win)
+			}
+			if got := hasYaku(yakus, YakuTanyao); got != tt.want {
+				t.Fatalf("断幺九 = %v, want %v (yakus=%v)", got, tt.want, yakus)
+			}
+		})
+	}
+}
+
+// 东场、0 号座位为庄家（东家），其余役都不成立的手牌只计役牌番数
+func TestYakuhai(t *testing.T) {
+	tests := []struct {
+		name     string
+		seat     int
+		meldType string
+		tiles    string
+		want     int
+	}{
+		{name: "green pon", seat: 1, meldType: "Peng", tiles: "666z", want: 1},
+		{name: "east pon by dealer", seat: 0, meldType: "Peng", tiles: "111z", want: 2},
+		{name: "east pon by non-dealer", seat: 2, meldType: "Peng", tiles: "111z", want: 1},
+		{name: "red ankan", seat: 1, meldType: "Ankan", tiles: "7777z", want: 1},
+		{name: "south kan by west seat", seat: 2, meldType: "Gang", tiles: "2222z", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			loser := (tt.seat + 3) % 4
+			setHand(t, eg, tt.seat, "567p345s6688s", meld(t, tt.meldType, tt.tiles, loser))
+			han, _, _, yakus := evalRon(t, eg, tt.seat, loser, "8s")
+			if han != tt.want || hasYaku(yakus, YakuYakuhai) != (tt.want > 0) {
+				t.Fatalf("役牌 %d 番, want %d (yakus=%v)", han, tt.want, yakus)
+			}
+		})
+	}
+}
+
+// 暗刻的役牌同样计番
+func TestYakuhaiConcealedTriplet(t *testing.T) {
+	eg := newTestEngine(t, noAkaRules())
+	setHand(t, eg, 1, "555z567p345s6688s")
+	han, _, _, yakus := evalRon(t, eg, 1, 0, "8s")
+	if han != 1 || !hasYaku(yakus, YakuYakuhai) {
+		t.Fatalf("白暗刻应计 1 番, got %d (yakus=%v)", han, yakus)
+	}
+}
+
+// 1 号座位（南家）荣和，东场
+func TestPinfu(t *testing.T) {
+	tests := []struct {
+		name string
+		hand string
+		win  string
+		want bool
+	}{
+		{name: "ryanmen", hand: "234m567m345p78s88p", win: "6s", want: true},
+		{name: "guest wind pair", hand: "234m567m345p78s44z", win: "9s", want: true},
+		{name: "kanchan", hand: "234m567m345p79s88p", win: "8s", want: false},
+		{name: "round wind pair", hand: "234m567m345p78s11z", win: "6s", want: false},
+		{name: "seat wind pair", hand: "234m567m345p78s22z", win: "6s", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			setHand(t, eg, 1, tt.hand)
+			_, _, fu, yakus := evalRon(t, eg, 1, 0, tt.win)
+			if got := hasYaku(yakus, YakuPinfu); got != tt.want {
+				t.Fatalf("平和 = %v, want %v (yakus=%v)", got, tt.want, yakus)
+			}
+			if tt.want && fu != 30 {
+				t.Fatalf("平和荣和应为 30 符, got %d", fu)
+			}
+		})
+	}
+}
+
+func TestPeikou(t *testing.T) {
+	tests := []struct {
+		name  string
+		hand  string
+		chi   string // 从 0 号座位吃的顺子，为空表示门清
+		win   string
+		want  []Yaku
+		never []Yaku
+	}{
+		{name: "iipeikou", hand: "223344m567p678s9p", win: "9p", want: []Yaku{YakuIppeiko}, never: []Yaku{YakuRyanpeiko}},
+		{name: "ryanpeikou", hand: "223344m556677p8s", win: "8s", want: []Yaku{YakuRyanpeiko}, never: []Yaku{YakuIppeiko, YakuChiitoi}},
+		{name: "open", hand: "234m567p678s9p", chi: "234m", win: "9p", never: []Yaku{YakuIppeiko}},
+		{name: "chiitoi shape", hand: "1122m3344p5566s7z", win: "7z", want: []Yaku{YakuChiitoi}, never: []Yaku{YakuRyanpeiko, YakuIppeiko}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			var melds []Meld
+			if tt.chi != "" {
+				melds = append(melds, meld(t, "Chi", tt.chi, 0))
+			}
+			setHand(t, eg, 1, tt.hand, melds...)
+			_, _, _, yakus := evalRon(t, eg, 1, 0, tt.win)
+			assertYakus(t, yakus, tt.want, tt.never)
+		})
+	}
+}
+
+func TestChiitoi(t *testing.T) {
+	eg := newTestEngine(t, noAkaRules())
+	setHand(t, eg, 1, "1133m5577p9s1177z")
+	han, _, fu, yakus := evalRon(t, eg, 1, 0, "9s")
+	if !hasYaku(yakus, YakuChiitoi) || han != 2 || fu != 25 {
+		t.Fatalf("七对子应为 2 番 25 符, got %d 番 %d 符 (yakus=%v)", han, fu, yakus)
+	}
+
+	// 四张相同的牌不能当作两个对子
+	setHand(t, eg, 1, "1111m3344p5566s7z")
+	if eg.canHu(1, parseTile(t, "7z")) {
+		t.Fatalf("含四张相同牌的手牌不是七对子")
+	}
+	if _, _, _, yakus := evalRon(t, eg, 1, 0, "7z"); hasYaku(yakus, YakuChiitoi) {
+		t.Fatalf("含四张相同牌的手牌不是七对子 (yakus=%v)", yakus)
+	}
+}
+
+// 手牌除清一色/混一色外没有其他役，总番数即一色的番数
+func TestFlush(t *testing.T) {
+	tests := []struct {
+		name    string
+		hand    string
+		pon     string // 从 0 号座位碰的牌，为空表示门清
+		want    []Yaku
+		wantHan int
+	}{
+		{name: "closed chinitsu", hand: "123p345p678p99p22p", want: []Yaku{YakuChinitsu}, wantHan: 6},
+		{name: "open honitsu", hand: "123p345p99p22p", pon: "333z", want: []Yaku{YakuHonitsu}, wantHan: 2},
+		{name: "mixed suits", hand: "123p345p678p99m22p", wantHan: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			var melds []Meld
+			if tt.pon != "" {
+				melds = append(melds, meld(t, "Peng", tt.pon, 0))
+			}
+			setHand(t, eg, 1, tt.hand, melds...)
+			han, _, _, yakus := evalRon(t, eg, 1, 0, "2p")
+			if han != tt.wantHan {
+				t.Fatalf("番数 = %d, want %d (yakus=%v)", han, tt.wantHan, yakus)
+			}
+			for _, y := range []Yaku{YakuHonitsu, YakuChinitsu} {
+				if hasYaku(yakus, y) != slices.Contains(tt.want, y) {
+					t.Fatalf("yakus=%v, want %v", yakus, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// 副露的顺子同样计入三色同顺与一气通贯，副露后各减 1 番
+func TestSanshokuIttsu(t *testing.T) {
+	tests := []struct {
+		name    string
+		hand    string
+		chis    []string // 从 0 号座位吃的顺子
+		win     string
+		want    Yaku
+		wantHan int
+	}{
+		{name: "sanshoku across melds", hand: "234m678p9s", chis: []string{"234p", "234s"}, win: "9s", want: YakuSanshoku, wantHan: 1},
+		{name: "closed sanshoku", hand: "234m234p234s678p9s", win: "9s", want: YakuSanshoku, wantHan: 2},
+		{name: "ittsu split with chi", hand: "123m789m345p8s", chis: []string{"456m"}, win: "8s", want: YakuIttsu, wantHan: 1},
+		{name: "broken sanshoku", hand: "234m678p9s", chis: []string{"234p", "345s"}, win: "9s", wantHan: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			var melds []Meld
+			for _, c := range tt.chis {
+				melds = append(melds, meld(t, "Chi", c, 0))
+			}
+			setHand(t, eg, 1, tt.hand, melds...)
+			han, _, _, yakus := evalRon(t, eg, 1, 0, tt.win)
+			if han != tt.wantHan || (tt.wantHan > 0 && !hasYaku(yakus, tt.want)) {
+				t.Fatalf("got %d 番 (yakus=%v), want %d 番 %v", han, yakus, tt.wantHan, tt.want)
+			}
+		})
+	}
+}
+
+// 双碰听牌时荣和补成的刻子算明刻，自摸才成三暗刻
+func TestToitoiSanankou(t *testing.T) {
+	tests := []struct {
+		name  string
+		hand  string
+		pon   string
+		tsumo bool
+		want  []Yaku
+		never []Yaku
+	}{
+		{name: "tsumo sanankou", hand: "222m555p345s99p88s", tsumo: true, want: []Yaku{YakuSananko}, never: []Yaku{YakuToitoi}},
+		{name: "ron breaks sanankou", hand: "222m555p345s99p88s", never: []Yaku{YakuSananko, YakuToitoi}},
+		{name: "open toitoi ron", hand: "222m555p99p88s", pon: "777s", want: []Yaku{YakuToitoi}, never: []Yaku{YakuSananko}},
+		{name: "open toitoi tsumo", hand: "222m555p99p88s", pon: "777s", tsumo: true, want: []Yaku{YakuToitoi, YakuSananko}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			var melds []Meld
+			if tt.pon != "" {
+				melds = append(melds, meld(t, "Peng", tt.pon, 0))
+			}
+			setHand(t, eg, 1, tt.hand, melds...)
+			var yakus []Yaku
+			if tt.tsumo {
+				_, _, _, yakus = evalTsumo(t, eg, 1, "8s")
+			} else {
+				_, _, _, yakus = evalRon(t, eg, 1, 0, "8s")
+			}
+			assertYakus(t, yakus, tt.want, tt.never)
+		})
+	}
+}
+
+func TestTerminalYaku(t *testing.T) {
+	tests := []struct {
+		name    string
+		hand    string
+		pon     string // 从 0 号座位碰的牌，为空表示门清
+		win     string
+		wantHan int
+		wantYm  int
+		want    []Yaku
+		never   []Yaku
+	}{
+		{name: "chanta", hand: "123m789p789s999m1z", win: "1z", wantHan: 2,
+			want: []Yaku{YakuChanta}, never: []Yaku{YakuJunchan}},
+		{name: "junchan", hand: "123m789p789s999m1s", win: "1s", wantHan: 3,
+			want: []Yaku{YakuJunchan}, never: []Yaku{YakuChanta}},
+		// 对对和 2 + 混老头 2 + 白 1
+		{name: "honroutou toitoi", hand: "999p999s22z55z", pon: "111m", win: "5z", wantHan: 5,
+			want: []Yaku{YakuHonroto, YakuToitoi, YakuYakuhai}, never: []Yaku{YakuChanta}},
+		// 七对子 2 + 混老头 2
+		{name: "honroutou chiitoi", hand: "1199m1199p1199s1z", win: "1z", wantHan: 4,
+			want: []Yaku{YakuHonroto, YakuChiitoi}},
+		{name: "chinroutou", hand: "999p111s99s99m", pon: "111m", win: "9s", wantYm: 1,
+			want: []Yaku{YakuChinroto}, never: []Yaku{YakuHonroto}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			var melds []Meld
+			if tt.pon != "" {
+				melds = append(melds, meld(t, "Peng", tt.pon, 0))
+			}
+			setHand(t, eg, 1, tt.hand, melds...)
+			han, ym, _, yakus := evalRon(t, eg, 1, 0, tt.win)
+			if ym != tt.wantYm || (ym == 0 && han != tt.wantHan) {
+				t.Fatalf("got %d 番 役满×%d, want %d 番 役满×%d (yakus=%v)", han, ym, tt.wantHan, tt.wantYm, yakus)
+			}
+			assertYakus(t, yakus, tt.want, tt.never)
+		})
+	}
+}
+
+func TestSuuankou(t *testing.T) {
+	tests := []struct {
+		name   string
+		hand   string
+		win    string
+		tsumo  bool
+		wantYm int
+		want   []Yaku
+		never  []Yaku
+	}{
+		{name: "shanpon tsumo", hand: "222m555p777s99p88s", win: "8s", tsumo: true, wantYm: 1,
+			want: []Yaku{YakuSuuankou}, never: []Yaku{YakuSuuankouTanki}},
+		{name: "shanpon ron", hand: "222m555p777s99p88s", win: "8s",
+			want: []Yaku{YakuToitoi, YakuSananko}, never: []Yaku{YakuSuuankou, YakuSuuankouTanki}},
+		{name: "tanki ron", hand: "222m555p777s888s9p", win: "9p", wantYm: 2,
+			want: []Yaku{YakuSuuankouTanki}, never: []Yaku{YakuSuuankou}},
+		{name: "tanki tsumo", hand: "222m555p777s888s9p", win: "9p", tsumo: true, wantYm: 2,
+			want: []Yaku{YakuSuuankouTanki}, never: []Yaku{YakuSuuankou}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			setHand(t, eg, 1, tt.hand)
+			var ym int
+			var yakus []Yaku
+			if tt.tsumo {
+				_, ym, _, yakus = evalTsumo(t, eg, 1, tt.win)
+			} else {
+				_, ym, _, yakus = evalRon(t, eg, 1, 0, tt.win)
+			}
+			if ym != tt.wantYm {
+				t.Fatalf("役满×%d, want ×%d (yakus=%v)", ym, tt.wantYm, yakus)
+			}
+			assertYakus(t, yakus, tt.want, tt.never)
+		})
+	}
+}
+
+func TestHonorYakuman(t *testing.T) {
+	tests := []struct {
+		name    string
+		hand    string
+		pon     string // 从 0 号座位碰的牌
+		win     string
+		wantHan int
+		wantYm  int
+		want    []Yaku
+		never   []Yaku
+	}{
+		{name: "daisangen with open pon", hand: "666z777z234m9p", pon: "555z", win: "9p", wantYm: 1,
+			want: []Yaku{YakuDaisangen}},
+		// 小三元 2 + 白、发各 1
+		{name: "shousangen with yakuhai", hand: "555z666z77z234m56p", win: "7p", wantHan: 4,
+			want: []Yaku{YakuShousangen, YakuYakuhai}, never: []Yaku{YakuDaisangen}},
+		{name: "shousuushii", hand: "222z333z44z23m", pon: "111z", win: "4m", wantYm: 1,
+			want: []Yaku{YakuShousushi}, never: []Yaku{YakuDaisushi}},
+		{name: "daisuushii", hand: "222z333z444z1m", pon: "111z", win: "1m", wantYm: 2,
+			want: []Yaku{YakuDaisushi}, never: []Yaku{YakuShousushi}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			var melds []Meld
+			if tt.pon != "" {
+				melds = append(melds, meld(t, "Peng", tt.pon, 0))
+			}
+			setHand(t, eg, 1, tt.hand, melds...)
+			han, ym, _, yakus := evalRon(t, eg, 1, 0, tt.win)
+			if ym != tt.wantYm || (ym == 0 && han != tt.wantHan) {
+				t.Fatalf("got %d 番 役满×%d, want %d 番 役满×%d (yakus=%v)", han, ym, tt.wantHan, tt.wantYm, yakus)
+			}
+			assertYakus(t, yakus, tt.want, tt.never)
+		})
+	}
+}
+
+func TestSingleColorYakuman(t *testing.T) {
+	tests := []struct {
+		name   string
+		hand   string
+		pon    string // 从 0 号座位碰的牌，为空表示门清
+		win    string
+		want   Yaku
+		broken bool // 不成立
+	}{
+		{name: "ryuuiisou with hatsu", hand: "234s234s666s88s66z", win: "8s", want: YakuRyuuiisou},
+		{name: "ryuuiisou without hatsu", hand: "234s234s666s888s2s", win: "2s", want: YakuRyuuiisou},
+		{name: "ryuuiisou with 5s", hand: "345s234s666s888s2s", win: "2s", want: YakuRyuuiisou, broken: true},
+		{name: "tsuuiisou", hand: "222z333z555z6z", pon: "111z", win: "6z", want: YakuTsuuiisou},
+		{name: "tsuuiisou with terminal", hand: "222z333z555z9m", pon: "111z", win: "9m", want: YakuTsuuiisou, broken: true},
+		{name: "chinroutou", hand: "111m999m111p99p99s", win: "9s", want: YakuChinroto},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			var melds []Meld
+			if tt.pon != "" {
+				melds = append(melds, meld(t, "Peng", tt.pon, 0))
+			}
+			setHand(t, eg, 1, tt.hand, melds...)
+			_, ym, _, yakus := evalRon(t, eg, 1, 0, tt.win)
+			if hasYaku(yakus, tt.want) == tt.broken || (!tt.broken && ym != 1) {
+				t.Fatalf("役满×%d (yakus=%v), want %v 成立=%v", ym, yakus, tt.want, !tt.broken)
+			}
+		})
+	}
+}
+
+// 和牌前已是 1112345678999 的九面听为纯正九莲宝灯（双倍），其余九莲宝灯为单倍役满
+func TestChuuren(t *testing.T) {
+	tests := []struct {
+		name   string
+		hand   string
+		win    string
+		wantYm int
+		want   Yaku
+		never  Yaku
+	}{
+		{name: "extra 8m", hand: "1112345678899m", win: "9m", wantYm: 1, want: YakuChuuren, never: YakuJunseiChuuren},
+		{name: "completed by 1m", hand: "1123456789999m", win: "1m", wantYm: 1, want: YakuChuuren, never: YakuJunseiChuuren},
+		{name: "pure nine-sided wait", hand: "1112345678999p", win: "5p", wantYm: 2, want: YakuJunseiChuuren, never: YakuChuuren},
+		{name: "pure on terminal", hand: "1112345678999p", win: "9p", wantYm: 2, want: YakuJunseiChuuren, never: YakuChuuren},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eg := newTestEngine(t, noAkaRules())
+			setHand(t, eg, 1, tt.hand)
+			_, ym, _, yakus := evalRon(t, eg, 1, 0, tt.win)
+			if ym != tt.wantYm {
+				t.Fatalf("役满×%d, want ×%d (yakus=%v)", ym, tt.wantYm, yakus)
+			}
+			assertYakus(t, yakus, []Yaku{tt.want}, []Yaku{tt.never})
+		})
+	}
+}
+
+// tsumoWithHand 保留座位的巡目状态，把手牌换成 hand（最后一张为摸到的牌）后评估自摸
+func tsumoWithHand(t *testing.T, eg *RiichiMahjong4p, seat int, hand string) []Yaku {
+	t.Helper()
+	p := eg.Players[seat]
+	p.Tiles = parseTiles(t, hand)
+	win := p.Tiles[len(p.Tiles)-1]
+	p.NewestTile = &win
+	_, _, _, yakus, _ := eg.evalClaimYakuman(HuClaim{WinnerSeat: seat, WinTile: win}, RoundEndTsumo)
+	return yakus
+}
+
+func TestFirstTurnYakuman(t *testing.T) {
+	t.Run("tenhou", func(t *testing.T) {
+		eg := newTestEngine(t, noAkaRules())
+		yakus := tsumoWithHand(t, eg, 0, "234m567m345p678s88p")
+		assertYakus(t, yakus, []Yaku{YakuTenhou}, []Yaku{YakuChiihou})
+	})
+
+	t.Run("chiihou", func(t *testing.T) {
+		eg := setupFuritenTable(t, false)
+		eg.Players[1].FirstTurn = true
+		dropTile(t, eg, 0, Tile{Type: So4, ID: 1})
+		passAll(eg)
+		yakus := tsumoWithHand(t, eg, 1, "234m567m345p678s88p")
+		assertYakus(t, yakus, []Yaku{YakuChiihou}, []Yaku{YakuTenhou})
+	})
+
+	// 庄家打出的牌被 2 号座位碰走，1 号座位的第一巡被打断
+	t.Run("chiihou broken by pon", func(t *testing.T) {
+		eg := setupFuritenTable(t, false)
+		eg.Players[1].FirstTurn = true
+		setHand(t, eg, 2, "13579m1357p44s24z")
+		dropTile(t, eg, 0, Tile{Type: So4, ID: 1})
+		if !hasOperation(eg.Reactions[2], "PENG") {
+			t.Fatalf("2 号座位应能碰 4s")
+		}
+		eg.handlePengEvent(&share.PengTileEvent{
+			GameMessageEvent: eg.replayUser(2),
+			Tiles:            toShareTiles(parseTiles(t, "44s")),
+		})
+		if len(eg.Players[2].Melds) != 1 {
+			t.Fatalf("碰牌没有执行")
+		}
+		yakus := tsumoWithHand(t, eg, 1, "234m567m345p678s88p")
+		if hasYaku(yakus, YakuChiihou) {
+			t.Fatalf("被鸣牌打断后不应计地和 (yakus=%v)", yakus)
+		}
+	})
+}
+
+// replaceDraw 把座位本巡摸到的牌换成 tile
+func replaceDraw(t *testing.T, p *PlayerImage, tile string) {
+	t.Helper()
+	if p.NewestTile == nil || !p.RemoveTile(*p.NewestTile) {
+		t.Fatalf("座位 %d 没有摸到的牌", p.SeatIndex)
+	}
+	drawTsumo(p, parseTile(t, tile))
+}
+
+// 庄家立直，其他三家各打出一张 9m 后庄家自摸
+func TestIppatsu(t *testing.T) {
+	for _, pon := range []bool{false, true} {
+		name := "tsumo after one go-around"
+		if pon {
+			name = "broken by pon"
+		}
+		t.Run(name, func(t *testing.T) {
+			eg := setupFuritenTable(t, false)
+			setHand(t, eg, 0, "234m567m345p78s88p1z")
+			setHand(t, eg, 2, "13579m13579p246z")
+			if pon {
+				setHand(t, eg, 3, "1357m99m13579p24z")
+			}
+			eg.handleRiichiEvent(&share.RiichiEvent{GameMessageEvent: eg.replayUser(0)})
+			dropTile(t, eg, 0, Tile{Type: East, ID: 1})
+			if !eg.Players[0].IsRiichi || !eg.Players[0].IppatsuEligible {
+				t.Fatalf("立直宣言失败")
+			}
+			passAll(eg)
+
+			dropTile(t, eg, 1, Tile{Type: Man9, ID: 1})
+			if pon {
+				eg.handlePengEvent(&share.PengTileEvent{
+					GameMessageEvent: eg.replayUser(3),
+					Tiles:            toShareTiles(parseTiles(t, "99m")),
+				})
+				dropTile(t, eg, 3, Tile{Type: Man1, ID: 1})
+			} else {
+				passAll(eg)
+				dropTile(t, eg, 2, Tile{Type: Man9, ID: 1})
+				passAll(eg)
+				dropTile(t, eg, 3, Tile{Type: Man9, ID: 1})
+			}
+			passAll(eg)
+
+			if eg.TurnManager.GetCurrentPlayer() != 0 {
+				t.Fatalf("应轮到庄家摸牌")
+			}
+			replaceDraw(t, eg.Players[0], "6s")
+			_, _, _, yakus, _ := eg.evalClaimYakuman(HuClaim{WinnerSeat: 0, WinTile: *eg.Players[0].NewestTile}, RoundEndTsumo)
+			if hasYaku(yakus, YakuIppatsu) == pon {
+				t.Fatalf("一发 = %v, 碰牌打断=%v (yakus=%v)", !pon, pon, yakus)
+			}
+			assertYakus(t, yakus, []Yaku{YakuRiichi, YakuTsumo}, nil)
+		})
+	}
+}
+
+func TestDoubleRiichi(t *testing.T) {
+	t.Run("first discard", func(t *testing.T) {
+		eg := setupFuritenTable(t, false)
+		setHand(t, eg, 0, "234m567m345p78s88p1z").FirstTurn = true
+		eg.handleRiichiEvent(&share.RiichiEvent{GameMessageEvent: eg.replayUser(0)})
+		dropTile(t, eg, 0, Tile{Type: East, ID: 1})
+		if p := eg.Players[0]; !p.IsRiichi || !p.DoubleRiichi {
+			t.Fatalf("第一巡宣言立直应为两立直")
+		}
+		_, _, _, yakus := evalRon(t, eg, 0, 1, "6s")
+		assertYakus(t, yakus, []Yaku{YakuDoubleRiichi}, []Yaku{YakuRiichi})
+	})
+
+	// 庄家打出的 9m 被 2 号座位碰掉，3 号座位的第一巡立直只是普通立直
+	t.Run("after pon", func(t *testing.T) {
+		eg := setupFuritenTable(t, false)
+		setHand(t, eg, 2, "1357m99m13579p24z")
+		setHand(t, eg, 3, "234m567m345p78s88p").FirstTurn = true
+		dropTile(t, eg, 0, Tile{Type: Man9, ID: 1})
+		eg.handlePengEvent(&share.PengTileEvent{
+			GameMessageEvent: eg.replayUser(2),
+			Tiles:            toShareTiles(parseTiles(t, "99m")),
+		})
+		if eg.Players[3].FirstTurn {
+			t.Fatalf("鸣牌后所有人的第一巡都应结束")
+		}
+		dropTile(t, eg, 2, Tile{Type: Man1, ID: 1})
+		passAll(eg)
+
+		if eg.TurnManager.GetCurrentPlayer() != 3 {
+			t.Fatalf("应轮到 3 号座位摸牌")
+		}
+		replaceDraw(t, eg.Players[3], "1z")
+		eg.handleRiichiEvent(&share.RiichiEvent{GameMessageEvent: eg.replayUser(3)})
+		dropTile(t, eg, 3, Tile{Type: East, ID: 3})
+		if p := eg.Players[3]; !p.IsRiichi || p.DoubleRiichi {
+			t.Fatalf("鸣牌后的立直不是两立直")
+		}
+		_, _, _, yakus := evalRon(t, eg, 3, 0, "6s")
+		assertYakus(t, yakus, []Yaku{YakuRiichi}, []Yaku{YakuDoubleRiichi})
+	})
+}
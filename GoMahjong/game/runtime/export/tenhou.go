@@ -0,0 +1,330 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"game/domain/entity"
+	"game/domain/repository"
+	"game/infrastructure/utils"
+	"game/runtime/engines/mahjong"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TenhouLog 天凤牌谱 JSON（tenhou.net/6 的 log 格式），导出的是其中的一个子集：
+//   - 每局：[局序, 本场, 供托], 开局点数, 宝牌指示牌, 里宝牌指示牌, 然后每个座位依次是 配牌/摸牌/切牌, 最后是结果
+//   - 牌编码：11-19 万、21-29 筒、31-39 索、41-47 东南西北白发中，赤五为 51/52/53
+//   - 摸切写 60，立直宣言牌写 "r" + 牌（摸切立直为 "r60"）
+//   - 吃/碰/明杠写在摸牌列，暗杠/加杠写在切牌列，字母位置表示来源（上家在前、对家在第二张前、下家在最后一张前）
+//   - 加杠：碰的写法把 p 换成 k，并在被碰的牌后面插入加上的牌
+//   - 局记录没有供托数、杠宝牌和里宝牌，供托固定为 0，宝牌只有开局的指示牌，里宝牌为空
+//   - 多家和了时只给出一次合计的点数变化，后面依次是每家的和了信息
+type TenhouLog struct {
+	Title []string   `json:"title"`
+	Name  []string   `json:"name"`
+	Rule  TenhouRule `json:"rule"`
+	Log   [][]any    `json:"log"`
+}
+
+// TenhouRule 规则：aka 为是否使用赤牌，aka51-aka53 为万、筒、索赤 5 的张数
+type TenhouRule struct {
+	Disp  string `json:"disp"`
+	Aka   int    `json:"aka"`
+	Aka51 int    `json:"aka51"`
+	Aka52 int    `json:"aka52"`
+	Aka53 int    `json:"aka53"`
+}
+
+func tenhouRule(disp string, aka mahjong.AkaRules) TenhouRule {
+	rule := TenhouRule{Disp: disp, Aka51: aka.Man, Aka52: aka.Pin, Aka53: aka.So}
+	if aka.Man > 0 || aka.Pin > 0 || aka.So > 0 {
+		rule.Aka = 1
+	}
+	return rule
+}
+
+// TenhouExporter 从对局记录导出天凤格式牌谱
+type TenhouExporter struct {
+	repo repository.GameRecordRepository
+}
+
+func NewTenhouExporter(repo repository.GameRecordRepository) *TenhouExporter {
+	return &TenhouExporter{repo: repo}
+}
+
+// Export 读取对局记录及其所有局记录并导出
+func (e *TenhouExporter) Export(ctx context.Context, gameRecordID primitive.ObjectID) (*TenhouLog, error) {
+	record, err := e.repo.FindGameRecord(ctx, gameRecordID)
+	if err != nil {
+		return nil, err
+	}
+	rounds, err := e.repo.FindRoundRecords(ctx, gameRecordID)
+	if err != nil {
+		return nil, err
+	}
+	return ToTenhouLog(record, rounds)
+}
+
+// ToTenhouLog 把对局记录转换为天凤牌谱，rounds 会按局号、开始时间排序
+func ToTenhouLog(record *entity.GameRecord, rounds []*entity.RoundRecord) (*TenhouLog, error) {
+	if record == nil {
+		return nil, fmt.Errorf("对局记录为空")
+	}
+	seatCount := len(record.Players)
+	if seatCount != 3 && seatCount != 4 {
+		return nil, fmt.Errorf("不支持的人数: %d", seatCount)
+	}
+
+	names := make([]string, 4)
+	for _, p := range record.Players {
+		if p.SeatIndex >= 0 && p.SeatIndex < 4 {
+			names[p.SeatIndex] = p.UserID
+		}
+	}
+	aka := mahjong.RecordAkaRules(record)
+
+	sorted := make([]*entity.RoundRecord, len(rounds))
+	copy(sorted, rounds)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].RoundNumber != sorted[j].RoundNumber {
+			return sorted[i].RoundNumber < sorted[j].RoundNumber
+		}
+		return sorted[i].StartTime.Before(sorted[j].StartTime)
+	})
+
+	result := &TenhouLog{
+		Title: []string{record.RoomID, record.StartTime.Format("2006/01/02")},
+		Name:  names,
+		Rule:  tenhouRule(record.GameType, aka),
+		Log:   make([][]any, 0, len(sorted)),
+	}
+	for _, round := range sorted {
+		result.Log = append(result.Log, exportRound(round, seatCount, aka))
+	}
+	return result, nil
+}
+
+var roundWinds = map[string]int{"东": 0, "南": 1, "西": 2, "北": 3}
+
+// seatLog 单个座位一局内的 配牌/摸牌/切牌
+type seatLog struct {
+	hand     []int
+	takes    []any
+	discards []any
+	lastDraw any  // 最近一次摸到的牌（事件数据），鸣牌后为 nil（鸣牌后的切牌不算摸切）
+	riichi   bool // 已宣言立直，下一张切牌为宣言牌
+}
+
+func exportRound(round *entity.RoundRecord, seatCount int, aka mahjong.AkaRules) []any {
+	seats := make([]*seatLog, seatCount)
+	for i := range seats {
+		seats[i] = &seatLog{hand: []int{}, takes: []any{}, discards: []any{}}
+	}
+	var dora []int
+
+	for _, event := range round.Events {
+		seat := event.SeatIndex
+		if event.EventType != entity.EventTypeRoundStart && event.EventType != entity.EventTypeRoundEnd &&
+			(seat < 0 || seat >= seatCount) {
+			continue
+		}
+		switch event.EventType {
+		case entity.EventTypeRoundStart:
+			dora = tileCodes(event.Data["dora_indicators"], aka)
+			for i, hand := range utils.ToSlice(event.Data["hands"]) {
+				if i >= seatCount {
+					continue
+				}
+				// 庄家配牌时已摸了第 14 张（最后一张），天凤的配牌只有 13 张，第 14 张写在摸牌列
+				tiles := utils.ToSlice(hand)
+				if len(tiles)%3 == 2 {
+					first := tiles[len(tiles)-1]
+					tiles = tiles[:len(tiles)-1]
+					seats[i].takes = append(seats[i].takes, tileCode(first, aka))
+					seats[i].lastDraw = first
+				}
+				seats[i].hand = tileCodes(tiles, aka)
+			}
+		case entity.EventTypeDrawTile:
+			seats[seat].takes = append(seats[seat].takes, tileCode(event.Data["tile"], aka))
+			seats[seat].lastDraw = event.Data["tile"]
+		case entity.EventTypeDiscardTile:
+			s := seats[seat]
+			code := tileCode(event.Data["tile"], aka)
+			if sameTile(event.Data["tile"], s.lastDraw) {
+				code = 60
+			}
+			s.lastDraw = nil
+			if s.riichi {
+				s.riichi = false
+				s.discards = append(s.discards, "r"+strconv.Itoa(code))
+			} else {
+				s.discards = append(s.discards, code)
+			}
+		case entity.EventTypeRiichi:
+			seats[seat].riichi = true
+		case entity.EventTypeChi, entity.EventTypePeng, entity.EventTypeGang:
+			codes := tileCodes(event.Data["tiles"], aka)
+			from := utils.ToInt(event.Data["from_seat"])
+			letter := map[string]string{entity.EventTypeChi: "c", entity.EventTypePeng: "p", entity.EventTypeGang: "m"}[event.EventType]
+			seats[seat].takes = append(seats[seat].takes, callString(letter, relativeSeat(seat, from, seatCount), codes))
+			seats[seat].lastDraw = nil
+		case entity.EventTypeAnkan:
+			codes := tileCodes(event.Data["tiles"], aka)
+			if len(codes) == 4 {
+				seats[seat].discards = append(seats[seat].discards, joinCodes(codes[:3])+"a"+strconv.Itoa(codes[3]))
+			}
+			seats[seat].lastDraw = nil
+		case entity.EventTypeKakan:
+			codes := tileCodes(event.Data["tiles"], aka)
+			if len(codes) == 4 {
+				from := utils.ToInt(event.Data["from_seat"])
+				pon := callString("p", relativeSeat(seat, from, seatCount), codes[:3])
+				kakan := strings.Replace(pon, "p"+strconv.Itoa(codes[0]), "k"+strconv.Itoa(codes[0])+strconv.Itoa(codes[3]), 1)
+				seats[seat].discards = append(seats[seat].discards, kakan)
+			}
+			seats[seat].lastDraw = nil
+		}
+	}
+
+	startPoints := round.StartPoints(seatCount)
+	if startPoints == nil {
+		startPoints = make([]int, seatCount)
+	}
+
+	kyoku := roundWinds[round.RoundWind]*4 + round.RoundNumber - 1
+	entry := []any{
+		[]int{kyoku, round.Honba, 0},
+		startPoints,
+		nonNil(dora),
+		[]int{},
+	}
+	for _, s := range seats {
+		entry = append(entry, s.hand, s.takes, s.discards)
+	}
+	return append(entry, exportResult(round.RoundResult, seatCount))
+}
+
+var drawNames = map[string]string{
+	mahjong.RoundEndDrawExhaustive: "流局",
+	mahjong.RoundEndDraw3Ron:       "三家和了",
+	mahjong.RoundEndDraw4Kan:       "四槓散了",
+	mahjong.RoundEndKyuushuu:       "九種九牌",
+	mahjong.RoundEndSuufon:         "四風連打",
+	mahjong.RoundEndChombo:         "錯和",
+}
+
+func exportResult(result *entity.RoundResult, seatCount int) []any {
+	if result == nil {
+		return []any{"不明"}
+	}
+	delta := result.Delta[:seatCount]
+	if name, ok := drawNames[result.EndType]; ok {
+		return []any{name, delta}
+	}
+
+	out := []any{"和了", delta}
+	for _, c := range result.Claims {
+		from := c.LoserSeat
+		if result.EndType == mahjong.RoundEndTsumo {
+			from = c.WinnerSeat
+		}
+		info := []any{c.WinnerSeat, from, c.WinnerSeat, fmt.Sprintf("%d符%d飜%d点", c.Fu, c.Han, c.Points)}
+		for _, y := range c.Yaku {
+			info = append(info, y)
+		}
+		out = append(out, info)
+	}
+	return out
+}
+
+// relativeSeat 来源相对自己的位置：1 上家、2 对家、3 下家（三麻没有对家，下家记为 3）
+func relativeSeat(self, from, seatCount int) int {
+	rel := (self - from + seatCount) % seatCount
+	if seatCount == 3 && rel == 2 {
+		return 3
+	}
+	return rel
+}
+
+// callString 副露写法，codes[0] 为鸣到的牌，字母和鸣到的牌放在来源对应的位置
+func callString(letter string, rel int, codes []int) string {
+	if len(codes) == 0 {
+		return ""
+	}
+	called, own := codes[0], codes[1:]
+	pos := 0
+	switch rel {
+	case 2:
+		pos = 1
+	case 3:
+		pos = len(own)
+	}
+	if letter == "c" {
+		pos = 0
+	}
+	var b strings.Builder
+	for i := 0; i <= len(own); i++ {
+		if i == pos {
+			b.WriteString(letter)
+			b.WriteString(strconv.Itoa(called))
+		}
+		if i < len(own) {
+			b.WriteString(strconv.Itoa(own[i]))
+		}
+	}
+	return b.String()
+}
+
+// TileCode 牌编码为天凤格式，按对局的赤牌规则区分赤 5 与普通 5
+func TileCode(tileType, id int, aka mahjong.AkaRules) int {
+	t := mahjong.TileType(tileType)
+	if (mahjong.Tile{Type: t, ID: id}).IsRedFive() && aka.Count(t) > 0 {
+		return 51 + tileType/9
+	}
+	if t.IsHonor() {
+		return 41 + tileType - int(mahjong.East)
+	}
+	return (tileType/9+1)*10 + tileType%9 + 1
+}
+
+func tileCode(value any, aka mahjong.AkaRules) int {
+	m := utils.ToMap(value)
+	return TileCode(utils.ToInt(m["type"]), utils.ToInt(m["id"]), aka)
+}
+
+func tileCodes(value any, aka mahjong.AkaRules) []int {
+	items := utils.ToSlice(value)
+	codes := make([]int, 0, len(items))
+	for _, item := range items {
+		codes = append(codes, tileCode(item, aka))
+	}
+	return codes
+}
+
+// sameTile 同一张牌（类型和 ID 都相同），用来判断摸切
+func sameTile(a, b any) bool {
+	ma, mb := utils.ToMap(a), utils.ToMap(b)
+	if ma == nil || mb == nil {
+		return false
+	}
+	return utils.ToInt(ma["type"]) == utils.ToInt(mb["type"]) && utils.ToInt(ma["id"]) == utils.ToInt(mb["id"])
+}
+
+func joinCodes(codes []int) string {
+	var b strings.Builder
+	for _, c := range codes {
+		b.WriteString(strconv.Itoa(c))
+	}
+	return b.String()
+}
+
+func nonNil(codes []int) []int {
+	if codes == nil {
+		return []int{}
+	}
+	return codes
+}
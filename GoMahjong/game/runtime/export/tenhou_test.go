@@ -0,0 +1,104 @@
+package export
+
+import (
+	"encoding/json"
+	"game/domain/entity"
+	"game/runtime/engines/mahjong"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// tile 与 GamePersister 记录的事件数据格式一致
+func tile(tileType, id int) map[string]interface{} {
+	return map[string]interface{}{"type": tileType, "id": id}
+}
+
+// hand 配牌，除赤 5 外 ID 都为 1
+func hand(types ...int) []map[string]interface{} {
+	tiles := make([]map[string]interface{}, len(types))
+	for i, t := range types {
+		tiles[i] = tile(t, 1)
+	}
+	return tiles
+}
+
+// goldenRound 东一局：庄家摸切第 14 张，下家手切后被吃，北家摸到赤 5p 摸切立直，荣和庄家摸切的 4s
+func goldenRound() (*entity.GameRecord, []*entity.RoundRecord) {
+	record := &entity.GameRecord{
+		RoomID:    "room-1",
+		GameType:  "riichi_mahjong_4p",
+		StartTime: time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC),
+		Aka:       &entity.AkaRules{Man: 1, Pin: 1, So: 1},
+	}
+	for seat := 0; seat < 4; seat++ {
+		record.Players = append(record.Players, entity.PlayerInfo{UserID: "u" + string(rune('0'+seat)), SeatIndex: seat})
+	}
+
+	round := entity.NewRoundRecord(record.ID, 1, "东", 0, 0)
+	round.AddEvent(entity.EventTypeRoundStart, -1, map[string]interface{}{
+		"dora_indicators": []map[string]interface{}{tile(8, 2)},
+		"hands": [][]map[string]interface{}{
+			hand(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 27),
+			hand(9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 28),
+			hand(21, 22, 23, 24, 25, 26, 29, 29, 30, 30, 31, 31, 33),
+			hand(0, 1, 2, 13, 14, 15, 23, 24, 25, 28, 28, 32, 32),
+		},
+	})
+	round.AddEvent(entity.EventTypeDiscardTile, 0, map[string]interface{}{"tile": tile(27, 1)})
+	round.AddEvent(entity.EventTypeDrawTile, 1, map[string]interface{}{"tile": tile(30, 2)})
+	round.AddEvent(entity.EventTypeDiscardTile, 1, map[string]interface{}{"tile": tile(20, 1)})
+	round.AddEvent(entity.EventTypeChi, 2, map[string]interface{}{
+		"from_seat": 1,
+		"tiles":     []map[string]interface{}{tile(20, 1), tile(21, 1), tile(22, 1)},
+	})
+	round.AddEvent(entity.EventTypeDiscardTile, 2, map[string]interface{}{"tile": tile(33, 1)})
+	round.AddEvent(entity.EventTypeDrawTile, 3, map[string]interface{}{"tile": tile(13, 0)})
+	round.AddEvent(entity.EventTypeRiichi, 3, map[string]interface{}{})
+	round.AddEvent(entity.EventTypeDiscardTile, 3, map[string]interface{}{"tile": tile(13, 0)})
+	round.AddEvent(entity.EventTypeDrawTile, 0, map[string]interface{}{"tile": tile(21, 2)})
+	round.AddEvent(entity.EventTypeDiscardTile, 0, map[string]interface{}{"tile": tile(21, 2)})
+	round.AddEvent(entity.EventTypeRon, 3, map[string]interface{}{"winner_seat": 3, "loser_seat": 0, "win_tile": tile(21, 2)})
+	// 记录的是结算前的点数，立直时扣的 1000 点已扣除；点数变化为放铳 2600 + 供托 1000
+	round.CompleteRound(&entity.RoundResult{
+		EndType: mahjong.RoundEndRon,
+		Claims: []entity.HuClaim{{
+			WinnerSeat: 3, LoserSeat: 0, WinTile: entity.Tile{Type: 21, ID: 2},
+			Han: 2, Fu: 40, Points: 2600, Yaku: []string{"立直", "赤ドラ"},
+		}},
+		Delta:      [4]int{-2600, 0, 0, 3600},
+		Points:     [4]int{25000, 25000, 25000, 24000},
+		NextDealer: 1,
+	})
+	round.AddEvent(entity.EventTypeRoundEnd, -1, map[string]interface{}{})
+	return record, []*entity.RoundRecord{round}
+}
+
+func TestToTenhouLogGolden(t *testing.T) {
+	record, rounds := goldenRound()
+	got, err := ToTenhouLog(record, rounds)
+	if err != nil {
+		t.Fatalf("ToTenhouLog: %v", err)
+	}
+	gotJSON, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+
+	wantJSON, err := os.ReadFile("testdata/tenhou_round.json")
+	if err != nil {
+		t.Fatalf("读取 golden 文件: %v", err)
+	}
+	// 按 JSON 值比较，golden 文件可以按可读的格式排版
+	var gotValue, wantValue any
+	if err := json.Unmarshal(gotJSON, &gotValue); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if err := json.Unmarshal(wantJSON, &wantValue); err != nil {
+		t.Fatalf("golden 文件不是合法的 JSON: %v", err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Fatalf("导出的牌谱与 golden 文件不一致\n got: %s", gotJSON)
+	}
+}
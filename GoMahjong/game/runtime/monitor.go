@@ -2,7 +2,6 @@ package game
 
 import (
 	"context"
-	"fmt"
 	"game/infrastructure/discovery"
 	"game/infrastructure/log"
 	"runtime"
@@ -65,10 +64,10 @@ func (m *Monitor) reportLoad() {
 
 	err := m.registry.UpdateLoad(load)
 	if err != nil {
-		log.Error(fmt.Sprintf("Monitor 上报负载信息失败: %v", err))
+		log.Error("Monitor 上报负载信息失败: %v", err)
 	} else {
-		log.Debug(fmt.Sprintf("Monitor 上报负载信息成功: Load=%.2f, Games=%d, UserMap=%d, CPU=%.2f, Mem=%.2f",
-			load, loadInfo.GameCount, loadInfo.PlayerCount, loadInfo.CPUUsage, loadInfo.MemUsage))
+		log.Debug("Monitor 上报负载信息成功: Load=%.2f, Games=%d, UserMap=%d, CPU=%.2f, Mem=%.2f",
+			load, loadInfo.GameCount, loadInfo.PlayerCount, loadInfo.CPUUsage, loadInfo.MemUsage)
 	}
 }
 
@@ -96,7 +95,7 @@ func (m *Monitor) getCPUUsage() float64 {
 	// 对于负载均衡，我们关心的是系统整体 CPU 使用率
 	percentages, err := cpu.Percent(200*time.Millisecond, false)
 	if err != nil {
-		log.Error(fmt.Sprintf("Monitor 获取 CPU 使用率失败: %v", err))
+		log.Error("Monitor 获取 CPU 使用率失败: %v", err)
 		return 0.0
 	}
 
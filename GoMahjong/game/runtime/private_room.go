@@ -0,0 +1,150 @@
+package game
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"game/infrastructure/log"
+	"time"
+)
+
+// PrivateRoomTTL 房间号有效期：未满员的房间过期后解散，已开局的房间号过期后不再保留
+const PrivateRoomTTL = 10 * time.Minute
+
+var (
+	ErrPrivateRoomNotFound = errors.New("房间号不存在或已过期")
+	ErrPrivateRoomFull     = errors.New("房间已满")
+	ErrPrivateRoomExists   = errors.New("房间号已被占用")
+	ErrPlayerInRoom        = errors.New("玩家已在房间中")
+)
+
+// PrivateRoom 私人房间：由玩家创建，其他玩家通过房间号加入，满员后才创建 Room 并初始化引擎
+type PrivateRoom struct {
+	Code       string
+	OwnerID    string
+	EngineType int32
+	Capacity   int
+	Users      map[string]string // userID -> connectorTopic
+	Order      []string          // 加入顺序，房主在最前
+	RoomID     string            // 开局后的房间 ID，未开局为空
+	ExpireAt   time.Time
+}
+
+// Started 是否已满员开局
+func (pr *PrivateRoom) Started() bool {
+	return pr.RoomID != ""
+}
+
+// clone 返回副本，调用方在锁外使用
+func (pr *PrivateRoom) clone() *PrivateRoom {
+	users := make(map[string]string, len(pr.Users))
+	for k, v := range pr.Users {
+		users[k] = v
+	}
+	cp := *pr
+	cp.Users = users
+	cp.Order = append([]string(nil), pr.Order...)
+	return &cp
+}
+
+// GeneratePrivateRoomCode 生成 6 位数字房间号，唯一性由调用方保证
+func GeneratePrivateRoomCode() string {
+	randomBytes := make([]byte, 4)
+	rand.Read(randomBytes)
+	return fmt.Sprintf("%06d", binary.BigEndian.Uint32(randomBytes)%1000000)
+}
+
+// CreatePrivateRoom 创建私人房间，房主自动入座
+func (rm *RoomManager) CreatePrivateRoom(code, ownerID, connectorTopic string, engineType int32, expireAt time.Time) (*PrivateRoom, error) {
+	capacity := engineCapacity(engineType)
+	if capacity == 0 {
+		return nil, fmt.Errorf("不支持的引擎类型: %d", engineType)
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if _, exists := rm.enginePrototypes[engineType]; !exists {
+		return nil, fmt.Errorf("不支持的引擎类型: %d", engineType)
+	}
+	if _, exists := rm.privateRooms[code]; exists {
+		return nil, ErrPrivateRoomExists
+	}
+	if _, exists := rm.playerRoom[ownerID]; exists {
+		return nil, ErrPlayerInRoom
+	}
+
+	pr := &PrivateRoom{
+		Code:       code,
+		OwnerID:    ownerID,
+		EngineType: engineType,
+		Capacity:   capacity,
+		Users:      map[string]string{ownerID: connectorTopic},
+		Order:      []string{ownerID},
+		ExpireAt:   expireAt,
+	}
+	rm.privateRooms[code] = pr
+
+	log.Info("RoomManager 创建私人房间 %s，房主: %s，引擎类型: %d", code, ownerID, engineType)
+	return pr.clone(), nil
+}
+
+// JoinPrivateRoom 通过房间号加入，重复加入只更新 connector
+// 最后一人加入时先把房间标记为已开局，再创建 Room 并初始化引擎，满员后的加入会被拒绝
+// 返回的 Room 只在本次加入触发开局时非空
+func (rm *RoomManager) JoinPrivateRoom(code, userID, connectorTopic string, now time.Time) (*PrivateRoom, *Room, error) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	pr, exists := rm.privateRooms[code]
+	if !exists || now.After(pr.ExpireAt) {
+		return nil, nil, ErrPrivateRoomNotFound
+	}
+	if pr.Started() {
+		return nil, nil, ErrPrivateRoomFull
+	}
+	if _, joined := pr.Users[userID]; joined {
+		pr.Users[userID] = connectorTopic
+		return pr.clone(), nil, nil
+	}
+	if len(pr.Users) >= pr.Capacity {
+		return nil, nil, ErrPrivateRoomFull
+	}
+	if _, exists := rm.playerRoom[userID]; exists {
+		return nil, nil, ErrPlayerInRoom
+	}
+
+	pr.Users[userID] = connectorTopic
+	pr.Order = append(pr.Order, userID)
+	if len(pr.Users) < pr.Capacity {
+		return pr.clone(), nil, nil
+	}
+
+	room, err := rm.createRoomLocked(pr.Users, pr.EngineType)
+	if err != nil {
+		delete(rm.privateRooms, code)
+		return pr.clone(), nil, err
+	}
+	pr.RoomID = room.ID
+	log.Info("RoomManager 私人房间 %s 满员开局，房间: %s", code, room.ID)
+	return pr.clone(), room, nil
+}
+
+// ExpirePrivateRooms 清理过期的房间号，返回其中未开局（需要通知玩家解散）的房间
+func (rm *RoomManager) ExpirePrivateRooms(now time.Time) []*PrivateRoom {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	var dismissed []*PrivateRoom
+	for code, pr := range rm.privateRooms {
+		if !now.After(pr.ExpireAt) {
+			continue
+		}
+		delete(rm.privateRooms, code)
+		if !pr.Started() {
+			dismissed = append(dismissed, pr.clone())
+		}
+	}
+	return dismissed
+}
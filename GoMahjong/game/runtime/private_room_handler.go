@@ -0,0 +1,172 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"game/infrastructure/log"
+	"game/infrastructure/message/protocol"
+	"game/infrastructure/message/transfer"
+	"time"
+)
+
+const (
+	privateRoomCodeRetry     = 5                // 房间号冲突时的重试次数
+	privateRoomSweepInterval = 30 * time.Second // 过期房间号的清理间隔
+)
+
+// handleCreatePrivateRoom 创建私人房间，通过队列组主题到达，由任意一个 game 节点处理
+func (w *Worker) handleCreatePrivateRoom(data []byte) any {
+	var req transfer.PrivateRoomReq
+	if err := json.Unmarshal(data, &req); err != nil || req.UserID == "" {
+		log.Warn("handleCreatePrivateRoom json 解析失败")
+		return nil
+	}
+	if w.PrivateRoomRepository == nil {
+		w.pushPrivateRoomFail(req, "私人房间未开放")
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	for range privateRoomCodeRetry {
+		code := GeneratePrivateRoomCode()
+		ok, err := w.PrivateRoomRepository.Reserve(ctx, code, w.NodeID, PrivateRoomTTL)
+		if err != nil {
+			w.pushPrivateRoomFail(req, "创建房间失败")
+			return nil
+		}
+		if !ok {
+			continue
+		}
+
+		pr, err := w.RoomManager.CreatePrivateRoom(code, req.UserID, req.ConnectorID, req.EngineType, time.Now().Add(PrivateRoomTTL))
+		if err != nil {
+			_ = w.PrivateRoomRepository.Release(ctx, code)
+			log.Warn("Game Worker 创建私人房间失败: userID=%s, err=%v", req.UserID, err)
+			w.pushPrivateRoomFail(req, err.Error())
+			return nil
+		}
+		w.pushPrivateRoomUpdate(pr, "房间已创建")
+		return nil
+	}
+
+	log.Warn("Game Worker 生成房间号失败，连续冲突 %d 次", privateRoomCodeRetry)
+	w.pushPrivateRoomFail(req, "创建房间失败，请重试")
+	return nil
+}
+
+// handleJoinPrivateRoom 加入私人房间，connector 按房间号索引直接转发到创建房间的节点
+func (w *Worker) handleJoinPrivateRoom(data []byte) any {
+	var req transfer.PrivateRoomReq
+	if err := json.Unmarshal(data, &req); err != nil || req.UserID == "" {
+		log.Warn("handleJoinPrivateRoom json 解析失败")
+		return nil
+	}
+
+	pr, room, err := w.RoomManager.JoinPrivateRoom(req.Code, req.UserID, req.ConnectorID, time.Now())
+	if err != nil {
+		if pr == nil {
+			w.pushPrivateRoomFail(req, err.Error())
+			return nil
+		}
+		// 满员后初始化引擎失败，房间已解散
+		log.Error("Game Worker 私人房间 %s 开局失败: %v", req.Code, err)
+		w.releasePrivateRoomCode(pr.Code)
+		w.pushPrivateRoomDismissed(pr, "开局失败，房间已解散")
+		return nil
+	}
+
+	if room != nil {
+		// 开局后由引擎推送匹配成功，这里只通知房间状态
+		w.pushPrivateRoomUpdate(pr, "人数已满，游戏开始")
+		return nil
+	}
+	w.pushPrivateRoomUpdate(pr, "玩家已加入")
+	return nil
+}
+
+// privateRoomSweepLoop 定期清理过期的房间号，解散未满员的房间
+func (w *Worker) privateRoomSweepLoop(ctx context.Context) {
+	ticker := time.NewTicker(privateRoomSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, pr := range w.RoomManager.ExpirePrivateRooms(now) {
+				log.Info("Game Worker 私人房间 %s 过期解散", pr.Code)
+				w.releasePrivateRoomCode(pr.Code)
+				w.pushPrivateRoomDismissed(pr, "房间已过期")
+			}
+		}
+	}
+}
+
+func (w *Worker) releasePrivateRoomCode(code string) {
+	if w.PrivateRoomRepository == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	_ = w.PrivateRoomRepository.Release(ctx, code)
+}
+
+func (w *Worker) pushPrivateRoomUpdate(pr *PrivateRoom, message string) {
+	w.pushPrivateRoom(pr.Users, &transfer.PrivateRoomUpdateDTO{
+		Success:  true,
+		Message:  message,
+		Code:     pr.Code,
+		Players:  pr.Order,
+		Capacity: pr.Capacity,
+		Started:  pr.Started(),
+	})
+}
+
+func (w *Worker) pushPrivateRoomDismissed(pr *PrivateRoom, message string) {
+	w.pushPrivateRoom(pr.Users, &transfer.PrivateRoomUpdateDTO{
+		Success: false,
+		Message: message,
+		Code:    pr.Code,
+	})
+}
+
+func (w *Worker) pushPrivateRoomFail(req transfer.PrivateRoomReq, message string) {
+	w.pushPrivateRoom(map[string]string{req.UserID: req.ConnectorID}, &transfer.PrivateRoomUpdateDTO{
+		Success: false,
+		Message: message,
+		Code:    req.Code,
+	})
+}
+
+// pushPrivateRoom 按 connector 分组推送私人房间状态
+func (w *Worker) pushPrivateRoom(users map[string]string, dto *transfer.PrivateRoomUpdateDTO) {
+	data, err := json.Marshal(dto)
+	if err != nil {
+		return
+	}
+	connectorGroups := make(map[string][]string)
+	for userID, connectorID := range users {
+		if connectorID != "" {
+			connectorGroups[connectorID] = append(connectorGroups[connectorID], userID)
+		}
+	}
+	for connectorID, userIDs := range connectorGroups {
+		packet := &transfer.ServicePacket{
+			Source:      w.NodeID,
+			Destination: connectorID,
+			Route:       transfer.PrivateRoomUpdate,
+			PushUser:    userIDs,
+			Body: &protocol.Message{
+				Type:  protocol.Push,
+				Route: transfer.PrivateRoomUpdate,
+				Data:  data,
+			},
+		}
+		if err := w.PushMessage(packet); err != nil {
+			log.Warn("Game Worker 推送私人房间状态失败: connector=%s, err=%v", connectorID, err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package game
+
+import (
+	"errors"
+	"game/infrastructure/log"
+	"game/runtime/engines"
+	"game/runtime/share"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	log.InitLog("game_runtime_test", "error")
+	os.Exit(m.Run())
+}
+
+// stubEngine 只记录初始化参数的空引擎
+type stubEngine struct {
+	roomID string
+	users  map[string]*share.UserInfo
+}
+
+func (e *stubEngine) InitializeEngine(roomID string, users map[string]*share.UserInfo) error {
+	e.roomID, e.users = roomID, users
+	return nil
+}
+func (e *stubEngine) NotifyEvent(share.GameEvent) {}
+func (e *stubEngine) Clone() engines.Engine       { return &stubEngine{} }
+func (e *stubEngine) Close()                      {}
+
+func newTestRoomManager(t *testing.T) *RoomManager {
+	t.Helper()
+	rm := NewRoomManager()
+	if err := rm.SetEnginePrototype(int32(engines.RIICHI_MAHJONG_4P_ENGINE), &stubEngine{}); err != nil {
+		t.Fatalf("SetEnginePrototype: %v", err)
+	}
+	return rm
+}
+
+func TestGeneratePrivateRoomCode(t *testing.T) {
+	for range 100 {
+		code := GeneratePrivateRoomCode()
+		if len(code) != 6 {
+			t.Fatalf("房间号应为 6 位, got %q", code)
+		}
+		for _, c := range code {
+			if c < '0' || c > '9' {
+				t.Fatalf("房间号应只含数字, got %q", code)
+			}
+		}
+	}
+}
+
+func TestPrivateRoomJoin(t *testing.T) {
+	rm := newTestRoomManager(t)
+	engineType := int32(engines.RIICHI_MAHJONG_4P_ENGINE)
+	now := time.Now()
+	expireAt := now.Add(PrivateRoomTTL)
+
+	if _, err := rm.CreatePrivateRoom("123456", "owner", "c1", engineType, expireAt); err != nil {
+		t.Fatalf("CreatePrivateRoom: %v", err)
+	}
+	if _, err := rm.CreatePrivateRoom("123456", "other", "c1", engineType, expireAt); !errors.Is(err, ErrPrivateRoomExists) {
+		t.Fatalf("房间号重复应返回 ErrPrivateRoomExists, got %v", err)
+	}
+	if _, err := rm.CreatePrivateRoom("654321", "other", "c1", int32(engines.RIICHI_MAHJONG_3P_ENGINE), expireAt); err == nil {
+		t.Fatalf("未注入原型的引擎类型应创建失败")
+	}
+	if _, _, err := rm.JoinPrivateRoom("000000", "u1", "c1", now); !errors.Is(err, ErrPrivateRoomNotFound) {
+		t.Fatalf("不存在的房间号应返回 ErrPrivateRoomNotFound, got %v", err)
+	}
+
+	for _, userID := range []string{"u1", "u2"} {
+		pr, room, err := rm.JoinPrivateRoom("123456", userID, "c1", now)
+		if err != nil || room != nil || pr.Started() {
+			t.Fatalf("%s 加入: room=%v err=%v", userID, room, err)
+		}
+	}
+	// 重复加入只更新 connector，不占座位
+	pr, room, err := rm.JoinPrivateRoom("123456", "u1", "c2", now)
+	if err != nil || room != nil || len(pr.Order) != 3 || pr.Users["u1"] != "c2" {
+		t.Fatalf("重复加入: %+v room=%v err=%v", pr, room, err)
+	}
+
+	pr, room, err = rm.JoinPrivateRoom("123456", "u3", "c1", now)
+	if err != nil || room == nil {
+		t.Fatalf("最后一人加入应开局: room=%v err=%v", room, err)
+	}
+	if pr.RoomID != room.ID || pr.Order[0] != "owner" || len(room.Users) != 4 {
+		t.Fatalf("开局房间错误: %+v", pr)
+	}
+	if engine := room.Engine.(*stubEngine); engine.roomID != room.ID || len(engine.users) != 4 {
+		t.Fatalf("引擎应以满员的玩家初始化: %+v", engine)
+	}
+	if got, ok := rm.GetPlayerRoom("u1"); !ok || got.ID != room.ID {
+		t.Fatalf("玩家应路由到开局的房间")
+	}
+
+	if _, _, err := rm.JoinPrivateRoom("123456", "u4", "c1", now); !errors.Is(err, ErrPrivateRoomFull) {
+		t.Fatalf("满员后加入应返回 ErrPrivateRoomFull, got %v", err)
+	}
+	if _, err := rm.CreatePrivateRoom("654321", "u1", "c1", engineType, expireAt); !errors.Is(err, ErrPlayerInRoom) {
+		t.Fatalf("已在房间中的玩家不能再创建房间, got %v", err)
+	}
+}
+
+func TestExpirePrivateRooms(t *testing.T) {
+	rm := newTestRoomManager(t)
+	engineType := int32(engines.RIICHI_MAHJONG_4P_ENGINE)
+	now := time.Now()
+
+	if _, err := rm.CreatePrivateRoom("111111", "a", "c1", engineType, now.Add(time.Minute)); err != nil {
+		t.Fatalf("CreatePrivateRoom: %v", err)
+	}
+	if _, err := rm.CreatePrivateRoom("222222", "b", "c1", engineType, now.Add(time.Hour)); err != nil {
+		t.Fatalf("CreatePrivateRoom: %v", err)
+	}
+	// 满员开局的房间号过期后只清理，不需要通知解散
+	if _, err := rm.CreatePrivateRoom("333333", "c", "c1", engineType, now.Add(time.Minute)); err != nil {
+		t.Fatalf("CreatePrivateRoom: %v", err)
+	}
+	for _, userID := range []string{"c1", "c2", "c3"} {
+		if _, _, err := rm.JoinPrivateRoom("333333", userID, "c1", now); err != nil {
+			t.Fatalf("JoinPrivateRoom: %v", err)
+		}
+	}
+
+	later := now.Add(2 * time.Minute)
+	if _, _, err := rm.JoinPrivateRoom("111111", "u1", "c1", later); !errors.Is(err, ErrPrivateRoomNotFound) {
+		t.Fatalf("过期的房间号应返回 ErrPrivateRoomNotFound, got %v", err)
+	}
+	if rm.ExpirePrivateRooms(now) != nil {
+		t.Fatalf("未过期时不应清理")
+	}
+	dismissed := rm.ExpirePrivateRooms(later)
+	if len(dismissed) != 1 || dismissed[0].Code != "111111" || dismissed[0].OwnerID != "a" {
+		t.Fatalf("应只解散未开局的过期房间, got %+v", dismissed)
+	}
+	if _, ok := rm.privateRooms["333333"]; ok {
+		t.Fatalf("已开局的过期房间号也应清理")
+	}
+	if _, _, err := rm.JoinPrivateRoom("222222", "u1", "c1", later); err != nil {
+		t.Fatalf("未过期的房间不受影响: %v", err)
+	}
+	if _, err := rm.CreatePrivateRoom("111111", "d", "c1", engineType, later.Add(time.Minute)); err != nil {
+		t.Fatalf("过期清理后房间号可以复用: %v", err)
+	}
+}
@@ -18,6 +18,7 @@ type Room struct {
 	Users      map[string]*share.UserInfo // userID -> UserInfo（Engine 和 Room 共用）
 	AllowWatch bool                       // 是否允许观战
 	Engine     engines.Engine             // 游戏引擎
+	EngineType int32                      // 引擎类型
 	CreatedAt  time.Time                  // 创建时间
 	mu         sync.RWMutex               // 保护 Users 的读写锁
 }
@@ -55,7 +56,7 @@ func NewRoom(engine engines.Engine, users map[string]string) (*Room, error) {
 	room := &Room{
 		ID:         GenerateRoomID(),
 		Users:      userInfo,
-		AllowWatch: false,
+		AllowWatch: true, // 观战者只会收到公开信息
 		Engine:     engine,
 		CreatedAt:  time.Now(),
 	}
@@ -73,7 +74,7 @@ func (r *Room) RemovePlayer(userID string) error {
 	}
 
 	delete(r.Users, userID)
-	log.Info(fmt.Sprintf("Room[%s] 玩家 %s 离开房间", r.ID, userID))
+	log.Info("Room[%s] 玩家 %s 离开房间", r.ID, userID)
 	return nil
 }
 
@@ -13,6 +13,7 @@ import (
 type RoomManager struct {
 	rooms            map[string]*Room         // roomID -> Room
 	playerRoom       map[string]string        // playerID -> roomID
+	privateRooms     map[string]*PrivateRoom  // 房间号 -> 私人房间
 	enginePrototypes map[int32]engines.Engine // engineType -> Engine 原型
 	mu               sync.RWMutex
 }
@@ -22,6 +23,7 @@ func NewRoomManager() *RoomManager {
 	return &RoomManager{
 		rooms:            make(map[string]*Room),
 		playerRoom:       make(map[string]string),
+		privateRooms:     make(map[string]*PrivateRoom),
 		enginePrototypes: make(map[int32]engines.Engine),
 	}
 }
@@ -37,24 +39,36 @@ func (rm *RoomManager) SetEnginePrototype(engineType int32, engine engines.Engin
 	defer rm.mu.Unlock()
 
 	rm.enginePrototypes[engineType] = engine
-	log.Info(fmt.Sprintf("RoomManager 注入 Engine 原型: engineType=%d", engineType))
+	log.Info("RoomManager 注入 Engine 原型: engineType=%d", engineType)
 	return nil
 }
 
 // CreateRoom 创建房间并添加玩家（使用原型模式）
 // 返回：房间实例和错误
 func (rm *RoomManager) CreateRoom(users map[string]string, engineType int32) (*Room, error) {
-	pass := false
-	if len(users) == 4 && engineType == int32(engines.RIICHI_MAHJONG_4P_ENGINE) {
-		pass = true
-	}
-	if !pass {
+	if len(users) != engineCapacity(engineType) {
 		return nil, errors.New("玩家列表异常")
 	}
 
 	rm.mu.Lock()
 	defer rm.mu.Unlock()
 
+	return rm.createRoomLocked(users, engineType)
+}
+
+// engineCapacity 引擎需要的玩家数，不支持的引擎返回 0
+func engineCapacity(engineType int32) int {
+	switch engineType {
+	case int32(engines.RIICHI_MAHJONG_4P_ENGINE), int32(engines.RIICHI_MAHJONG_4P_TONPUU_ENGINE):
+		return 4
+	case int32(engines.RIICHI_MAHJONG_3P_ENGINE):
+		return 3
+	}
+	return 0
+}
+
+// createRoomLocked 克隆引擎、创建房间并初始化，需要在持有锁的情况下调用
+func (rm *RoomManager) createRoomLocked(users map[string]string, engineType int32) (*Room, error) {
 	// 检查玩家是否已在其他房间中
 	for userID := range users {
 		if roomID, exists := rm.playerRoom[userID]; exists {
@@ -77,6 +91,7 @@ func (rm *RoomManager) CreateRoom(users map[string]string, engineType int32) (*R
 	if err != nil {
 		return nil, fmt.Errorf("创建房间失败: %v", err)
 	}
+	room.EngineType = engineType
 
 	// 步骤 3：更新路由映射
 	for userID := range users {
@@ -90,7 +105,7 @@ func (rm *RoomManager) CreateRoom(users map[string]string, engineType int32) (*R
 	}
 	rm.rooms[room.ID] = room
 
-	log.Info(fmt.Sprintf("RoomManager 创建房间 %s，玩家数: %d，引擎类型: %d", room.ID, len(users), engineType))
+	log.Info("RoomManager 创建房间 %s，玩家数: %d，引擎类型: %d", room.ID, len(users), engineType)
 	return room, nil
 }
 
@@ -141,7 +156,7 @@ func (rm *RoomManager) DeleteRoom(roomID string) error {
 	// 删除房间
 	delete(rm.rooms, roomID)
 
-	log.Info(fmt.Sprintf("RoomManager 删除房间 %s", roomID))
+	log.Info("RoomManager 删除房间 %s", roomID)
 	return nil
 }
 
@@ -170,7 +185,7 @@ func (rm *RoomManager) UpdatePlayerConnector(userID, newConnectorTopic string) e
 	}
 
 	player.SetOnline(newConnectorTopic)
-	log.Info(fmt.Sprintf("RoomManager 更新玩家 %s 的 connector topic: %s", userID, newConnectorTopic))
+	log.Info("RoomManager 更新玩家 %s 的 connector topic: %s", userID, newConnectorTopic)
 	return nil
 }
 
@@ -14,6 +14,12 @@ type GameEvent interface {
 
 type GameMessageEvent struct {
 	UserID string `json:"userID"` // 用户 ID（用于查找座位）
+	Seq    int    `json:"seq"`    // 客户端回传的操作序号，0 表示不校验
+}
+
+// GetSeq 客户端回传的操作序号，引擎用来丢弃过期或重复的操作
+func (e *GameMessageEvent) GetSeq() int {
+	return e.Seq
 }
 
 func (e *GameMessageEvent) GetUserID() string {
@@ -35,6 +41,11 @@ func (e *DropTileEvent) GetTile() Tile {
 
 type PengTileEvent struct {
 	GameMessageEvent
+	Tiles []Tile `json:"tiles"` // 选择从手牌中拿出的两张牌（区分赤牌），为空时使用第一个碰牌选项
+}
+
+func (e *PengTileEvent) GetTiles() []Tile {
+	return e.Tiles
 }
 
 func (e *PengTileEvent) GetEventType() string {
@@ -67,12 +78,43 @@ func (e *TouchHuEvent) GetEventType() string {
 
 type ReconnectEvent struct {
 	GameMessageEvent
+	ConnectorNodeID string `json:"connectorNodeID"` // 重连所在的 connector，续连时可能与掉线前不同；为空时沿用原 connector
 }
 
 func (e *ReconnectEvent) GetEventType() string {
 	return "Reconnect"
 }
 
+// SerializerEvent 客户端协商的推送格式（connector 在握手或匹配成功后通知）
+type SerializerEvent struct {
+	GameMessageEvent
+	Serializer string `json:"serializer"`
+}
+
+func (e *SerializerEvent) GetEventType() string {
+	return "Serializer"
+}
+
+// DisconnectEvent 玩家掉线（connector 检测到连接断开后通知）
+type DisconnectEvent struct {
+	GameMessageEvent
+}
+
+func (e *DisconnectEvent) GetEventType() string {
+	return "Disconnect"
+}
+
+// SpectateEvent 观战者加入或离开（只会收到公开信息）
+type SpectateEvent struct {
+	GameMessageEvent
+	ConnectorNodeID string `json:"connectorNodeID"`
+	Leave           bool   `json:"leave"`
+}
+
+func (e *SpectateEvent) GetEventType() string {
+	return "Spectate"
+}
+
 type GangEvent struct {
 	GameMessageEvent
 }
@@ -111,12 +153,46 @@ func (e *KakanEvent) GetTile() Tile {
 
 type ChiEvent struct {
 	GameMessageEvent
+	Tiles []Tile `json:"tiles"` // 选择从手牌中拿出的两张牌（区分赤牌），为空时使用第一个吃牌选项
 }
 
 func (e *ChiEvent) GetEventType() string {
 	return "Chi"
 }
 
+func (e *ChiEvent) GetTiles() []Tile {
+	return e.Tiles
+}
+
+// KyuushuuEvent 九种九牌流局宣言（第一巡摸牌后）
+type KyuushuuEvent struct {
+	GameMessageEvent
+}
+
+func (e *KyuushuuEvent) GetEventType() string {
+	return "Kyuushuu"
+}
+
+// KitaEvent 拔北（三麻，玩家自己回合把北放到一边并补牌）
+type KitaEvent struct {
+	GameMessageEvent
+}
+
+func (e *KitaEvent) GetEventType() string {
+	return "Kita"
+}
+
+// ScorePreviewEvent 试算：假设以 Tile 和牌（自摸或荣和）时的番符与点数，不改变对局状态
+type ScorePreviewEvent struct {
+	GameMessageEvent
+	Tile  Tile `json:"tile"`  // 假设的和牌
+	Tsumo bool `json:"tsumo"` // 是否按自摸计算
+}
+
+func (e *ScorePreviewEvent) GetEventType() string {
+	return "ScorePreview"
+}
+
 type RiichiEvent struct {
 	GameMessageEvent
 }
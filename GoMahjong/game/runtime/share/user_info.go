@@ -1,19 +1,25 @@
 package share
 
+import "game/infrastructure/message/transfer"
+
 // UserInfo 和游戏逻辑隔离的用户信息
 type UserInfo struct {
 	UserID          string // 用户 ID
 	ConnectorNodeID string // connector 的 topic（用于主动推送消息）
 	IsOnline        bool   // 是否在线
+	Serializer      string // 客户端握手时协商的推送格式，空表示默认 json
+	IsBot           bool   // 匹配超时补位的 bot，没有连接，按超时自动操作
 	SeatIndex       int
 }
 
 // NewUserInfo 创建玩家信息
 func NewUserInfo(userID, connectorNodeID string) *UserInfo {
+	isBot := transfer.IsBotUser(userID)
 	return &UserInfo{
 		UserID:          userID,
 		ConnectorNodeID: connectorNodeID,
-		IsOnline:        true,
+		IsOnline:        !isBot,
+		IsBot:           isBot,
 	}
 }
 
@@ -26,13 +26,16 @@ import (
 */
 
 type Worker struct {
-	RoomManager          *RoomManager
-	MiddleWorker         *node.NatsWorker
-	Monitor              *Monitor
-	Registry             *discovery.Registry
-	GameService          svc.GameService                 // 游戏服务
-	GameRecordRepository repository.GameRecordRepository // 游戏记录仓储
-	NodeID               string                          // 当前 game 节点 ID（用于 NATS topic）
+	RoomManager           *RoomManager
+	MiddleWorker          *node.NatsWorker
+	Monitor               *Monitor
+	Registry              *discovery.Registry
+	GameService           svc.GameService                  // 游戏服务
+	GameRecordRepository  repository.GameRecordRepository  // 游戏记录仓储
+	LeaderboardRepository repository.LeaderboardRepository // 排行榜仓储
+	PrivateRoomRepository repository.PrivateRoomRepository // 私人房间号索引
+	CrashReportRepository repository.CrashReportRepository // 房间崩溃报告
+	NodeID                string                           // 当前 game 节点 ID（用于 NATS topic）
 
 	destroyRoomCh chan string
 	destroyMu     sync.Mutex
@@ -102,6 +105,21 @@ func (w *Worker) SetGameRecordRepository(repo repository.GameRecordRepository) {
 	w.GameRecordRepository = repo
 }
 
+// SetLeaderboardRepository 设置 LeaderboardRepository（由容器注入）
+func (w *Worker) SetLeaderboardRepository(repo repository.LeaderboardRepository) {
+	w.LeaderboardRepository = repo
+}
+
+// SetPrivateRoomRepository 设置 PrivateRoomRepository（由容器注入）
+func (w *Worker) SetPrivateRoomRepository(repo repository.PrivateRoomRepository) {
+	w.PrivateRoomRepository = repo
+}
+
+// SetCrashReportRepository 设置 CrashReportRepository（由容器注入）
+func (w *Worker) SetCrashReportRepository(repo repository.CrashReportRepository) {
+	w.CrashReportRepository = repo
+}
+
 // Start 启动 Worker
 // natsURL: NATS 服务地址，如 "nats://localhost:4222"
 // etcdConf: etcd 配置
@@ -111,18 +129,19 @@ func (w *Worker) Start(ctx context.Context, natsURL string, etcdConf config.Etcd
 	if err != nil {
 		return fmt.Errorf("注册到 etcd 失败: %v", err)
 	}
-	log.Info(fmt.Sprintf("Game Worker[%s] 注册到 etcd 成功", w.NodeID))
+	log.Info("Game Worker[%s] 注册到 etcd 成功", w.NodeID)
 
 	err = w.MiddleWorker.Run(natsURL, w.NodeID)
 	if err != nil {
 		return fmt.Errorf("启动 NATS 监听失败: %v", err)
 	}
-	log.Info(fmt.Sprintf("Game Worker[%s] 启动 NATS 监听成功, topic: %s", w.NodeID, w.NodeID))
+	log.Info("Game Worker[%s] 启动 NATS 监听成功, topic: %s", w.NodeID, w.NodeID)
 
 	// 启动 Monitor 负载上报
 	go w.Monitor.Report(ctx)
+	go w.privateRoomSweepLoop(ctx)
 
-	log.Info(fmt.Sprintf("Game Worker[%s] 启动成功", w.NodeID))
+	log.Info("Game Worker[%s] 启动成功", w.NodeID)
 	return nil
 }
 
@@ -131,7 +150,15 @@ func (w *Worker) registerHandlers() {
 	handlers := make(node.SubscriberHandler)
 
 	handlers["game.play.droptile"] = w.handleDropTileHandler
-	handlers["game.reconnect"] = w.handleReconnect
+	handlers["game.play.kyuushuu"] = w.handleKyuushuuHandler
+	handlers["game.play.kita"] = w.handleKitaHandler
+	handlers["game.play.preview"] = w.handleScorePreviewHandler
+	handlers[transfer.GameReconnect] = w.handleReconnect
+	handlers[transfer.GameDisconnect] = w.handleDisconnect
+	handlers[transfer.GameSerializer] = w.handleSerializer
+	handlers[transfer.GameSpectate] = w.handleSpectate
+	handlers[transfer.GameCreatePrivateRoom] = w.handleCreatePrivateRoom
+	handlers[transfer.GameJoinPrivateRoom] = w.handleJoinPrivateRoom
 
 	w.MiddleWorker.RegisterHandlers(handlers)
 	log.Info("Game Worker 注册消息处理器完成")
@@ -158,7 +185,7 @@ func (w *Worker) PushConnector(connectorNodeID, route string, data []byte) error
 		return fmt.Errorf("推送消息失败: %v", err)
 	}
 
-	log.Info(fmt.Sprintf("Game Worker 推送消息给 Connector %s, route: %s", connectorNodeID, route))
+	log.Info("Game Worker 推送消息给 Connector %s, route: %s", connectorNodeID, route)
 	return nil
 }
 
@@ -184,5 +211,5 @@ func (w *Worker) Close() {
 	if w.MiddleWorker != nil {
 		w.MiddleWorker.Close()
 	}
-	log.Info(fmt.Sprintf("Game Worker[%s] 已关闭", w.NodeID))
+	log.Info("Game Worker[%s] 已关闭", w.NodeID)
 }
@@ -2,13 +2,25 @@ package repository
 
 import (
 	"context"
+	"time"
 )
 
+// MMRWindow MMR 匹配窗口：等待越久窗口越宽
+type MMRWindow struct {
+	Base           float64 // 初始窗口（MMR 差值）
+	WidenPerSecond float64 // 每等待一秒放宽的 MMR
+	Max            float64 // 窗口上限
+	MaxAnchors     int     // 每次最多尝试的锚点（按等待时间从早到晚）
+}
+
 type MarchQueueRepository interface {
-	JoinQueue(ctx context.Context, poolID, userID string, score float64) error
+	JoinQueue(ctx context.Context, poolID, userID string, score, mmr float64) error
 	RemoveFromQueue(ctx context.Context, userID string) error
 	IsInQueue(ctx context.Context, userID string) (bool, string, error)
 	GetUserPool(ctx context.Context, userID string) (string, error)
 	PopPlayers(ctx context.Context, poolID string, count int) ([]string, error)
+	PopPlayersByMMR(ctx context.Context, poolID string, count int, window MMRWindow) ([]string, error)
+	// PopPlayersOrBots 人数不足 count 且最早入队的玩家等待超过 timeout 时取出全部玩家，空位用 bot ID 补齐；人数足够时不出队，交给匹配策略
+	PopPlayersOrBots(ctx context.Context, poolID string, count int, timeout time.Duration) ([]string, error)
 	GetQueueSize(ctx context.Context, poolID string) (int, error)
 }
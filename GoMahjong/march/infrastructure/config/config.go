@@ -94,7 +94,8 @@ const (
 	ModeCasual4 MatchMode = "classic:casual4"
 	ModeCasual3 MatchMode = "classic:casual3"
 
-	ScorePoll MatchStrategy = "classic:poll"
+	ScorePoll MatchStrategy = "classic:poll" // 先来先服务
+	ScoreMMR  MatchStrategy = "classic:mmr"  // MMR 窗口匹配，窗口随等待时间放宽
 )
 
 type MarchPoolConfig struct {
@@ -102,6 +103,16 @@ type MarchPoolConfig struct {
 	Strategy  MatchStrategy `mapstructure:"strategy"`
 	BatchSize int           `mapstructure:"batchSize"`
 	Internal  int64         `mapstructure:"internal"`
+	MMR       MMRConfig     `mapstructure:"mmr"` // 仅 classic:mmr 使用
+	// BotFillTimeout 等待超过该时长（毫秒）仍凑不齐人时用 bot 补位，0 表示不补位
+	BotFillTimeout int64 `mapstructure:"botFillTimeout"`
+}
+
+// MMRConfig MMR 匹配窗口，未配置的字段使用默认值
+type MMRConfig struct {
+	BaseWindow     float64 `mapstructure:"baseWindow"`     // 初始 MMR 差值窗口
+	WidenPerSecond float64 `mapstructure:"widenPerSecond"` // 每等待一秒放宽的 MMR
+	MaxWindow      float64 `mapstructure:"maxWindow"`      // 窗口上限
 }
 
 func Load(configFile string) error {
@@ -0,0 +1,43 @@
+package node
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReconnectDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		base     time.Duration
+	}{
+		{attempts: 0, base: 500 * time.Millisecond},
+		{attempts: 1, base: time.Second},
+		{attempts: 3, base: 4 * time.Second},
+		{attempts: 5, base: 16 * time.Second},
+		{attempts: 6, base: reconnectWaitMax},
+		{attempts: 15, base: reconnectWaitMax},
+		{attempts: 16, base: reconnectWaitMax},
+		{attempts: 64, base: reconnectWaitMax},
+		{attempts: 1 << 20, base: reconnectWaitMax},
+		{attempts: -1, base: reconnectWaitMax},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 1000; i++ {
+			got := reconnectDelay(tt.attempts)
+			if got < tt.base || got > tt.base+tt.base/5 {
+				t.Fatalf("reconnectDelay(%d) = %v, want [%v, %v]", tt.attempts, got, tt.base, tt.base+tt.base/5)
+			}
+		}
+	}
+}
+
+// 抖动应让各节点的重连时间分散开
+func TestReconnectDelayJitter(t *testing.T) {
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 100; i++ {
+		seen[reconnectDelay(0)] = true
+	}
+	if len(seen) < 2 {
+		t.Fatalf("100 次重连等待都相同: %v", seen)
+	}
+}
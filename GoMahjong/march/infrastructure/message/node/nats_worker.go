@@ -77,7 +77,7 @@ func (worker *NatsWorker) readChanMessage() {
 					}
 				}()
 			} else {
-				log.Warn("NatsWorker-不支持的路由类型: %#v")
+				log.Warn("NatsWorker-不支持的路由类型: %s", route)
 			}
 		}
 	}
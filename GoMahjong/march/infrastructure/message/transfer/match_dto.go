@@ -0,0 +1,22 @@
+package transfer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// BotUserPrefix bot 玩家 ID 前缀，game 节点据此识别 bot 座位（与 game/infrastructure/message/transfer 保持一致）
+const BotUserPrefix = "bot_"
+
+// NewBotUserID 生成 bot 玩家 ID，格式：bot_<random>
+func NewBotUserID() string {
+	randomBytes := make([]byte, 6)
+	rand.Read(randomBytes)
+	return BotUserPrefix + hex.EncodeToString(randomBytes)
+}
+
+// IsBotUser 是否为 bot 玩家
+func IsBotUser(userID string) bool {
+	return strings.HasPrefix(userID, BotUserPrefix)
+}
@@ -16,19 +16,28 @@ import (
 const (
 	marchPlayerInfoTTL = 30 * time.Minute
 	queueKeyPrefix     = "march:queue"
+	mmrKeyPrefix       = "march:mmr"
 	userPoolKey        = "march:user:pool"
 )
 
+// getQueueKey 等待时间索引：score 为入队时间（秒）
 func getQueueKey(poolID string) string {
 	return fmt.Sprintf("%s:%s", queueKeyPrefix, poolID)
 }
 
+// getMMRKey MMR 索引：score 为玩家 MMR，成员与等待时间索引一致
+func getMMRKey(poolID string) string {
+	return fmt.Sprintf("%s:%s", mmrKeyPrefix, poolID)
+}
+
 var joinQueueScript = `
 local queueKey = KEYS[1]
 local userPoolKey = KEYS[2]
+local mmrKey = KEYS[3]
 local userID = ARGV[1]
 local score = tonumber(ARGV[2])
 local poolID = ARGV[3]
+local mmr = tonumber(ARGV[4])
 
 local existingPool = redis.call('HGET', userPoolKey, userID)
 if existingPool ~= false and existingPool ~= nil and existingPool ~= "" then
@@ -42,6 +51,7 @@ if existingPool ~= false and existingPool ~= nil and existingPool ~= "" then
 end
 
 redis.call('ZADD', queueKey, score, userID)
+redis.call('ZADD', mmrKey, mmr, userID)
 redis.call('HSET', userPoolKey, userID, poolID)
 
 return 1
@@ -50,6 +60,7 @@ return 1
 var popPlayersScript = `
 local queueKey = KEYS[1]
 local userPoolKey = KEYS[2]
+local mmrKey = KEYS[3]
 local count = tonumber(ARGV[1])
 
 local queueSize = redis.call('ZCARD', queueKey)
@@ -66,6 +77,7 @@ local result = {}
 for i = 1, #players do
 	local userID = players[i]
 	redis.call('ZREM', queueKey, userID)
+	redis.call('ZREM', mmrKey, userID)
 	redis.call('HDEL', userPoolKey, userID)
 	table.insert(result, userID)
 end
@@ -73,6 +85,91 @@ end
 return result
 `
 
+// popPlayersByMMRScript 按等待时间从早到晚依次作为锚点，在锚点 MMR 的窗口内凑人，
+// 窗口 = min(base + 等待秒数 * widen, max)，窗口内的玩家同样按等待时间优先
+var popPlayersByMMRScript = `
+local queueKey = KEYS[1]
+local userPoolKey = KEYS[2]
+local mmrKey = KEYS[3]
+local count = tonumber(ARGV[1])
+local now = tonumber(ARGV[2])
+local base = tonumber(ARGV[3])
+local widen = tonumber(ARGV[4])
+local maxWindow = tonumber(ARGV[5])
+local maxAnchors = tonumber(ARGV[6])
+
+if redis.call('ZCARD', queueKey) < count then
+	return {}
+end
+
+local anchors = redis.call('ZRANGE', queueKey, 0, maxAnchors - 1, 'WITHSCORES')
+for i = 1, #anchors, 2 do
+	local anchor = anchors[i]
+	local joinedAt = tonumber(anchors[i + 1])
+	local anchorMMR = tonumber(redis.call('ZSCORE', mmrKey, anchor) or 0)
+	local window = math.min(base + math.max(now - joinedAt, 0) * widen, maxWindow)
+
+	local candidates = redis.call('ZRANGEBYSCORE', mmrKey, anchorMMR - window, anchorMMR + window)
+	if #candidates >= count then
+		local waiting = {}
+		for _, userID in ipairs(candidates) do
+			if userID ~= anchor then
+				local t = redis.call('ZSCORE', queueKey, userID)
+				if t then
+					table.insert(waiting, {userID, tonumber(t)})
+				end
+			end
+		end
+		if #waiting >= count - 1 then
+			table.sort(waiting, function(a, b) return a[2] < b[2] end)
+			local result = {anchor}
+			for j = 1, count - 1 do
+				table.insert(result, waiting[j][1])
+			end
+			for _, userID in ipairs(result) do
+				redis.call('ZREM', queueKey, userID)
+				redis.call('ZREM', mmrKey, userID)
+				redis.call('HDEL', userPoolKey, userID)
+			end
+			return result
+		end
+	end
+end
+
+return {}
+`
+
+// popPlayersOrBotsScript 人数不足 count 且最早入队的玩家等待超过 timeout 时，取出队列中全部玩家（不足 count 的部分由调用方用 bot 补位）
+// 人数足够时不出队，由匹配策略（按 MMR 等）决定成桌，避免 bot 补位退化为按入队顺序匹配
+var popPlayersOrBotsScript = `
+local queueKey = KEYS[1]
+local userPoolKey = KEYS[2]
+local mmrKey = KEYS[3]
+local count = tonumber(ARGV[1])
+local now = tonumber(ARGV[2])
+local timeout = tonumber(ARGV[3])
+
+local queueSize = redis.call('ZCARD', queueKey)
+if queueSize == 0 or queueSize >= count then
+	return {}
+end
+
+local oldest = redis.call('ZRANGE', queueKey, 0, 0, 'WITHSCORES')
+if now - tonumber(oldest[2]) < timeout then
+	return {}
+end
+local players = redis.call('ZRANGE', queueKey, 0, -1)
+
+for i = 1, #players do
+	local userID = players[i]
+	redis.call('ZREM', queueKey, userID)
+	redis.call('ZREM', mmrKey, userID)
+	redis.call('HDEL', userPoolKey, userID)
+end
+
+return players
+`
+
 var removeFromQueueScript = `
 local userPoolKey = KEYS[1]
 local userID = ARGV[1]
@@ -83,8 +180,10 @@ if poolID == false or poolID == nil or poolID == "" then
 end
 
 local queueKey = "march:queue:" .. poolID
+local mmrKey = "march:mmr:" .. poolID
 
 redis.call('ZREM', queueKey, userID)
+redis.call('ZREM', mmrKey, userID)
 redis.call('HDEL', userPoolKey, userID)
 
 return 1
@@ -98,7 +197,7 @@ func NewRedisMarchQueueRepository(redis *database.RedisManager) repository.March
 	return &RedisMarchQueueRepository{redis: redis}
 }
 
-func (q *RedisMarchQueueRepository) JoinQueue(ctx context.Context, poolID, userID string, score float64) error {
+func (q *RedisMarchQueueRepository) JoinQueue(ctx context.Context, poolID, userID string, score, mmr float64) error {
 	if poolID == "" || userID == "" {
 		return fmt.Errorf("poolID 和 userID 不能为空")
 	}
@@ -106,7 +205,7 @@ func (q *RedisMarchQueueRepository) JoinQueue(ctx context.Context, poolID, userI
 	queueKey := getQueueKey(poolID)
 
 	anyResult, err := q.redis.EvalScript(ctx, "joinQueueScript", joinQueueScript,
-		[]string{queueKey, userPoolKey}, userID, score, poolID)
+		[]string{queueKey, userPoolKey, getMMRKey(poolID)}, userID, score, poolID, mmr)
 	if err != nil {
 		return fmt.Errorf("执行 joinQueue Lua 脚本失败: %w", err)
 	}
@@ -159,7 +258,7 @@ func (q *RedisMarchQueueRepository) RemoveFromQueue(ctx context.Context, userID
 		log.Debug("玩家 %s 从匹配队列移除", userID)
 		return nil
 	case 0:
-		return nil
+		return transfer.ErrPlayerNotInQueue
 	default:
 		return fmt.Errorf("removeFromQueue 返回未知结果: %d", result)
 	}
@@ -229,14 +328,61 @@ func (q *RedisMarchQueueRepository) PopPlayers(ctx context.Context, poolID strin
 		return nil, fmt.Errorf("poolID 不能为空")
 	}
 
-	queueKey := getQueueKey(poolID)
+	anyResult, err := q.redis.EvalScript(ctx, "popPlayersScript", popPlayersScript,
+		[]string{getQueueKey(poolID), userPoolKey, getMMRKey(poolID)}, count)
+	return parsePoppedPlayers(poolID, anyResult, err)
+}
+
+func (q *RedisMarchQueueRepository) PopPlayersByMMR(ctx context.Context, poolID string, count int, window repository.MMRWindow) ([]string, error) {
+	if count <= 0 {
+		return []string{}, nil
+	}
+
+	if poolID == "" {
+		return nil, fmt.Errorf("poolID 不能为空")
+	}
+
+	anyResult, err := q.redis.EvalScript(ctx, "popPlayersByMMRScript", popPlayersByMMRScript,
+		[]string{getQueueKey(poolID), userPoolKey, getMMRKey(poolID)},
+		count, time.Now().Unix(), window.Base, window.WidenPerSecond, window.Max, window.MaxAnchors)
+	return parsePoppedPlayers(poolID, anyResult, err)
+}
+
+func (q *RedisMarchQueueRepository) PopPlayersOrBots(ctx context.Context, poolID string, count int, timeout time.Duration) ([]string, error) {
+	if count <= 0 {
+		return []string{}, nil
+	}
+
+	if poolID == "" {
+		return nil, fmt.Errorf("poolID 不能为空")
+	}
+
+	anyResult, err := q.redis.EvalScript(ctx, "popPlayersOrBotsScript", popPlayersOrBotsScript,
+		[]string{getQueueKey(poolID), userPoolKey, getMMRKey(poolID)},
+		count, time.Now().Unix(), int64(timeout/time.Second))
+	players, err := parsePoppedPlayers(poolID, anyResult, err)
+	if err != nil || len(players) == 0 {
+		return players, err
+	}
+
+	bots := 0
+	for len(players) < count {
+		players = append(players, transfer.NewBotUserID())
+		bots++
+	}
+	if bots > 0 {
+		log.Info("匹配池 %s 等待超时，%d 名玩家 + %d 个 bot 成桌", poolID, count-bots, bots)
+	}
+	return players, nil
+}
 
-	anyResult, err := q.redis.EvalScript(ctx, "popPlayersScript", popPlayersScript, []string{queueKey, userPoolKey}, count)
+// parsePoppedPlayers 解析出队脚本返回的 userID 列表
+func parsePoppedPlayers(poolID string, anyResult any, err error) ([]string, error) {
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
 			return []string{}, nil
 		}
-		return nil, fmt.Errorf("执行 popPlayers Lua 脚本失败: %w", err)
+		return nil, fmt.Errorf("执行出队 Lua 脚本失败: %w", err)
 	}
 
 	if anyResult == nil {
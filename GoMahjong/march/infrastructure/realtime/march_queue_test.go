@@ -0,0 +1,150 @@
+package realtime
+
+import (
+	"context"
+	"march/domain/repository"
+	"march/infrastructure/config"
+	"march/infrastructure/database"
+	"march/infrastructure/log"
+	"march/infrastructure/message/transfer"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestMain(m *testing.M) {
+	log.InitLog("march_test", "error")
+	os.Exit(m.Run())
+}
+
+const testPool = "rank:test"
+
+// newTestQueue 连接 miniredis 的匹配队列，Lua 脚本在 miniredis 中执行
+func newTestQueue(t *testing.T) *RedisMarchQueueRepository {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	manager := database.NewRedis(config.RedisConf{Addr: mr.Addr()})
+	t.Cleanup(func() { manager.Close() })
+	return NewRedisMarchQueueRepository(manager).(*RedisMarchQueueRepository)
+}
+
+// joinAt 玩家在 waited 之前以 mmr 入队
+func joinAt(t *testing.T, q *RedisMarchQueueRepository, userID string, mmr float64, waited time.Duration) {
+	t.Helper()
+	score := float64(time.Now().Add(-waited).Unix())
+	if err := q.JoinQueue(context.Background(), testPool, userID, score, mmr); err != nil {
+		t.Fatalf("%s 入队失败: %v", userID, err)
+	}
+}
+
+func testWindow() repository.MMRWindow {
+	return repository.MMRWindow{Base: 100, WidenPerSecond: 5, Max: 1000, MaxAnchors: 50}
+}
+
+func TestPopPlayersByMMR(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("tight window", func(t *testing.T) {
+		q := newTestQueue(t)
+		joinAt(t, q, "anchor", 1500, 3*time.Second)
+		joinAt(t, q, "far", 2100, 2*time.Second)
+		joinAt(t, q, "near1", 1520, time.Second)
+		joinAt(t, q, "near2", 1450, time.Second)
+		joinAt(t, q, "near3", 1580, 0)
+
+		players, err := q.PopPlayersByMMR(ctx, testPool, 4, testWindow())
+		if err != nil {
+			t.Fatalf("PopPlayersByMMR: %v", err)
+		}
+		slices.Sort(players)
+		if want := []string{"anchor", "near1", "near2", "near3"}; !slices.Equal(players, want) {
+			t.Fatalf("应匹配窗口内的玩家, got %v want %v", players, want)
+		}
+		if size, _ := q.GetQueueSize(ctx, testPool); size != 1 {
+			t.Fatalf("窗口外的玩家应留在队列中, size=%d", size)
+		}
+		if pool, _ := q.GetUserPool(ctx, "near1"); pool != "" {
+			t.Fatalf("出队的玩家应清除匹配池映射, got %q", pool)
+		}
+	})
+
+	t.Run("window widens with wait", func(t *testing.T) {
+		mmrs := []float64{1000, 1300, 1600, 1900}
+		for _, tt := range []struct {
+			waited time.Duration
+			want   int
+		}{
+			{waited: 0},
+			{waited: 60 * time.Second},           // 窗口 400，仍凑不齐
+			{waited: 200 * time.Second, want: 4}, // 窗口封顶 1000，覆盖全部
+		} {
+			q := newTestQueue(t)
+			for i, mmr := range mmrs {
+				joinAt(t, q, string(rune('a'+i)), mmr, tt.waited)
+			}
+			players, err := q.PopPlayersByMMR(ctx, testPool, 4, testWindow())
+			if err != nil {
+				t.Fatalf("PopPlayersByMMR: %v", err)
+			}
+			if len(players) != tt.want {
+				t.Fatalf("等待 %v 后匹配到 %v, want %d 人", tt.waited, players, tt.want)
+			}
+		}
+	})
+}
+
+func TestPopPlayersOrBots(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		humans    int
+		waited    time.Duration
+		wantBots  int
+		wantTable bool
+	}{
+		{name: "three humans and one bot", humans: 3, waited: time.Minute, wantBots: 1, wantTable: true},
+		{name: "one human and three bots", humans: 1, waited: time.Minute, wantBots: 3, wantTable: true},
+		{name: "not timed out", humans: 3, waited: 10 * time.Second},
+		{name: "full table left to strategy", humans: 4, waited: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestQueue(t)
+			for i := 0; i < tt.humans; i++ {
+				joinAt(t, q, string(rune('a'+i)), 1500, tt.waited)
+			}
+
+			players, err := q.PopPlayersOrBots(ctx, testPool, 4, 30*time.Second)
+			if err != nil {
+				t.Fatalf("PopPlayersOrBots: %v", err)
+			}
+			if !tt.wantTable {
+				if len(players) != 0 {
+					t.Fatalf("不应成桌, got %v", players)
+				}
+				if size, _ := q.GetQueueSize(ctx, testPool); size != tt.humans {
+					t.Fatalf("玩家应留在队列中, size=%d", size)
+				}
+				return
+			}
+			if len(players) != 4 {
+				t.Fatalf("应凑满 4 人, got %v", players)
+			}
+			bots := 0
+			for _, userID := range players {
+				if transfer.IsBotUser(userID) {
+					bots++
+				}
+			}
+			if bots != tt.wantBots {
+				t.Fatalf("bot 数 = %d, want %d (players=%v)", bots, tt.wantBots, players)
+			}
+			if size, _ := q.GetQueueSize(ctx, testPool); size != 0 {
+				t.Fatalf("成桌的玩家应全部出队, size=%d", size)
+			}
+		})
+	}
+}
@@ -2,11 +2,15 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"march/infrastructure/log"
 	"march/infrastructure/message/transfer"
 	"march/runtime/application/service"
 
 	"march/pb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // MatchProvider 实现 gRPC MatchService
@@ -46,6 +50,10 @@ func (p *MatchProvider) LeaveQueue(ctx context.Context, req *pb.LeaveQueueReques
 	}
 
 	if err := p.matchService.LeaveQueue(ctx, req.GetUserID()); err != nil {
+		if errors.Is(err, transfer.ErrPlayerNotInQueue) {
+			// 已被匹配池取出（正在成桌）或本就不在队列中
+			return nil, status.Error(codes.FailedPrecondition, "user not in queue")
+		}
 		return &pb.LeaveQueueResponse{Message: err.Error()}, transfer.ErrService
 	}
 
@@ -0,0 +1,90 @@
+package grpc
+
+import (
+	"context"
+	"march/domain/entity"
+	"march/domain/repository"
+	"march/infrastructure/config"
+	"march/infrastructure/database"
+	"march/infrastructure/log"
+	"march/infrastructure/realtime"
+	"march/pb"
+	"march/runtime/application/service/impl"
+	"os"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMain(m *testing.M) {
+	log.InitLog("march_test", "error")
+	os.Exit(m.Run())
+}
+
+// rankedUsers 只实现 FindByID，所有用户的段位分相同
+type rankedUsers struct {
+	repository.UserRepository
+	ranking int
+}
+
+func (r rankedUsers) FindByID(ctx context.Context, id string) (*entity.User, error) {
+	return &entity.User{Ranking: r.ranking}, nil
+}
+
+// newTestProvider 匹配队列跑在 miniredis 上的 MatchProvider
+func newTestProvider(t *testing.T) (*MatchProvider, repository.MarchQueueRepository) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	manager := database.NewRedis(config.RedisConf{Addr: mr.Addr()})
+	t.Cleanup(func() { manager.Close() })
+	queueRepo := realtime.NewRedisMarchQueueRepository(manager)
+	return NewMatchProvider(impl.NewMatchService(queueRepo, rankedUsers{ranking: 1800})), queueRepo
+}
+
+func TestLeaveQueue(t *testing.T) {
+	ctx := context.Background()
+	users := []string{"a", "b", "c", "d"}
+
+	t.Run("clean cancel", func(t *testing.T) {
+		p, queueRepo := newTestProvider(t)
+		for _, userID := range users {
+			if _, err := p.JoinQueue(ctx, &pb.JoinQueueRequest{UserID: userID, PoolID: "classic:rank4"}); err != nil {
+				t.Fatalf("JoinQueue(%s): %v", userID, err)
+			}
+		}
+		// 段位场加入的是按段位分的池，取消时要从该池移除
+		poolID, _ := queueRepo.GetUserPool(ctx, "a")
+		if poolID == "" || poolID == "classic:rank4" {
+			t.Fatalf("应加入具体段位的匹配池, got %q", poolID)
+		}
+
+		if _, err := p.LeaveQueue(ctx, &pb.LeaveQueueRequest{UserID: "a"}); err != nil {
+			t.Fatalf("LeaveQueue: %v", err)
+		}
+		if inQueue, pool, _ := queueRepo.IsInQueue(ctx, "a"); inQueue || pool != "" {
+			t.Fatalf("取消后不应在队列中, inQueue=%v pool=%q", inQueue, pool)
+		}
+		if size, _ := queueRepo.GetQueueSize(ctx, poolID); size != 3 {
+			t.Fatalf("匹配池应剩 3 人, got %d", size)
+		}
+	})
+
+	t.Run("cancel after matched", func(t *testing.T) {
+		p, queueRepo := newTestProvider(t)
+		for _, userID := range users {
+			if _, err := p.JoinQueue(ctx, &pb.JoinQueueRequest{UserID: userID, PoolID: "classic:normal"}); err != nil {
+				t.Fatalf("JoinQueue(%s): %v", userID, err)
+			}
+		}
+		if players, err := queueRepo.PopPlayers(ctx, "classic:normal", 4); err != nil || len(players) != 4 {
+			t.Fatalf("应取出 4 人成桌, got %v err=%v", players, err)
+		}
+
+		_, err := p.LeaveQueue(ctx, &pb.LeaveQueueRequest{UserID: "a"})
+		if status.Code(err) != codes.FailedPrecondition {
+			t.Fatalf("已成桌后取消应返回 FailedPrecondition, got %v", err)
+		}
+	})
+}
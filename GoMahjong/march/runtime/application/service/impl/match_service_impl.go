@@ -38,14 +38,23 @@ func (s *MatchServiceImpl) JoinQueue(ctx context.Context, poolID, userID string)
 		return errors.Join(transfer.ErrPlayerAlreadyInQueue, fmt.Errorf("已在匹配队列: %s", existPool))
 	}
 
-	finalPoolID, err := s.resolvePoolID(ctx, poolID, userID)
+	// 段位场按分数分池，必须查到用户；其他池查不到时 MMR 按 0 处理
+	ranking := 0
+	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("解析匹配池ID失败: %w", err)
+		if strings.HasPrefix(poolID, rank4Prefix) {
+			return fmt.Errorf("查询用户信息失败: %w", err)
+		}
+		log.Warn("玩家 %s 查询用户信息失败，MMR 按 0 处理: %v", userID, err)
+	} else {
+		ranking = user.Ranking
 	}
 
+	finalPoolID := s.resolvePoolID(poolID, userID, ranking)
 	score := float64(time.Now().Unix())
+	mmr := float64(ranking)
 
-	if err := s.queueRepo.JoinQueue(ctx, finalPoolID, userID, score); err != nil {
+	if err := s.queueRepo.JoinQueue(ctx, finalPoolID, userID, score, mmr); err != nil {
 		return fmt.Errorf("加入队列失败: %w", err)
 	}
 
@@ -53,25 +62,22 @@ func (s *MatchServiceImpl) JoinQueue(ctx context.Context, poolID, userID string)
 	return nil
 }
 
-func (s *MatchServiceImpl) resolvePoolID(ctx context.Context, poolID, userID string) (string, error) {
+func (s *MatchServiceImpl) resolvePoolID(poolID, userID string, ranking int) string {
 	if !strings.HasPrefix(poolID, rank4Prefix) {
-		return poolID, nil
-	}
-
-	user, err := s.userRepo.FindByID(ctx, userID)
-	if err != nil {
-		return "", fmt.Errorf("查询用户信息失败: %w", err)
+		return poolID
 	}
 
-	rankingType := vo.GetRankingByScore(user.Ranking)
+	rankingType := vo.GetRankingByScore(ranking)
 	rankingString := rankingType.String()
 
 	finalPoolID := fmt.Sprintf("%s:%s", rank4Prefix, rankingString)
 
-	log.Info("玩家 %s ranking=%d, 段位=%s, 匹配池=%s", userID, user.Ranking, rankingString, finalPoolID)
-	return finalPoolID, nil
+	log.Info("玩家 %s ranking=%d, 段位=%s, 匹配池=%s", userID, ranking, rankingString, finalPoolID)
+	return finalPoolID
 }
 
+// LeaveQueue 出队与匹配池取人都在 Lua 脚本中原子完成，二者只有一个能成功；
+// 已被取出成桌时返回 ErrPlayerNotInQueue
 func (s *MatchServiceImpl) LeaveQueue(ctx context.Context, userID string) error {
 	if err := s.queueRepo.RemoveFromQueue(ctx, userID); err != nil {
 		return fmt.Errorf("离开队列失败: %w", err)
@@ -7,6 +7,7 @@ import (
 	"march/infrastructure/config"
 	"march/infrastructure/discovery"
 	"march/infrastructure/log"
+	"march/infrastructure/message/transfer"
 	"march/runtime/application/service"
 	"strings"
 	"sync"
@@ -19,6 +20,7 @@ type MatchPool struct {
 	batchSize       int
 	interval        time.Duration
 	requiredPlayers int
+	botFillTimeout  time.Duration // 0 表示不使用 bot 补位
 
 	queueRepo    repository.MarchQueueRepository
 	routerRepo   repository.UserRouterRepository
@@ -36,7 +38,7 @@ func NewMatchPool(cfg config.MarchPoolConfig,
 	resultChan chan<- *service.MatchResult,
 ) (*MatchPool, error) {
 	requiredPlayers := inferRequiredPlayers(string(cfg.PoolID))
-	strategy, err := createStrategy(cfg.Strategy)
+	strategy, err := createStrategy(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -47,6 +49,7 @@ func NewMatchPool(cfg config.MarchPoolConfig,
 		batchSize:       cfg.BatchSize,
 		interval:        time.Duration(cfg.Internal) * time.Millisecond,
 		requiredPlayers: requiredPlayers,
+		botFillTimeout:  time.Duration(cfg.BotFillTimeout) * time.Millisecond,
 		queueRepo:       queueRepo,
 		routerRepo:      routerRepo,
 		nodeSelector:    nodeSelector,
@@ -105,12 +108,24 @@ func (p *MatchPool) tryMatch() (*service.MatchResult, error) {
 	if err != nil {
 		return nil, err
 	}
+	// 策略未成桌时尝试 bot 补位，补位只在排队人数不足一桌时生效，人数足够时仍等待策略（如 MMR 窗口放宽）成桌
+	if len(playerIDs) < p.requiredPlayers && p.botFillTimeout > 0 {
+		playerIDs, err = p.queueRepo.PopPlayersOrBots(ctx, p.poolID, p.requiredPlayers, p.botFillTimeout)
+		if err != nil {
+			return nil, err
+		}
+	}
 	if len(playerIDs) < p.requiredPlayers {
 		return nil, nil
 	}
 
 	players := make(map[string]string, len(playerIDs))
 	for _, userID := range playerIDs {
+		// bot 没有 connector，由 game 节点按超时自动操作
+		if transfer.IsBotUser(userID) {
+			players[userID] = ""
+			continue
+		}
 		connectorRoute, err := p.routerRepo.GetConnectorRouter(ctx, userID)
 		if err != nil {
 			return nil, nil
@@ -151,12 +166,14 @@ func inferRequiredPlayers(poolID string) int {
 	return 4
 }
 
-func createStrategy(strategy config.MatchStrategy) (MatchStrategy, error) {
-	switch strategy {
-	case config.ScorePoll:
+func createStrategy(cfg config.MarchPoolConfig) (MatchStrategy, error) {
+	switch cfg.Strategy {
+	case config.ScorePoll, "":
 		return NewPollStrategy(), nil
+	case config.ScoreMMR:
+		return NewMMRStrategy(cfg.MMR), nil
 	default:
-		return nil, fmt.Errorf("不支持的匹配策略: %s", strategy)
+		return nil, fmt.Errorf("不支持的匹配策略: %s", cfg.Strategy)
 	}
 }
 
@@ -3,6 +3,7 @@ package runtime
 import (
 	"context"
 	"march/domain/repository"
+	"march/infrastructure/config"
 )
 
 type MatchStrategy interface {
@@ -25,3 +26,41 @@ func (s *PollStrategy) Match(ctx context.Context, queueRepo repository.MarchQueu
 	}
 	return players, nil
 }
+
+const (
+	DefaultMMRBaseWindow     = 100  // 初始窗口
+	DefaultMMRWidenPerSecond = 5    // 每秒放宽
+	DefaultMMRMaxWindow      = 1000 // 最宽窗口
+	DefaultMMRMaxAnchors     = 50   // 每次最多尝试的锚点数
+)
+
+// MMRStrategy 以等待最久的玩家为锚点，在其 MMR 窗口内凑齐人数，窗口随锚点等待时间放宽
+type MMRStrategy struct {
+	window repository.MMRWindow
+}
+
+func NewMMRStrategy(cfg config.MMRConfig) MatchStrategy {
+	window := repository.MMRWindow{
+		Base:           cfg.BaseWindow,
+		WidenPerSecond: cfg.WidenPerSecond,
+		Max:            cfg.MaxWindow,
+		MaxAnchors:     DefaultMMRMaxAnchors,
+	}
+	if window.Base <= 0 {
+		window.Base = DefaultMMRBaseWindow
+	}
+	if window.WidenPerSecond <= 0 {
+		window.WidenPerSecond = DefaultMMRWidenPerSecond
+	}
+	if window.Max < window.Base {
+		window.Max = max(window.Base, DefaultMMRMaxWindow)
+	}
+	return &MMRStrategy{window: window}
+}
+
+func (s *MMRStrategy) Match(ctx context.Context, queueRepo repository.MarchQueueRepository, poolID string, requiredPlayers int) ([]string, error) {
+	if requiredPlayers <= 0 {
+		return nil, nil
+	}
+	return queueRepo.PopPlayersByMMR(ctx, poolID, requiredPlayers, s.window)
+}